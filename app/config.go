@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+	"github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/config"
+)
+
+var flagProperties = []struct {
+	flag     string
+	property string
+	usage    string
+}{
+	{"log-dirs", "log.dirs", "comma-separated log directories"},
+	{"controller-quorum-voters", "controller.quorum.voters", "comma-separated id@host:port controller quorum voters"},
+	{"replica-lag-time-max-ms", "replica.lag.time.max.ms", "time a follower may lag before leaving the ISR"},
+	{"broker-session-timeout-ms", "broker.session.timeout.ms", "time without a fetch before a broker is fenced"},
+	{"auto-leader-rebalance-enable", "auto.leader.rebalance.enable", "periodically move leadership back to preferred replicas"},
+	{"leader-imbalance-check-interval-seconds", "leader.imbalance.check.interval.seconds", "interval between preferred leader rebalances"},
+	{"leader-replication-throttled-rate", "leader.replication.throttled.rate", "bytes/s a leader sends to throttled replicas"},
+	{"follower-replication-throttled-rate", "follower.replication.throttled.rate", "bytes/s a throttled follower fetches"},
+	{"broker-rack", "broker.rack", "rack of this broker"},
+	{"replica-selector", "replica.selector.class", "replica selector for consumer fetches: rack-aware or leader"},
+}
+
+func loadServerConfig(args []string) (*config.ServerConfig, error) {
+	path, flags, err := splitArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	var overrides []string
+	fs := flag.NewFlagSet("broker", flag.ContinueOnError)
+	fs.Func("override", "key=value that replaces a server.properties entry (repeatable)", func(v string) error {
+		overrides = append(overrides, v)
+		return nil
+	})
+	fs.Func("listen", "address the broker listens on (shorthand for listeners=PLAINTEXT://address)", func(v string) error {
+		overrides = append(overrides, "listeners=PLAINTEXT://"+v)
+		return nil
+	})
+	for _, fp := range flagProperties {
+		fs.Func(fp.flag, fp.usage+" ("+fp.property+")", func(v string) error {
+			overrides = append(overrides, fp.property+"="+v)
+			return nil
+		})
+	}
+	if err := fs.Parse(flags); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	return config.Load(path, os.Environ(), overrides)
+}
+
+func splitArgs(args []string) (string, []string, error) {
+	i := slices.IndexFunc(args, func(a string) bool { return strings.HasPrefix(a, "-") })
+	if i < 0 {
+		i = len(args)
+	}
+
+	switch i {
+	case 0:
+		return "", args[i:], nil
+	case 1:
+		return args[0], args[i:], nil
+	}
+	return "", nil, errors.New("expected a single server.properties path before the flags")
+}
+
+func checkNodeRoles(cfg *config.ServerConfig, nodeID int32, voters map[int32]string) error {
+	if cfg.NodeID >= 0 && cfg.NodeID != nodeID {
+		return fmt.Errorf("node.id %d does not match node.id %d in meta.properties", cfg.NodeID, nodeID)
+	}
+
+	if _, voter := voters[nodeID]; !voter {
+		return fmt.Errorf("node %d is not in controller.quorum.voters, every node must be a controller voter", nodeID)
+	}
+	return nil
+}
+
+func staticBrokerConfigs(nodeID int32, cfg *config.ServerConfig) map[string]string {
+	configs := map[string]string{
+		domain.BrokerConfigNodeID:                strconv.Itoa(int(nodeID)),
+		domain.BrokerConfigLogDirs:               strings.Join(cfg.LogDirs, ","),
+		domain.BrokerConfigReplicaLagTimeMaxMs:   strconv.FormatInt(cfg.ReplicaLagTimeMaxMs, 10),
+		domain.BrokerConfigLeaderThrottledRate:   strconv.FormatInt(cfg.LeaderReplicationThrottledRate, 10),
+		domain.BrokerConfigFollowerThrottledRate: strconv.FormatInt(cfg.FollowerReplicationThrottledRate, 10),
+	}
+	if cfg.BrokerRack != "" {
+		configs[domain.BrokerConfigRack] = cfg.BrokerRack
+	}
+	for k, v := range cfg.TopicDefaults {
+		configs[k] = v
+	}
+	return configs
+}
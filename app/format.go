@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+	"github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/config"
+	"github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/storage"
+)
+
+func runFormat(args []string) error {
+	path, flags, err := splitArgs(args)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load(path, os.Environ(), nil)
+	if err != nil {
+		return err
+	}
+	defaultNodeID := 1
+	if cfg.NodeID >= 0 {
+		defaultNodeID = int(cfg.NodeID)
+	}
+
+	fs := flag.NewFlagSet("format", flag.ContinueOnError)
+	clusterID := fs.String("cluster-id", "", "cluster id (a random one is generated when empty)")
+	nodeID := fs.Int("node-id", defaultNodeID, "node id of this broker (node.id)")
+	dirs := fs.String("log-dirs", strings.Join(cfg.LogDirs, ","), "comma-separated log directories (log.dirs)")
+	if err := fs.Parse(flags); err != nil {
+		return err
+	}
+
+	if *clusterID == "" {
+		id, err := storage.NewClusterID()
+		if err != nil {
+			return err
+		}
+		*clusterID = id
+	}
+
+	paths := strings.Split(*dirs, ",")
+	identity := domain.ClusterIdentity{ClusterID: *clusterID, NodeID: int32(*nodeID)}
+	if err := storage.FormatLogDirs(paths, identity); err != nil {
+		return err
+	}
+
+	fmt.Printf("Formatted %s with cluster.id %s and node.id %d\n", strings.Join(paths, ","), identity.ClusterID, identity.NodeID)
+	return nil
+}
@@ -1,72 +1,295 @@
 package main
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
 
 	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/response"
 	"github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/codec"
+	"github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/controller"
 	netinfra "github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/net"
+	"github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/raft"
+	"github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/replication"
 	"github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/repository"
 	"github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/storage"
 	"github.com/codecrafters-io/kafka-starter-go/internal/ports"
 	"github.com/codecrafters-io/kafka-starter-go/internal/usecase"
 )
 
+const (
+	quorumStartupTimeout = 5 * time.Second
+)
+
 func main() {
-	diskManager := storage.NewDiskManager("/tmp/kraft-combined-logs/__cluster_metadata-0/00000000000000000000.log")
+	if len(os.Args) > 1 && os.Args[1] == "format" {
+		if err := runFormat(os.Args[2:]); err != nil {
+			fmt.Println("format failed:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	cfg, err := loadServerConfig(os.Args[1:])
+	if err != nil {
+		fmt.Println("invalid configuration:", err)
+		os.Exit(2)
+	}
+	for _, w := range cfg.Warnings {
+		fmt.Println("configuration:", w)
+	}
+
+	logDirs := cfg.LogDirs
+	selector, err := replicaSelector(cfg.ReplicaSelector)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	identity, err := storage.LoadClusterIdentity(logDirs)
+	if err != nil {
+		fmt.Println("cluster identity unavailable:", err)
+		if errors.Is(err, storage.ErrNotFormatted) {
+			fmt.Println("run `format --cluster-id <id> --node-id <id>` first")
+		}
+		os.Exit(1)
+	}
+
+	voters, err := parseQuorumVoters(cfg.QuorumVoters(identity.NodeID))
+	if err != nil {
+		fmt.Println("invalid controller quorum voters:", err)
+		os.Exit(1)
+	}
+	if err := checkNodeRoles(cfg, identity.NodeID, voters); err != nil {
+		fmt.Println("invalid configuration:", err)
+		os.Exit(1)
+	}
+
+	diskManager := storage.NewDiskManager(filepath.Join(cfg.MetadataLogDir, domain.MetadataTopicName+"-0"))
+	if err := os.MkdirAll(diskManager.Dir(), 0755); err != nil {
+		fmt.Println("metadata log directory unavailable:", err)
+		os.Exit(1)
+	}
+
+	transport := raft.NewTCPTransport(identity.NodeID, identity.ClusterID, voters, codec.NewBinaryQuorumClientCodec())
+	node, err := raft.NewNode(raft.Config{
+		NodeID:    identity.NodeID,
+		ClusterID: identity.ClusterID,
+		Voters:    voters,
+	}, diskManager, transport)
+	if err != nil {
+		fmt.Println("metadata quorum unavailable:", err)
+		os.Exit(1)
+	}
+	if err := node.Start(); err != nil {
+		fmt.Println("metadata quorum failed to start:", err)
+		os.Exit(1)
+	}
+	go node.Run(nil)
+
+	if !node.WaitForHighWatermark(quorumStartupTimeout) {
+		fmt.Println("metadata quorum has no leader yet, serving metadata as it commits")
+	}
+
+	staticConfigs := staticBrokerConfigs(identity.NodeID, cfg)
 	metadataLoader := repository.NewMetadataLoader(diskManager)
+	metadataLoader.LimitTo(node.HighWatermark)
+	metadataLoader.UseStaticConfigs(staticConfigs)
 	metadata, err := metadataLoader.Load()
 	if err != nil {
 		fmt.Println("metadata load failed, starting with empty metadata:", err)
-		metadata = &repository.LoadedMetadata{
-			ByName: map[string]*domain.TopicMetadata{},
-			ByUUID: map[[16]byte]*domain.TopicMetadata{},
-		}
+		metadata = repository.EmptyMetadataImage()
 	}
 	repo := repository.NewKraftMetadataRepository(metadata)
 
-	logManager := storage.NewLogManager("/tmp/kraft-combined-logs")
+	listener := repository.NewMetadataListener(metadataLoader, repo, time.Second)
+	node.SetSnapshotter(listener)
+	node.OnCommit(func() {
+		if err := listener.Refresh(); err != nil {
+			fmt.Println("metadata refresh failed:", err)
+		}
+	})
+	go listener.Run(nil)
+	metadataWriter := repository.NewMetadataWriter(node, listener)
 
-	parser := codec.NewBinaryRequestParser()
-	builder := codec.NewBinaryResponseBuilder()
-	processor := usecase.NewRequestProcessor(repo, logManager)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-signals
+		node.Resign()
+		os.Exit(0)
+	}()
 
-	server := netinfra.NewTCPServer("0.0.0.0:9092")
+	logManager, err := storage.NewLogManager(logDirs)
+	if err != nil {
+		fmt.Println("log directories unavailable:", err)
+		os.Exit(1)
+	}
 
-	fmt.Println("Kafka minimal server started")
+	if err := metadata.ValidateLocalBroker(identity.NodeID, localDirectoryIDs(logManager)); err != nil {
+		fmt.Println("broker registration mismatch:", err)
+		os.Exit(1)
+	}
 
-	server.Start(func(conn ports.Connection) {
-		defer conn.Close()
+	controllerID := func() int32 { return node.Leader().LeaderID }
+	replicaClient := replication.NewTCPClient(identity.NodeID, repo, voters, controllerID, codec.NewBinaryQuorumClientCodec())
+	replicas := replication.NewReplicaManager(replication.Config{
+		NodeID:            identity.NodeID,
+		ClusterID:         identity.ClusterID,
+		ReplicaLagTimeMax: time.Duration(cfg.ReplicaLagTimeMaxMs) * time.Millisecond,
+		LeaderThrottle:    cfg.LeaderReplicationThrottledRate,
+		FollowerThrottle:  cfg.FollowerReplicationThrottledRate,
+		Rack:              cfg.BrokerRack,
+		ReplicaSelector:   selector,
+	}, repo, logManager, replicaClient)
+	replicas.Start()
+
+	ctrl := controller.NewController(controller.Config{
+		NodeID:            identity.NodeID,
+		SessionTimeout:    time.Duration(cfg.BrokerSessionTimeoutMs) * time.Millisecond,
+		AutoRebalance:     cfg.AutoLeaderRebalanceEnable,
+		RebalanceInterval: time.Duration(cfg.LeaderImbalanceCheckIntervalSeconds) * time.Second,
+		Rack:              cfg.BrokerRack,
 
-		buf := make([]byte, 1024)
+		DefaultNumPartitions:     cfg.NumPartitions,
+		DefaultReplicationFactor: cfg.DefaultReplicationFactor,
+	}, repo, metadataWriter, node)
+	ctrl.Start()
+
+	parser := codec.NewBinaryRequestParser()
+	builder := codec.NewBinaryResponseBuilder()
+	batchCodec := codec.NewBinaryRecordBatchCodec()
+	advertised := cfg.AdvertisedListeners[0]
+	local := domain.LocalBroker{
+		ClusterIdentity: identity,
+		Endpoint: domain.BrokerEndpoint{
+			Listener: advertised.Name,
+			Host:     advertised.Host,
+			Port:     advertised.Port,
+		},
+		Configs: staticConfigs,
+	}
+	if cfg.BrokerRack != "" {
+		local.Rack = &cfg.BrokerRack
+	}
+	processor := usecase.NewRequestProcessor(usecase.Dependencies{
+		MetadataRepo:   repo,
+		LogManager:     logManager,
+		BatchCodec:     batchCodec,
+		MetadataWriter: metadataWriter,
+		Quorum:         node,
+		Replicas:       replicas,
+		Elector:        ctrl,
+		Reassigner:     ctrl,
+		Creator:        ctrl,
+		Configs:        ctrl,
+		Local:          local,
+	})
+
+	handle := func(conn ports.Connection) {
+		defer conn.Close()
 
 		for {
-			n, err := conn.Read(buf)
+			data, err := readFrame(conn)
 			if err != nil {
-				if errors.Is(err, io.EOF) {
-					return
+				if !errors.Is(err, io.EOF) {
+					fmt.Println(err)
 				}
-				fmt.Println(err)
 				return
 			}
 
-			req, err := parser.Parse(buf[:n])
+			req, err := parser.Parse(data)
 			if err != nil {
 				fmt.Println(err)
 				continue
 			}
 
 			resp, _ := processor.Process(req)
-			msg, _ := builder.Build(resp)
+			frame, err := builder.BuildFrame(resp)
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
 
-			_, err = conn.Write(msg)
+			err = writeFrame(conn, frame)
+			frame.Close()
 			if err != nil {
 				fmt.Println(err)
 				return
 			}
 		}
-	})
+	}
 
+	errs := make(chan error, len(cfg.Listeners))
+	for _, l := range cfg.Listeners {
+		server := netinfra.NewTCPServer(l.Address())
+		go func() {
+			errs <- fmt.Errorf("listener %s: %w", l.Name, server.Start(handle))
+		}()
+	}
+
+	fmt.Println("Kafka minimal server started")
+
+	fmt.Println(<-errs)
+	os.Exit(1)
+}
+
+func readFrame(conn ports.Connection) ([]byte, error) {
+	data := make([]byte, 4)
+	if _, err := io.ReadFull(conn, data); err != nil {
+		return nil, err
+	}
+
+	size := binary.BigEndian.Uint32(data)
+	if size > domain.MaxRequestSize {
+		return nil, fmt.Errorf("request of %d bytes exceeds the limit", size)
+	}
+
+	data = append(data, make([]byte, size)...)
+	if _, err := io.ReadFull(conn, data[4:]); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func writeFrame(conn ports.Connection, frame *response.Frame) error {
+	for _, part := range frame.Parts {
+		if part.Region != nil {
+			if _, err := conn.WriteRegion(part.Region); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if _, err := conn.Write(part.Bytes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func replicaSelector(name string) (ports.ReplicaSelector, error) {
+	switch name {
+	case "rack-aware":
+		return replication.RackAwareSelector{}, nil
+	case "leader":
+		return replication.LeaderSelector{}, nil
+	}
+	return nil, fmt.Errorf("unknown replica selector %q", name)
+}
+
+func localDirectoryIDs(logManager *storage.LogManager) [][16]byte {
+	dirs := logManager.Dirs()
+	ids := make([][16]byte, 0, len(dirs))
+	for _, d := range dirs {
+		ids = append(ids, d.ID)
+	}
+	return ids
 }
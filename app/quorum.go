@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+func parseQuorumVoters(s string) (map[int32]string, error) {
+	voters := make(map[int32]string)
+
+	for _, entry := range strings.Split(s, ",") {
+		id, addr, ok := strings.Cut(strings.TrimSpace(entry), "@")
+		if !ok {
+			return nil, fmt.Errorf("voter %q is not in id@host:port form", entry)
+		}
+
+		n, err := strconv.ParseInt(id, 10, 32)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("voter %q has an invalid id", entry)
+		}
+		if _, _, err := splitHostPort(addr); err != nil {
+			return nil, fmt.Errorf("voter %q: %w", entry, err)
+		}
+		if _, dup := voters[int32(n)]; dup {
+			return nil, fmt.Errorf("voter %d listed more than once", n)
+		}
+		voters[int32(n)] = addr
+	}
+
+	return voters, nil
+}
+
+func splitHostPort(addr string) (string, int32, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", 0, err
+	}
+
+	n, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid port %q", port)
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	return host, int32(n), nil
+}
@@ -0,0 +1,74 @@
+package domain
+
+type ClusterIdentity struct {
+	ClusterID string
+	NodeID    int32
+}
+
+type BrokerEndpoint struct {
+	Listener         string
+	Host             string
+	Port             int32
+	SecurityProtocol int16
+}
+
+type BrokerRegistration struct {
+	ID                   int32
+	Epoch                int64
+	IncarnationID        [16]byte
+	Endpoints            []BrokerEndpoint
+	Rack                 *string
+	Fenced               bool
+	InControlledShutdown bool
+	LogDirs              [][16]byte
+}
+
+func (b *BrokerRegistration) Clone() *BrokerRegistration {
+	c := *b
+	c.Endpoints = append([]BrokerEndpoint(nil), b.Endpoints...)
+	c.LogDirs = append([][16]byte(nil), b.LogDirs...)
+	if b.Rack != nil {
+		rack := *b.Rack
+		c.Rack = &rack
+	}
+	return &c
+}
+
+type BrokerFencing struct {
+	BrokerID int32
+	Epoch    int64
+	Fenced   bool
+}
+
+type ControllerRegistration struct {
+	ID            int32
+	IncarnationID [16]byte
+	Endpoints     []BrokerEndpoint
+}
+
+type LocalBroker struct {
+	ClusterIdentity
+	Endpoint BrokerEndpoint
+	Rack     *string
+	Configs  map[string]string
+}
+
+func (b *BrokerRegistration) Endpoint(listener string) (BrokerEndpoint, bool) {
+	return selectEndpoint(b.Endpoints, listener)
+}
+
+func (c *ControllerRegistration) Endpoint(listener string) (BrokerEndpoint, bool) {
+	return selectEndpoint(c.Endpoints, listener)
+}
+
+func selectEndpoint(endpoints []BrokerEndpoint, listener string) (BrokerEndpoint, bool) {
+	for _, ep := range endpoints {
+		if ep.Listener == listener {
+			return ep, true
+		}
+	}
+	if len(endpoints) > 0 {
+		return endpoints[0], true
+	}
+	return BrokerEndpoint{}, false
+}
@@ -0,0 +1,514 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	ConfigResourceTopic  int8 = 2
+	ConfigResourceBroker int8 = 4
+)
+
+const (
+	ConfigSourceUnknown int8 = iota
+	ConfigSourceDynamicTopic
+	ConfigSourceDynamicBroker
+	ConfigSourceDynamicDefaultBroker
+	ConfigSourceStaticBroker
+	ConfigSourceDefault
+)
+
+type ConfigType int8
+
+const (
+	ConfigTypeUnknown ConfigType = iota
+	ConfigTypeBoolean
+	ConfigTypeString
+	ConfigTypeInt
+	ConfigTypeShort
+	ConfigTypeLong
+	ConfigTypeDouble
+	ConfigTypeList
+)
+
+const (
+	ConfigOpSet int8 = iota
+	ConfigOpDelete
+	ConfigOpAppend
+	ConfigOpSubtract
+)
+
+const (
+	BrokerConfigNodeID                = "node.id"
+	BrokerConfigRack                  = "broker.rack"
+	BrokerConfigLogDirs               = "log.dirs"
+	BrokerConfigReplicaLagTimeMaxMs   = "replica.lag.time.max.ms"
+	BrokerConfigLeaderThrottledRate   = "leader.replication.throttled.rate"
+	BrokerConfigFollowerThrottledRate = "follower.replication.throttled.rate"
+	BrokerConfigCompressionType       = "compression.type"
+	BrokerConfigMessageTimestampType  = "log.message.timestamp.type"
+	BrokerConfigTimestampBeforeMaxMs  = "log.message.timestamp.before.max.ms"
+	BrokerConfigTimestampAfterMaxMs   = "log.message.timestamp.after.max.ms"
+	BrokerConfigMinInsyncReplicas     = "min.insync.replicas"
+	BrokerConfigUncleanLeaderElection = "unclean.leader.election.enable"
+)
+
+type ConfigResource struct {
+	Type int8
+	Name string
+}
+
+type ConfigDefinition struct {
+	Name          string
+	Type          ConfigType
+	Default       *string
+	Documentation string
+	ValidValues   []string
+	Min           *int64
+	ReadOnly      bool
+	Synonym       string
+	validItem     func(string) bool
+}
+
+type ConfigSynonym struct {
+	Name   string
+	Value  *string
+	Source int8
+}
+
+type ConfigDescription struct {
+	Name          string
+	Value         *string
+	Source        int8
+	ReadOnly      bool
+	Type          ConfigType
+	Documentation string
+	Synonyms      []ConfigSynonym
+}
+
+type ConfigLayers struct {
+	Topic          map[string]string
+	Broker         map[string]string
+	ClusterDefault map[string]string
+	Static         map[string]string
+}
+
+type ConfigOp struct {
+	Name  string
+	Op    int8
+	Value *string
+}
+
+type ConfigAlteration struct {
+	Resource    ConfigResource
+	Incremental bool
+	Ops         []ConfigOp
+}
+
+type ConfigAlterationResult struct {
+	Resource     ConfigResource
+	ErrorCode    int16
+	ErrorMessage *string
+}
+
+type ConfigChange struct {
+	Resource ConfigResource
+	Name     string
+	Value    *string
+}
+
+var (
+	compressionTypes = []string{"uncompressed", "zstd", "lz4", "snappy", "gzip", TopicCompressionProducer}
+	timestampTypes   = []string{TimestampTypeNameCreateTime, TimestampTypeNameLogAppendTime}
+)
+
+var brokerConfigs = []ConfigDefinition{
+	{
+		Name:          BrokerConfigNodeID,
+		Type:          ConfigTypeInt,
+		ReadOnly:      true,
+		Documentation: "The node ID associated with the roles this process is playing.",
+	},
+	{
+		Name:          BrokerConfigRack,
+		Type:          ConfigTypeString,
+		ReadOnly:      true,
+		Documentation: "Rack of the broker, used for rack-aware replica placement and follower fetching.",
+	},
+	{
+		Name:          BrokerConfigLogDirs,
+		Type:          ConfigTypeList,
+		ReadOnly:      true,
+		Documentation: "The directories in which the log data is kept.",
+	},
+	{
+		Name:          BrokerConfigReplicaLagTimeMaxMs,
+		Type:          ConfigTypeLong,
+		Default:       configValue("30000"),
+		Min:           configMin(0),
+		ReadOnly:      true,
+		Documentation: "If a follower hasn't caught up to the leader's log end offset for at least this time, the leader removes it from the ISR.",
+	},
+	{
+		Name:          BrokerConfigLeaderThrottledRate,
+		Type:          ConfigTypeLong,
+		Default:       configValue(strconv.FormatInt(math.MaxInt64, 10)),
+		Min:           configMin(0),
+		Documentation: "Bytes per second that leaders send to throttled replicas.",
+	},
+	{
+		Name:          BrokerConfigFollowerThrottledRate,
+		Type:          ConfigTypeLong,
+		Default:       configValue(strconv.FormatInt(math.MaxInt64, 10)),
+		Min:           configMin(0),
+		Documentation: "Bytes per second that followers fetch for throttled replicas.",
+	},
+	{
+		Name:          BrokerConfigCompressionType,
+		Type:          ConfigTypeString,
+		Default:       configValue(TopicCompressionProducer),
+		ValidValues:   compressionTypes,
+		Documentation: "Default final compression type for topics, 'producer' retains the codec set by the producer.",
+	},
+	{
+		Name:          BrokerConfigMessageTimestampType,
+		Type:          ConfigTypeString,
+		Default:       configValue(TimestampTypeNameCreateTime),
+		ValidValues:   timestampTypes,
+		Documentation: "Default for whether record timestamps are the producer's create time or the log append time.",
+	},
+	{
+		Name:          BrokerConfigTimestampBeforeMaxMs,
+		Type:          ConfigTypeLong,
+		Default:       configValue(strconv.FormatInt(math.MaxInt64, 10)),
+		Min:           configMin(0),
+		Documentation: "Default for how far a record timestamp may lie before the broker's time.",
+	},
+	{
+		Name:          BrokerConfigTimestampAfterMaxMs,
+		Type:          ConfigTypeLong,
+		Default:       configValue(strconv.FormatInt(math.MaxInt64, 10)),
+		Min:           configMin(0),
+		Documentation: "Default for how far a record timestamp may lie after the broker's time.",
+	},
+	{
+		Name:          BrokerConfigMinInsyncReplicas,
+		Type:          ConfigTypeInt,
+		Default:       configValue(strconv.Itoa(DefaultMinInsyncReplicas)),
+		Min:           configMin(1),
+		Documentation: "Default minimum number of in-sync replicas that must acknowledge a write with acks=all.",
+	},
+	{
+		Name:          BrokerConfigUncleanLeaderElection,
+		Type:          ConfigTypeBoolean,
+		Default:       configValue("false"),
+		Documentation: "Default for whether replicas outside the ISR may be elected leader as a last resort.",
+	},
+}
+
+var topicConfigs = []ConfigDefinition{
+	{
+		Name:          TopicConfigCompressionType,
+		Type:          ConfigTypeString,
+		ValidValues:   compressionTypes,
+		Synonym:       BrokerConfigCompressionType,
+		Documentation: "Final compression type of the topic, 'producer' retains the codec set by the producer.",
+	},
+	{
+		Name:          TopicConfigMessageTimestampType,
+		Type:          ConfigTypeString,
+		ValidValues:   timestampTypes,
+		Synonym:       BrokerConfigMessageTimestampType,
+		Documentation: "Whether record timestamps are the producer's create time or the log append time.",
+	},
+	{
+		Name:          TopicConfigTimestampBeforeMaxMs,
+		Type:          ConfigTypeLong,
+		Min:           configMin(0),
+		Synonym:       BrokerConfigTimestampBeforeMaxMs,
+		Documentation: "Maximum allowed difference between the broker's time and an earlier record timestamp.",
+	},
+	{
+		Name:          TopicConfigTimestampAfterMaxMs,
+		Type:          ConfigTypeLong,
+		Min:           configMin(0),
+		Synonym:       BrokerConfigTimestampAfterMaxMs,
+		Documentation: "Maximum allowed difference between the broker's time and a later record timestamp.",
+	},
+	{
+		Name:          TopicConfigMinInsyncReplicas,
+		Type:          ConfigTypeInt,
+		Min:           configMin(1),
+		Synonym:       BrokerConfigMinInsyncReplicas,
+		Documentation: "Minimum number of in-sync replicas that must acknowledge a write with acks=all.",
+	},
+	{
+		Name:          TopicConfigUncleanLeaderElection,
+		Type:          ConfigTypeBoolean,
+		Synonym:       BrokerConfigUncleanLeaderElection,
+		Documentation: "Whether replicas outside the ISR may be elected leader as a last resort.",
+	},
+	{
+		Name:          TopicConfigLeaderThrottledReplicas,
+		Type:          ConfigTypeList,
+		Default:       configValue(""),
+		validItem:     validThrottledReplica,
+		Documentation: "Replicas, as partition:broker pairs or '*', whose log is throttled on the leader side.",
+	},
+	{
+		Name:          TopicConfigFollowerThrottledReplicas,
+		Type:          ConfigTypeList,
+		Default:       configValue(""),
+		validItem:     validThrottledReplica,
+		Documentation: "Replicas, as partition:broker pairs or '*', whose log is throttled on the follower side.",
+	},
+}
+
+func BrokerConfigDefinitions() []ConfigDefinition {
+	return brokerConfigs
+}
+
+func TopicConfigDefinitions() []ConfigDefinition {
+	return topicConfigs
+}
+
+func BrokerConfigDefinition(name string) (ConfigDefinition, bool) {
+	return findConfigDefinition(brokerConfigs, name)
+}
+
+func TopicConfigDefinition(name string) (ConfigDefinition, bool) {
+	return findConfigDefinition(topicConfigs, name)
+}
+
+func findConfigDefinition(defs []ConfigDefinition, name string) (ConfigDefinition, bool) {
+	for _, d := range defs {
+		if d.Name == name {
+			return d, true
+		}
+	}
+	return ConfigDefinition{}, false
+}
+
+func (d ConfigDefinition) Validate(value string) string {
+	invalid := func(reason string) string {
+		return fmt.Sprintf("invalid value %s for configuration %s: %s", value, d.Name, reason)
+	}
+
+	switch d.Type {
+	case ConfigTypeBoolean:
+		if !strings.EqualFold(value, "true") && !strings.EqualFold(value, "false") {
+			return invalid("expected value to be either true or false")
+		}
+
+	case ConfigTypeInt, ConfigTypeShort, ConfigTypeLong:
+		bits := map[ConfigType]int{ConfigTypeShort: 16, ConfigTypeInt: 32, ConfigTypeLong: 64}[d.Type]
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, bits)
+		if err != nil {
+			return invalid(fmt.Sprintf("not a number of type %s", d.Type))
+		}
+		if d.Min != nil && n < *d.Min {
+			return invalid(fmt.Sprintf("value must be at least %d", *d.Min))
+		}
+
+	case ConfigTypeList:
+		for _, item := range SplitConfigList(value) {
+			if d.validItem != nil && !d.validItem(item) {
+				return invalid(fmt.Sprintf("%q is not a valid entry", item))
+			}
+		}
+	}
+
+	if len(d.ValidValues) > 0 && !slices.Contains(d.ValidValues, value) {
+		return invalid("string must be one of: " + strings.Join(d.ValidValues, ", "))
+	}
+	return ""
+}
+
+func (d ConfigDefinition) Apply(configs map[string]string, op ConfigOp) (int16, string) {
+	switch op.Op {
+	case ConfigOpDelete:
+		delete(configs, d.Name)
+		return 0, ""
+
+	case ConfigOpSet:
+		if op.Value == nil {
+			return ErrorInvalidRequest, "null value not supported for configuration " + d.Name
+		}
+		if msg := d.Validate(*op.Value); msg != "" {
+			return ErrorInvalidConfig, msg
+		}
+		configs[d.Name] = *op.Value
+		return 0, ""
+
+	case ConfigOpAppend, ConfigOpSubtract:
+		if d.Type != ConfigTypeList {
+			return ErrorInvalidConfig, fmt.Sprintf("config value append or subtract is not allowed for config %s since it is not a list", d.Name)
+		}
+		if op.Value == nil {
+			return ErrorInvalidRequest, "null value not supported for configuration " + d.Name
+		}
+
+		current, ok := configs[d.Name]
+		if !ok && d.Default != nil {
+			current = *d.Default
+		}
+		items := SplitConfigList(current)
+		for _, item := range SplitConfigList(*op.Value) {
+			if op.Op == ConfigOpAppend && !slices.Contains(items, item) {
+				items = append(items, item)
+			}
+			if op.Op == ConfigOpSubtract {
+				items = slices.DeleteFunc(items, func(s string) bool { return s == item })
+			}
+		}
+
+		value := strings.Join(items, ",")
+		if msg := d.Validate(value); msg != "" {
+			return ErrorInvalidConfig, msg
+		}
+		configs[d.Name] = value
+		return 0, ""
+	}
+	return ErrorInvalidRequest, fmt.Sprintf("unknown config operation %d", op.Op)
+}
+
+func DescribeTopicConfig(d ConfigDefinition, layers ConfigLayers) ConfigDescription {
+	synonyms := appendSynonym(nil, d.Name, ConfigSourceDynamicTopic, layers.Topic)
+	if d.Synonym == "" {
+		return describeConfig(d, appendDefaultSynonym(synonyms, d))
+	}
+
+	broker, _ := BrokerConfigDefinition(d.Synonym)
+	synonyms = appendSynonym(synonyms, d.Synonym, ConfigSourceDynamicDefaultBroker, layers.ClusterDefault)
+	synonyms = appendSynonym(synonyms, d.Synonym, ConfigSourceStaticBroker, layers.Static)
+	return describeConfig(d, appendDefaultSynonym(synonyms, broker))
+}
+
+func DescribeBrokerConfig(d ConfigDefinition, layers ConfigLayers) ConfigDescription {
+	synonyms := appendSynonym(nil, d.Name, ConfigSourceDynamicBroker, layers.Broker)
+	synonyms = appendSynonym(synonyms, d.Name, ConfigSourceDynamicDefaultBroker, layers.ClusterDefault)
+	synonyms = appendSynonym(synonyms, d.Name, ConfigSourceStaticBroker, layers.Static)
+	return describeConfig(d, appendDefaultSynonym(synonyms, d))
+}
+
+func describeConfig(d ConfigDefinition, synonyms []ConfigSynonym) ConfigDescription {
+	desc := ConfigDescription{
+		Name:          d.Name,
+		Source:        ConfigSourceDefault,
+		ReadOnly:      d.ReadOnly,
+		Type:          d.Type,
+		Documentation: d.Documentation,
+		Synonyms:      synonyms,
+	}
+	if len(synonyms) > 0 {
+		desc.Value = synonyms[0].Value
+		desc.Source = synonyms[0].Source
+	}
+	return desc
+}
+
+func appendSynonym(out []ConfigSynonym, name string, source int8, layer map[string]string) []ConfigSynonym {
+	if v, ok := layer[name]; ok {
+		out = append(out, ConfigSynonym{Name: name, Value: &v, Source: source})
+	}
+	return out
+}
+
+func appendDefaultSynonym(out []ConfigSynonym, d ConfigDefinition) []ConfigSynonym {
+	if d.Default != nil {
+		out = append(out, ConfigSynonym{Name: d.Name, Value: d.Default, Source: ConfigSourceDefault})
+	}
+	return out
+}
+
+func TopicConfigDefaults(layers ...map[string]string) map[string]string {
+	var out map[string]string
+	for _, d := range topicConfigs {
+		if d.Synonym == "" {
+			continue
+		}
+		v, ok := LookupConfig(d.Synonym, layers...)
+		if !ok {
+			continue
+		}
+		if out == nil {
+			out = map[string]string{}
+		}
+		out[d.Name] = v
+	}
+	return out
+}
+
+func LookupConfig(name string, layers ...map[string]string) (string, bool) {
+	for _, layer := range layers {
+		if v, ok := layer[name]; ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+func SplitConfigList(value string) []string {
+	out := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func SortedConfigNames(configs map[string]string) []string {
+	names := make([]string, 0, len(configs))
+	for name := range configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (t ConfigType) String() string {
+	switch t {
+	case ConfigTypeBoolean:
+		return "BOOLEAN"
+	case ConfigTypeString:
+		return "STRING"
+	case ConfigTypeInt:
+		return "INT"
+	case ConfigTypeShort:
+		return "SHORT"
+	case ConfigTypeLong:
+		return "LONG"
+	case ConfigTypeDouble:
+		return "DOUBLE"
+	case ConfigTypeList:
+		return "LIST"
+	}
+	return "UNKNOWN"
+}
+
+func validThrottledReplica(item string) bool {
+	if item == "*" {
+		return true
+	}
+	partition, broker, ok := strings.Cut(item, ":")
+	if !ok {
+		return false
+	}
+	_, perr := strconv.ParseInt(strings.TrimSpace(partition), 10, 32)
+	_, berr := strconv.ParseInt(strings.TrimSpace(broker), 10, 32)
+	return perr == nil && berr == nil
+}
+
+func configValue(s string) *string {
+	return &s
+}
+
+func configMin(n int64) *int64 {
+	return &n
+}
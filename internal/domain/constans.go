@@ -4,12 +4,94 @@ const DescribeTopicPartitionsApikey = 75
 const ApiVersionApikey = 18
 const FetchApikey = 1
 const ProduceApiKey = 0
+const DescribeLogDirsApiKey = 35
+const AlterReplicaLogDirsApiKey = 34
+const DescribeClusterApiKey = 60
+const UpdateFeaturesApiKey = 57
+const VoteApiKey = 52
+const BeginQuorumEpochApiKey = 53
+const EndQuorumEpochApiKey = 54
+const FetchSnapshotApiKey = 59
+const DescribeQuorumApiKey = 55
+const AlterPartitionApiKey = 56
+const ElectLeadersApiKey = 43
+const OffsetForLeaderEpochApiKey = 23
+const AlterPartitionReassignmentsApiKey = 45
+const ListPartitionReassignmentsApiKey = 46
+const CreateTopicsApiKey = 19
+const DescribeConfigsApiKey = 32
+const AlterConfigsApiKey = 33
+const IncrementalAlterConfigsApiKey = 44
 
 const NONE = 0
 const MaximumVersionApiKey = 4
 const MaximumVersionFetchApiKey = 16
 const MaximumVersionProduceApiKey = 11
+const MinimumVersionDescribeLogDirsApiKey = 2
+const MaximumVersionDescribeLogDirsApiKey = 4
+const MinimumVersionAlterReplicaLogDirsApiKey = 2
+const MaximumVersionAlterReplicaLogDirsApiKey = 2
+const MaximumVersionDescribeClusterApiKey = 1
+const MaximumVersionUpdateFeaturesApiKey = 1
+const MaximumVersionVoteApiKey = 0
+const MaximumVersionBeginQuorumEpochApiKey = 0
+const MaximumVersionEndQuorumEpochApiKey = 0
+const MaximumVersionFetchSnapshotApiKey = 0
+const MaximumVersionDescribeQuorumApiKey = 1
+const MaximumVersionAlterPartitionApiKey = 3
+const MaximumVersionElectLeadersApiKey = 2
+const MaximumVersionOffsetForLeaderEpochApiKey = 4
+const MaximumVersionAlterPartitionReassignmentsApiKey = 0
+const MaximumVersionListPartitionReassignmentsApiKey = 0
+const MinimumVersionCreateTopicsApiKey = 2
+const MaximumVersionCreateTopicsApiKey = 7
+const MinimumVersionDescribeConfigsApiKey = 1
+const MaximumVersionDescribeConfigsApiKey = 4
+const MaximumVersionAlterConfigsApiKey = 2
+const MaximumVersionIncrementalAlterConfigsApiKey = 1
 
+const MaxRequestSize = 100 << 20
+
+const ErrorUnknownServerError = -1
 const ErrorNotSupportedApiVersion = 35
 const ErrorUnknownTopicOrPartition = 3
 const ErrorUnknownTopicId = 100
+const ErrorCorruptMessage = 2
+const ErrorInvalidTimestamp = 32
+const ErrorKafkaStorage = 56
+const ErrorLogDirNotFound = 57
+const ErrorUnsupportedEndpointType = 115
+const ErrorInvalidRequest = 42
+const ErrorInvalidUpdateVersion = 95
+const ErrorFeatureUpdateFailed = 96
+const ErrorOffsetOutOfRange = 1
+const ErrorNotLeaderOrFollower = 6
+const ErrorRequestTimedOut = 7
+const ErrorNotEnoughReplicas = 19
+const ErrorNotEnoughReplicasAfterAppend = 20
+const ErrorNotController = 41
+const ErrorFencedLeaderEpoch = 74
+const ErrorUnknownLeaderEpoch = 75
+const ErrorPreferredLeaderNotAvailable = 80
+const ErrorEligibleLeadersNotAvailable = 83
+const ErrorElectionNotNeeded = 84
+const ErrorInconsistentVoterSet = 94
+const ErrorSnapshotNotFound = 98
+const ErrorPositionOutOfRange = 99
+const ErrorInconsistentClusterID = 104
+const ErrorIneligibleReplica = 107
+const ErrorInvalidReplicaAssignment = 39
+const ErrorNoReassignmentInProgress = 85
+const ErrorInvalidTopic = 17
+const ErrorTopicAlreadyExists = 36
+const ErrorInvalidPartitions = 37
+const ErrorInvalidReplicationFactor = 38
+const ErrorInvalidConfig = 40
+
+const AcksAll = -1
+
+const EndpointTypeBroker = 1
+const EndpointTypeController = 2
+
+const ClusterAuthorizedOperationsAll = 8080
+const AuthorizedOperationsOmitted = -2147483648
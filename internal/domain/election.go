@@ -0,0 +1,71 @@
+package domain
+
+import "slices"
+
+type ElectionType int8
+
+const (
+	ElectionPreferred ElectionType = 0
+	ElectionUnclean   ElectionType = 1
+)
+
+type ElectionTarget struct {
+	Topic      string
+	Partitions []int32
+}
+
+type ElectionResult struct {
+	Topic        string
+	Partition    int32
+	ErrorCode    int16
+	ErrorMessage *string
+}
+
+func ElectLeader(pm PartitionMetadata, isr []int32, alive func(int32) bool, unclean bool) (int32, []int32) {
+	for _, id := range pm.Replicas {
+		if slices.Contains(isr, id) && alive(id) {
+			return id, isr
+		}
+	}
+
+	if len(isr) == 0 {
+		for _, id := range pm.Replicas {
+			if slices.Contains(pm.EligibleLeaderReplicas, id) && alive(id) {
+				return id, []int32{id}
+			}
+		}
+	}
+
+	if unclean {
+		for _, id := range pm.Replicas {
+			if alive(id) {
+				return id, []int32{id}
+			}
+		}
+	}
+	return NoLeader, isr
+}
+
+func PreferredLeader(pm PartitionMetadata) int32 {
+	if len(pm.Replicas) == 0 {
+		return NoLeader
+	}
+	return pm.Replicas[0]
+}
+
+func EligibleLeaders(pm PartitionMetadata, isr []int32, minISR int) []int32 {
+	elr := make([]int32, 0)
+	if len(isr) >= minISR {
+		return elr
+	}
+
+	for _, id := range pm.Replicas {
+		if slices.Contains(isr, id) {
+			continue
+		}
+		if slices.Contains(pm.ISR, id) || slices.Contains(pm.EligibleLeaderReplicas, id) {
+			elr = append(elr, id)
+		}
+	}
+	return elr
+}
@@ -0,0 +1,16 @@
+package domain
+
+import "errors"
+
+var (
+	ErrLogDirOffline  = errors.New("log directory offline")
+	ErrLogDirNotFound = errors.New("log directory not found")
+
+	ErrCorruptRecords      = errors.New("corrupt record batch")
+	ErrOffsetOutOfRange    = errors.New("offset out of range")
+	ErrNotLeaderOrFollower = errors.New("not the leader or follower for partition")
+	ErrReplicationTimedOut = errors.New("timed out waiting for in-sync replicas")
+	ErrNotEnoughReplicas   = errors.New("in-sync replicas below min.insync.replicas")
+
+	ErrNotController = errors.New("not the active controller")
+)
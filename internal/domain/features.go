@@ -0,0 +1,59 @@
+package domain
+
+const (
+	FeatureMetadataVersion    = "metadata.version"
+	FeatureKRaftVersion       = "kraft.version"
+	FeatureTransactionVersion = "transaction.version"
+)
+
+type MetadataFeatureLevel int16
+
+const (
+	MetadataVersionUnset   MetadataFeatureLevel = 0
+	MetadataVersionMinimum MetadataFeatureLevel = 1
+	MetadataVersion3_7_IV2 MetadataFeatureLevel = 17
+	MetadataVersion4_0_IV1 MetadataFeatureLevel = 23
+	MetadataVersionLatest  MetadataFeatureLevel = MetadataVersion4_0_IV1
+)
+
+const FinalizedFeaturesEpochUnknown = -1
+
+const (
+	FeatureUpgrade         int8 = 1
+	FeatureSafeDowngrade   int8 = 2
+	FeatureUnsafeDowngrade int8 = 3
+)
+
+func (v MetadataFeatureLevel) AtLeast(other MetadataFeatureLevel) bool {
+	if v == MetadataVersionUnset {
+		return true
+	}
+	return v >= other
+}
+
+func (v MetadataFeatureLevel) IsDirectoryAssignmentSupported() bool {
+	return v.AtLeast(MetadataVersion3_7_IV2)
+}
+
+type SupportedFeature struct {
+	Name       string
+	MinVersion int16
+	MaxVersion int16
+}
+
+func SupportedFeatures() []SupportedFeature {
+	return []SupportedFeature{
+		{Name: FeatureKRaftVersion, MinVersion: 0, MaxVersion: 1},
+		{Name: FeatureMetadataVersion, MinVersion: int16(MetadataVersionMinimum), MaxVersion: int16(MetadataVersionLatest)},
+		{Name: FeatureTransactionVersion, MinVersion: 0, MaxVersion: 2},
+	}
+}
+
+type FinalizedFeatures struct {
+	Epoch  int64
+	Levels map[string]int16
+}
+
+func (f FinalizedFeatures) MetadataVersion() MetadataFeatureLevel {
+	return MetadataFeatureLevel(f.Levels[FeatureMetadataVersion])
+}
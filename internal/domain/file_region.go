@@ -0,0 +1,16 @@
+package domain
+
+import "os"
+
+type FileRegion struct {
+	File   *os.File
+	Offset int64
+	Length int64
+}
+
+func (r *FileRegion) Close() error {
+	if r == nil || r.File == nil {
+		return nil
+	}
+	return r.File.Close()
+}
@@ -0,0 +1,22 @@
+package domain
+
+type LogDirDescription struct {
+	Path        string
+	Offline     bool
+	TotalBytes  int64
+	UsableBytes int64
+	Partitions  []LogDirPartition
+}
+
+type LogDirPartition struct {
+	Topic     string
+	Partition int32
+	Size      int64
+	OffsetLag int64
+	IsFuture  bool
+}
+
+type LogAppendInfo struct {
+	BaseOffset int64
+	LastOffset int64
+}
@@ -0,0 +1,23 @@
+package domain
+
+type MetadataChangeType int8
+
+const (
+	TopicCreated MetadataChangeType = iota
+	TopicDeleted
+	PartitionChanged
+	TopicConfigChanged
+	BrokerConfigChanged
+)
+
+type MetadataChange struct {
+	Type      MetadataChangeType
+	Topic     string
+	TopicID   [16]byte
+	Partition int32
+}
+
+type MetadataVersion struct {
+	Offset int64
+	Epoch  int32
+}
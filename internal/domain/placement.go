@@ -0,0 +1,73 @@
+package domain
+
+import "sort"
+
+type BrokerPlacement struct {
+	ID   int32
+	Rack string
+}
+
+func PlaceReplicas(brokers []BrokerPlacement, partitions int32, replicationFactor int16, startIndex, shift int) [][]int32 {
+	arranged, numRacks := rackAlternatedBrokers(brokers)
+	n := len(arranged)
+
+	rackOf := make(map[int32]string, n)
+	for _, b := range brokers {
+		rackOf[b.ID] = b.Rack
+	}
+
+	out := make([][]int32, 0, partitions)
+	for p := 0; p < int(partitions); p++ {
+		if p > 0 && p%n == 0 {
+			shift++
+		}
+
+		first := (p + startIndex) % n
+		leader := arranged[first]
+		replicas := []int32{leader}
+		racks := map[string]bool{rackOf[leader]: true}
+		used := map[int32]bool{leader: true}
+
+		for k := 0; len(replicas) < int(replicationFactor); k++ {
+			id := arranged[replicaIndex(first, shift*numRacks, k, n)]
+			rack := rackOf[id]
+			if (racks[rack] && len(racks) < numRacks) || (used[id] && len(used) < n) {
+				continue
+			}
+			replicas = append(replicas, id)
+			racks[rack] = true
+			used[id] = true
+		}
+		out = append(out, replicas)
+	}
+	return out
+}
+
+func replicaIndex(first, secondShift, index, n int) int {
+	shift := 1 + (secondShift+index)%(n-1)
+	return (first + shift) % n
+}
+
+func rackAlternatedBrokers(brokers []BrokerPlacement) ([]int32, int) {
+	byRack := map[string][]int32{}
+	for _, b := range brokers {
+		byRack[b.Rack] = append(byRack[b.Rack], b.ID)
+	}
+
+	racks := make([]string, 0, len(byRack))
+	for rack, ids := range byRack {
+		racks = append(racks, rack)
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	}
+	sort.Strings(racks)
+
+	out := make([]int32, 0, len(brokers))
+	for i := 0; len(out) < len(brokers); i++ {
+		for _, rack := range racks {
+			if i < len(byRack[rack]) {
+				out = append(out, byRack[rack][i])
+			}
+		}
+	}
+	return out, len(racks)
+}
@@ -0,0 +1,91 @@
+package domain
+
+const MetadataTopicName = "__cluster_metadata"
+const MetadataPartition = 0
+
+var MetadataTopicID = [16]byte{15: 1}
+
+const ReplicaIDConsumer = -1
+
+type LeaderAndEpoch struct {
+	LeaderID int32
+	Epoch    int32
+}
+
+type SnapshotID struct {
+	EndOffset int64
+	Epoch     int32
+}
+
+type EpochEndOffset struct {
+	Epoch     int32
+	EndOffset int64
+}
+
+type QuorumVote struct {
+	CandidateID     int32
+	CandidateEpoch  int32
+	LastOffsetEpoch int32
+	LastOffset      int64
+}
+
+type QuorumVoteResult struct {
+	ErrorCode int16
+	Leader    LeaderAndEpoch
+	Granted   bool
+}
+
+type QuorumEpochResult struct {
+	ErrorCode int16
+	Leader    LeaderAndEpoch
+}
+
+type QuorumFetch struct {
+	ReplicaID          int32
+	CurrentLeaderEpoch int32
+	FetchOffset        int64
+	LastFetchedEpoch   int32
+	MaxBytes           int32
+}
+
+type QuorumFetchResult struct {
+	ErrorCode      int16
+	Leader         LeaderAndEpoch
+	HighWatermark  int64
+	LogStartOffset int64
+	Records        []byte
+	DivergingEpoch *EpochEndOffset
+	SnapshotID     *SnapshotID
+}
+
+type QuorumSnapshotFetch struct {
+	ReplicaID          int32
+	CurrentLeaderEpoch int32
+	SnapshotID         SnapshotID
+	Position           int64
+	MaxBytes           int32
+}
+
+type QuorumSnapshotChunk struct {
+	ErrorCode  int16
+	Leader     LeaderAndEpoch
+	SnapshotID SnapshotID
+	Size       int64
+	Position   int64
+	Bytes      []byte
+}
+
+type QuorumReplicaState struct {
+	ReplicaID             int32
+	LogEndOffset          int64
+	LastFetchTimestamp    int64
+	LastCaughtUpTimestamp int64
+}
+
+type QuorumDescription struct {
+	ErrorCode     int16
+	Leader        LeaderAndEpoch
+	HighWatermark int64
+	Voters        []QuorumReplicaState
+	Observers     []QuorumReplicaState
+}
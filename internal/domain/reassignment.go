@@ -0,0 +1,134 @@
+package domain
+
+import "slices"
+
+type ReassignmentTarget struct {
+	Topic     string
+	Partition int32
+	Replicas  []int32
+}
+
+type ReassignmentResult struct {
+	Topic        string
+	Partition    int32
+	ErrorCode    int16
+	ErrorMessage *string
+}
+
+func (p PartitionMetadata) Reassigning() bool {
+	return len(p.AddingReplicas) > 0 || len(p.RemovingReplicas) > 0
+}
+
+func OriginalReplicas(pm PartitionMetadata) []int32 {
+	return without(pm.Replicas, pm.AddingReplicas)
+}
+
+func TargetReplicas(pm PartitionMetadata) []int32 {
+	return without(pm.Replicas, pm.RemovingReplicas)
+}
+
+func Reassign(pm PartitionMetadata, target []int32) PartitionMetadata {
+	original := OriginalReplicas(pm)
+	removing := without(original, target)
+
+	next := pm.Clone()
+	next.Replicas = append(slices.Clone(target), removing...)
+	next.AddingReplicas = without(target, original)
+	next.RemovingReplicas = removing
+	next.ISR = intersect(pm.ISR, next.Replicas)
+	return AdvanceReassignment(next)
+}
+
+func CancelReassignment(pm PartitionMetadata) PartitionMetadata {
+	next := pm.Clone()
+	next.Replicas = OriginalReplicas(pm)
+	next.AddingReplicas = make([]int32, 0)
+	next.RemovingReplicas = make([]int32, 0)
+	next.ISR = intersect(pm.ISR, next.Replicas)
+	return AdvanceReassignment(next)
+}
+
+func AdvanceReassignment(pm PartitionMetadata) PartitionMetadata {
+	target := TargetReplicas(pm)
+	if pm.Reassigning() && len(without(target, pm.ISR)) == 0 {
+		pm.Replicas = target
+		pm.AddingReplicas = make([]int32, 0)
+		pm.RemovingReplicas = make([]int32, 0)
+		pm.ISR = intersect(pm.ISR, target)
+	}
+
+	if !slices.Contains(pm.Replicas, pm.LeaderID) || !slices.Contains(pm.ISR, pm.LeaderID) {
+		pm.LeaderID = NoLeader
+		for _, id := range pm.Replicas {
+			if slices.Contains(pm.ISR, id) {
+				pm.LeaderID = id
+				break
+			}
+		}
+	}
+	return pm
+}
+
+func DiffPartition(meta *TopicMetadata, pm PartitionMetadata, next PartitionMetadata) (PartitionChange, bool) {
+	change := PartitionChange{
+		TopicID:   meta.TopicID,
+		Partition: pm.PartitionIndex,
+		Leader:    NoLeaderChange,
+	}
+	changed := false
+
+	if next.LeaderID != pm.LeaderID {
+		change.Leader = next.LeaderID
+		changed = true
+	}
+	if !slices.Equal(next.ISR, pm.ISR) {
+		change.ISR = nonNil(next.ISR)
+		changed = true
+	}
+	if !slices.Equal(next.Replicas, pm.Replicas) {
+		change.Replicas = nonNil(next.Replicas)
+		changed = true
+	}
+	if !slices.Equal(next.AddingReplicas, pm.AddingReplicas) {
+		change.AddingReplicas = nonNil(next.AddingReplicas)
+		changed = true
+	}
+	if !slices.Equal(next.RemovingReplicas, pm.RemovingReplicas) {
+		change.RemovingReplicas = nonNil(next.RemovingReplicas)
+		changed = true
+	}
+
+	elr := intersect(EligibleLeaders(pm, next.ISR, meta.MinInsyncReplicas()), next.Replicas)
+	if !slices.Equal(elr, pm.EligibleLeaderReplicas) {
+		change.ELR = elr
+		changed = true
+	}
+	return change, changed
+}
+
+func without(ids []int32, remove []int32) []int32 {
+	out := make([]int32, 0, len(ids))
+	for _, id := range ids {
+		if !slices.Contains(remove, id) {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+func intersect(ids []int32, keep []int32) []int32 {
+	out := make([]int32, 0, len(ids))
+	for _, id := range ids {
+		if slices.Contains(keep, id) {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+func nonNil(ids []int32) []int32 {
+	if ids == nil {
+		return make([]int32, 0)
+	}
+	return ids
+}
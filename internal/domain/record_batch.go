@@ -0,0 +1,86 @@
+package domain
+
+type CompressionType int8
+
+const (
+	CompressionNone   CompressionType = 0
+	CompressionGzip   CompressionType = 1
+	CompressionSnappy CompressionType = 2
+	CompressionLz4    CompressionType = 3
+	CompressionZstd   CompressionType = 4
+)
+
+const compressionCodecMask = 0x07
+
+type TimestampType int8
+
+const (
+	TimestampTypeCreateTime    TimestampType = 0
+	TimestampTypeLogAppendTime TimestampType = 1
+)
+
+const timestampTypeMask = 0x08
+
+var compressionTypeNames = map[string]CompressionType{
+	"uncompressed": CompressionNone,
+	"none":         CompressionNone,
+	"gzip":         CompressionGzip,
+	"snappy":       CompressionSnappy,
+	"lz4":          CompressionLz4,
+	"zstd":         CompressionZstd,
+}
+
+func ParseCompressionType(name string) (CompressionType, bool) {
+	c, ok := compressionTypeNames[name]
+	return c, ok
+}
+
+type RecordBatch struct {
+	BaseOffset           int64
+	PartitionLeaderEpoch int32
+	Magic                int8
+	Attributes           int16
+	LastOffsetDelta      int32
+	BaseTimestamp        int64
+	MaxTimestamp         int64
+	ProducerID           int64
+	ProducerEpoch        int16
+	BaseSequence         int32
+	Records              []Record
+}
+
+func (b *RecordBatch) Compression() CompressionType {
+	return CompressionType(b.Attributes & compressionCodecMask)
+}
+
+func (b *RecordBatch) SetCompression(c CompressionType) {
+	b.Attributes = b.Attributes&^compressionCodecMask | int16(c)&compressionCodecMask
+}
+
+func (b *RecordBatch) TimestampType() TimestampType {
+	if b.Attributes&timestampTypeMask != 0 {
+		return TimestampTypeLogAppendTime
+	}
+	return TimestampTypeCreateTime
+}
+
+func (b *RecordBatch) SetTimestampType(t TimestampType) {
+	b.Attributes &^= timestampTypeMask
+	if t == TimestampTypeLogAppendTime {
+		b.Attributes |= timestampTypeMask
+	}
+}
+
+type Record struct {
+	Attributes     int8
+	TimestampDelta int64
+	OffsetDelta    int32
+	Key            []byte
+	Value          []byte
+	Headers        []RecordHeader
+}
+
+type RecordHeader struct {
+	Key   string
+	Value []byte
+}
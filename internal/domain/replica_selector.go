@@ -0,0 +1,19 @@
+package domain
+
+import "time"
+
+type ClientMetadata struct {
+	RackID string
+}
+
+type ReplicaView struct {
+	BrokerID     int32
+	Rack         string
+	LogEndOffset int64
+	LastCaughtUp time.Time
+}
+
+type PartitionView struct {
+	Leader   ReplicaView
+	Replicas []ReplicaView
+}
@@ -0,0 +1,27 @@
+package request
+
+import "github.com/codecrafters-io/kafka-starter-go/internal/domain"
+
+type AlterPartitionRequest struct {
+	BrokerID    int32
+	BrokerEpoch int64
+	Topics      []AlterPartitionTopic
+}
+
+func (r *AlterPartitionRequest) ApiKey() uint16 {
+	return domain.AlterPartitionApiKey
+}
+
+type AlterPartitionTopic struct {
+	TopicName  string
+	TopicID    [16]byte
+	Partitions []AlterPartitionPartition
+}
+
+type AlterPartitionPartition struct {
+	PartitionIndex      int32
+	LeaderEpoch         int32
+	NewISR              []int32
+	LeaderRecoveryState int8
+	PartitionEpoch      int32
+}
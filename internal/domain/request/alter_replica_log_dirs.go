@@ -0,0 +1,16 @@
+package request
+
+import "github.com/codecrafters-io/kafka-starter-go/internal/domain"
+
+type AlterReplicaLogDirsRequest struct {
+	Dirs []AlterReplicaLogDir
+}
+
+func (r *AlterReplicaLogDirsRequest) ApiKey() uint16 {
+	return domain.AlterReplicaLogDirsApiKey
+}
+
+type AlterReplicaLogDir struct {
+	Path   string
+	Topics []TopicPartitions
+}
@@ -0,0 +1,49 @@
+package request
+
+import "github.com/codecrafters-io/kafka-starter-go/internal/domain"
+
+type DescribeConfigsRequest struct {
+	Resources            []DescribeConfigsResource
+	IncludeSynonyms      bool
+	IncludeDocumentation bool
+}
+
+func (r *DescribeConfigsRequest) ApiKey() uint16 {
+	return domain.DescribeConfigsApiKey
+}
+
+type DescribeConfigsResource struct {
+	ResourceType      int8
+	ResourceName      string
+	ConfigurationKeys []string
+}
+
+type AlterConfigsRequest struct {
+	Resources    []AlterConfigsResource
+	ValidateOnly bool
+}
+
+func (r *AlterConfigsRequest) ApiKey() uint16 {
+	return domain.AlterConfigsApiKey
+}
+
+type IncrementalAlterConfigsRequest struct {
+	Resources    []AlterConfigsResource
+	ValidateOnly bool
+}
+
+func (r *IncrementalAlterConfigsRequest) ApiKey() uint16 {
+	return domain.IncrementalAlterConfigsApiKey
+}
+
+type AlterConfigsResource struct {
+	ResourceType int8
+	ResourceName string
+	Configs      []AlterableConfig
+}
+
+type AlterableConfig struct {
+	Name            string
+	ConfigOperation int8
+	Value           *string
+}
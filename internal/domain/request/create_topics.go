@@ -0,0 +1,31 @@
+package request
+
+import "github.com/codecrafters-io/kafka-starter-go/internal/domain"
+
+type CreateTopicsRequest struct {
+	Topics       []CreatableTopic
+	TimeoutMs    int32
+	ValidateOnly bool
+}
+
+func (r *CreateTopicsRequest) ApiKey() uint16 {
+	return domain.CreateTopicsApiKey
+}
+
+type CreatableTopic struct {
+	Name              string
+	NumPartitions     int32
+	ReplicationFactor int16
+	Assignments       []CreatableReplicaAssignment
+	Configs           []CreatableTopicConfig
+}
+
+type CreatableReplicaAssignment struct {
+	PartitionIndex int32
+	BrokerIDs      []int32
+}
+
+type CreatableTopicConfig struct {
+	Name  string
+	Value *string
+}
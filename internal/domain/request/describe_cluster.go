@@ -0,0 +1,12 @@
+package request
+
+import "github.com/codecrafters-io/kafka-starter-go/internal/domain"
+
+type DescribeClusterRequest struct {
+	IncludeClusterAuthorizedOperations bool
+	EndpointType                       int8
+}
+
+func (r *DescribeClusterRequest) ApiKey() uint16 {
+	return domain.DescribeClusterApiKey
+}
@@ -0,0 +1,16 @@
+package request
+
+import "github.com/codecrafters-io/kafka-starter-go/internal/domain"
+
+type DescribeLogDirsRequest struct {
+	Topics []TopicPartitions
+}
+
+func (r *DescribeLogDirsRequest) ApiKey() uint16 {
+	return domain.DescribeLogDirsApiKey
+}
+
+type TopicPartitions struct {
+	Name       string
+	Partitions []int32
+}
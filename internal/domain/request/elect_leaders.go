@@ -0,0 +1,18 @@
+package request
+
+import "github.com/codecrafters-io/kafka-starter-go/internal/domain"
+
+type ElectLeadersRequest struct {
+	ElectionType    int8
+	TopicPartitions []ElectLeadersTopic
+	TimeoutMs       int32
+}
+
+func (r *ElectLeadersRequest) ApiKey() uint16 {
+	return domain.ElectLeadersApiKey
+}
+
+type ElectLeadersTopic struct {
+	Topic      string
+	Partitions []int32
+}
@@ -3,11 +3,30 @@ package request
 import "github.com/codecrafters-io/kafka-starter-go/internal/domain"
 
 type FetchRequest struct {
-	Topics []FetchTopic
+	ClusterID    *string
+	ReplicaID    int32
+	ReplicaEpoch int64
+	MaxWaitMs    int32
+	MinBytes     int32
+	MaxBytes     int32
+	SessionID    int32
+	SessionEpoch int32
+	Topics       []FetchTopic
+	RackID       string
 }
 
 type FetchTopic struct {
-	TopicID [16]byte
+	TopicID    [16]byte
+	Partitions []FetchPartition
+}
+
+type FetchPartition struct {
+	Partition          int32
+	CurrentLeaderEpoch int32
+	FetchOffset        int64
+	LastFetchedEpoch   int32
+	LogStartOffset     int64
+	PartitionMaxBytes  int32
 }
 
 func (*FetchRequest) ApiKey() uint16 { return domain.FetchApikey }
@@ -0,0 +1,23 @@
+package request
+
+import "github.com/codecrafters-io/kafka-starter-go/internal/domain"
+
+type OffsetForLeaderEpochRequest struct {
+	ReplicaID int32
+	Topics    []OffsetForLeaderTopic
+}
+
+func (r *OffsetForLeaderEpochRequest) ApiKey() uint16 {
+	return domain.OffsetForLeaderEpochApiKey
+}
+
+type OffsetForLeaderTopic struct {
+	Topic      string
+	Partitions []OffsetForLeaderPartition
+}
+
+type OffsetForLeaderPartition struct {
+	Partition          int32
+	CurrentLeaderEpoch int32
+	LeaderEpoch        int32
+}
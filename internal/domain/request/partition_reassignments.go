@@ -0,0 +1,36 @@
+package request
+
+import "github.com/codecrafters-io/kafka-starter-go/internal/domain"
+
+type AlterPartitionReassignmentsRequest struct {
+	TimeoutMs int32
+	Topics    []ReassignableTopic
+}
+
+func (r *AlterPartitionReassignmentsRequest) ApiKey() uint16 {
+	return domain.AlterPartitionReassignmentsApiKey
+}
+
+type ReassignableTopic struct {
+	Name       string
+	Partitions []ReassignablePartition
+}
+
+type ReassignablePartition struct {
+	PartitionIndex int32
+	Replicas       []int32
+}
+
+type ListPartitionReassignmentsRequest struct {
+	TimeoutMs int32
+	Topics    []ListPartitionReassignmentsTopic
+}
+
+func (r *ListPartitionReassignmentsRequest) ApiKey() uint16 {
+	return domain.ListPartitionReassignmentsApiKey
+}
+
+type ListPartitionReassignmentsTopic struct {
+	Name             string
+	PartitionIndexes []int32
+}
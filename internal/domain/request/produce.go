@@ -3,7 +3,9 @@ package request
 import "github.com/codecrafters-io/kafka-starter-go/internal/domain"
 
 type ProduceRequest struct {
-	Topics []ProduceTopic
+	Acks      int16
+	TimeoutMs int32
+	Topics    []ProduceTopic
 }
 
 func (p *ProduceRequest) ApiKey() uint16 {
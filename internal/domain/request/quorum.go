@@ -0,0 +1,63 @@
+package request
+
+import "github.com/codecrafters-io/kafka-starter-go/internal/domain"
+
+type QuorumPartition struct {
+	TopicName      string
+	PartitionIndex int32
+}
+
+type VoteRequest struct {
+	ClusterID *string
+	QuorumPartition
+	Vote domain.QuorumVote
+}
+
+func (r *VoteRequest) ApiKey() uint16 {
+	return domain.VoteApiKey
+}
+
+type BeginQuorumEpochRequest struct {
+	ClusterID *string
+	QuorumPartition
+	LeaderID    int32
+	LeaderEpoch int32
+}
+
+func (r *BeginQuorumEpochRequest) ApiKey() uint16 {
+	return domain.BeginQuorumEpochApiKey
+}
+
+type EndQuorumEpochRequest struct {
+	ClusterID *string
+	QuorumPartition
+	LeaderID            int32
+	LeaderEpoch         int32
+	PreferredSuccessors []int32
+}
+
+func (r *EndQuorumEpochRequest) ApiKey() uint16 {
+	return domain.EndQuorumEpochApiKey
+}
+
+type FetchSnapshotRequest struct {
+	ClusterID *string
+	ReplicaID int32
+	MaxBytes  int32
+	QuorumPartition
+	CurrentLeaderEpoch int32
+	SnapshotID         domain.SnapshotID
+	Position           int64
+}
+
+func (r *FetchSnapshotRequest) ApiKey() uint16 {
+	return domain.FetchSnapshotApiKey
+}
+
+type DescribeQuorumRequest struct {
+	QuorumPartition
+}
+
+func (r *DescribeQuorumRequest) ApiKey() uint16 {
+	return domain.DescribeQuorumApiKey
+}
@@ -0,0 +1,19 @@
+package request
+
+import "github.com/codecrafters-io/kafka-starter-go/internal/domain"
+
+type UpdateFeaturesRequest struct {
+	TimeoutMs    int32
+	Updates      []FeatureUpdate
+	ValidateOnly bool
+}
+
+func (r *UpdateFeaturesRequest) ApiKey() uint16 {
+	return domain.UpdateFeaturesApiKey
+}
+
+type FeatureUpdate struct {
+	Feature         string
+	MaxVersionLevel int16
+	UpgradeType     int8
+}
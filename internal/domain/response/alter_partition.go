@@ -0,0 +1,30 @@
+package response
+
+import "github.com/codecrafters-io/kafka-starter-go/internal/domain"
+
+type AlterPartitionResponseBody struct {
+	Version        uint16
+	ThrottleTimeMs int32
+	ErrorCode      int16
+	Topics         []AlterPartitionTopicResult
+}
+
+func (b *AlterPartitionResponseBody) ApiKey() uint16 {
+	return domain.AlterPartitionApiKey
+}
+
+type AlterPartitionTopicResult struct {
+	TopicName  string
+	TopicID    [16]byte
+	Partitions []AlterPartitionPartitionResult
+}
+
+type AlterPartitionPartitionResult struct {
+	PartitionIndex      int32
+	ErrorCode           int16
+	LeaderID            int32
+	LeaderEpoch         int32
+	ISR                 []int32
+	LeaderRecoveryState int8
+	PartitionEpoch      int32
+}
@@ -0,0 +1,22 @@
+package response
+
+import "github.com/codecrafters-io/kafka-starter-go/internal/domain"
+
+type AlterReplicaLogDirsResponseBody struct {
+	ThrottleTimeMs int32
+	Results        []AlterReplicaLogDirTopic
+}
+
+func (b *AlterReplicaLogDirsResponseBody) ApiKey() uint16 {
+	return domain.AlterReplicaLogDirsApiKey
+}
+
+type AlterReplicaLogDirTopic struct {
+	TopicName  string
+	Partitions []AlterReplicaLogDirPartition
+}
+
+type AlterReplicaLogDirPartition struct {
+	PartitionIndex int32
+	ErrorCode      int16
+}
@@ -39,3 +39,147 @@ func GetProduceApiKey() ApiKeyResponse {
 		MaxVersion: domain.MaximumVersionProduceApiKey,
 	}
 }
+
+func GetDescribeLogDirsApiKey() ApiKeyResponse {
+	return ApiKeyResponse{
+		ApiKey:     domain.DescribeLogDirsApiKey,
+		MinVersion: domain.MinimumVersionDescribeLogDirsApiKey,
+		MaxVersion: domain.MaximumVersionDescribeLogDirsApiKey,
+	}
+}
+
+func GetAlterReplicaLogDirsApiKey() ApiKeyResponse {
+	return ApiKeyResponse{
+		ApiKey:     domain.AlterReplicaLogDirsApiKey,
+		MinVersion: domain.MinimumVersionAlterReplicaLogDirsApiKey,
+		MaxVersion: domain.MaximumVersionAlterReplicaLogDirsApiKey,
+	}
+}
+
+func GetDescribeClusterApiKey() ApiKeyResponse {
+	return ApiKeyResponse{
+		ApiKey:     domain.DescribeClusterApiKey,
+		MinVersion: domain.NONE,
+		MaxVersion: domain.MaximumVersionDescribeClusterApiKey,
+	}
+}
+
+func GetUpdateFeaturesApiKey() ApiKeyResponse {
+	return ApiKeyResponse{
+		ApiKey:     domain.UpdateFeaturesApiKey,
+		MinVersion: domain.NONE,
+		MaxVersion: domain.MaximumVersionUpdateFeaturesApiKey,
+	}
+}
+
+func GetVoteApiKey() ApiKeyResponse {
+	return ApiKeyResponse{
+		ApiKey:     domain.VoteApiKey,
+		MinVersion: domain.NONE,
+		MaxVersion: domain.MaximumVersionVoteApiKey,
+	}
+}
+
+func GetBeginQuorumEpochApiKey() ApiKeyResponse {
+	return ApiKeyResponse{
+		ApiKey:     domain.BeginQuorumEpochApiKey,
+		MinVersion: domain.NONE,
+		MaxVersion: domain.MaximumVersionBeginQuorumEpochApiKey,
+	}
+}
+
+func GetEndQuorumEpochApiKey() ApiKeyResponse {
+	return ApiKeyResponse{
+		ApiKey:     domain.EndQuorumEpochApiKey,
+		MinVersion: domain.NONE,
+		MaxVersion: domain.MaximumVersionEndQuorumEpochApiKey,
+	}
+}
+
+func GetFetchSnapshotApiKey() ApiKeyResponse {
+	return ApiKeyResponse{
+		ApiKey:     domain.FetchSnapshotApiKey,
+		MinVersion: domain.NONE,
+		MaxVersion: domain.MaximumVersionFetchSnapshotApiKey,
+	}
+}
+
+func GetDescribeQuorumApiKey() ApiKeyResponse {
+	return ApiKeyResponse{
+		ApiKey:     domain.DescribeQuorumApiKey,
+		MinVersion: domain.NONE,
+		MaxVersion: domain.MaximumVersionDescribeQuorumApiKey,
+	}
+}
+
+func GetAlterPartitionApiKey() ApiKeyResponse {
+	return ApiKeyResponse{
+		ApiKey:     domain.AlterPartitionApiKey,
+		MinVersion: domain.NONE,
+		MaxVersion: domain.MaximumVersionAlterPartitionApiKey,
+	}
+}
+
+func GetElectLeadersApiKey() ApiKeyResponse {
+	return ApiKeyResponse{
+		ApiKey:     domain.ElectLeadersApiKey,
+		MinVersion: domain.NONE,
+		MaxVersion: domain.MaximumVersionElectLeadersApiKey,
+	}
+}
+
+func GetOffsetForLeaderEpochApiKey() ApiKeyResponse {
+	return ApiKeyResponse{
+		ApiKey:     domain.OffsetForLeaderEpochApiKey,
+		MinVersion: domain.NONE,
+		MaxVersion: domain.MaximumVersionOffsetForLeaderEpochApiKey,
+	}
+}
+
+func GetAlterPartitionReassignmentsApiKey() ApiKeyResponse {
+	return ApiKeyResponse{
+		ApiKey:     domain.AlterPartitionReassignmentsApiKey,
+		MinVersion: domain.NONE,
+		MaxVersion: domain.MaximumVersionAlterPartitionReassignmentsApiKey,
+	}
+}
+
+func GetListPartitionReassignmentsApiKey() ApiKeyResponse {
+	return ApiKeyResponse{
+		ApiKey:     domain.ListPartitionReassignmentsApiKey,
+		MinVersion: domain.NONE,
+		MaxVersion: domain.MaximumVersionListPartitionReassignmentsApiKey,
+	}
+}
+
+func GetCreateTopicsApiKey() ApiKeyResponse {
+	return ApiKeyResponse{
+		ApiKey:     domain.CreateTopicsApiKey,
+		MinVersion: domain.MinimumVersionCreateTopicsApiKey,
+		MaxVersion: domain.MaximumVersionCreateTopicsApiKey,
+	}
+}
+
+func GetDescribeConfigsApiKey() ApiKeyResponse {
+	return ApiKeyResponse{
+		ApiKey:     domain.DescribeConfigsApiKey,
+		MinVersion: domain.MinimumVersionDescribeConfigsApiKey,
+		MaxVersion: domain.MaximumVersionDescribeConfigsApiKey,
+	}
+}
+
+func GetAlterConfigsApiKey() ApiKeyResponse {
+	return ApiKeyResponse{
+		ApiKey:     domain.AlterConfigsApiKey,
+		MinVersion: domain.NONE,
+		MaxVersion: domain.MaximumVersionAlterConfigsApiKey,
+	}
+}
+
+func GetIncrementalAlterConfigsApiKey() ApiKeyResponse {
+	return ApiKeyResponse{
+		ApiKey:     domain.IncrementalAlterConfigsApiKey,
+		MinVersion: domain.NONE,
+		MaxVersion: domain.MaximumVersionIncrementalAlterConfigsApiKey,
+	}
+}
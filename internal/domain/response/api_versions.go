@@ -3,11 +3,27 @@ package response
 import "github.com/codecrafters-io/kafka-starter-go/internal/domain"
 
 type ApiVersionsResponseBody struct {
-	ErrorCode    uint16
-	ApiKeys      []ApiKeyResponse
-	ThrottleTime uint32
+	Version                uint16
+	ErrorCode              uint16
+	ApiKeys                []ApiKeyResponse
+	ThrottleTime           uint32
+	SupportedFeatures      []SupportedFeatureKey
+	FinalizedFeaturesEpoch int64
+	FinalizedFeatures      []FinalizedFeatureKey
 }
 
 func (b *ApiVersionsResponseBody) ApiKey() uint16 {
 	return domain.ApiVersionApikey
 }
+
+type SupportedFeatureKey struct {
+	Name       string
+	MinVersion int16
+	MaxVersion int16
+}
+
+type FinalizedFeatureKey struct {
+	Name            string
+	MaxVersionLevel int16
+	MinVersionLevel int16
+}
@@ -0,0 +1,65 @@
+package response
+
+import "github.com/codecrafters-io/kafka-starter-go/internal/domain"
+
+type DescribeConfigsResponseBody struct {
+	Version        uint16
+	ThrottleTimeMs int32
+	Results        []DescribeConfigsResult
+}
+
+func (b *DescribeConfigsResponseBody) ApiKey() uint16 {
+	return domain.DescribeConfigsApiKey
+}
+
+type DescribeConfigsResult struct {
+	ErrorCode    int16
+	ErrorMessage *string
+	ResourceType int8
+	ResourceName string
+	Configs      []DescribeConfigsResourceResult
+}
+
+type DescribeConfigsResourceResult struct {
+	Name          string
+	Value         *string
+	ReadOnly      bool
+	ConfigSource  int8
+	IsSensitive   bool
+	Synonyms      []DescribeConfigsSynonym
+	ConfigType    int8
+	Documentation *string
+}
+
+type DescribeConfigsSynonym struct {
+	Name   string
+	Value  *string
+	Source int8
+}
+
+type AlterConfigsResponseBody struct {
+	Version        uint16
+	ThrottleTimeMs int32
+	Responses      []AlterConfigsResourceResponse
+}
+
+func (b *AlterConfigsResponseBody) ApiKey() uint16 {
+	return domain.AlterConfigsApiKey
+}
+
+type IncrementalAlterConfigsResponseBody struct {
+	Version        uint16
+	ThrottleTimeMs int32
+	Responses      []AlterConfigsResourceResponse
+}
+
+func (b *IncrementalAlterConfigsResponseBody) ApiKey() uint16 {
+	return domain.IncrementalAlterConfigsApiKey
+}
+
+type AlterConfigsResourceResponse struct {
+	ErrorCode    int16
+	ErrorMessage *string
+	ResourceType int8
+	ResourceName string
+}
@@ -0,0 +1,31 @@
+package response
+
+import "github.com/codecrafters-io/kafka-starter-go/internal/domain"
+
+type CreateTopicsResponseBody struct {
+	Version        uint16
+	ThrottleTimeMs int32
+	Topics         []CreatableTopicResult
+}
+
+func (b *CreateTopicsResponseBody) ApiKey() uint16 {
+	return domain.CreateTopicsApiKey
+}
+
+type CreatableTopicResult struct {
+	Name              string
+	TopicID           [16]byte
+	ErrorCode         int16
+	ErrorMessage      *string
+	NumPartitions     int32
+	ReplicationFactor int16
+	Configs           []CreatableTopicConfigs
+}
+
+type CreatableTopicConfigs struct {
+	Name         string
+	Value        *string
+	ReadOnly     bool
+	ConfigSource int8
+	IsSensitive  bool
+}
@@ -0,0 +1,26 @@
+package response
+
+import "github.com/codecrafters-io/kafka-starter-go/internal/domain"
+
+type DescribeClusterResponseBody struct {
+	Version                     uint16
+	ThrottleTimeMs              int32
+	ErrorCode                   int16
+	ErrorMessage                *string
+	EndpointType                int8
+	ClusterID                   string
+	ControllerID                int32
+	Brokers                     []DescribeClusterBroker
+	ClusterAuthorizedOperations int32
+}
+
+func (b *DescribeClusterResponseBody) ApiKey() uint16 {
+	return domain.DescribeClusterApiKey
+}
+
+type DescribeClusterBroker struct {
+	BrokerID int32
+	Host     string
+	Port     int32
+	Rack     *string
+}
@@ -0,0 +1,34 @@
+package response
+
+import "github.com/codecrafters-io/kafka-starter-go/internal/domain"
+
+type DescribeLogDirsResponseBody struct {
+	Version        uint16
+	ThrottleTimeMs int32
+	ErrorCode      int16
+	Results        []LogDirResult
+}
+
+func (b *DescribeLogDirsResponseBody) ApiKey() uint16 {
+	return domain.DescribeLogDirsApiKey
+}
+
+type LogDirResult struct {
+	ErrorCode   int16
+	LogDir      string
+	Topics      []LogDirTopic
+	TotalBytes  int64
+	UsableBytes int64
+}
+
+type LogDirTopic struct {
+	Name       string
+	Partitions []LogDirPartition
+}
+
+type LogDirPartition struct {
+	PartitionIndex int32
+	PartitionSize  int64
+	OffsetLag      int64
+	IsFutureKey    bool
+}
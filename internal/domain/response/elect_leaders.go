@@ -0,0 +1,25 @@
+package response
+
+import "github.com/codecrafters-io/kafka-starter-go/internal/domain"
+
+type ElectLeadersResponseBody struct {
+	Version                uint16
+	ThrottleTimeMs         int32
+	ErrorCode              int16
+	ReplicaElectionResults []ReplicaElectionResult
+}
+
+func (b *ElectLeadersResponseBody) ApiKey() uint16 {
+	return domain.ElectLeadersApiKey
+}
+
+type ReplicaElectionResult struct {
+	Topic           string
+	PartitionResult []PartitionElectionResult
+}
+
+type PartitionElectionResult struct {
+	PartitionID  int32
+	ErrorCode    int16
+	ErrorMessage *string
+}
@@ -19,10 +19,15 @@ type FetchTopicResponse struct {
 }
 
 type FetchPartitionResponse struct {
-	PartitionIndex   int32
-	ErrorCode        int16
-	HighWatermark    int64
-	LastStableOffset int64
-	LogStartOffset   int64
-	Records          []byte
+	PartitionIndex       int32
+	ErrorCode            int16
+	HighWatermark        int64
+	LastStableOffset     int64
+	LogStartOffset       int64
+	PreferredReadReplica *int32
+	Records              []byte
+	RecordsRegion        *domain.FileRegion
+	DivergingEpoch       *domain.EpochEndOffset
+	CurrentLeader        *domain.LeaderAndEpoch
+	SnapshotID           *domain.SnapshotID
 }
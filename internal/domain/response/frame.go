@@ -0,0 +1,34 @@
+package response
+
+import "github.com/codecrafters-io/kafka-starter-go/internal/domain"
+
+type Frame struct {
+	Parts []FramePart
+}
+
+type FramePart struct {
+	Bytes  []byte
+	Region *domain.FileRegion
+}
+
+func (f *Frame) Size() int64 {
+	var n int64
+	for _, p := range f.Parts {
+		if p.Region != nil {
+			n += p.Region.Length
+		} else {
+			n += int64(len(p.Bytes))
+		}
+	}
+	return n
+}
+
+func (f *Frame) Close() error {
+	var firstErr error
+	for _, p := range f.Parts {
+		if err := p.Region.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
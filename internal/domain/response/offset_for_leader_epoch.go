@@ -0,0 +1,25 @@
+package response
+
+import "github.com/codecrafters-io/kafka-starter-go/internal/domain"
+
+type OffsetForLeaderEpochResponseBody struct {
+	Version        uint16
+	ThrottleTimeMs int32
+	Topics         []OffsetForLeaderTopicResult
+}
+
+func (b *OffsetForLeaderEpochResponseBody) ApiKey() uint16 {
+	return domain.OffsetForLeaderEpochApiKey
+}
+
+type OffsetForLeaderTopicResult struct {
+	Topic      string
+	Partitions []EpochEndOffsetResult
+}
+
+type EpochEndOffsetResult struct {
+	ErrorCode   int16
+	Partition   int32
+	LeaderEpoch int32
+	EndOffset   int64
+}
@@ -0,0 +1,48 @@
+package response
+
+import "github.com/codecrafters-io/kafka-starter-go/internal/domain"
+
+type AlterPartitionReassignmentsResponseBody struct {
+	ThrottleTimeMs int32
+	ErrorCode      int16
+	ErrorMessage   *string
+	Responses      []ReassignableTopicResponse
+}
+
+func (b *AlterPartitionReassignmentsResponseBody) ApiKey() uint16 {
+	return domain.AlterPartitionReassignmentsApiKey
+}
+
+type ReassignableTopicResponse struct {
+	Name       string
+	Partitions []ReassignablePartitionResponse
+}
+
+type ReassignablePartitionResponse struct {
+	PartitionIndex int32
+	ErrorCode      int16
+	ErrorMessage   *string
+}
+
+type ListPartitionReassignmentsResponseBody struct {
+	ThrottleTimeMs int32
+	ErrorCode      int16
+	ErrorMessage   *string
+	Topics         []OngoingTopicReassignment
+}
+
+func (b *ListPartitionReassignmentsResponseBody) ApiKey() uint16 {
+	return domain.ListPartitionReassignmentsApiKey
+}
+
+type OngoingTopicReassignment struct {
+	Name       string
+	Partitions []OngoingPartitionReassignment
+}
+
+type OngoingPartitionReassignment struct {
+	PartitionIndex   int32
+	Replicas         []int32
+	AddingReplicas   []int32
+	RemovingReplicas []int32
+}
@@ -0,0 +1,66 @@
+package response
+
+import "github.com/codecrafters-io/kafka-starter-go/internal/domain"
+
+type QuorumPartitionResult struct {
+	TopicName      string
+	PartitionIndex int32
+	ErrorCode      int16
+	LeaderID       int32
+	LeaderEpoch    int32
+}
+
+type VoteResponseBody struct {
+	ErrorCode   int16
+	Partition   QuorumPartitionResult
+	VoteGranted bool
+}
+
+func (b *VoteResponseBody) ApiKey() uint16 {
+	return domain.VoteApiKey
+}
+
+type BeginQuorumEpochResponseBody struct {
+	ErrorCode int16
+	Partition QuorumPartitionResult
+}
+
+func (b *BeginQuorumEpochResponseBody) ApiKey() uint16 {
+	return domain.BeginQuorumEpochApiKey
+}
+
+type EndQuorumEpochResponseBody struct {
+	ErrorCode int16
+	Partition QuorumPartitionResult
+}
+
+func (b *EndQuorumEpochResponseBody) ApiKey() uint16 {
+	return domain.EndQuorumEpochApiKey
+}
+
+type FetchSnapshotResponseBody struct {
+	ThrottleTimeMs   int32
+	ErrorCode        int16
+	Partition        QuorumPartitionResult
+	SnapshotID       domain.SnapshotID
+	Size             int64
+	Position         int64
+	UnalignedRecords []byte
+}
+
+func (b *FetchSnapshotResponseBody) ApiKey() uint16 {
+	return domain.FetchSnapshotApiKey
+}
+
+type DescribeQuorumResponseBody struct {
+	Version       uint16
+	ErrorCode     int16
+	Partition     QuorumPartitionResult
+	HighWatermark int64
+	CurrentVoters []domain.QuorumReplicaState
+	Observers     []domain.QuorumReplicaState
+}
+
+func (b *DescribeQuorumResponseBody) ApiKey() uint16 {
+	return domain.DescribeQuorumApiKey
+}
@@ -0,0 +1,20 @@
+package response
+
+import "github.com/codecrafters-io/kafka-starter-go/internal/domain"
+
+type UpdateFeaturesResponseBody struct {
+	ThrottleTimeMs int32
+	ErrorCode      int16
+	ErrorMessage   *string
+	Results        []UpdatableFeatureResult
+}
+
+func (b *UpdateFeaturesResponseBody) ApiKey() uint16 {
+	return domain.UpdateFeaturesApiKey
+}
+
+type UpdatableFeatureResult struct {
+	Feature      string
+	ErrorCode    int16
+	ErrorMessage *string
+}
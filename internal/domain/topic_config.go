@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	TopicConfigCompressionType       = "compression.type"
+	TopicConfigMessageTimestampType  = "message.timestamp.type"
+	TopicConfigTimestampBeforeMaxMs  = "message.timestamp.before.max.ms"
+	TopicConfigTimestampAfterMaxMs   = "message.timestamp.after.max.ms"
+	TopicConfigMinInsyncReplicas     = "min.insync.replicas"
+	TopicConfigUncleanLeaderElection = "unclean.leader.election.enable"
+
+	TopicConfigLeaderThrottledReplicas   = "leader.replication.throttled.replicas"
+	TopicConfigFollowerThrottledReplicas = "follower.replication.throttled.replicas"
+)
+
+const TopicCompressionProducer = "producer"
+
+const DefaultMinInsyncReplicas = 1
+
+const (
+	TimestampTypeNameCreateTime    = "CreateTime"
+	TimestampTypeNameLogAppendTime = "LogAppendTime"
+)
+
+func (t *TopicMetadata) Config(name string) (string, bool) {
+	if v, ok := t.Configs[name]; ok {
+		return v, true
+	}
+	v, ok := t.Defaults[name]
+	return v, ok
+}
+
+func (t *TopicMetadata) MinInsyncReplicas() int {
+	v, _ := t.Config(TopicConfigMinInsyncReplicas)
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return DefaultMinInsyncReplicas
+	}
+	return n
+}
+
+func (t *TopicMetadata) UncleanLeaderElection() bool {
+	v, _ := t.Config(TopicConfigUncleanLeaderElection)
+	return strings.EqualFold(v, "true")
+}
+
+func (t *TopicMetadata) ThrottledReplica(key string, partition int32, brokerID int32) bool {
+	want := strconv.Itoa(int(partition)) + ":" + strconv.Itoa(int(brokerID))
+	v, _ := t.Config(key)
+	for _, entry := range strings.Split(v, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "*" || entry == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+)
+
+const (
+	DefaultNumPartitions     = 1
+	DefaultReplicationFactor = 1
+
+	maxTopicNameLength = 249
+)
+
+type ConfigEntry struct {
+	Name  string
+	Value *string
+}
+
+type TopicCreation struct {
+	Name              string
+	NumPartitions     int32
+	ReplicationFactor int16
+	Assignments       map[int32][]int32
+	Configs           []ConfigEntry
+}
+
+type TopicCreationResult struct {
+	Name              string
+	TopicID           [16]byte
+	ErrorCode         int16
+	ErrorMessage      *string
+	NumPartitions     int32
+	ReplicationFactor int16
+	Configs           map[string]string
+}
+
+func ValidateTopicName(name string) string {
+	switch {
+	case name == "":
+		return "topic name is illegal, it can't be empty"
+	case name == "." || name == "..":
+		return "topic name cannot be \".\" or \"..\""
+	case len(name) > maxTopicNameLength:
+		return fmt.Sprintf("topic name is illegal, it can't be longer than %d characters", maxTopicNameLength)
+	case name == MetadataTopicName:
+		return "creation of internal topic " + name + " is prohibited"
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '.' || c == '_' || c == '-') {
+			return fmt.Sprintf("topic name %q is illegal, it contains a character other than ASCII alphanumerics, '.', '_' and '-'", name)
+		}
+	}
+	return ""
+}
+
+func ManualAssignment(assignments map[int32][]int32) ([][]int32, string) {
+	out := make([][]int32, len(assignments))
+	for index, replicas := range assignments {
+		if index < 0 || int(index) >= len(assignments) {
+			return nil, "partitions should be a consecutive 0-based integer sequence"
+		}
+		if len(replicas) == 0 {
+			return nil, fmt.Sprintf("partition %d has an empty replica assignment", index)
+		}
+		for i, id := range replicas {
+			if slices.Contains(replicas[:i], id) {
+				return nil, fmt.Sprintf("partition %d has duplicate replicas", index)
+			}
+		}
+		out[index] = replicas
+	}
+	for _, replicas := range out {
+		if len(replicas) != len(out[0]) {
+			return nil, "all partitions must have the same number of replicas"
+		}
+	}
+	return out, ""
+}
@@ -4,12 +4,69 @@ type TopicMetadata struct {
 	Name       string
 	TopicID    [16]byte
 	Partitions []PartitionMetadata
+	Configs    map[string]string
+	Defaults   map[string]string
 }
 
 type PartitionMetadata struct {
-	PartitionIndex int32
-	LeaderID       int32
-	LeaderEpoch    int32
-	Replicas       []int32
-	ISR            []int32
+	PartitionIndex         int32
+	LeaderID               int32
+	LeaderEpoch            int32
+	PartitionEpoch         int32
+	LeaderRecoveryState    int8
+	Replicas               []int32
+	ISR                    []int32
+	RemovingReplicas       []int32
+	AddingReplicas         []int32
+	EligibleLeaderReplicas []int32
+	LastKnownELR           []int32
+	Directories            [][16]byte
+}
+
+func (p PartitionMetadata) Clone() PartitionMetadata {
+	c := p
+	c.Replicas = append([]int32(nil), p.Replicas...)
+	c.ISR = append([]int32(nil), p.ISR...)
+	c.RemovingReplicas = append([]int32(nil), p.RemovingReplicas...)
+	c.AddingReplicas = append([]int32(nil), p.AddingReplicas...)
+	c.EligibleLeaderReplicas = append([]int32(nil), p.EligibleLeaderReplicas...)
+	c.LastKnownELR = append([]int32(nil), p.LastKnownELR...)
+	c.Directories = append([][16]byte(nil), p.Directories...)
+	return c
+}
+
+func (t *TopicMetadata) Clone() *TopicMetadata {
+	c := &TopicMetadata{
+		Name:       t.Name,
+		TopicID:    t.TopicID,
+		Partitions: make([]PartitionMetadata, 0, len(t.Partitions)),
+		Defaults:   t.Defaults,
+	}
+	for _, p := range t.Partitions {
+		c.Partitions = append(c.Partitions, p.Clone())
+	}
+	if t.Configs != nil {
+		c.Configs = make(map[string]string, len(t.Configs))
+		for k, v := range t.Configs {
+			c.Configs[k] = v
+		}
+	}
+	return c
+}
+
+const (
+	NoLeader       = -1
+	NoLeaderChange = -2
+)
+
+type PartitionChange struct {
+	TopicID          [16]byte
+	Partition        int32
+	ISR              []int32
+	Leader           int32
+	Replicas         []int32
+	AddingReplicas   []int32
+	RemovingReplicas []int32
+	ELR              []int32
+	LastKnownELR     []int32
 }
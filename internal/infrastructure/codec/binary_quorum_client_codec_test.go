@@ -0,0 +1,243 @@
+package codec
+
+import (
+	"testing"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/response"
+)
+
+func roundTripRequest(t *testing.T, version uint16, body request.RequestBody) request.RequestBody {
+	t.Helper()
+
+	frame, err := NewBinaryQuorumClientCodec().EncodeRequest(request.RequestHeader{
+		ApiVersion:    version,
+		CorrelationID: 9,
+		ClientID:      []byte("raft-1"),
+	}, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := NewBinaryRequestParser().Parse(frame)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Header.ApiKey != body.ApiKey() || req.Header.CorrelationID != 9 {
+		t.Fatalf("unexpected header %+v", req.Header)
+	}
+	return req.Body
+}
+
+func roundTripResponse(t *testing.T, apiKey uint16, body response.ResponseBody) response.ResponseBody {
+	t.Helper()
+
+	out, err := NewBinaryResponseBuilder().Build(&response.MessageResponse{CorrelationID: 9, Body: body})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := NewBinaryQuorumClientCodec().DecodeResponse(apiKey, out[4:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.CorrelationID != 9 {
+		t.Fatalf("unexpected correlation id %d", resp.CorrelationID)
+	}
+	return resp.Body
+}
+
+func TestQuorumCodec_VoteRoundTrip(t *testing.T) {
+	clusterID := "cluster"
+	partition := request.QuorumPartition{TopicName: domain.MetadataTopicName}
+	vote := domain.QuorumVote{CandidateID: 2, CandidateEpoch: 5, LastOffsetEpoch: 4, LastOffset: 120}
+
+	got := roundTripRequest(t, 0, &request.VoteRequest{ClusterID: &clusterID, QuorumPartition: partition, Vote: vote}).(*request.VoteRequest)
+	if *got.ClusterID != clusterID || got.QuorumPartition != partition || got.Vote != vote {
+		t.Fatalf("unexpected vote request %+v", got)
+	}
+
+	resp := roundTripResponse(t, domain.VoteApiKey, &response.VoteResponseBody{
+		Partition:   response.QuorumPartitionResult{TopicName: domain.MetadataTopicName, LeaderID: -1, LeaderEpoch: 5},
+		VoteGranted: true,
+	}).(*response.VoteResponseBody)
+	if !resp.VoteGranted || resp.Partition.LeaderEpoch != 5 || resp.Partition.LeaderID != -1 {
+		t.Fatalf("unexpected vote response %+v", resp)
+	}
+}
+
+func TestQuorumCodec_QuorumEpochRoundTrip(t *testing.T) {
+	partition := request.QuorumPartition{TopicName: domain.MetadataTopicName}
+
+	begin := roundTripRequest(t, 0, &request.BeginQuorumEpochRequest{
+		QuorumPartition: partition,
+		LeaderID:        1,
+		LeaderEpoch:     3,
+	}).(*request.BeginQuorumEpochRequest)
+	if begin.ClusterID != nil || begin.LeaderID != 1 || begin.LeaderEpoch != 3 {
+		t.Fatalf("unexpected begin request %+v", begin)
+	}
+
+	end := roundTripRequest(t, 0, &request.EndQuorumEpochRequest{
+		QuorumPartition:     partition,
+		LeaderID:            1,
+		LeaderEpoch:         3,
+		PreferredSuccessors: []int32{3, 2},
+	}).(*request.EndQuorumEpochRequest)
+	if len(end.PreferredSuccessors) != 2 || end.PreferredSuccessors[0] != 3 {
+		t.Fatalf("unexpected end request %+v", end)
+	}
+
+	resp := roundTripResponse(t, domain.EndQuorumEpochApiKey, &response.EndQuorumEpochResponseBody{
+		Partition: response.QuorumPartitionResult{
+			TopicName:   domain.MetadataTopicName,
+			ErrorCode:   domain.ErrorFencedLeaderEpoch,
+			LeaderID:    2,
+			LeaderEpoch: 4,
+		},
+	}).(*response.EndQuorumEpochResponseBody)
+	if resp.Partition.ErrorCode != domain.ErrorFencedLeaderEpoch || resp.Partition.LeaderID != 2 {
+		t.Fatalf("unexpected end response %+v", resp)
+	}
+}
+
+func TestQuorumCodec_FetchRoundTrip(t *testing.T) {
+	clusterID := "cluster"
+	got := roundTripRequest(t, QuorumFetchVersion, &request.FetchRequest{
+		ClusterID:    &clusterID,
+		ReplicaID:    2,
+		ReplicaEpoch: -1,
+		MaxBytes:     1024,
+		Topics: []request.FetchTopic{{
+			TopicID: domain.MetadataTopicID,
+			Partitions: []request.FetchPartition{{
+				CurrentLeaderEpoch: 3,
+				FetchOffset:        40,
+				LastFetchedEpoch:   2,
+				LogStartOffset:     -1,
+				PartitionMaxBytes:  512,
+			}},
+		}},
+	}).(*request.FetchRequest)
+
+	if got.ClusterID == nil || *got.ClusterID != clusterID || got.ReplicaID != 2 || got.MaxBytes != 1024 {
+		t.Fatalf("unexpected fetch request %+v", got)
+	}
+	part := got.Topics[0].Partitions[0]
+	if got.Topics[0].TopicID != domain.MetadataTopicID || part.FetchOffset != 40 || part.LastFetchedEpoch != 2 || part.PartitionMaxBytes != 512 {
+		t.Fatalf("unexpected fetch partition %+v", part)
+	}
+
+	resp := roundTripResponse(t, domain.FetchApikey, &response.FetchResponseBody{
+		Responses: []response.FetchTopicResponse{{
+			TopicID: domain.MetadataTopicID,
+			Partitions: []response.FetchPartitionResponse{{
+				HighWatermark:  30,
+				LogStartOffset: 10,
+				Records:        []byte("batch"),
+				DivergingEpoch: &domain.EpochEndOffset{Epoch: 2, EndOffset: 35},
+				CurrentLeader:  &domain.LeaderAndEpoch{LeaderID: 1, Epoch: 3},
+				SnapshotID:     &domain.SnapshotID{EndOffset: 10, Epoch: 1},
+			}},
+		}},
+	}).(*response.FetchResponseBody)
+
+	p := resp.Responses[0].Partitions[0]
+	if p.HighWatermark != 30 || p.LogStartOffset != 10 || string(p.Records) != "batch" {
+		t.Fatalf("unexpected fetch partition %+v", p)
+	}
+	if *p.DivergingEpoch != (domain.EpochEndOffset{Epoch: 2, EndOffset: 35}) ||
+		*p.CurrentLeader != (domain.LeaderAndEpoch{LeaderID: 1, Epoch: 3}) ||
+		*p.SnapshotID != (domain.SnapshotID{EndOffset: 10, Epoch: 1}) {
+		t.Fatalf("unexpected tagged fields %+v", p)
+	}
+}
+
+func TestQuorumCodec_FetchSnapshotRoundTrip(t *testing.T) {
+	clusterID := "cluster"
+	id := domain.SnapshotID{EndOffset: 100, Epoch: 4}
+
+	got := roundTripRequest(t, 0, &request.FetchSnapshotRequest{
+		ClusterID:          &clusterID,
+		ReplicaID:          3,
+		MaxBytes:           4096,
+		QuorumPartition:    request.QuorumPartition{TopicName: domain.MetadataTopicName},
+		CurrentLeaderEpoch: 4,
+		SnapshotID:         id,
+		Position:           512,
+	}).(*request.FetchSnapshotRequest)
+	if *got.ClusterID != clusterID || got.ReplicaID != 3 || got.SnapshotID != id || got.Position != 512 {
+		t.Fatalf("unexpected fetch snapshot request %+v", got)
+	}
+
+	resp := roundTripResponse(t, domain.FetchSnapshotApiKey, &response.FetchSnapshotResponseBody{
+		Partition:        response.QuorumPartitionResult{TopicName: domain.MetadataTopicName, LeaderID: 1, LeaderEpoch: 4},
+		SnapshotID:       id,
+		Size:             2048,
+		Position:         512,
+		UnalignedRecords: []byte("chunk"),
+	}).(*response.FetchSnapshotResponseBody)
+	if resp.SnapshotID != id || resp.Size != 2048 || resp.Position != 512 || string(resp.UnalignedRecords) != "chunk" {
+		t.Fatalf("unexpected fetch snapshot response %+v", resp)
+	}
+}
+
+func TestQuorumCodec_AlterPartitionRoundTrip(t *testing.T) {
+	for _, version := range []uint16{0, 1, 2, 3} {
+		req := &request.AlterPartitionRequest{
+			BrokerID:    1,
+			BrokerEpoch: 7,
+			Topics: []request.AlterPartitionTopic{{
+				Partitions: []request.AlterPartitionPartition{{
+					PartitionIndex: 2,
+					LeaderEpoch:    3,
+					NewISR:         []int32{1, 2},
+					PartitionEpoch: 4,
+				}},
+			}},
+		}
+		if version >= 2 {
+			req.Topics[0].TopicID = [16]byte{5}
+		} else {
+			req.Topics[0].TopicName = "orders"
+		}
+
+		got := roundTripRequest(t, version, req).(*request.AlterPartitionRequest)
+		if got.BrokerID != 1 || got.BrokerEpoch != 7 || len(got.Topics) != 1 {
+			t.Fatalf("v%d: unexpected request %+v", version, got)
+		}
+		topic := got.Topics[0]
+		if topic.TopicName != req.Topics[0].TopicName || topic.TopicID != req.Topics[0].TopicID {
+			t.Fatalf("v%d: unexpected topic %+v", version, topic)
+		}
+		p := topic.Partitions[0]
+		if p.PartitionIndex != 2 || p.LeaderEpoch != 3 || len(p.NewISR) != 2 || p.NewISR[1] != 2 || p.PartitionEpoch != 4 {
+			t.Fatalf("v%d: unexpected partition %+v", version, p)
+		}
+	}
+
+	got := roundTripResponse(t, domain.AlterPartitionApiKey, &response.AlterPartitionResponseBody{
+		Version: AlterPartitionVersion,
+		Topics: []response.AlterPartitionTopicResult{{
+			TopicID: [16]byte{5},
+			Partitions: []response.AlterPartitionPartitionResult{{
+				PartitionIndex: 2,
+				ErrorCode:      domain.ErrorFencedLeaderEpoch,
+				LeaderID:       1,
+				LeaderEpoch:    3,
+				ISR:            []int32{1},
+				PartitionEpoch: 5,
+			}},
+		}},
+	}).(*response.AlterPartitionResponseBody)
+
+	if len(got.Topics) != 1 || got.Topics[0].TopicID != [16]byte{5} {
+		t.Fatalf("unexpected response %+v", got)
+	}
+	p := got.Topics[0].Partitions[0]
+	if p.PartitionIndex != 2 || p.ErrorCode != domain.ErrorFencedLeaderEpoch || p.LeaderID != 1 ||
+		p.LeaderEpoch != 3 || len(p.ISR) != 1 || p.PartitionEpoch != 5 {
+		t.Fatalf("unexpected partition result %+v", p)
+	}
+}
@@ -0,0 +1,163 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"testing"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+)
+
+func sampleBatch(c domain.CompressionType) domain.RecordBatch {
+	rb := domain.RecordBatch{
+		BaseOffset:      0,
+		Magic:           2,
+		LastOffsetDelta: 1,
+		BaseTimestamp:   1000,
+		MaxTimestamp:    1001,
+		ProducerID:      -1,
+		ProducerEpoch:   -1,
+		BaseSequence:    -1,
+		Records: []domain.Record{
+			{OffsetDelta: 0, Key: nil, Value: []byte("hello")},
+			{
+				OffsetDelta:    1,
+				TimestampDelta: 1,
+				Key:            []byte("k"),
+				Value:          []byte("world"),
+				Headers:        []domain.RecordHeader{{Key: "h", Value: []byte("v")}},
+			},
+		},
+	}
+	rb.SetCompression(c)
+	return rb
+}
+
+func TestRecordBatchCodec_RoundTrip(t *testing.T) {
+	c := NewBinaryRecordBatchCodec()
+
+	codecs := []domain.CompressionType{
+		domain.CompressionNone,
+		domain.CompressionGzip,
+		domain.CompressionSnappy,
+		domain.CompressionLz4,
+		domain.CompressionZstd,
+	}
+
+	for _, codec := range codecs {
+		raw, err := c.Encode([]domain.RecordBatch{sampleBatch(codec)})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		batches, err := c.Decode(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(batches) != 1 || len(batches[0].Records) != 2 {
+			t.Fatal("expected 1 batch with 2 records")
+		}
+
+		rb := batches[0]
+		if rb.Compression() != codec {
+			t.Fatal("wrong compression")
+		}
+		if string(rb.Records[1].Value) != "world" || rb.Records[0].Key != nil {
+			t.Fatal("wrong record contents")
+		}
+		if rb.Records[1].Headers[0].Key != "h" {
+			t.Fatal("wrong header")
+		}
+
+		again, err := c.Encode(batches)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(again, raw) {
+			t.Fatal("re-encoding is not stable")
+		}
+	}
+}
+
+func TestRecordBatchCodec_CRCMismatch(t *testing.T) {
+	c := NewBinaryRecordBatchCodec()
+
+	raw, err := c.Encode([]domain.RecordBatch{sampleBatch(domain.CompressionNone)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw[len(raw)-1] ^= 0xff
+
+	if _, err := c.Decode(raw); err == nil {
+		t.Fatal("expected crc error")
+	}
+}
+
+func TestRecordBatchCodec_Truncated(t *testing.T) {
+	c := NewBinaryRecordBatchCodec()
+
+	raw, err := c.Encode([]domain.RecordBatch{sampleBatch(domain.CompressionGzip)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Decode(raw[:len(raw)-3]); err == nil {
+		t.Fatal("expected truncation error")
+	}
+}
+
+func rawBatch(count int32, records []byte) []byte {
+	out, _ := NewBinaryRecordBatchCodec().Encode([]domain.RecordBatch{{Magic: 2}})
+	out = append(out, records...)
+	binary.BigEndian.PutUint32(out[8:12], uint32(len(out)-recordBatchLogOverhead))
+	binary.BigEndian.PutUint32(out[57:61], uint32(count))
+	binary.BigEndian.PutUint32(out[17:21], crc32.Checksum(out[21:], castagnoli))
+	return out
+}
+
+func TestRecordBatchCodec_NegativeRecordLength(t *testing.T) {
+	records := binary.AppendVarint(nil, -50)
+	records = append(records, make([]byte, 8)...)
+
+	if _, err := NewBinaryRecordBatchCodec().Decode(rawBatch(1, records)); err == nil {
+		t.Fatal("expected a negative record length to be rejected")
+	}
+}
+
+func TestRecordBatchCodec_RecordsCountBeyondPayload(t *testing.T) {
+	if _, err := NewBinaryRecordBatchCodec().Decode(rawBatch(0x7fffffff, nil)); err == nil {
+		t.Fatal("expected a records count larger than the payload to be rejected")
+	}
+}
+
+func TestRecordBatchCodec_TimestampTypeRecomputesCRC(t *testing.T) {
+	c := NewBinaryRecordBatchCodec()
+
+	rb := sampleBatch(domain.CompressionNone)
+	original, err := c.Encode([]domain.RecordBatch{rb})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rb.SetTimestampType(domain.TimestampTypeLogAppendTime)
+	rb.MaxTimestamp = 9999
+
+	raw, err := c.Encode([]domain.RecordBatch{rb})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(raw[17:21], original[17:21]) {
+		t.Fatal("expected crc to change")
+	}
+
+	batches, err := c.Decode(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if batches[0].TimestampType() != domain.TimestampTypeLogAppendTime {
+		t.Fatal("expected log append time")
+	}
+}
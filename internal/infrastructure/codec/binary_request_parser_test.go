@@ -2,6 +2,7 @@ package codec
 
 import (
 	"encoding/binary"
+	"slices"
 	"testing"
 
 	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
@@ -137,7 +138,7 @@ func TestParse_Produce(t *testing.T) {
 	payload = append(payload, 0x00, 0x00)
 	payload = append(payload, emptyTagBuffer()...)
 	payload = append(payload, uvarint(1)...)
-	payload = append(payload, 0x00, 0x00)
+	payload = append(payload, 0xff, 0xff)
 	payload = append(payload, 0x00, 0x00, 0x00, 0x01)
 	payload = append(payload, uvarint(2)...)
 	payload = append(payload, compactString("test")...)
@@ -163,4 +164,530 @@ func TestParse_Produce(t *testing.T) {
 	if len(prod.Topics) != 1 {
 		t.Fatal("expected 1 topic")
 	}
+	if prod.Acks != -1 || prod.TimeoutMs != 1 {
+		t.Fatalf("unexpected acks %d and timeout %d", prod.Acks, prod.TimeoutMs)
+	}
+}
+
+func frameRequest(apiKey uint16, version uint16, correlationID uint32, payload []byte) []byte {
+	buf := make([]byte, 12)
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(payload)+8))
+	binary.BigEndian.PutUint16(buf[4:6], apiKey)
+	binary.BigEndian.PutUint16(buf[6:8], version)
+	binary.BigEndian.PutUint32(buf[8:12], correlationID)
+	return append(buf, payload...)
+}
+
+func int32Array(vs ...int32) []byte {
+	b := uvarint(uint64(len(vs) + 1))
+	for _, v := range vs {
+		b = binary.BigEndian.AppendUint32(b, uint32(v))
+	}
+	return b
+}
+
+func TestParse_DescribeLogDirs(t *testing.T) {
+	p := NewBinaryRequestParser()
+
+	payload := []byte{}
+	payload = append(payload, 0x00, 0x00)
+	payload = append(payload, emptyTagBuffer()...)
+	payload = append(payload, uvarint(2)...)
+	payload = append(payload, compactString("test")...)
+	payload = append(payload, int32Array(0, 2)...)
+	payload = append(payload, emptyTagBuffer()...)
+	payload = append(payload, emptyTagBuffer()...)
+
+	req, err := p.Parse(frameRequest(domain.DescribeLogDirsApiKey, 4, 3, payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := req.Body.(*request.DescribeLogDirsRequest)
+	if len(body.Topics) != 1 || body.Topics[0].Name != "test" {
+		t.Fatal("expected topic test")
+	}
+	if len(body.Topics[0].Partitions) != 2 || body.Topics[0].Partitions[1] != 2 {
+		t.Fatal("wrong partitions")
+	}
+}
+
+func TestParse_DescribeLogDirs_AllTopics(t *testing.T) {
+	p := NewBinaryRequestParser()
+
+	payload := []byte{}
+	payload = append(payload, 0x00, 0x00)
+	payload = append(payload, emptyTagBuffer()...)
+	payload = append(payload, uvarint(0)...)
+	payload = append(payload, emptyTagBuffer()...)
+
+	req, err := p.Parse(frameRequest(domain.DescribeLogDirsApiKey, 4, 3, payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if req.Body.(*request.DescribeLogDirsRequest).Topics != nil {
+		t.Fatal("expected null topics")
+	}
+}
+
+func TestParse_AlterReplicaLogDirs(t *testing.T) {
+	p := NewBinaryRequestParser()
+
+	payload := []byte{}
+	payload = append(payload, 0x00, 0x00)
+	payload = append(payload, emptyTagBuffer()...)
+	payload = append(payload, uvarint(2)...)
+	payload = append(payload, compactString("/data/b")...)
+	payload = append(payload, uvarint(2)...)
+	payload = append(payload, compactString("test")...)
+	payload = append(payload, int32Array(1)...)
+	payload = append(payload, emptyTagBuffer()...)
+	payload = append(payload, emptyTagBuffer()...)
+	payload = append(payload, emptyTagBuffer()...)
+
+	req, err := p.Parse(frameRequest(domain.AlterReplicaLogDirsApiKey, 2, 4, payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := req.Body.(*request.AlterReplicaLogDirsRequest)
+	if len(body.Dirs) != 1 || body.Dirs[0].Path != "/data/b" {
+		t.Fatal("wrong dir")
+	}
+	if body.Dirs[0].Topics[0].Partitions[0] != 1 {
+		t.Fatal("wrong partition")
+	}
+}
+
+func TestParse_DescribeCluster(t *testing.T) {
+	p := NewBinaryRequestParser()
+
+	payload := []byte{0x00, 0x00}
+	payload = append(payload, emptyTagBuffer()...)
+	payload = append(payload, 1, 2)
+	payload = append(payload, emptyTagBuffer()...)
+
+	req, err := p.Parse(frameRequest(60, 1, 3, payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, ok := req.Body.(*request.DescribeClusterRequest)
+	if !ok {
+		t.Fatalf("unexpected body %T", req.Body)
+	}
+	if !body.IncludeClusterAuthorizedOperations || body.EndpointType != 2 {
+		t.Fatalf("unexpected request %+v", body)
+	}
+
+	v0 := []byte{0x00, 0x00}
+	v0 = append(v0, emptyTagBuffer()...)
+	v0 = append(v0, 0)
+	v0 = append(v0, emptyTagBuffer()...)
+
+	req, err = p.Parse(frameRequest(60, 0, 3, v0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Body.(*request.DescribeClusterRequest).EndpointType != 1 {
+		t.Fatal("v0 must default to broker endpoints")
+	}
+}
+
+func TestParse_DescribeQuorum(t *testing.T) {
+	p := NewBinaryRequestParser()
+
+	payload := []byte{0x00, 0x00}
+	payload = append(payload, emptyTagBuffer()...)
+	payload = append(payload, 2)
+	payload = append(payload, compactString("__cluster_metadata")...)
+	payload = append(payload, 2, 0, 0, 0, 0)
+	payload = append(payload, emptyTagBuffer()...)
+	payload = append(payload, emptyTagBuffer()...)
+	payload = append(payload, emptyTagBuffer()...)
+
+	req, err := p.Parse(frameRequest(55, 1, 3, payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, ok := req.Body.(*request.DescribeQuorumRequest)
+	if !ok {
+		t.Fatalf("unexpected body %T", req.Body)
+	}
+	if body.TopicName != "__cluster_metadata" || body.PartitionIndex != 0 {
+		t.Fatalf("unexpected request %+v", body)
+	}
+}
+
+func TestParse_UpdateFeatures(t *testing.T) {
+	p := NewBinaryRequestParser()
+
+	v1 := []byte{0x00, 0x00}
+	v1 = append(v1, emptyTagBuffer()...)
+	v1 = append(v1, 0, 0, 0x75, 0x30)
+	v1 = append(v1, uvarint(2)...)
+	v1 = append(v1, compactString("metadata.version")...)
+	v1 = append(v1, 0, 21, 3)
+	v1 = append(v1, emptyTagBuffer()...)
+	v1 = append(v1, 1)
+	v1 = append(v1, emptyTagBuffer()...)
+
+	req, err := p.Parse(frameRequest(57, 1, 1, v1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := req.Body.(*request.UpdateFeaturesRequest)
+	if body.TimeoutMs != 30000 || !body.ValidateOnly || len(body.Updates) != 1 {
+		t.Fatalf("unexpected request %+v", body)
+	}
+	if u := body.Updates[0]; u.Feature != "metadata.version" || u.MaxVersionLevel != 21 || u.UpgradeType != 3 {
+		t.Fatalf("unexpected update %+v", u)
+	}
+
+	v0 := []byte{0x00, 0x00}
+	v0 = append(v0, emptyTagBuffer()...)
+	v0 = append(v0, 0, 0, 0, 0)
+	v0 = append(v0, uvarint(2)...)
+	v0 = append(v0, compactString("kraft.version")...)
+	v0 = append(v0, 0, 0, 1)
+	v0 = append(v0, emptyTagBuffer()...)
+	v0 = append(v0, emptyTagBuffer()...)
+
+	req, err = p.Parse(frameRequest(57, 0, 1, v0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Body.(*request.UpdateFeaturesRequest).Updates[0].UpgradeType != 2 {
+		t.Fatal("v0 allow_downgrade must map to a safe downgrade")
+	}
+}
+
+func TestParse_ElectLeaders(t *testing.T) {
+	p := NewBinaryRequestParser()
+
+	v1 := []byte{0x00, 0x00}
+	v1 = append(v1, 1)
+	v1 = append(v1, 0, 0, 0, 1)
+	v1 = append(v1, 0, 3, 'f', 'o', 'o')
+	v1 = append(v1, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 4)
+	v1 = append(v1, 0, 0, 0x75, 0x30)
+
+	req, err := p.Parse(frameRequest(43, 1, 1, v1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := req.Body.(*request.ElectLeadersRequest)
+	if body.ElectionType != 1 || body.TimeoutMs != 30000 || len(body.TopicPartitions) != 1 {
+		t.Fatalf("unexpected request %+v", body)
+	}
+	if tp := body.TopicPartitions[0]; tp.Topic != "foo" || len(tp.Partitions) != 2 || tp.Partitions[1] != 4 {
+		t.Fatalf("unexpected topic partitions %+v", tp)
+	}
+
+	v2 := []byte{0x00, 0x00}
+	v2 = append(v2, emptyTagBuffer()...)
+	v2 = append(v2, 0)
+	v2 = append(v2, uvarint(0)...)
+	v2 = append(v2, 0, 0, 0, 0)
+	v2 = append(v2, emptyTagBuffer()...)
+
+	req, err = p.Parse(frameRequest(43, 2, 1, v2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body := req.Body.(*request.ElectLeadersRequest); body.ElectionType != 0 || body.TopicPartitions != nil {
+		t.Fatalf("null topic partitions must select every partition, got %+v", body)
+	}
+}
+
+func TestParse_OffsetForLeaderEpoch(t *testing.T) {
+	p := NewBinaryRequestParser()
+
+	v2 := []byte{0x00, 0x00}
+	v2 = append(v2, 0, 0, 0, 1)
+	v2 = append(v2, 0, 3, 'f', 'o', 'o')
+	v2 = append(v2, 0, 0, 0, 1, 0, 0, 0, 2, 0, 0, 0, 7, 0, 0, 0, 5)
+
+	req, err := p.Parse(frameRequest(23, 2, 1, v2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := req.Body.(*request.OffsetForLeaderEpochRequest)
+	if body.ReplicaID != -1 || len(body.Topics) != 1 || body.Topics[0].Topic != "foo" {
+		t.Fatalf("unexpected request %+v", body)
+	}
+	if part := body.Topics[0].Partitions[0]; part.Partition != 2 || part.CurrentLeaderEpoch != 7 || part.LeaderEpoch != 5 {
+		t.Fatalf("unexpected partition %+v", part)
+	}
+
+	v4 := []byte{0x00, 0x00}
+	v4 = append(v4, emptyTagBuffer()...)
+	v4 = append(v4, 0, 0, 0, 3)
+	v4 = append(v4, uvarint(2)...)
+	v4 = append(v4, compactString("bar")...)
+	v4 = append(v4, uvarint(2)...)
+	v4 = append(v4, 0, 0, 0, 0, 0xff, 0xff, 0xff, 0xff, 0, 0, 0, 4)
+	v4 = append(v4, emptyTagBuffer()...)
+	v4 = append(v4, emptyTagBuffer()...)
+	v4 = append(v4, emptyTagBuffer()...)
+
+	req, err = p.Parse(frameRequest(23, 4, 1, v4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body = req.Body.(*request.OffsetForLeaderEpochRequest)
+	if body.ReplicaID != 3 || body.Topics[0].Topic != "bar" {
+		t.Fatalf("unexpected request %+v", body)
+	}
+	if part := body.Topics[0].Partitions[0]; part.CurrentLeaderEpoch != -1 || part.LeaderEpoch != 4 {
+		t.Fatalf("unexpected partition %+v", part)
+	}
+}
+
+func TestParse_PartitionReassignments(t *testing.T) {
+	p := NewBinaryRequestParser()
+
+	alter := []byte{0x00, 0x00}
+	alter = append(alter, emptyTagBuffer()...)
+	alter = append(alter, 0, 0, 0x75, 0x30)
+	alter = append(alter, uvarint(2)...)
+	alter = append(alter, compactString("foo")...)
+	alter = append(alter, uvarint(3)...)
+	alter = append(alter, 0, 0, 0, 0)
+	alter = append(alter, uvarint(3)...)
+	alter = append(alter, 0, 0, 0, 2, 0, 0, 0, 3)
+	alter = append(alter, emptyTagBuffer()...)
+	alter = append(alter, 0, 0, 0, 1)
+	alter = append(alter, uvarint(0)...)
+	alter = append(alter, emptyTagBuffer()...)
+	alter = append(alter, emptyTagBuffer()...)
+	alter = append(alter, emptyTagBuffer()...)
+
+	req, err := p.Parse(frameRequest(45, 0, 1, alter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := req.Body.(*request.AlterPartitionReassignmentsRequest)
+	if body.TimeoutMs != 30000 || len(body.Topics) != 1 || body.Topics[0].Name != "foo" || len(body.Topics[0].Partitions) != 2 {
+		t.Fatalf("unexpected request %+v", body)
+	}
+	parts := body.Topics[0].Partitions
+	if !slices.Equal(parts[0].Replicas, []int32{2, 3}) || parts[1].PartitionIndex != 1 || parts[1].Replicas != nil {
+		t.Fatalf("expected replicas [2 3] and a cancellation, got %+v", parts)
+	}
+
+	list := []byte{0x00, 0x00}
+	list = append(list, emptyTagBuffer()...)
+	list = append(list, 0, 0, 0x75, 0x30)
+	list = append(list, uvarint(0)...)
+	list = append(list, emptyTagBuffer()...)
+
+	req, err = p.Parse(frameRequest(46, 0, 1, list))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body := req.Body.(*request.ListPartitionReassignmentsRequest); body.Topics != nil {
+		t.Fatalf("null topics must list every reassignment, got %+v", body)
+	}
+}
+
+func TestParse_FetchRackID(t *testing.T) {
+	p := NewBinaryRequestParser()
+
+	payload := []byte{0x00, 0x00}
+	payload = append(payload, emptyTagBuffer()...)
+	payload = append(payload, make([]byte, 4+4+4+1+4+4)...)
+	payload = append(payload, uvarint(2)...)
+	payload = append(payload, make([]byte, 16)...)
+	payload = append(payload, uvarint(1)...)
+	payload = append(payload, emptyTagBuffer()...)
+	payload = append(payload, uvarint(1)...)
+	payload = append(payload, compactString("rack-a")...)
+	payload = append(payload, emptyTagBuffer()...)
+
+	req, err := p.Parse(frameRequest(domain.FetchApikey, 16, 1, payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body := req.Body.(*request.FetchRequest); body.RackID != "rack-a" {
+		t.Fatalf("expected rack-a, got %q", body.RackID)
+	}
+}
+
+func TestParse_CreateTopics(t *testing.T) {
+	p := NewBinaryRequestParser()
+
+	v7 := []byte{0x00, 0x00}
+	v7 = append(v7, emptyTagBuffer()...)
+	v7 = append(v7, uvarint(3)...)
+	v7 = append(v7, compactString("foo")...)
+	v7 = append(v7, 0, 0, 0, 3, 0, 2)
+	v7 = append(v7, uvarint(1)...)
+	v7 = append(v7, uvarint(3)...)
+	v7 = append(v7, compactString("min.insync.replicas")...)
+	v7 = append(v7, compactString("2")...)
+	v7 = append(v7, emptyTagBuffer()...)
+	v7 = append(v7, compactString("cleanup.policy")...)
+	v7 = append(v7, 0)
+	v7 = append(v7, emptyTagBuffer()...)
+	v7 = append(v7, emptyTagBuffer()...)
+	v7 = append(v7, compactString("bar")...)
+	v7 = append(v7, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff)
+	v7 = append(v7, uvarint(2)...)
+	v7 = append(v7, 0, 0, 0, 0)
+	v7 = append(v7, int32Array(1, 2)...)
+	v7 = append(v7, emptyTagBuffer()...)
+	v7 = append(v7, uvarint(1)...)
+	v7 = append(v7, emptyTagBuffer()...)
+	v7 = append(v7, 0, 0, 0x75, 0x30)
+	v7 = append(v7, 1)
+	v7 = append(v7, emptyTagBuffer()...)
+
+	req, err := p.Parse(frameRequest(19, 7, 1, v7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := req.Body.(*request.CreateTopicsRequest)
+	if body.TimeoutMs != 30000 || !body.ValidateOnly || len(body.Topics) != 2 {
+		t.Fatalf("unexpected request %+v", body)
+	}
+	foo := body.Topics[0]
+	if foo.Name != "foo" || foo.NumPartitions != 3 || foo.ReplicationFactor != 2 || len(foo.Configs) != 2 {
+		t.Fatalf("unexpected topic %+v", foo)
+	}
+	if foo.Configs[0].Name != "min.insync.replicas" || *foo.Configs[0].Value != "2" || foo.Configs[1].Value != nil {
+		t.Fatalf("unexpected configs %+v", foo.Configs)
+	}
+	bar := body.Topics[1]
+	if bar.NumPartitions != -1 || bar.ReplicationFactor != -1 || len(bar.Assignments) != 1 ||
+		!slices.Equal(bar.Assignments[0].BrokerIDs, []int32{1, 2}) {
+		t.Fatalf("unexpected topic %+v", bar)
+	}
+
+	v4 := []byte{0x00, 0x00}
+	v4 = append(v4, 0, 0, 0, 1)
+	v4 = append(v4, 0, 3, 'b', 'a', 'z')
+	v4 = append(v4, 0, 0, 0, 1, 0, 1)
+	v4 = append(v4, 0, 0, 0, 0)
+	v4 = append(v4, 0, 0, 0, 1)
+	v4 = append(v4, 0, 1, 'k', 0xff, 0xff)
+	v4 = append(v4, 0, 0, 0x03, 0xe8)
+	v4 = append(v4, 0)
+
+	req, err = p.Parse(frameRequest(19, 4, 1, v4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body = req.Body.(*request.CreateTopicsRequest)
+	if body.ValidateOnly || body.TimeoutMs != 1000 || len(body.Topics) != 1 || body.Topics[0].Name != "baz" {
+		t.Fatalf("unexpected request %+v", body)
+	}
+	if cfg := body.Topics[0].Configs; len(cfg) != 1 || cfg[0].Name != "k" || cfg[0].Value != nil {
+		t.Fatalf("unexpected configs %+v", cfg)
+	}
+}
+
+func TestParse_DescribeConfigs(t *testing.T) {
+	p := NewBinaryRequestParser()
+
+	v4 := []byte{0x00, 0x00}
+	v4 = append(v4, emptyTagBuffer()...)
+	v4 = append(v4, uvarint(3)...)
+	v4 = append(v4, 2)
+	v4 = append(v4, compactString("foo")...)
+	v4 = append(v4, uvarint(2)...)
+	v4 = append(v4, compactString("min.insync.replicas")...)
+	v4 = append(v4, emptyTagBuffer()...)
+	v4 = append(v4, 4)
+	v4 = append(v4, compactString("1")...)
+	v4 = append(v4, uvarint(0)...)
+	v4 = append(v4, emptyTagBuffer()...)
+	v4 = append(v4, 1, 1)
+	v4 = append(v4, emptyTagBuffer()...)
+
+	req, err := p.Parse(frameRequest(32, 4, 1, v4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := req.Body.(*request.DescribeConfigsRequest)
+	if !body.IncludeSynonyms || !body.IncludeDocumentation || len(body.Resources) != 2 {
+		t.Fatalf("unexpected request %+v", body)
+	}
+	if r := body.Resources[0]; r.ResourceType != 2 || r.ResourceName != "foo" ||
+		!slices.Equal(r.ConfigurationKeys, []string{"min.insync.replicas"}) {
+		t.Fatalf("unexpected topic resource %+v", r)
+	}
+	if r := body.Resources[1]; r.ResourceType != 4 || r.ResourceName != "1" || r.ConfigurationKeys != nil {
+		t.Fatalf("unexpected broker resource %+v", r)
+	}
+
+	v1 := []byte{0x00, 0x00}
+	v1 = append(v1, 0, 0, 0, 1)
+	v1 = append(v1, 4, 0, 0)
+	v1 = append(v1, 0, 0, 0, 0)
+	v1 = append(v1, 0)
+
+	req, err = p.Parse(frameRequest(32, 1, 1, v1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body = req.Body.(*request.DescribeConfigsRequest)
+	if body.IncludeSynonyms || len(body.Resources) != 1 || body.Resources[0].ConfigurationKeys == nil {
+		t.Fatalf("unexpected v1 request %+v", body)
+	}
+}
+
+func TestParse_AlterConfigs(t *testing.T) {
+	p := NewBinaryRequestParser()
+
+	v1 := []byte{0x00, 0x00}
+	v1 = append(v1, emptyTagBuffer()...)
+	v1 = append(v1, uvarint(2)...)
+	v1 = append(v1, 2)
+	v1 = append(v1, compactString("foo")...)
+	v1 = append(v1, uvarint(3)...)
+	v1 = append(v1, compactString("leader.replication.throttled.replicas")...)
+	v1 = append(v1, 2)
+	v1 = append(v1, compactString("0:1")...)
+	v1 = append(v1, emptyTagBuffer()...)
+	v1 = append(v1, compactString("min.insync.replicas")...)
+	v1 = append(v1, 1, 0)
+	v1 = append(v1, emptyTagBuffer()...)
+	v1 = append(v1, emptyTagBuffer()...)
+	v1 = append(v1, 1)
+	v1 = append(v1, emptyTagBuffer()...)
+
+	req, err := p.Parse(frameRequest(44, 1, 1, v1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	incremental := req.Body.(*request.IncrementalAlterConfigsRequest)
+	if !incremental.ValidateOnly || len(incremental.Resources) != 1 || len(incremental.Resources[0].Configs) != 2 {
+		t.Fatalf("unexpected request %+v", incremental)
+	}
+	configs := incremental.Resources[0].Configs
+	if configs[0].ConfigOperation != 2 || *configs[0].Value != "0:1" || configs[1].ConfigOperation != 1 || configs[1].Value != nil {
+		t.Fatalf("unexpected configs %+v", configs)
+	}
+
+	v0 := []byte{0x00, 0x00}
+	v0 = append(v0, 0, 0, 0, 1)
+	v0 = append(v0, 4, 0, 0)
+	v0 = append(v0, 0, 0, 0, 1)
+	v0 = append(v0, 0, 1, 'k', 0, 1, 'v')
+	v0 = append(v0, 0)
+
+	req, err = p.Parse(frameRequest(33, 0, 1, v0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy := req.Body.(*request.AlterConfigsRequest)
+	if legacy.ValidateOnly || len(legacy.Resources) != 1 || legacy.Resources[0].ResourceType != 4 {
+		t.Fatalf("unexpected legacy request %+v", legacy)
+	}
+	if c := legacy.Resources[0].Configs; len(c) != 1 || c[0].Name != "k" || *c[0].Value != "v" || c[0].ConfigOperation != 0 {
+		t.Fatalf("unexpected legacy configs %+v", c)
+	}
 }
@@ -1,9 +1,12 @@
 package codec
 
 import (
+	"bytes"
 	"encoding/binary"
+	"os"
 	"testing"
 
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
 	"github.com/codecrafters-io/kafka-starter-go/internal/domain/response"
 )
 
@@ -173,3 +176,576 @@ func TestBuild_Produce(t *testing.T) {
 		t.Fatal("invalid size")
 	}
 }
+
+func fetchResponseWith(p response.FetchPartitionResponse) *response.MessageResponse {
+	return &response.MessageResponse{
+		CorrelationID: 8,
+		Body: &response.FetchResponseBody{
+			Responses: []response.FetchTopicResponse{
+				{Partitions: []response.FetchPartitionResponse{p}},
+			},
+		},
+	}
+}
+
+func TestBuildFrame_FetchStreamsFileRegion(t *testing.T) {
+	b := NewBinaryResponseBuilder()
+
+	records := []byte{0x0a, 0x0b, 0x0c, 0x0d, 0x0e}
+
+	f, err := os.CreateTemp(t.TempDir(), "segment")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write(append([]byte{0xff, 0xff}, records...)); err != nil {
+		t.Fatal(err)
+	}
+
+	region := &domain.FileRegion{File: f, Offset: 2, Length: int64(len(records))}
+
+	frame, err := b.BuildFrame(fetchResponseWith(response.FetchPartitionResponse{RecordsRegion: region}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(frame.Parts) != 4 || frame.Parts[2].Region != region {
+		t.Fatal("expected records to be a separate file region part")
+	}
+
+	size := binary.BigEndian.Uint32(frame.Parts[0].Bytes)
+	if int64(size) != frame.Size()-4 {
+		t.Fatal("invalid size")
+	}
+
+	streamed, err := flattenFrame(frame)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inMemory, err := b.Build(fetchResponseWith(response.FetchPartitionResponse{Records: records}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(streamed, inMemory) {
+		t.Fatal("streamed frame differs from in-memory response")
+	}
+}
+
+func TestBuild_DescribeLogDirs_VersionedFields(t *testing.T) {
+	b := NewBinaryResponseBuilder()
+
+	body := &response.DescribeLogDirsResponseBody{
+		Results: []response.LogDirResult{
+			{
+				LogDir: "/data",
+				Topics: []response.LogDirTopic{
+					{
+						Name:       "t",
+						Partitions: []response.LogDirPartition{{PartitionIndex: 0, PartitionSize: 10}},
+					},
+				},
+				TotalBytes:  100,
+				UsableBytes: 50,
+			},
+		},
+	}
+
+	body.Version = 2
+	v2, err := b.Build(&response.MessageResponse{CorrelationID: 1, Body: body})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body.Version = 4
+	v4, err := b.Build(&response.MessageResponse{CorrelationID: 1, Body: body})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(v4)-len(v2) != 2+8+8 {
+		t.Fatal("expected error code and byte totals only in v4")
+	}
+
+	size := binary.BigEndian.Uint32(v4[:4])
+	if int(size) != len(v4)-4 {
+		t.Fatal("invalid size")
+	}
+}
+
+func TestBuild_AlterReplicaLogDirs(t *testing.T) {
+	b := NewBinaryResponseBuilder()
+
+	out, err := b.Build(&response.MessageResponse{
+		CorrelationID: 5,
+		Body: &response.AlterReplicaLogDirsResponseBody{
+			Results: []response.AlterReplicaLogDirTopic{
+				{
+					TopicName:  "t",
+					Partitions: []response.AlterReplicaLogDirPartition{{PartitionIndex: 0, ErrorCode: 57}},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []byte{
+		0, 0, 0, 5, 0,
+		0, 0, 0, 0,
+		2, 2, 't',
+		2, 0, 0, 0, 0, 0, 57, 0,
+		0,
+		0,
+	}
+	if !bytes.Equal(out[4:], expected) {
+		t.Fatalf("unexpected payload %v", out[4:])
+	}
+}
+
+func TestBuild_DescribeCluster(t *testing.T) {
+	b := NewBinaryResponseBuilder()
+
+	rack := "r"
+	out, err := b.Build(&response.MessageResponse{
+		CorrelationID: 2,
+		Body: &response.DescribeClusterResponseBody{
+			Version:      1,
+			EndpointType: 1,
+			ClusterID:    "c",
+			ControllerID: 1,
+			Brokers: []response.DescribeClusterBroker{
+				{BrokerID: 1, Host: "h", Port: 9092, Rack: &rack},
+			},
+			ClusterAuthorizedOperations: -2147483648,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []byte{
+		0, 0, 0, 2, 0,
+		0, 0, 0, 0,
+		0, 0,
+		0,
+		1,
+		2, 'c',
+		0, 0, 0, 1,
+		2, 0, 0, 0, 1, 2, 'h', 0, 0, 0x23, 0x84, 2, 'r', 0,
+		0x80, 0, 0, 0,
+		0,
+	}
+	if !bytes.Equal(out[4:], expected) {
+		t.Fatalf("unexpected payload %v", out[4:])
+	}
+}
+
+func TestBuild_DescribeQuorum_VersionedTimestamps(t *testing.T) {
+	b := NewBinaryResponseBuilder()
+
+	body := &response.DescribeQuorumResponseBody{
+		Partition:     response.QuorumPartitionResult{TopicName: "m", LeaderID: 1, LeaderEpoch: 2},
+		HighWatermark: 3,
+		CurrentVoters: []domain.QuorumReplicaState{{ReplicaID: 1, LogEndOffset: 4, LastFetchTimestamp: 5, LastCaughtUpTimestamp: 6}},
+	}
+
+	out, err := b.Build(&response.MessageResponse{CorrelationID: 2, Body: body})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []byte{
+		0, 0, 0, 2, 0,
+		0, 0,
+		2, 2, 'm',
+		2, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 2,
+		0, 0, 0, 0, 0, 0, 0, 3,
+		2, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 4, 0,
+		1,
+		0, 0,
+		0,
+	}
+	if !bytes.Equal(out[4:], expected) {
+		t.Fatalf("unexpected v0 payload %v", out[4:])
+	}
+
+	body.Version = 1
+	out, err = b.Build(&response.MessageResponse{CorrelationID: 2, Body: body})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out[4:]) != len(expected)+16 {
+		t.Fatalf("v1 must add fetch and caught-up timestamps, got %v", out[4:])
+	}
+}
+
+func TestBuild_ApiVersions_FeatureTags(t *testing.T) {
+	b := NewBinaryResponseBuilder()
+
+	out, err := b.Build(&response.MessageResponse{
+		CorrelationID: 1,
+		Body: &response.ApiVersionsResponseBody{
+			Version:                4,
+			SupportedFeatures:      []response.SupportedFeatureKey{{Name: "a", MinVersion: 0, MaxVersion: 1}},
+			FinalizedFeaturesEpoch: 5,
+			FinalizedFeatures:      []response.FinalizedFeatureKey{{Name: "a", MaxVersionLevel: 1, MinVersionLevel: 1}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []byte{
+		0, 0, 0, 1,
+		0, 0,
+		1,
+		0, 0, 0, 0,
+		3,
+		0, 8, 2, 2, 'a', 0, 0, 0, 1, 0,
+		1, 8, 0, 0, 0, 0, 0, 0, 0, 5,
+		2, 8, 2, 2, 'a', 0, 1, 0, 1, 0,
+	}
+	if !bytes.Equal(out[4:], expected) {
+		t.Fatalf("unexpected payload %v", out[4:])
+	}
+
+	out, err = b.Build(&response.MessageResponse{
+		CorrelationID: 1,
+		Body:          &response.ApiVersionsResponseBody{Version: 4, FinalizedFeaturesEpoch: -1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out[len(out)-1] != 0 {
+		t.Fatal("expected empty tag buffer without features")
+	}
+}
+
+func TestBuild_ElectLeaders_FlexibleVersions(t *testing.T) {
+	b := NewBinaryResponseBuilder()
+
+	body := &response.ElectLeadersResponseBody{
+		ReplicaElectionResults: []response.ReplicaElectionResult{{
+			Topic:           "t",
+			PartitionResult: []response.PartitionElectionResult{{PartitionID: 1, ErrorCode: domain.ErrorElectionNotNeeded}},
+		}},
+	}
+
+	out, err := b.Build(&response.MessageResponse{CorrelationID: 2, Body: body})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []byte{
+		0, 0, 0, 2,
+		0, 0, 0, 0,
+		0, 0, 0, 1, 0, 1, 't',
+		0, 0, 0, 1, 0, 0, 0, 1, 0, 84, 0xff, 0xff,
+	}
+	if !bytes.Equal(out[4:], expected) {
+		t.Fatalf("unexpected v0 payload %v", out[4:])
+	}
+
+	body.Version = 2
+	out, err = b.Build(&response.MessageResponse{CorrelationID: 2, Body: body})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = []byte{
+		0, 0, 0, 2, 0,
+		0, 0, 0, 0,
+		0, 0,
+		2, 2, 't',
+		2, 0, 0, 0, 1, 0, 84, 0, 0,
+		0,
+		0,
+	}
+	if !bytes.Equal(out[4:], expected) {
+		t.Fatalf("unexpected v2 payload %v", out[4:])
+	}
+}
+
+func TestBuild_OffsetForLeaderEpoch(t *testing.T) {
+	b := NewBinaryResponseBuilder()
+
+	body := &response.OffsetForLeaderEpochResponseBody{
+		Topics: []response.OffsetForLeaderTopicResult{{
+			Topic:      "t",
+			Partitions: []response.EpochEndOffsetResult{{Partition: 1, LeaderEpoch: 3, EndOffset: 9}},
+		}},
+	}
+
+	out, err := b.Build(&response.MessageResponse{CorrelationID: 2, Body: body})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []byte{
+		0, 0, 0, 2,
+		0, 0, 0, 1, 0, 1, 't',
+		0, 0, 0, 1, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 9,
+	}
+	if !bytes.Equal(out[4:], expected) {
+		t.Fatalf("unexpected v0 payload %v", out[4:])
+	}
+
+	body.Version = 4
+	out, err = b.Build(&response.MessageResponse{CorrelationID: 2, Body: body})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = []byte{
+		0, 0, 0, 2, 0,
+		0, 0, 0, 0,
+		2, 2, 't',
+		2, 0, 0, 0, 0, 0, 1, 0, 0, 0, 3, 0, 0, 0, 0, 0, 0, 0, 9, 0,
+		0,
+		0,
+	}
+	if !bytes.Equal(out[4:], expected) {
+		t.Fatalf("unexpected v4 payload %v", out[4:])
+	}
+}
+
+func TestBuild_ListPartitionReassignments(t *testing.T) {
+	b := NewBinaryResponseBuilder()
+
+	body := &response.ListPartitionReassignmentsResponseBody{
+		Topics: []response.OngoingTopicReassignment{{
+			Name: "t",
+			Partitions: []response.OngoingPartitionReassignment{{
+				PartitionIndex:   1,
+				Replicas:         []int32{2, 1},
+				AddingReplicas:   []int32{2},
+				RemovingReplicas: []int32{1},
+			}},
+		}},
+	}
+
+	out, err := b.Build(&response.MessageResponse{CorrelationID: 2, HeaderVersion: 1, Body: body})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []byte{
+		0, 0, 0, 2, 0,
+		0, 0, 0, 0,
+		0, 0,
+		0,
+		2, 2, 't',
+		2, 0, 0, 0, 1,
+		3, 0, 0, 0, 2, 0, 0, 0, 1,
+		2, 0, 0, 0, 2,
+		2, 0, 0, 0, 1,
+		0,
+		0,
+		0,
+	}
+	if !bytes.Equal(out[4:], expected) {
+		t.Fatalf("unexpected payload %v", out[4:])
+	}
+}
+
+func TestBuild_Fetch_PreferredReadReplica(t *testing.T) {
+	b := NewBinaryResponseBuilder()
+
+	preferred := int32(2)
+	out, err := b.Build(fetchResponseWith(response.FetchPartitionResponse{PreferredReadReplica: &preferred}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const offset = 4 + 4 + 1 + 4 + 2 + 4 + 1 + 16 + 1 + 4 + 2 + 8 + 8 + 8 + 1
+	if got := int32(binary.BigEndian.Uint32(out[offset:])); got != 2 {
+		t.Fatalf("expected preferred read replica 2, got %d", got)
+	}
+
+	out, err = b.Build(fetchResponseWith(response.FetchPartitionResponse{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := int32(binary.BigEndian.Uint32(out[offset:])); got != -1 {
+		t.Fatalf("expected no preferred read replica, got %d", got)
+	}
+}
+
+func TestBuild_CreateTopics(t *testing.T) {
+	b := NewBinaryResponseBuilder()
+
+	value := "2"
+	msg := "x"
+	body := &response.CreateTopicsResponseBody{
+		Version: 7,
+		Topics: []response.CreatableTopicResult{
+			{
+				Name:              "t",
+				TopicID:           [16]byte{15: 9},
+				NumPartitions:     1,
+				ReplicationFactor: 3,
+				Configs: []response.CreatableTopicConfigs{{
+					Name:         "k",
+					Value:        &value,
+					ConfigSource: domain.ConfigSourceDynamicTopic,
+				}},
+			},
+			{
+				Name:              "u",
+				ErrorCode:         domain.ErrorTopicAlreadyExists,
+				ErrorMessage:      &msg,
+				NumPartitions:     -1,
+				ReplicationFactor: -1,
+			},
+		},
+	}
+
+	out, err := b.Build(&response.MessageResponse{CorrelationID: 2, HeaderVersion: 1, Body: body})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []byte{0, 0, 0, 2, 0, 0, 0, 0, 0, 3}
+	expected = append(expected, 2, 't')
+	expected = append(expected, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 9)
+	expected = append(expected, 0, 0, 0, 0, 0, 0, 1, 0, 3)
+	expected = append(expected, 2, 2, 'k', 2, '2', 0, 1, 0, 0)
+	expected = append(expected, 0)
+	expected = append(expected, 2, 'u')
+	expected = append(expected, make([]byte, 16)...)
+	expected = append(expected, 0, 36, 2, 'x', 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0)
+	expected = append(expected, 0)
+	expected = append(expected, 0)
+	if !bytes.Equal(out[4:], expected) {
+		t.Fatalf("unexpected v7 payload %v", out[4:])
+	}
+
+	body.Version = 4
+	out, err = b.Build(&response.MessageResponse{CorrelationID: 2, Body: body})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = []byte{
+		0, 0, 0, 2,
+		0, 0, 0, 0,
+		0, 0, 0, 2,
+		0, 1, 't', 0, 0, 0xff, 0xff,
+		0, 1, 'u', 0, 36, 0, 1, 'x',
+	}
+	if !bytes.Equal(out[4:], expected) {
+		t.Fatalf("unexpected v4 payload %v", out[4:])
+	}
+}
+
+func TestBuild_DescribeConfigs(t *testing.T) {
+	b := NewBinaryResponseBuilder()
+
+	value := "2"
+	def := "1"
+	doc := "d"
+	msg := "x"
+	body := &response.DescribeConfigsResponseBody{
+		Version: 4,
+		Results: []response.DescribeConfigsResult{
+			{
+				ResourceType: 2,
+				ResourceName: "t",
+				Configs: []response.DescribeConfigsResourceResult{{
+					Name:         "k",
+					Value:        &value,
+					ConfigSource: domain.ConfigSourceDynamicTopic,
+					Synonyms: []response.DescribeConfigsSynonym{
+						{Name: "k", Value: &value, Source: domain.ConfigSourceDynamicTopic},
+						{Name: "b", Value: &def, Source: domain.ConfigSourceDefault},
+					},
+					ConfigType:    int8(domain.ConfigTypeInt),
+					Documentation: &doc,
+				}},
+			},
+			{
+				ErrorCode:    domain.ErrorInvalidRequest,
+				ErrorMessage: &msg,
+				ResourceType: 4,
+				ResourceName: "9",
+				Configs:      []response.DescribeConfigsResourceResult{},
+			},
+		},
+	}
+
+	out, err := b.Build(&response.MessageResponse{CorrelationID: 2, HeaderVersion: 1, Body: body})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []byte{0, 0, 0, 2, 0, 0, 0, 0, 0, 3}
+	expected = append(expected, 0, 0, 0, 2, 2, 't')
+	expected = append(expected, 2, 2, 'k', 2, '2', 0, 1, 0)
+	expected = append(expected, 3, 2, 'k', 2, '2', 1, 0, 2, 'b', 2, '1', 5, 0)
+	expected = append(expected, 3, 2, 'd', 0)
+	expected = append(expected, 0)
+	expected = append(expected, 0, 42, 2, 'x', 4, 2, '9', 1, 0)
+	expected = append(expected, 0)
+	if !bytes.Equal(out[4:], expected) {
+		t.Fatalf("unexpected v4 payload %v", out[4:])
+	}
+
+	body.Version = 1
+	out, err = b.Build(&response.MessageResponse{CorrelationID: 2, Body: body})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = []byte{0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 2}
+	expected = append(expected, 0, 0, 0xff, 0xff, 2, 0, 1, 't')
+	expected = append(expected, 0, 0, 0, 1, 0, 1, 'k', 0, 1, '2', 0, 1, 0)
+	expected = append(expected, 0, 0, 0, 2, 0, 1, 'k', 0, 1, '2', 1, 0, 1, 'b', 0, 1, '1', 5)
+	expected = append(expected, 0, 42, 0, 1, 'x', 4, 0, 1, '9', 0, 0, 0, 0)
+	if !bytes.Equal(out[4:], expected) {
+		t.Fatalf("unexpected v1 payload %v", out[4:])
+	}
+}
+
+func TestBuild_AlterConfigs(t *testing.T) {
+	b := NewBinaryResponseBuilder()
+
+	msg := "x"
+	responses := []response.AlterConfigsResourceResponse{
+		{ResourceType: 2, ResourceName: "t"},
+		{ErrorCode: domain.ErrorInvalidConfig, ErrorMessage: &msg, ResourceType: 4, ResourceName: ""},
+	}
+
+	out, err := b.Build(&response.MessageResponse{
+		CorrelationID: 2,
+		HeaderVersion: 1,
+		Body:          &response.IncrementalAlterConfigsResponseBody{Version: 1, Responses: responses},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []byte{0, 0, 0, 2, 0, 0, 0, 0, 0, 3}
+	expected = append(expected, 0, 0, 0, 2, 2, 't', 0)
+	expected = append(expected, 0, 40, 2, 'x', 4, 1, 0)
+	expected = append(expected, 0)
+	if !bytes.Equal(out[4:], expected) {
+		t.Fatalf("unexpected incremental payload %v", out[4:])
+	}
+
+	out, err = b.Build(&response.MessageResponse{
+		CorrelationID: 2,
+		Body:          &response.AlterConfigsResponseBody{Version: 0, Responses: responses},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = []byte{0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 2}
+	expected = append(expected, 0, 0, 0xff, 0xff, 2, 0, 1, 't')
+	expected = append(expected, 0, 40, 0, 1, 'x', 4, 0, 0)
+	if !bytes.Equal(out[4:], expected) {
+		t.Fatalf("unexpected legacy payload %v", out[4:])
+	}
+}
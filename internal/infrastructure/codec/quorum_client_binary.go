@@ -0,0 +1,595 @@
+package codec
+
+import (
+	"errors"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/response"
+	"github.com/codecrafters-io/kafka-starter-go/internal/ports"
+)
+
+const (
+	QuorumFetchVersion    = 16
+	AlterPartitionVersion = 2
+)
+
+type BinaryQuorumClientCodec struct{}
+
+func NewBinaryQuorumClientCodec() ports.QuorumClientCodec {
+	return &BinaryQuorumClientCodec{}
+}
+
+func (c *BinaryQuorumClientCodec) EncodeRequest(
+	header request.RequestHeader,
+	body request.RequestBody,
+) ([]byte, error) {
+
+	out := make([]byte, 0)
+	out = appendUint16(out, body.ApiKey())
+	out = appendUint16(out, header.ApiVersion)
+	out = appendUint32(out, header.CorrelationID)
+	out = appendString(out, string(header.ClientID))
+
+	switch r := body.(type) {
+	case *request.VoteRequest:
+		out = appendUvarint(out, 0)
+		out = appendCompactNullableString(out, r.ClusterID)
+		out = appendQuorumPartition(out, r.QuorumPartition, true, func(out []byte) []byte {
+			out = appendInt32(out, r.Vote.CandidateEpoch)
+			out = appendInt32(out, r.Vote.CandidateID)
+			out = appendInt32(out, r.Vote.LastOffsetEpoch)
+			return appendInt64(out, r.Vote.LastOffset)
+		})
+		out = appendUvarint(out, 0)
+
+	case *request.BeginQuorumEpochRequest:
+		out = appendNullableString(out, r.ClusterID)
+		out = appendQuorumPartition(out, r.QuorumPartition, false, func(out []byte) []byte {
+			out = appendInt32(out, r.LeaderID)
+			return appendInt32(out, r.LeaderEpoch)
+		})
+
+	case *request.EndQuorumEpochRequest:
+		out = appendNullableString(out, r.ClusterID)
+		out = appendQuorumPartition(out, r.QuorumPartition, false, func(out []byte) []byte {
+			out = appendInt32(out, r.LeaderID)
+			out = appendInt32(out, r.LeaderEpoch)
+			out = appendInt32(out, int32(len(r.PreferredSuccessors)))
+			for _, id := range r.PreferredSuccessors {
+				out = appendInt32(out, id)
+			}
+			return out
+		})
+
+	case *request.FetchSnapshotRequest:
+		out = appendUvarint(out, 0)
+		out = appendInt32(out, r.ReplicaID)
+		out = appendInt32(out, r.MaxBytes)
+		out = appendQuorumPartition(out, r.QuorumPartition, true, func(out []byte) []byte {
+			out = appendInt32(out, r.CurrentLeaderEpoch)
+			out = appendSnapshotID(out, r.SnapshotID)
+			return appendInt64(out, r.Position)
+		})
+		out = appendTaggedFields(out, clusterIDTags(r.ClusterID)...)
+
+	case *request.FetchRequest:
+		out = appendUvarint(out, 0)
+		out = appendFetchRequest(out, r)
+
+	case *request.AlterPartitionRequest:
+		out = appendUvarint(out, 0)
+		out = appendAlterPartitionRequest(out, r, header.ApiVersion)
+
+	default:
+		return nil, errors.New("quorum client: unsupported request type")
+	}
+
+	return wrapWithSize(out), nil
+}
+
+func (c *BinaryQuorumClientCodec) DecodeResponse(apiKey uint16, b []byte) (*response.MessageResponse, error) {
+	offset := 0
+	if err := need(b, offset, 4, "quorum response: correlation id"); err != nil {
+		return nil, err
+	}
+	resp := &response.MessageResponse{CorrelationID: uint32(readInt32(b, &offset))}
+
+	var err error
+	switch apiKey {
+	case domain.VoteApiKey:
+		resp.Body, err = decodeVoteResponse(b, &offset)
+	case domain.BeginQuorumEpochApiKey:
+		body := &response.BeginQuorumEpochResponseBody{}
+		body.ErrorCode, body.Partition, err = decodeQuorumEpochResponse(b, &offset)
+		resp.Body = body
+	case domain.EndQuorumEpochApiKey:
+		body := &response.EndQuorumEpochResponseBody{}
+		body.ErrorCode, body.Partition, err = decodeQuorumEpochResponse(b, &offset)
+		resp.Body = body
+	case domain.FetchSnapshotApiKey:
+		resp.Body, err = decodeFetchSnapshotResponse(b, &offset)
+	case domain.FetchApikey:
+		resp.Body, err = decodeFetchResponse(b, &offset)
+	case domain.AlterPartitionApiKey:
+		resp.Body, err = decodeAlterPartitionResponse(b, &offset)
+	default:
+		err = errors.New("quorum client: unsupported response type")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func decodeVoteResponse(b []byte, offset *int) (*response.VoteResponseBody, error) {
+	if _, err := skipTagBuffer(b, offset); err != nil {
+		return nil, err
+	}
+	if err := need(b, *offset, 2, "vote response: error code"); err != nil {
+		return nil, err
+	}
+	body := &response.VoteResponseBody{ErrorCode: readInt16(b, offset)}
+
+	err := readSingleQuorumResult(b, offset, true, &body.Partition, func() error {
+		if err := need(b, *offset, 2+4+4+1, "vote response: partition"); err != nil {
+			return err
+		}
+		body.Partition.ErrorCode = readInt16(b, offset)
+		body.Partition.LeaderID = readInt32(b, offset)
+		body.Partition.LeaderEpoch = readInt32(b, offset)
+		body.VoteGranted = b[*offset] != 0
+		*offset++
+		return nil
+	})
+	return body, err
+}
+
+func decodeQuorumEpochResponse(b []byte, offset *int) (int16, response.QuorumPartitionResult, error) {
+	var p response.QuorumPartitionResult
+	if err := need(b, *offset, 2, "quorum epoch response: error code"); err != nil {
+		return 0, p, err
+	}
+	errorCode := readInt16(b, offset)
+
+	err := readSingleQuorumResult(b, offset, false, &p, func() error {
+		if err := need(b, *offset, 2+4+4, "quorum epoch response: partition"); err != nil {
+			return err
+		}
+		p.ErrorCode = readInt16(b, offset)
+		p.LeaderID = readInt32(b, offset)
+		p.LeaderEpoch = readInt32(b, offset)
+		return nil
+	})
+	return errorCode, p, err
+}
+
+func decodeFetchSnapshotResponse(b []byte, offset *int) (*response.FetchSnapshotResponseBody, error) {
+	if _, err := skipTagBuffer(b, offset); err != nil {
+		return nil, err
+	}
+	if err := need(b, *offset, 6, "fetch snapshot response: header"); err != nil {
+		return nil, err
+	}
+	body := &response.FetchSnapshotResponseBody{
+		ThrottleTimeMs: readInt32(b, offset),
+		ErrorCode:      readInt16(b, offset),
+	}
+	body.Partition.LeaderID = -1
+	body.Partition.LeaderEpoch = -1
+
+	topics, err := readCompactArrayLen(b, offset)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < topics; i++ {
+		name, err := readCompactString(b, offset)
+		if err != nil {
+			return nil, err
+		}
+		body.Partition.TopicName = name
+
+		partitions, err := readCompactArrayLen(b, offset)
+		if err != nil {
+			return nil, err
+		}
+		for j := 0; j < partitions; j++ {
+			if err := need(b, *offset, 6, "fetch snapshot response: partition"); err != nil {
+				return nil, err
+			}
+			body.Partition.PartitionIndex = readInt32(b, offset)
+			body.Partition.ErrorCode = readInt16(b, offset)
+
+			if body.SnapshotID, err = readSnapshotID(b, offset); err != nil {
+				return nil, err
+			}
+			if err := need(b, *offset, 16, "fetch snapshot response: position"); err != nil {
+				return nil, err
+			}
+			body.Size = readInt64(b, offset)
+			body.Position = readInt64(b, offset)
+
+			records, err := readCompactBytes(b, offset)
+			if err != nil {
+				return nil, err
+			}
+			body.UnalignedRecords = append([]byte(nil), records...)
+
+			err = readTagBuffer(b, offset, func(tag uint64, data []byte) error {
+				if tag != 0 {
+					return nil
+				}
+				leader, err := readLeaderAndEpoch(data)
+				body.Partition.LeaderID = leader.LeaderID
+				body.Partition.LeaderEpoch = leader.Epoch
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		if _, err := skipTagBuffer(b, offset); err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = skipTagBuffer(b, offset)
+	return body, err
+}
+
+func decodeFetchResponse(b []byte, offset *int) (*response.FetchResponseBody, error) {
+	if _, err := skipTagBuffer(b, offset); err != nil {
+		return nil, err
+	}
+	if err := need(b, *offset, 10, "fetch response: header"); err != nil {
+		return nil, err
+	}
+	body := &response.FetchResponseBody{
+		ThrottleTimeMs: readInt32(b, offset),
+		ErrorCode:      readInt16(b, offset),
+		SessionID:      readInt32(b, offset),
+	}
+
+	topics, err := readCompactArrayLen(b, offset)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < topics; i++ {
+		if err := need(b, *offset, 16, "fetch response: topic id"); err != nil {
+			return nil, err
+		}
+		var topic response.FetchTopicResponse
+		copy(topic.TopicID[:], b[*offset:*offset+16])
+		*offset += 16
+
+		partitions, err := readCompactArrayLen(b, offset)
+		if err != nil {
+			return nil, err
+		}
+		for j := 0; j < partitions; j++ {
+			p, err := decodeFetchPartition(b, offset)
+			if err != nil {
+				return nil, err
+			}
+			topic.Partitions = append(topic.Partitions, p)
+		}
+		if _, err := skipTagBuffer(b, offset); err != nil {
+			return nil, err
+		}
+		body.Responses = append(body.Responses, topic)
+	}
+
+	_, err = skipTagBuffer(b, offset)
+	return body, err
+}
+
+func decodeAlterPartitionResponse(b []byte, offset *int) (*response.AlterPartitionResponseBody, error) {
+	if _, err := skipTagBuffer(b, offset); err != nil {
+		return nil, err
+	}
+	if err := need(b, *offset, 6, "alter partition response: header"); err != nil {
+		return nil, err
+	}
+	body := &response.AlterPartitionResponseBody{
+		Version:        AlterPartitionVersion,
+		ThrottleTimeMs: readInt32(b, offset),
+		ErrorCode:      readInt16(b, offset),
+	}
+
+	topics, err := readCompactArrayLen(b, offset)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < topics; i++ {
+		if err := need(b, *offset, 16, "alter partition response: topic id"); err != nil {
+			return nil, err
+		}
+		var topic response.AlterPartitionTopicResult
+		copy(topic.TopicID[:], b[*offset:*offset+16])
+		*offset += 16
+
+		partitions, err := readCompactArrayLen(b, offset)
+		if err != nil {
+			return nil, err
+		}
+		for j := 0; j < partitions; j++ {
+			if err := need(b, *offset, 4+2+4+4, "alter partition response: partition"); err != nil {
+				return nil, err
+			}
+			p := response.AlterPartitionPartitionResult{
+				PartitionIndex: readInt32(b, offset),
+				ErrorCode:      readInt16(b, offset),
+				LeaderID:       readInt32(b, offset),
+				LeaderEpoch:    readInt32(b, offset),
+			}
+			if p.ISR, err = readCompactInt32Array(b, offset); err != nil {
+				return nil, err
+			}
+			if err := need(b, *offset, 1+4, "alter partition response: partition epoch"); err != nil {
+				return nil, err
+			}
+			p.LeaderRecoveryState = int8(b[*offset])
+			*offset++
+			p.PartitionEpoch = readInt32(b, offset)
+			if _, err := skipTagBuffer(b, offset); err != nil {
+				return nil, err
+			}
+			topic.Partitions = append(topic.Partitions, p)
+		}
+		if _, err := skipTagBuffer(b, offset); err != nil {
+			return nil, err
+		}
+		body.Topics = append(body.Topics, topic)
+	}
+
+	_, err = skipTagBuffer(b, offset)
+	return body, err
+}
+
+func decodeFetchPartition(b []byte, offset *int) (response.FetchPartitionResponse, error) {
+	var p response.FetchPartitionResponse
+	if err := need(b, *offset, 4+2+8+8+8, "fetch response: partition"); err != nil {
+		return p, err
+	}
+	p.PartitionIndex = readInt32(b, offset)
+	p.ErrorCode = readInt16(b, offset)
+	p.HighWatermark = readInt64(b, offset)
+	p.LastStableOffset = readInt64(b, offset)
+	p.LogStartOffset = readInt64(b, offset)
+
+	aborted, err := readCompactArrayLen(b, offset)
+	if err != nil {
+		return p, err
+	}
+	for i := 0; i < aborted; i++ {
+		if err := need(b, *offset, 16, "fetch response: aborted transaction"); err != nil {
+			return p, err
+		}
+		*offset += 16
+		if _, err := skipTagBuffer(b, offset); err != nil {
+			return p, err
+		}
+	}
+
+	if err := need(b, *offset, 4, "fetch response: preferred read replica"); err != nil {
+		return p, err
+	}
+	*offset += 4
+
+	records, err := readCompactBytes(b, offset)
+	if err != nil {
+		return p, err
+	}
+	p.Records = append([]byte(nil), records...)
+
+	err = readTagBuffer(b, offset, func(tag uint64, data []byte) error {
+		pos := 0
+		switch tag {
+		case 0:
+			if err := need(data, pos, 12, "fetch response: diverging epoch"); err != nil {
+				return err
+			}
+			p.DivergingEpoch = &domain.EpochEndOffset{
+				Epoch:     readInt32(data, &pos),
+				EndOffset: readInt64(data, &pos),
+			}
+		case 1:
+			leader, err := readLeaderAndEpoch(data)
+			if err != nil {
+				return err
+			}
+			p.CurrentLeader = &leader
+		case 2:
+			id, err := readSnapshotID(data, &pos)
+			if err != nil {
+				return err
+			}
+			p.SnapshotID = &id
+		}
+		return nil
+	})
+	return p, err
+}
+
+func readSingleQuorumResult(
+	b []byte,
+	offset *int,
+	flexible bool,
+	p *response.QuorumPartitionResult,
+	readFields func() error,
+) error {
+
+	topics, err := readArrayLen(b, offset, flexible)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < topics; i++ {
+		if flexible {
+			p.TopicName, err = readCompactString(b, offset)
+		} else {
+			var name *string
+			name, err = readNullableString(b, offset)
+			if name != nil {
+				p.TopicName = *name
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		partitions, err := readArrayLen(b, offset, flexible)
+		if err != nil {
+			return err
+		}
+		for j := 0; j < partitions; j++ {
+			if err := need(b, *offset, 4, "quorum response: partition index"); err != nil {
+				return err
+			}
+			p.PartitionIndex = readInt32(b, offset)
+			if err := readFields(); err != nil {
+				return err
+			}
+			if flexible {
+				if _, err := skipTagBuffer(b, offset); err != nil {
+					return err
+				}
+			}
+		}
+		if flexible {
+			if _, err := skipTagBuffer(b, offset); err != nil {
+				return err
+			}
+		}
+	}
+
+	if flexible {
+		_, err = skipTagBuffer(b, offset)
+	}
+	return err
+}
+
+func readLeaderAndEpoch(data []byte) (domain.LeaderAndEpoch, error) {
+	pos := 0
+	if err := need(data, pos, 8, "leader and epoch"); err != nil {
+		return domain.LeaderAndEpoch{}, err
+	}
+	return domain.LeaderAndEpoch{
+		LeaderID: readInt32(data, &pos),
+		Epoch:    readInt32(data, &pos),
+	}, nil
+}
+
+func appendQuorumPartition(
+	out []byte,
+	p request.QuorumPartition,
+	flexible bool,
+	fields func(out []byte) []byte,
+) []byte {
+
+	if flexible {
+		out = appendUvarint(out, 2)
+		out = appendCompactString(out, p.TopicName)
+		out = appendUvarint(out, 2)
+	} else {
+		out = appendInt32(out, 1)
+		out = appendString(out, p.TopicName)
+		out = appendInt32(out, 1)
+	}
+
+	out = appendInt32(out, p.PartitionIndex)
+	out = fields(out)
+
+	if flexible {
+		out = appendUvarint(out, 0)
+		out = appendUvarint(out, 0)
+	}
+	return out
+}
+
+func appendFetchRequest(out []byte, r *request.FetchRequest) []byte {
+	out = appendInt32(out, r.MaxWaitMs)
+	out = appendInt32(out, r.MinBytes)
+	out = appendInt32(out, r.MaxBytes)
+	out = append(out, 0)
+	out = appendInt32(out, r.SessionID)
+	out = appendInt32(out, r.SessionEpoch)
+
+	out = appendUvarint(out, uint64(len(r.Topics)+1))
+	for _, t := range r.Topics {
+		out = append(out, t.TopicID[:]...)
+		out = appendUvarint(out, uint64(len(t.Partitions)+1))
+		for _, p := range t.Partitions {
+			out = appendInt32(out, p.Partition)
+			out = appendInt32(out, p.CurrentLeaderEpoch)
+			out = appendInt64(out, p.FetchOffset)
+			out = appendInt32(out, p.LastFetchedEpoch)
+			out = appendInt64(out, p.LogStartOffset)
+			out = appendInt32(out, p.PartitionMaxBytes)
+			out = appendUvarint(out, 0)
+		}
+		out = appendUvarint(out, 0)
+	}
+
+	out = appendUvarint(out, 1)
+	out = appendCompactString(out, "")
+
+	fields := clusterIDTags(r.ClusterID)
+	if r.ReplicaID >= 0 {
+		data := appendInt32(nil, r.ReplicaID)
+		data = appendInt64(data, r.ReplicaEpoch)
+		fields = append(fields, taggedField{tag: 1, data: appendUvarint(data, 0)})
+	}
+	return appendTaggedFields(out, fields...)
+}
+
+func appendAlterPartitionRequest(out []byte, r *request.AlterPartitionRequest, version uint16) []byte {
+	out = appendInt32(out, r.BrokerID)
+	out = appendInt64(out, r.BrokerEpoch)
+
+	out = appendUvarint(out, uint64(len(r.Topics)+1))
+	for _, t := range r.Topics {
+		if version >= 2 {
+			out = append(out, t.TopicID[:]...)
+		} else {
+			out = appendCompactString(out, t.TopicName)
+		}
+
+		out = appendUvarint(out, uint64(len(t.Partitions)+1))
+		for _, p := range t.Partitions {
+			out = appendInt32(out, p.PartitionIndex)
+			out = appendInt32(out, p.LeaderEpoch)
+			if version >= 3 {
+				out = appendUvarint(out, uint64(len(p.NewISR)+1))
+				for _, id := range p.NewISR {
+					out = appendInt32(out, id)
+					out = appendInt64(out, -1)
+					out = appendUvarint(out, 0)
+				}
+			} else {
+				out = appendCompactInt32Array(out, p.NewISR)
+			}
+			if version >= 1 {
+				out = append(out, byte(p.LeaderRecoveryState))
+			}
+			out = appendInt32(out, p.PartitionEpoch)
+			out = appendUvarint(out, 0)
+		}
+		out = appendUvarint(out, 0)
+	}
+
+	return appendUvarint(out, 0)
+}
+
+func clusterIDTags(clusterID *string) []taggedField {
+	if clusterID == nil {
+		return nil
+	}
+	return []taggedField{{tag: 0, data: appendCompactNullableString(nil, clusterID)}}
+}
+
+func appendNullableString(out []byte, s *string) []byte {
+	if s == nil {
+		return appendInt16(out, -1)
+	}
+	return appendString(out, *s)
+}
@@ -0,0 +1,256 @@
+package codec
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+	"github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/compression"
+	"github.com/codecrafters-io/kafka-starter-go/internal/ports"
+)
+
+const (
+	recordBatchHeaderLen        = 61
+	recordBatchLogOverhead      = 12
+	recordBatchCRCOffset        = 17
+	recordBatchAttributesOffset = 21
+	recordBatchMagic            = 2
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+type BinaryRecordBatchCodec struct{}
+
+func NewBinaryRecordBatchCodec() ports.RecordBatchCodec {
+	return &BinaryRecordBatchCodec{}
+}
+
+func (c *BinaryRecordBatchCodec) Decode(data []byte) ([]domain.RecordBatch, error) {
+	batches := make([]domain.RecordBatch, 0)
+
+	for len(data) > 0 {
+		if err := need(data, 0, recordBatchHeaderLen, "record batch: header"); err != nil {
+			return nil, err
+		}
+
+		batchLen := int(int32(binary.BigEndian.Uint32(data[8:12])))
+		end := recordBatchLogOverhead + batchLen
+		if batchLen < recordBatchHeaderLen-recordBatchLogOverhead {
+			return nil, errors.New("record batch: invalid length")
+		}
+		if err := need(data, 0, end, "record batch: body"); err != nil {
+			return nil, err
+		}
+
+		batch, err := decodeRecordBatch(data[:end])
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, batch)
+		data = data[end:]
+	}
+
+	return batches, nil
+}
+
+func decodeRecordBatch(b []byte) (domain.RecordBatch, error) {
+	rb := domain.RecordBatch{
+		BaseOffset:           int64(binary.BigEndian.Uint64(b[0:8])),
+		PartitionLeaderEpoch: int32(binary.BigEndian.Uint32(b[12:16])),
+		Magic:                int8(b[16]),
+		Attributes:           int16(binary.BigEndian.Uint16(b[21:23])),
+		LastOffsetDelta:      int32(binary.BigEndian.Uint32(b[23:27])),
+		BaseTimestamp:        int64(binary.BigEndian.Uint64(b[27:35])),
+		MaxTimestamp:         int64(binary.BigEndian.Uint64(b[35:43])),
+		ProducerID:           int64(binary.BigEndian.Uint64(b[43:51])),
+		ProducerEpoch:        int16(binary.BigEndian.Uint16(b[51:53])),
+		BaseSequence:         int32(binary.BigEndian.Uint32(b[53:57])),
+	}
+
+	if rb.Magic != recordBatchMagic {
+		return rb, errors.New("record batch: unsupported magic")
+	}
+
+	crc := binary.BigEndian.Uint32(b[recordBatchCRCOffset:recordBatchAttributesOffset])
+	if crc32.Checksum(b[recordBatchAttributesOffset:], castagnoli) != crc {
+		return rb, errors.New("record batch: crc mismatch")
+	}
+
+	count := int(int32(binary.BigEndian.Uint32(b[57:61])))
+	if count < 0 {
+		return rb, errors.New("record batch: negative records count")
+	}
+
+	payload, err := compression.Decompress(rb.Compression(), b[recordBatchHeaderLen:], domain.MaxRequestSize)
+	if err != nil {
+		return rb, err
+	}
+
+	offset := 0
+	rb.Records = make([]domain.Record, 0)
+	for i := 0; i < count; i++ {
+		rec, err := decodeRecord(payload, &offset)
+		if err != nil {
+			return rb, err
+		}
+		rb.Records = append(rb.Records, rec)
+	}
+
+	if offset != len(payload) {
+		return rb, errors.New("record batch: trailing bytes after records")
+	}
+
+	return rb, nil
+}
+
+func decodeRecord(b []byte, offset *int) (domain.Record, error) {
+	rec := domain.Record{}
+
+	length, err := readVarintPayload(b, offset)
+	if err != nil {
+		return rec, err
+	}
+	if length < 0 {
+		return rec, errors.New("record: negative length")
+	}
+	if err := need(b, *offset, int(length), "record"); err != nil {
+		return rec, err
+	}
+	end := *offset + int(length)
+	body := b[:end]
+
+	if err := need(body, *offset, 1, "record: attributes"); err != nil {
+		return rec, err
+	}
+	rec.Attributes = int8(body[*offset])
+	*offset++
+
+	if rec.TimestampDelta, err = readVarintPayload(body, offset); err != nil {
+		return rec, err
+	}
+
+	offsetDelta, err := readVarintPayload(body, offset)
+	if err != nil {
+		return rec, err
+	}
+	rec.OffsetDelta = int32(offsetDelta)
+
+	if rec.Key, err = readVarintBytes(body, offset); err != nil {
+		return rec, err
+	}
+	if rec.Value, err = readVarintBytes(body, offset); err != nil {
+		return rec, err
+	}
+
+	headers, err := readVarintPayload(body, offset)
+	if err != nil {
+		return rec, err
+	}
+	if headers < 0 {
+		return rec, errors.New("record: negative headers count")
+	}
+
+	for i := int64(0); i < headers; i++ {
+		key, err := readVarintBytes(body, offset)
+		if err != nil {
+			return rec, err
+		}
+		value, err := readVarintBytes(body, offset)
+		if err != nil {
+			return rec, err
+		}
+		rec.Headers = append(rec.Headers, domain.RecordHeader{Key: string(key), Value: value})
+	}
+
+	if *offset != end {
+		return rec, errors.New("record: length mismatch")
+	}
+
+	return rec, nil
+}
+
+func (c *BinaryRecordBatchCodec) Encode(batches []domain.RecordBatch) ([]byte, error) {
+	out := make([]byte, 0)
+
+	for _, rb := range batches {
+		raw := make([]byte, 0)
+		for _, rec := range rb.Records {
+			raw = appendRecord(raw, rec)
+		}
+
+		payload, err := compression.Compress(rb.Compression(), raw)
+		if err != nil {
+			return nil, err
+		}
+
+		body := make([]byte, 0, recordBatchHeaderLen-recordBatchAttributesOffset+len(payload))
+		body = appendInt16(body, rb.Attributes)
+		body = appendInt32(body, rb.LastOffsetDelta)
+		body = appendInt64(body, rb.BaseTimestamp)
+		body = appendInt64(body, rb.MaxTimestamp)
+		body = appendInt64(body, rb.ProducerID)
+		body = appendInt16(body, rb.ProducerEpoch)
+		body = appendInt32(body, rb.BaseSequence)
+		body = appendInt32(body, int32(len(rb.Records)))
+		body = append(body, payload...)
+
+		out = appendInt64(out, rb.BaseOffset)
+		out = appendInt32(out, int32(recordBatchAttributesOffset-recordBatchLogOverhead+len(body)))
+		out = appendInt32(out, rb.PartitionLeaderEpoch)
+		out = append(out, recordBatchMagic)
+		out = appendUint32(out, crc32.Checksum(body, castagnoli))
+		out = append(out, body...)
+	}
+
+	return out, nil
+}
+
+func appendRecord(buf []byte, rec domain.Record) []byte {
+	body := make([]byte, 0)
+	body = append(body, byte(rec.Attributes))
+	body = binary.AppendVarint(body, rec.TimestampDelta)
+	body = binary.AppendVarint(body, int64(rec.OffsetDelta))
+	body = appendVarintBytes(body, rec.Key)
+	body = appendVarintBytes(body, rec.Value)
+	body = binary.AppendVarint(body, int64(len(rec.Headers)))
+	for _, h := range rec.Headers {
+		body = appendVarintBytes(body, []byte(h.Key))
+		body = appendVarintBytes(body, h.Value)
+	}
+
+	buf = binary.AppendVarint(buf, int64(len(body)))
+	return append(buf, body...)
+}
+
+func appendVarintBytes(buf []byte, v []byte) []byte {
+	if v == nil {
+		return binary.AppendVarint(buf, -1)
+	}
+	buf = binary.AppendVarint(buf, int64(len(v)))
+	return append(buf, v...)
+}
+
+func readVarintPayload(b []byte, offset *int) (int64, error) {
+	v, n := binary.Varint(b[*offset:])
+	if n <= 0 {
+		return 0, errors.New("varint: decode error")
+	}
+	*offset += n
+	return v, nil
+}
+
+func readVarintBytes(b []byte, offset *int) ([]byte, error) {
+	ln, err := readVarintPayload(b, offset)
+	if err != nil || ln < 0 {
+		return nil, err
+	}
+
+	if err := need(b, *offset, int(ln), "varint bytes"); err != nil {
+		return nil, err
+	}
+
+	out := b[*offset : *offset+int(ln)]
+	*offset += int(ln)
+	return out, nil
+}
@@ -42,11 +42,65 @@ func (p *BinaryRequestParser) Parse(data []byte) (*request.MessageRequest, error
 		body, err = parseDescribeTopicPartitionsRequest(payload)
 
 	case domain.FetchApikey:
-		body, err = parseFetchRequest(payload)
+		body, err = parseFetchRequest(payload, header.ApiVersion)
 
 	case domain.ProduceApiKey:
 		body, err = parseProduceRequest(payload)
 
+	case domain.DescribeLogDirsApiKey:
+		body, err = parseDescribeLogDirsRequest(payload)
+
+	case domain.AlterReplicaLogDirsApiKey:
+		body, err = parseAlterReplicaLogDirsRequest(payload)
+
+	case domain.DescribeClusterApiKey:
+		body, err = parseDescribeClusterRequest(payload, header.ApiVersion)
+
+	case domain.UpdateFeaturesApiKey:
+		body, err = parseUpdateFeaturesRequest(payload, header.ApiVersion)
+
+	case domain.VoteApiKey:
+		body, err = parseVoteRequest(payload)
+
+	case domain.BeginQuorumEpochApiKey:
+		body, err = parseBeginQuorumEpochRequest(payload)
+
+	case domain.EndQuorumEpochApiKey:
+		body, err = parseEndQuorumEpochRequest(payload)
+
+	case domain.FetchSnapshotApiKey:
+		body, err = parseFetchSnapshotRequest(payload)
+
+	case domain.DescribeQuorumApiKey:
+		body, err = parseDescribeQuorumRequest(payload)
+
+	case domain.AlterPartitionApiKey:
+		body, err = parseAlterPartitionRequest(payload, header.ApiVersion)
+
+	case domain.ElectLeadersApiKey:
+		body, err = parseElectLeadersRequest(payload, header.ApiVersion)
+
+	case domain.OffsetForLeaderEpochApiKey:
+		body, err = parseOffsetForLeaderEpochRequest(payload, header.ApiVersion)
+
+	case domain.AlterPartitionReassignmentsApiKey:
+		body, err = parseAlterPartitionReassignmentsRequest(payload)
+
+	case domain.ListPartitionReassignmentsApiKey:
+		body, err = parseListPartitionReassignmentsRequest(payload)
+
+	case domain.CreateTopicsApiKey:
+		body, err = parseCreateTopicsRequest(payload, header.ApiVersion)
+
+	case domain.DescribeConfigsApiKey:
+		body, err = parseDescribeConfigsRequest(payload, header.ApiVersion)
+
+	case domain.AlterConfigsApiKey:
+		body, err = parseAlterConfigsRequest(payload, header.ApiVersion)
+
+	case domain.IncrementalAlterConfigsApiKey:
+		body, err = parseIncrementalAlterConfigsRequest(payload, header.ApiVersion)
+
 	default:
 		body = &request.ApiVersionsRequest{}
 	}
@@ -134,32 +188,30 @@ func parseDescribeTopicPartitionsRequest(b []byte) (*request.DescribeTopicPartit
 	}, nil
 }
 
-func parseFetchRequest(b []byte) (*request.FetchRequest, error) {
+func parseFetchRequest(b []byte, version uint16) (*request.FetchRequest, error) {
 	offset := 0
-	r := &request.FetchRequest{}
+	r := &request.FetchRequest{ReplicaID: domain.ReplicaIDConsumer, ReplicaEpoch: -1}
 
-	if err := need(b, offset, 2, "fetch: client_id length"); err != nil {
+	if err := skipFlexibleHeader(b, &offset, "fetch"); err != nil {
 		return nil, err
 	}
-	clientLen := int(binary.BigEndian.Uint16(b[offset:]))
-	offset += 2
 
-	if err := need(b, offset, clientLen, "fetch: client_id bytes"); err != nil {
-		return nil, err
+	if version >= 12 && version < 15 {
+		if err := need(b, offset, 4, "fetch: replica_id"); err != nil {
+			return nil, err
+		}
+		r.ReplicaID = readInt32(b, &offset)
 	}
-	offset += clientLen
 
-	if err := need(b, offset, 1, "fetch: header tag buffer"); err != nil {
+	if err := need(b, offset, 4+4+4+1+4+4, "fetch: request fields"); err != nil {
 		return nil, err
 	}
+	r.MaxWaitMs = readInt32(b, &offset)
+	r.MinBytes = readInt32(b, &offset)
+	r.MaxBytes = readInt32(b, &offset)
 	offset++
-
-	offset += 4
-	offset += 4
-	offset += 4
-	offset++
-	offset += 4
-	offset += 4
+	r.SessionID = readInt32(b, &offset)
+	r.SessionEpoch = readInt32(b, &offset)
 
 	topicsPlus1, err := readUvarintPayload(b, &offset)
 	if err != nil {
@@ -171,15 +223,94 @@ func parseFetchRequest(b []byte) (*request.FetchRequest, error) {
 		return r, nil
 	}
 
-	if err := need(b, offset, 16, "fetch: topic_id"); err != nil {
+	for i := 0; i < topicsCount; i++ {
+		if err := need(b, offset, 16, "fetch: topic_id"); err != nil {
+			return nil, err
+		}
+
+		var topic request.FetchTopic
+		copy(topic.TopicID[:], b[offset:offset+16])
+		offset += 16
+
+		if offset >= len(b) {
+			r.Topics = append(r.Topics, topic)
+			return r, nil
+		}
+
+		partitions, err := readCompactArrayLen(b, &offset)
+		if err != nil {
+			return nil, err
+		}
+
+		for j := 0; j < partitions; j++ {
+			if err := need(b, offset, 4+4+8+4+8+4, "fetch: partition"); err != nil {
+				return nil, err
+			}
+			topic.Partitions = append(topic.Partitions, request.FetchPartition{
+				Partition:          readInt32(b, &offset),
+				CurrentLeaderEpoch: readInt32(b, &offset),
+				FetchOffset:        readInt64(b, &offset),
+				LastFetchedEpoch:   readInt32(b, &offset),
+				LogStartOffset:     readInt64(b, &offset),
+				PartitionMaxBytes:  readInt32(b, &offset),
+			})
+			if _, err := skipTagBuffer(b, &offset); err != nil {
+				return nil, err
+			}
+		}
+
+		if _, err := skipTagBuffer(b, &offset); err != nil {
+			return nil, err
+		}
+
+		r.Topics = append(r.Topics, topic)
+	}
+
+	if offset >= len(b) {
+		return r, nil
+	}
+
+	forgotten, err := readCompactArrayLen(b, &offset)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < forgotten; i++ {
+		if err := need(b, offset, 16, "fetch: forgotten topic_id"); err != nil {
+			return nil, err
+		}
+		offset += 16
+		if _, err := readCompactInt32Array(b, &offset); err != nil {
+			return nil, err
+		}
+		if _, err := skipTagBuffer(b, &offset); err != nil {
+			return nil, err
+		}
+	}
+
+	if r.RackID, err = readCompactString(b, &offset); err != nil {
 		return nil, err
 	}
 
-	var id [16]byte
-	copy(id[:], b[offset:offset+16])
-	offset += 16
+	err = readTagBuffer(b, &offset, func(tag uint64, data []byte) error {
+		pos := 0
+		switch tag {
+		case 0:
+			clusterID, err := readCompactNullableString(data, &pos)
+			r.ClusterID = clusterID
+			return err
+		case 1:
+			if err := need(data, pos, 12, "fetch: replica state"); err != nil {
+				return err
+			}
+			r.ReplicaID = readInt32(data, &pos)
+			r.ReplicaEpoch = readInt64(data, &pos)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	r.Topics = append(r.Topics, request.FetchTopic{TopicID: id})
 	return r, nil
 }
 
@@ -208,8 +339,11 @@ func parseProduceRequest(b []byte) (*request.ProduceRequest, error) {
 		return nil, err
 	}
 
-	offset += 2
-	offset += 4
+	if err := need(b, offset, 2+4, "produce: acks and timeout"); err != nil {
+		return nil, err
+	}
+	r.Acks = readInt16(b, &offset)
+	r.TimeoutMs = readInt32(b, &offset)
 
 	topicsPlus1, err := readUvarintPayload(b, &offset)
 	if err != nil {
@@ -270,7 +404,7 @@ func parseProduceRequest(b []byte) (*request.ProduceRequest, error) {
 }
 
 func need(b []byte, offset, size int, ctx string) error {
-	if len(b) < offset+size {
+	if size < 0 || len(b)-offset < size {
 		return errors.New(ctx + ": truncated")
 	}
 	return nil
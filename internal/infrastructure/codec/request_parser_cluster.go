@@ -0,0 +1,35 @@
+package codec
+
+import (
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/request"
+)
+
+func parseDescribeClusterRequest(b []byte, version uint16) (*request.DescribeClusterRequest, error) {
+	offset := 0
+	r := &request.DescribeClusterRequest{EndpointType: domain.EndpointTypeBroker}
+
+	if err := skipFlexibleHeader(b, &offset, "describe cluster"); err != nil {
+		return nil, err
+	}
+
+	if err := need(b, offset, 1, "describe cluster: include authorized operations"); err != nil {
+		return nil, err
+	}
+	r.IncludeClusterAuthorizedOperations = b[offset] != 0
+	offset++
+
+	if version >= 1 {
+		if err := need(b, offset, 1, "describe cluster: endpoint type"); err != nil {
+			return nil, err
+		}
+		r.EndpointType = int8(b[offset])
+		offset++
+	}
+
+	if _, err := skipTagBuffer(b, &offset); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
@@ -0,0 +1,179 @@
+package codec
+
+import (
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/request"
+)
+
+func parseDescribeConfigsRequest(b []byte, version uint16) (*request.DescribeConfigsRequest, error) {
+	offset := 0
+	flexible := version >= 4
+	r := &request.DescribeConfigsRequest{}
+
+	if err := skipConfigsHeader(b, &offset, flexible, "describe configs"); err != nil {
+		return nil, err
+	}
+
+	resources, err := readArrayLen(b, &offset, flexible)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < resources; i++ {
+		var res request.DescribeConfigsResource
+		if err := need(b, offset, 1, "describe configs: resource type"); err != nil {
+			return nil, err
+		}
+		res.ResourceType = int8(b[offset])
+		offset++
+		if res.ResourceName, err = readString(b, &offset, flexible); err != nil {
+			return nil, err
+		}
+
+		keys, err := readArrayLen(b, &offset, flexible)
+		if err != nil {
+			return nil, err
+		}
+		if keys >= 0 {
+			res.ConfigurationKeys = make([]string, 0, keys)
+		}
+		for j := 0; j < keys; j++ {
+			key, err := readString(b, &offset, flexible)
+			if err != nil {
+				return nil, err
+			}
+			res.ConfigurationKeys = append(res.ConfigurationKeys, key)
+		}
+
+		if flexible {
+			if _, err := skipTagBuffer(b, &offset); err != nil {
+				return nil, err
+			}
+		}
+		r.Resources = append(r.Resources, res)
+	}
+
+	if err := need(b, offset, 1, "describe configs: include synonyms"); err != nil {
+		return nil, err
+	}
+	r.IncludeSynonyms = b[offset] != 0
+	offset++
+	if version >= 3 {
+		if err := need(b, offset, 1, "describe configs: include documentation"); err != nil {
+			return nil, err
+		}
+		r.IncludeDocumentation = b[offset] != 0
+		offset++
+	}
+
+	if flexible {
+		if _, err := skipTagBuffer(b, &offset); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func parseAlterConfigsRequest(b []byte, version uint16) (*request.AlterConfigsRequest, error) {
+	resources, validateOnly, err := parseAlterConfigsResources(b, version >= 2, false, "alter configs")
+	if err != nil {
+		return nil, err
+	}
+	return &request.AlterConfigsRequest{Resources: resources, ValidateOnly: validateOnly}, nil
+}
+
+func parseIncrementalAlterConfigsRequest(b []byte, version uint16) (*request.IncrementalAlterConfigsRequest, error) {
+	resources, validateOnly, err := parseAlterConfigsResources(b, version >= 1, true, "incremental alter configs")
+	if err != nil {
+		return nil, err
+	}
+	return &request.IncrementalAlterConfigsRequest{Resources: resources, ValidateOnly: validateOnly}, nil
+}
+
+func parseAlterConfigsResources(
+	b []byte,
+	flexible bool,
+	incremental bool,
+	ctx string,
+) ([]request.AlterConfigsResource, bool, error) {
+
+	offset := 0
+	if err := skipConfigsHeader(b, &offset, flexible, ctx); err != nil {
+		return nil, false, err
+	}
+
+	n, err := readArrayLen(b, &offset, flexible)
+	if err != nil {
+		return nil, false, err
+	}
+	resources := make([]request.AlterConfigsResource, 0, max(n, 0))
+	for i := 0; i < n; i++ {
+		var res request.AlterConfigsResource
+		if err := need(b, offset, 1, ctx+": resource type"); err != nil {
+			return nil, false, err
+		}
+		res.ResourceType = int8(b[offset])
+		offset++
+		if res.ResourceName, err = readString(b, &offset, flexible); err != nil {
+			return nil, false, err
+		}
+
+		configs, err := readArrayLen(b, &offset, flexible)
+		if err != nil {
+			return nil, false, err
+		}
+		for j := 0; j < configs; j++ {
+			var c request.AlterableConfig
+			if c.Name, err = readString(b, &offset, flexible); err != nil {
+				return nil, false, err
+			}
+			if incremental {
+				if err := need(b, offset, 1, ctx+": config operation"); err != nil {
+					return nil, false, err
+				}
+				c.ConfigOperation = int8(b[offset])
+				offset++
+			}
+			if flexible {
+				c.Value, err = readCompactNullableString(b, &offset)
+			} else {
+				c.Value, err = readNullableString(b, &offset)
+			}
+			if err != nil {
+				return nil, false, err
+			}
+
+			if flexible {
+				if _, err := skipTagBuffer(b, &offset); err != nil {
+					return nil, false, err
+				}
+			}
+			res.Configs = append(res.Configs, c)
+		}
+
+		if flexible {
+			if _, err := skipTagBuffer(b, &offset); err != nil {
+				return nil, false, err
+			}
+		}
+		resources = append(resources, res)
+	}
+
+	if err := need(b, offset, 1, ctx+": validate only"); err != nil {
+		return nil, false, err
+	}
+	validateOnly := b[offset] != 0
+	offset++
+
+	if flexible {
+		if _, err := skipTagBuffer(b, &offset); err != nil {
+			return nil, false, err
+		}
+	}
+	return resources, validateOnly, nil
+}
+
+func skipConfigsHeader(b []byte, offset *int, flexible bool, ctx string) error {
+	if flexible {
+		return skipFlexibleHeader(b, offset, ctx)
+	}
+	return skipHeaderClientID(b, offset, ctx)
+}
@@ -0,0 +1,74 @@
+package codec
+
+import (
+	"encoding/binary"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/request"
+)
+
+func parseUpdateFeaturesRequest(b []byte, version uint16) (*request.UpdateFeaturesRequest, error) {
+	offset := 0
+	r := &request.UpdateFeaturesRequest{}
+
+	if err := skipFlexibleHeader(b, &offset, "update features"); err != nil {
+		return nil, err
+	}
+
+	if err := need(b, offset, 4, "update features: timeout"); err != nil {
+		return nil, err
+	}
+	r.TimeoutMs = int32(binary.BigEndian.Uint32(b[offset:]))
+	offset += 4
+
+	count, err := readCompactArrayLen(b, &offset)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < count; i++ {
+		name, err := readCompactString(b, &offset)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := need(b, offset, 3, "update features: feature update"); err != nil {
+			return nil, err
+		}
+		u := request.FeatureUpdate{
+			Feature:         name,
+			MaxVersionLevel: int16(binary.BigEndian.Uint16(b[offset:])),
+		}
+		offset += 2
+
+		if version == 0 {
+			u.UpgradeType = domain.FeatureUpgrade
+			if b[offset] != 0 {
+				u.UpgradeType = domain.FeatureSafeDowngrade
+			}
+		} else {
+			u.UpgradeType = int8(b[offset])
+		}
+		offset++
+
+		if _, err := skipTagBuffer(b, &offset); err != nil {
+			return nil, err
+		}
+
+		r.Updates = append(r.Updates, u)
+	}
+
+	if version >= 1 {
+		if err := need(b, offset, 1, "update features: validate only"); err != nil {
+			return nil, err
+		}
+		r.ValidateOnly = b[offset] != 0
+		offset++
+	}
+
+	if _, err := skipTagBuffer(b, &offset); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
@@ -0,0 +1,145 @@
+package codec
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/request"
+)
+
+func parseDescribeLogDirsRequest(b []byte) (*request.DescribeLogDirsRequest, error) {
+	offset := 0
+	r := &request.DescribeLogDirsRequest{}
+
+	if err := skipFlexibleHeader(b, &offset, "describe log dirs"); err != nil {
+		return nil, err
+	}
+
+	topicsCount, err := readCompactArrayLen(b, &offset)
+	if err != nil {
+		return nil, err
+	}
+	if topicsCount < 0 {
+		return r, nil
+	}
+
+	r.Topics = make([]request.TopicPartitions, 0, topicsCount)
+	for i := 0; i < topicsCount; i++ {
+		topic, err := readTopicPartitions(b, &offset)
+		if err != nil {
+			return nil, err
+		}
+		r.Topics = append(r.Topics, topic)
+	}
+
+	return r, nil
+}
+
+func parseAlterReplicaLogDirsRequest(b []byte) (*request.AlterReplicaLogDirsRequest, error) {
+	offset := 0
+	r := &request.AlterReplicaLogDirsRequest{}
+
+	if err := skipFlexibleHeader(b, &offset, "alter replica log dirs"); err != nil {
+		return nil, err
+	}
+
+	dirsCount, err := readCompactArrayLen(b, &offset)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < dirsCount; i++ {
+		path, err := readCompactString(b, &offset)
+		if err != nil {
+			return nil, err
+		}
+
+		dir := request.AlterReplicaLogDir{Path: path}
+
+		topicsCount, err := readCompactArrayLen(b, &offset)
+		if err != nil {
+			return nil, err
+		}
+
+		for j := 0; j < topicsCount; j++ {
+			topic, err := readTopicPartitions(b, &offset)
+			if err != nil {
+				return nil, err
+			}
+			dir.Topics = append(dir.Topics, topic)
+		}
+
+		if _, err := skipTagBuffer(b, &offset); err != nil {
+			return nil, err
+		}
+
+		r.Dirs = append(r.Dirs, dir)
+	}
+
+	return r, nil
+}
+
+func readTopicPartitions(b []byte, offset *int) (request.TopicPartitions, error) {
+	name, err := readCompactString(b, offset)
+	if err != nil {
+		return request.TopicPartitions{}, err
+	}
+
+	partitions, err := readCompactInt32Array(b, offset)
+	if err != nil {
+		return request.TopicPartitions{}, err
+	}
+
+	if _, err := skipTagBuffer(b, offset); err != nil {
+		return request.TopicPartitions{}, err
+	}
+
+	return request.TopicPartitions{Name: name, Partitions: partitions}, nil
+}
+
+func skipFlexibleHeader(b []byte, offset *int, ctx string) error {
+	if err := need(b, *offset, 2, ctx+": client_id length"); err != nil {
+		return err
+	}
+	clientLen := int(int16(binary.BigEndian.Uint16(b[*offset:])))
+	*offset += 2
+
+	if clientLen > 0 {
+		if err := need(b, *offset, clientLen, ctx+": client_id bytes"); err != nil {
+			return err
+		}
+		*offset += clientLen
+	}
+
+	_, err := skipTagBuffer(b, offset)
+	return err
+}
+
+func readCompactArrayLen(b []byte, offset *int) (int, error) {
+	lnPlus1, err := readUvarintPayload(b, offset)
+	if err != nil {
+		return 0, err
+	}
+	if lnPlus1 > uint64(len(b)) {
+		return 0, errors.New("compact array: invalid length")
+	}
+	return int(lnPlus1) - 1, nil
+}
+
+func readCompactInt32Array(b []byte, offset *int) ([]int32, error) {
+	n, err := readCompactArrayLen(b, offset)
+	if err != nil || n < 0 {
+		return nil, err
+	}
+
+	if err := need(b, *offset, 4*n, "compact int32 array"); err != nil {
+		return nil, err
+	}
+
+	out := make([]int32, n)
+	for i := range out {
+		out[i] = int32(binary.BigEndian.Uint32(b[*offset:]))
+		*offset += 4
+	}
+	return out, nil
+}
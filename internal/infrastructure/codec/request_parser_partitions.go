@@ -0,0 +1,379 @@
+package codec
+
+import (
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/request"
+)
+
+func parseAlterPartitionRequest(b []byte, version uint16) (*request.AlterPartitionRequest, error) {
+	offset := 0
+	r := &request.AlterPartitionRequest{}
+
+	if err := skipFlexibleHeader(b, &offset, "alter partition"); err != nil {
+		return nil, err
+	}
+
+	if err := need(b, offset, 12, "alter partition: broker"); err != nil {
+		return nil, err
+	}
+	r.BrokerID = readInt32(b, &offset)
+	r.BrokerEpoch = readInt64(b, &offset)
+
+	topics, err := readCompactArrayLen(b, &offset)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < topics; i++ {
+		var t request.AlterPartitionTopic
+		if version >= 2 {
+			if err := need(b, offset, 16, "alter partition: topic id"); err != nil {
+				return nil, err
+			}
+			copy(t.TopicID[:], b[offset:offset+16])
+			offset += 16
+		} else if t.TopicName, err = readCompactString(b, &offset); err != nil {
+			return nil, err
+		}
+
+		partitions, err := readCompactArrayLen(b, &offset)
+		if err != nil {
+			return nil, err
+		}
+		for j := 0; j < partitions; j++ {
+			p, err := readAlterPartitionPartition(b, &offset, version)
+			if err != nil {
+				return nil, err
+			}
+			t.Partitions = append(t.Partitions, p)
+		}
+
+		if _, err := skipTagBuffer(b, &offset); err != nil {
+			return nil, err
+		}
+		r.Topics = append(r.Topics, t)
+	}
+
+	if _, err := skipTagBuffer(b, &offset); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func readAlterPartitionPartition(b []byte, offset *int, version uint16) (request.AlterPartitionPartition, error) {
+	var p request.AlterPartitionPartition
+	if err := need(b, *offset, 8, "alter partition: partition"); err != nil {
+		return p, err
+	}
+	p.PartitionIndex = readInt32(b, offset)
+	p.LeaderEpoch = readInt32(b, offset)
+
+	if version >= 3 {
+		n, err := readCompactArrayLen(b, offset)
+		if err != nil {
+			return p, err
+		}
+		p.NewISR = make([]int32, 0, max(n, 0))
+		for i := 0; i < n; i++ {
+			if err := need(b, *offset, 12, "alter partition: broker state"); err != nil {
+				return p, err
+			}
+			p.NewISR = append(p.NewISR, readInt32(b, offset))
+			*offset += 8
+			if _, err := skipTagBuffer(b, offset); err != nil {
+				return p, err
+			}
+		}
+	} else {
+		isr, err := readCompactInt32Array(b, offset)
+		if err != nil {
+			return p, err
+		}
+		p.NewISR = isr
+	}
+
+	if version >= 1 {
+		if err := need(b, *offset, 1, "alter partition: leader recovery state"); err != nil {
+			return p, err
+		}
+		p.LeaderRecoveryState = int8(b[*offset])
+		*offset++
+	}
+
+	if err := need(b, *offset, 4, "alter partition: partition epoch"); err != nil {
+		return p, err
+	}
+	p.PartitionEpoch = readInt32(b, offset)
+
+	_, err := skipTagBuffer(b, offset)
+	return p, err
+}
+
+func parseElectLeadersRequest(b []byte, version uint16) (*request.ElectLeadersRequest, error) {
+	offset := 0
+	flexible := version >= 2
+	r := &request.ElectLeadersRequest{}
+
+	var err error
+	if flexible {
+		err = skipFlexibleHeader(b, &offset, "elect leaders")
+	} else {
+		err = skipHeaderClientID(b, &offset, "elect leaders")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if version >= 1 {
+		if err := need(b, offset, 1, "elect leaders: election type"); err != nil {
+			return nil, err
+		}
+		r.ElectionType = int8(b[offset])
+		offset++
+	}
+
+	topics, err := readArrayLen(b, &offset, flexible)
+	if err != nil {
+		return nil, err
+	}
+	if topics >= 0 {
+		r.TopicPartitions = make([]request.ElectLeadersTopic, 0, topics)
+	}
+	for i := 0; i < topics; i++ {
+		t, err := readElectLeadersTopic(b, &offset, flexible)
+		if err != nil {
+			return nil, err
+		}
+		r.TopicPartitions = append(r.TopicPartitions, t)
+	}
+
+	if err := need(b, offset, 4, "elect leaders: timeout"); err != nil {
+		return nil, err
+	}
+	r.TimeoutMs = readInt32(b, &offset)
+
+	if flexible {
+		if _, err := skipTagBuffer(b, &offset); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func readElectLeadersTopic(b []byte, offset *int, flexible bool) (request.ElectLeadersTopic, error) {
+	var t request.ElectLeadersTopic
+	name, err := readString(b, offset, flexible)
+	if err != nil {
+		return t, err
+	}
+	t.Topic = name
+
+	partitions, err := readArrayLen(b, offset, flexible)
+	if err != nil {
+		return t, err
+	}
+	if err := need(b, *offset, 4*max(partitions, 0), "elect leaders: partitions"); err != nil {
+		return t, err
+	}
+	for i := 0; i < partitions; i++ {
+		t.Partitions = append(t.Partitions, readInt32(b, offset))
+	}
+
+	if flexible {
+		if _, err := skipTagBuffer(b, offset); err != nil {
+			return t, err
+		}
+	}
+	return t, nil
+}
+
+func parseOffsetForLeaderEpochRequest(b []byte, version uint16) (*request.OffsetForLeaderEpochRequest, error) {
+	offset := 0
+	flexible := version >= 4
+	r := &request.OffsetForLeaderEpochRequest{ReplicaID: domain.ReplicaIDConsumer}
+
+	var err error
+	if flexible {
+		err = skipFlexibleHeader(b, &offset, "offset for leader epoch")
+	} else {
+		err = skipHeaderClientID(b, &offset, "offset for leader epoch")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if version >= 3 {
+		if err := need(b, offset, 4, "offset for leader epoch: replica id"); err != nil {
+			return nil, err
+		}
+		r.ReplicaID = readInt32(b, &offset)
+	}
+
+	topics, err := readArrayLen(b, &offset, flexible)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < topics; i++ {
+		var t request.OffsetForLeaderTopic
+		if t.Topic, err = readString(b, &offset, flexible); err != nil {
+			return nil, err
+		}
+
+		partitions, err := readArrayLen(b, &offset, flexible)
+		if err != nil {
+			return nil, err
+		}
+		for j := 0; j < partitions; j++ {
+			p, err := readOffsetForLeaderPartition(b, &offset, version)
+			if err != nil {
+				return nil, err
+			}
+			t.Partitions = append(t.Partitions, p)
+		}
+
+		if flexible {
+			if _, err := skipTagBuffer(b, &offset); err != nil {
+				return nil, err
+			}
+		}
+		r.Topics = append(r.Topics, t)
+	}
+
+	if flexible {
+		if _, err := skipTagBuffer(b, &offset); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func readOffsetForLeaderPartition(b []byte, offset *int, version uint16) (request.OffsetForLeaderPartition, error) {
+	p := request.OffsetForLeaderPartition{CurrentLeaderEpoch: -1}
+
+	if err := need(b, *offset, 4, "offset for leader epoch: partition"); err != nil {
+		return p, err
+	}
+	p.Partition = readInt32(b, offset)
+
+	if version >= 2 {
+		if err := need(b, *offset, 4, "offset for leader epoch: current leader epoch"); err != nil {
+			return p, err
+		}
+		p.CurrentLeaderEpoch = readInt32(b, offset)
+	}
+
+	if err := need(b, *offset, 4, "offset for leader epoch: leader epoch"); err != nil {
+		return p, err
+	}
+	p.LeaderEpoch = readInt32(b, offset)
+
+	if version >= 4 {
+		if _, err := skipTagBuffer(b, offset); err != nil {
+			return p, err
+		}
+	}
+	return p, nil
+}
+
+func parseAlterPartitionReassignmentsRequest(b []byte) (*request.AlterPartitionReassignmentsRequest, error) {
+	offset := 0
+	r := &request.AlterPartitionReassignmentsRequest{}
+
+	if err := skipFlexibleHeader(b, &offset, "alter partition reassignments"); err != nil {
+		return nil, err
+	}
+	if err := need(b, offset, 4, "alter partition reassignments: timeout"); err != nil {
+		return nil, err
+	}
+	r.TimeoutMs = readInt32(b, &offset)
+
+	topics, err := readCompactArrayLen(b, &offset)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < topics; i++ {
+		var t request.ReassignableTopic
+		if t.Name, err = readCompactString(b, &offset); err != nil {
+			return nil, err
+		}
+
+		partitions, err := readCompactArrayLen(b, &offset)
+		if err != nil {
+			return nil, err
+		}
+		for j := 0; j < partitions; j++ {
+			var part request.ReassignablePartition
+			if err := need(b, offset, 4, "alter partition reassignments: partition"); err != nil {
+				return nil, err
+			}
+			part.PartitionIndex = readInt32(b, &offset)
+			if part.Replicas, err = readCompactInt32Array(b, &offset); err != nil {
+				return nil, err
+			}
+			if _, err := skipTagBuffer(b, &offset); err != nil {
+				return nil, err
+			}
+			t.Partitions = append(t.Partitions, part)
+		}
+
+		if _, err := skipTagBuffer(b, &offset); err != nil {
+			return nil, err
+		}
+		r.Topics = append(r.Topics, t)
+	}
+
+	if _, err := skipTagBuffer(b, &offset); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func parseListPartitionReassignmentsRequest(b []byte) (*request.ListPartitionReassignmentsRequest, error) {
+	offset := 0
+	r := &request.ListPartitionReassignmentsRequest{}
+
+	if err := skipFlexibleHeader(b, &offset, "list partition reassignments"); err != nil {
+		return nil, err
+	}
+	if err := need(b, offset, 4, "list partition reassignments: timeout"); err != nil {
+		return nil, err
+	}
+	r.TimeoutMs = readInt32(b, &offset)
+
+	topics, err := readCompactArrayLen(b, &offset)
+	if err != nil {
+		return nil, err
+	}
+	if topics >= 0 {
+		r.Topics = make([]request.ListPartitionReassignmentsTopic, 0, topics)
+	}
+	for i := 0; i < topics; i++ {
+		var t request.ListPartitionReassignmentsTopic
+		if t.Name, err = readCompactString(b, &offset); err != nil {
+			return nil, err
+		}
+		if t.PartitionIndexes, err = readCompactInt32Array(b, &offset); err != nil {
+			return nil, err
+		}
+		if _, err := skipTagBuffer(b, &offset); err != nil {
+			return nil, err
+		}
+		r.Topics = append(r.Topics, t)
+	}
+
+	if _, err := skipTagBuffer(b, &offset); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func readString(b []byte, offset *int, flexible bool) (string, error) {
+	if flexible {
+		return readCompactString(b, offset)
+	}
+
+	s, err := readNullableString(b, offset)
+	if err != nil || s == nil {
+		return "", err
+	}
+	return *s, nil
+}
@@ -0,0 +1,344 @@
+package codec
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/request"
+)
+
+func parseVoteRequest(b []byte) (*request.VoteRequest, error) {
+	offset := 0
+	r := &request.VoteRequest{}
+
+	if err := skipFlexibleHeader(b, &offset, "vote"); err != nil {
+		return nil, err
+	}
+
+	clusterID, err := readCompactNullableString(b, &offset)
+	if err != nil {
+		return nil, err
+	}
+	r.ClusterID = clusterID
+
+	err = readSingleQuorumPartition(b, &offset, true, &r.QuorumPartition, func() error {
+		if err := need(b, offset, 20, "vote: partition"); err != nil {
+			return err
+		}
+		r.Vote = domain.QuorumVote{
+			CandidateEpoch:  readInt32(b, &offset),
+			CandidateID:     readInt32(b, &offset),
+			LastOffsetEpoch: readInt32(b, &offset),
+			LastOffset:      readInt64(b, &offset),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := skipTagBuffer(b, &offset); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func parseBeginQuorumEpochRequest(b []byte) (*request.BeginQuorumEpochRequest, error) {
+	offset := 0
+	r := &request.BeginQuorumEpochRequest{}
+
+	if err := skipHeaderClientID(b, &offset, "begin quorum epoch"); err != nil {
+		return nil, err
+	}
+
+	clusterID, err := readNullableString(b, &offset)
+	if err != nil {
+		return nil, err
+	}
+	r.ClusterID = clusterID
+
+	err = readSingleQuorumPartition(b, &offset, false, &r.QuorumPartition, func() error {
+		if err := need(b, offset, 8, "begin quorum epoch: partition"); err != nil {
+			return err
+		}
+		r.LeaderID = readInt32(b, &offset)
+		r.LeaderEpoch = readInt32(b, &offset)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func parseEndQuorumEpochRequest(b []byte) (*request.EndQuorumEpochRequest, error) {
+	offset := 0
+	r := &request.EndQuorumEpochRequest{}
+
+	if err := skipHeaderClientID(b, &offset, "end quorum epoch"); err != nil {
+		return nil, err
+	}
+
+	clusterID, err := readNullableString(b, &offset)
+	if err != nil {
+		return nil, err
+	}
+	r.ClusterID = clusterID
+
+	err = readSingleQuorumPartition(b, &offset, false, &r.QuorumPartition, func() error {
+		if err := need(b, offset, 12, "end quorum epoch: partition"); err != nil {
+			return err
+		}
+		r.LeaderID = readInt32(b, &offset)
+		r.LeaderEpoch = readInt32(b, &offset)
+
+		n := int(readInt32(b, &offset))
+		if n < 0 {
+			return nil
+		}
+		if err := need(b, offset, 4*n, "end quorum epoch: preferred successors"); err != nil {
+			return err
+		}
+		for i := 0; i < n; i++ {
+			r.PreferredSuccessors = append(r.PreferredSuccessors, readInt32(b, &offset))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func parseFetchSnapshotRequest(b []byte) (*request.FetchSnapshotRequest, error) {
+	offset := 0
+	r := &request.FetchSnapshotRequest{}
+
+	if err := skipFlexibleHeader(b, &offset, "fetch snapshot"); err != nil {
+		return nil, err
+	}
+
+	if err := need(b, offset, 8, "fetch snapshot: replica"); err != nil {
+		return nil, err
+	}
+	r.ReplicaID = readInt32(b, &offset)
+	r.MaxBytes = readInt32(b, &offset)
+
+	err := readSingleQuorumPartition(b, &offset, true, &r.QuorumPartition, func() error {
+		if err := need(b, offset, 4+12, "fetch snapshot: partition"); err != nil {
+			return err
+		}
+		r.CurrentLeaderEpoch = readInt32(b, &offset)
+		id, err := readSnapshotID(b, &offset)
+		if err != nil {
+			return err
+		}
+		r.SnapshotID = id
+
+		if err := need(b, offset, 8, "fetch snapshot: position"); err != nil {
+			return err
+		}
+		r.Position = readInt64(b, &offset)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = readTagBuffer(b, &offset, func(tag uint64, data []byte) error {
+		if tag != 0 {
+			return nil
+		}
+		pos := 0
+		clusterID, err := readCompactNullableString(data, &pos)
+		r.ClusterID = clusterID
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func parseDescribeQuorumRequest(b []byte) (*request.DescribeQuorumRequest, error) {
+	offset := 0
+	r := &request.DescribeQuorumRequest{}
+
+	if err := skipFlexibleHeader(b, &offset, "describe quorum"); err != nil {
+		return nil, err
+	}
+
+	err := readSingleQuorumPartition(b, &offset, true, &r.QuorumPartition, func() error {
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := skipTagBuffer(b, &offset); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func readSingleQuorumPartition(
+	b []byte,
+	offset *int,
+	flexible bool,
+	p *request.QuorumPartition,
+	readFields func() error,
+) error {
+
+	topics, err := readArrayLen(b, offset, flexible)
+	if err != nil {
+		return err
+	}
+	if topics != 1 {
+		return errors.New("quorum request: expected a single topic")
+	}
+
+	if flexible {
+		p.TopicName, err = readCompactString(b, offset)
+	} else {
+		var name *string
+		name, err = readNullableString(b, offset)
+		if name != nil {
+			p.TopicName = *name
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	partitions, err := readArrayLen(b, offset, flexible)
+	if err != nil {
+		return err
+	}
+	if partitions != 1 {
+		return errors.New("quorum request: expected a single partition")
+	}
+
+	if err := need(b, *offset, 4, "quorum request: partition index"); err != nil {
+		return err
+	}
+	p.PartitionIndex = readInt32(b, offset)
+
+	if err := readFields(); err != nil {
+		return err
+	}
+
+	if !flexible {
+		return nil
+	}
+	if _, err := skipTagBuffer(b, offset); err != nil {
+		return err
+	}
+	_, err = skipTagBuffer(b, offset)
+	return err
+}
+
+func readSnapshotID(b []byte, offset *int) (domain.SnapshotID, error) {
+	if err := need(b, *offset, 12, "snapshot id"); err != nil {
+		return domain.SnapshotID{}, err
+	}
+	id := domain.SnapshotID{
+		EndOffset: readInt64(b, offset),
+		Epoch:     readInt32(b, offset),
+	}
+	_, err := skipTagBuffer(b, offset)
+	return id, err
+}
+
+func skipHeaderClientID(b []byte, offset *int, ctx string) error {
+	if err := need(b, *offset, 2, ctx+": client_id length"); err != nil {
+		return err
+	}
+	clientLen := int(int16(binary.BigEndian.Uint16(b[*offset:])))
+	*offset += 2
+
+	if clientLen > 0 {
+		if err := need(b, *offset, clientLen, ctx+": client_id bytes"); err != nil {
+			return err
+		}
+		*offset += clientLen
+	}
+	return nil
+}
+
+func readArrayLen(b []byte, offset *int, flexible bool) (int, error) {
+	if flexible {
+		return readCompactArrayLen(b, offset)
+	}
+	if err := need(b, *offset, 4, "array length"); err != nil {
+		return 0, err
+	}
+	n := int(readInt32(b, offset))
+	if n > len(b) {
+		return 0, errors.New("array: invalid length")
+	}
+	return n, nil
+}
+
+func readNullableString(b []byte, offset *int) (*string, error) {
+	if err := need(b, *offset, 2, "string length"); err != nil {
+		return nil, err
+	}
+	ln := int(readInt16(b, offset))
+	if ln < 0 {
+		return nil, nil
+	}
+	if err := need(b, *offset, ln, "string"); err != nil {
+		return nil, err
+	}
+
+	s := string(b[*offset : *offset+ln])
+	*offset += ln
+	return &s, nil
+}
+
+func readTagBuffer(b []byte, offset *int, field func(tag uint64, data []byte) error) error {
+	count, err := readUvarintPayload(b, offset)
+	if err != nil {
+		return errors.New("tag buffer: truncated")
+	}
+
+	for i := uint64(0); i < count; i++ {
+		tag, err := readUvarintPayload(b, offset)
+		if err != nil {
+			return errors.New("tag id truncated")
+		}
+		size, err := readUvarintPayload(b, offset)
+		if err != nil {
+			return errors.New("tag size truncated")
+		}
+		if err := need(b, *offset, int(size), "tag value"); err != nil {
+			return err
+		}
+
+		data := b[*offset : *offset+int(size)]
+		*offset += int(size)
+		if err := field(tag, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readInt16(b []byte, offset *int) int16 {
+	v := int16(binary.BigEndian.Uint16(b[*offset:]))
+	*offset += 2
+	return v
+}
+
+func readInt32(b []byte, offset *int) int32 {
+	v := int32(binary.BigEndian.Uint32(b[*offset:]))
+	*offset += 4
+	return v
+}
+
+func readInt64(b []byte, offset *int) int64 {
+	v := int64(binary.BigEndian.Uint64(b[*offset:]))
+	*offset += 8
+	return v
+}
@@ -0,0 +1,126 @@
+package codec
+
+import (
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/request"
+)
+
+func parseCreateTopicsRequest(b []byte, version uint16) (*request.CreateTopicsRequest, error) {
+	offset := 0
+	flexible := version >= 5
+	r := &request.CreateTopicsRequest{}
+
+	var err error
+	if flexible {
+		err = skipFlexibleHeader(b, &offset, "create topics")
+	} else {
+		err = skipHeaderClientID(b, &offset, "create topics")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	topics, err := readArrayLen(b, &offset, flexible)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < topics; i++ {
+		t, err := readCreatableTopic(b, &offset, flexible)
+		if err != nil {
+			return nil, err
+		}
+		r.Topics = append(r.Topics, t)
+	}
+
+	if err := need(b, offset, 4+1, "create topics: request fields"); err != nil {
+		return nil, err
+	}
+	r.TimeoutMs = readInt32(b, &offset)
+	r.ValidateOnly = b[offset] != 0
+	offset++
+
+	if flexible {
+		if _, err := skipTagBuffer(b, &offset); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func readCreatableTopic(b []byte, offset *int, flexible bool) (request.CreatableTopic, error) {
+	var (
+		t   request.CreatableTopic
+		err error
+	)
+	if t.Name, err = readString(b, offset, flexible); err != nil {
+		return t, err
+	}
+	if err := need(b, *offset, 4+2, "create topics: topic fields"); err != nil {
+		return t, err
+	}
+	t.NumPartitions = readInt32(b, offset)
+	t.ReplicationFactor = readInt16(b, offset)
+
+	assignments, err := readArrayLen(b, offset, flexible)
+	if err != nil {
+		return t, err
+	}
+	for i := 0; i < assignments; i++ {
+		var a request.CreatableReplicaAssignment
+		if err := need(b, *offset, 4, "create topics: partition index"); err != nil {
+			return t, err
+		}
+		a.PartitionIndex = readInt32(b, offset)
+
+		brokers, err := readArrayLen(b, offset, flexible)
+		if err != nil {
+			return t, err
+		}
+		if err := need(b, *offset, 4*max(brokers, 0), "create topics: broker ids"); err != nil {
+			return t, err
+		}
+		a.BrokerIDs = make([]int32, 0, max(brokers, 0))
+		for j := 0; j < brokers; j++ {
+			a.BrokerIDs = append(a.BrokerIDs, readInt32(b, offset))
+		}
+
+		if flexible {
+			if _, err := skipTagBuffer(b, offset); err != nil {
+				return t, err
+			}
+		}
+		t.Assignments = append(t.Assignments, a)
+	}
+
+	configs, err := readArrayLen(b, offset, flexible)
+	if err != nil {
+		return t, err
+	}
+	for i := 0; i < configs; i++ {
+		var c request.CreatableTopicConfig
+		if c.Name, err = readString(b, offset, flexible); err != nil {
+			return t, err
+		}
+		if flexible {
+			c.Value, err = readCompactNullableString(b, offset)
+		} else {
+			c.Value, err = readNullableString(b, offset)
+		}
+		if err != nil {
+			return t, err
+		}
+
+		if flexible {
+			if _, err := skipTagBuffer(b, offset); err != nil {
+				return t, err
+			}
+		}
+		t.Configs = append(t.Configs, c)
+	}
+
+	if flexible {
+		if _, err := skipTagBuffer(b, offset); err != nil {
+			return t, err
+		}
+	}
+	return t, nil
+}
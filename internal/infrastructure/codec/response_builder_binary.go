@@ -4,6 +4,7 @@ import (
 	"encoding/binary"
 	"errors"
 
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
 	"github.com/codecrafters-io/kafka-starter-go/internal/domain/response"
 	"github.com/codecrafters-io/kafka-starter-go/internal/ports"
 )
@@ -32,11 +33,77 @@ func (b *BinaryResponseBuilder) Build(resp *response.MessageResponse) ([]byte, e
 	case *response.ProduceResponseBody:
 		return b.buildProduce(resp.CorrelationID, body)
 
+	case *response.DescribeLogDirsResponseBody:
+		return b.buildDescribeLogDirs(resp.CorrelationID, body)
+
+	case *response.AlterReplicaLogDirsResponseBody:
+		return b.buildAlterReplicaLogDirs(resp.CorrelationID, body)
+
+	case *response.DescribeClusterResponseBody:
+		return b.buildDescribeCluster(resp.CorrelationID, body)
+
+	case *response.UpdateFeaturesResponseBody:
+		return b.buildUpdateFeatures(resp.CorrelationID, body)
+
+	case *response.VoteResponseBody:
+		return b.buildVote(resp.CorrelationID, body)
+
+	case *response.BeginQuorumEpochResponseBody:
+		return b.buildQuorumEpoch(resp.CorrelationID, body.ErrorCode, body.Partition)
+
+	case *response.EndQuorumEpochResponseBody:
+		return b.buildQuorumEpoch(resp.CorrelationID, body.ErrorCode, body.Partition)
+
+	case *response.FetchSnapshotResponseBody:
+		return b.buildFetchSnapshot(resp.CorrelationID, body)
+
+	case *response.DescribeQuorumResponseBody:
+		return b.buildDescribeQuorum(resp.CorrelationID, body)
+
+	case *response.AlterPartitionResponseBody:
+		return b.buildAlterPartition(resp.CorrelationID, body)
+
+	case *response.ElectLeadersResponseBody:
+		return b.buildElectLeaders(resp.CorrelationID, body)
+
+	case *response.OffsetForLeaderEpochResponseBody:
+		return b.buildOffsetForLeaderEpoch(resp.CorrelationID, body)
+
+	case *response.AlterPartitionReassignmentsResponseBody:
+		return b.buildAlterPartitionReassignments(resp.CorrelationID, body)
+
+	case *response.ListPartitionReassignmentsResponseBody:
+		return b.buildListPartitionReassignments(resp.CorrelationID, body)
+
+	case *response.CreateTopicsResponseBody:
+		return b.buildCreateTopics(resp.CorrelationID, body)
+
+	case *response.DescribeConfigsResponseBody:
+		return b.buildDescribeConfigs(resp.CorrelationID, body)
+
+	case *response.AlterConfigsResponseBody:
+		return b.buildAlterConfigs(resp.CorrelationID, body.Version >= 2, body.ThrottleTimeMs, body.Responses)
+
+	case *response.IncrementalAlterConfigsResponseBody:
+		return b.buildAlterConfigs(resp.CorrelationID, body.Version >= 1, body.ThrottleTimeMs, body.Responses)
+
 	default:
 		return nil, errors.New("response: unsupported body type")
 	}
 }
 
+func (b *BinaryResponseBuilder) BuildFrame(resp *response.MessageResponse) (*response.Frame, error) {
+	if body, ok := resp.Body.(*response.FetchResponseBody); ok {
+		return b.buildFetchFrame(resp.CorrelationID, body), nil
+	}
+
+	msg, err := b.Build(resp)
+	if err != nil {
+		return nil, err
+	}
+	return &response.Frame{Parts: []response.FramePart{{Bytes: msg}}}, nil
+}
+
 func (b *BinaryResponseBuilder) buildApiVersions(
 	correlationID uint32,
 	body *response.ApiVersionsResponseBody,
@@ -58,12 +125,48 @@ func (b *BinaryResponseBuilder) buildApiVersions(
 	}
 
 	out = appendUint32(out, body.ThrottleTime)
-	out = append(out, 0)
+	if body.Version >= 3 {
+		out = appendApiVersionsTags(out, body)
+	} else {
+		out = append(out, 0)
+	}
 
 	payload := append(header, out...)
 	return wrapWithSize(payload), nil
 }
 
+func appendApiVersionsTags(out []byte, body *response.ApiVersionsResponseBody) []byte {
+	fields := make([]taggedField, 0, 3)
+
+	if len(body.SupportedFeatures) > 0 {
+		data := appendUvarint(nil, uint64(len(body.SupportedFeatures)+1))
+		for _, f := range body.SupportedFeatures {
+			data = appendCompactString(data, f.Name)
+			data = appendInt16(data, f.MinVersion)
+			data = appendInt16(data, f.MaxVersion)
+			data = appendUvarint(data, 0)
+		}
+		fields = append(fields, taggedField{tag: 0, data: data})
+	}
+
+	if body.FinalizedFeaturesEpoch != domain.FinalizedFeaturesEpochUnknown {
+		fields = append(fields, taggedField{tag: 1, data: appendInt64(nil, body.FinalizedFeaturesEpoch)})
+	}
+
+	if len(body.FinalizedFeatures) > 0 {
+		data := appendUvarint(nil, uint64(len(body.FinalizedFeatures)+1))
+		for _, f := range body.FinalizedFeatures {
+			data = appendCompactString(data, f.Name)
+			data = appendInt16(data, f.MaxVersionLevel)
+			data = appendInt16(data, f.MinVersionLevel)
+			data = appendUvarint(data, 0)
+		}
+		fields = append(fields, taggedField{tag: 2, data: data})
+	}
+
+	return appendTaggedFields(out, fields...)
+}
+
 func (b *BinaryResponseBuilder) buildDescribeTopicPartitions(
 	correlationID uint32,
 	body *response.DescribeTopicPartitionsResponseBody,
@@ -131,50 +234,84 @@ func (b *BinaryResponseBuilder) buildFetch(
 	body *response.FetchResponseBody,
 ) ([]byte, error) {
 
-	header := make([]byte, 0)
-	header = appendUint32(header, correlationID)
-	header = append(header, 0)
+	frame := b.buildFetchFrame(correlationID, body)
+	return flattenFrame(frame)
+}
 
-	out := make([]byte, 0)
+func (b *BinaryResponseBuilder) buildFetchFrame(
+	correlationID uint32,
+	body *response.FetchResponseBody,
+) *response.Frame {
 
-	out = appendInt32(out, body.ThrottleTimeMs)
-	out = appendInt16(out, body.ErrorCode)
-	out = appendInt32(out, body.SessionID)
+	w := &frameWriter{}
 
-	out = appendUvarint(out, uint64(len(body.Responses)+1))
+	w.buf = appendUint32(w.buf, correlationID)
+	w.buf = append(w.buf, 0)
 
-	for _, r := range body.Responses {
-		out = append(out, r.TopicID[:]...)
+	w.buf = appendInt32(w.buf, body.ThrottleTimeMs)
+	w.buf = appendInt16(w.buf, body.ErrorCode)
+	w.buf = appendInt32(w.buf, body.SessionID)
 
-		out = appendUvarint(out, uint64(len(r.Partitions)+1))
+	w.buf = appendUvarint(w.buf, uint64(len(body.Responses)+1))
 
-		for _, p := range r.Partitions {
-			out = appendInt32(out, p.PartitionIndex)
-			out = appendInt16(out, p.ErrorCode)
-			out = appendInt64(out, p.HighWatermark)
-			out = appendInt64(out, p.LastStableOffset)
-			out = appendInt64(out, p.LogStartOffset)
+	for _, r := range body.Responses {
+		w.buf = append(w.buf, r.TopicID[:]...)
 
-			out = appendUvarint(out, 1)
-			out = appendInt32(out, -1)
+		w.buf = appendUvarint(w.buf, uint64(len(r.Partitions)+1))
 
-			if len(p.Records) == 0 {
-				out = appendUvarint(out, 1)
+		for _, p := range r.Partitions {
+			w.buf = appendInt32(w.buf, p.PartitionIndex)
+			w.buf = appendInt16(w.buf, p.ErrorCode)
+			w.buf = appendInt64(w.buf, p.HighWatermark)
+			w.buf = appendInt64(w.buf, p.LastStableOffset)
+			w.buf = appendInt64(w.buf, p.LogStartOffset)
+
+			w.buf = appendUvarint(w.buf, 1)
+			if p.PreferredReadReplica != nil {
+				w.buf = appendInt32(w.buf, *p.PreferredReadReplica)
 			} else {
-				out = appendUvarint(out, uint64(len(p.Records)+1))
-				out = append(out, p.Records...)
+				w.buf = appendInt32(w.buf, -1)
 			}
 
-			out = append(out, 0)
+			switch {
+			case p.RecordsRegion != nil && p.RecordsRegion.Length > 0:
+				w.buf = appendUvarint(w.buf, uint64(p.RecordsRegion.Length+1))
+				w.region(p.RecordsRegion)
+			case len(p.Records) > 0:
+				w.buf = appendUvarint(w.buf, uint64(len(p.Records)+1))
+				w.buf = append(w.buf, p.Records...)
+			default:
+				p.RecordsRegion.Close()
+				w.buf = appendUvarint(w.buf, 1)
+			}
+
+			w.buf = appendFetchPartitionTags(w.buf, p)
 		}
 
-		out = append(out, 0)
+		w.buf = append(w.buf, 0)
 	}
 
-	out = append(out, 0)
+	w.buf = append(w.buf, 0)
 
-	payload := append(header, out...)
-	return wrapWithSize(payload), nil
+	return w.finish()
+}
+
+func appendFetchPartitionTags(out []byte, p response.FetchPartitionResponse) []byte {
+	fields := make([]taggedField, 0, 3)
+
+	if p.DivergingEpoch != nil {
+		data := appendInt32(nil, p.DivergingEpoch.Epoch)
+		data = appendInt64(data, p.DivergingEpoch.EndOffset)
+		fields = append(fields, taggedField{tag: 0, data: appendUvarint(data, 0)})
+	}
+	if p.CurrentLeader != nil {
+		fields = append(fields, taggedField{tag: 1, data: appendLeaderAndEpoch(nil, *p.CurrentLeader)})
+	}
+	if p.SnapshotID != nil {
+		fields = append(fields, taggedField{tag: 2, data: appendSnapshotID(nil, *p.SnapshotID)})
+	}
+
+	return appendTaggedFields(out, fields...)
 }
 
 func (b *BinaryResponseBuilder) buildProduce(
@@ -218,6 +355,55 @@ func (b *BinaryResponseBuilder) buildProduce(
 	return wrapWithSize(payload), nil
 }
 
+type frameWriter struct {
+	parts []response.FramePart
+	buf   []byte
+}
+
+func (w *frameWriter) flush() {
+	if len(w.buf) > 0 {
+		w.parts = append(w.parts, response.FramePart{Bytes: w.buf})
+		w.buf = nil
+	}
+}
+
+func (w *frameWriter) region(r *domain.FileRegion) {
+	w.flush()
+	w.parts = append(w.parts, response.FramePart{Region: r})
+}
+
+func (w *frameWriter) finish() *response.Frame {
+	w.flush()
+
+	frame := &response.Frame{Parts: w.parts}
+
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(frame.Size()))
+	frame.Parts = append([]response.FramePart{{Bytes: size[:]}}, frame.Parts...)
+
+	return frame
+}
+
+func flattenFrame(frame *response.Frame) ([]byte, error) {
+	defer frame.Close()
+
+	out := make([]byte, 0, frame.Size())
+	for _, p := range frame.Parts {
+		if p.Region == nil {
+			out = append(out, p.Bytes...)
+			continue
+		}
+
+		chunk := make([]byte, p.Region.Length)
+		if _, err := p.Region.File.ReadAt(chunk, p.Region.Offset); err != nil {
+			return nil, err
+		}
+		out = append(out, chunk...)
+	}
+
+	return out, nil
+}
+
 func wrapWithSize(payload []byte) []byte {
 	var size [4]byte
 	binary.BigEndian.PutUint32(size[:], uint32(len(payload)))
@@ -248,6 +434,33 @@ func appendUvarint(buf []byte, v uint64) []byte {
 	return append(buf, tmp[:n]...)
 }
 
+func appendCompactString(buf []byte, s string) []byte {
+	buf = appendUvarint(buf, uint64(len(s)+1))
+	return append(buf, s...)
+}
+
+func appendCompactNullableString(buf []byte, s *string) []byte {
+	if s == nil {
+		return appendUvarint(buf, 0)
+	}
+	return appendCompactString(buf, *s)
+}
+
+func appendCompactInt32Array(buf []byte, vs []int32) []byte {
+	buf = appendUvarint(buf, uint64(len(vs)+1))
+	for _, v := range vs {
+		buf = appendInt32(buf, v)
+	}
+	return buf
+}
+
+func appendBool(buf []byte, v bool) []byte {
+	if v {
+		return append(buf, 1)
+	}
+	return append(buf, 0)
+}
+
 func appendInt16(buf []byte, v int16) []byte {
 	return appendUint16(buf, uint16(v))
 }
@@ -0,0 +1,38 @@
+package codec
+
+import (
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/response"
+)
+
+func (b *BinaryResponseBuilder) buildDescribeCluster(
+	correlationID uint32,
+	body *response.DescribeClusterResponseBody,
+) ([]byte, error) {
+
+	out := make([]byte, 0)
+	out = appendUint32(out, correlationID)
+	out = appendUvarint(out, 0)
+
+	out = appendInt32(out, body.ThrottleTimeMs)
+	out = appendInt16(out, body.ErrorCode)
+	out = appendCompactNullableString(out, body.ErrorMessage)
+	if body.Version >= 1 {
+		out = append(out, byte(body.EndpointType))
+	}
+	out = appendCompactString(out, body.ClusterID)
+	out = appendInt32(out, body.ControllerID)
+
+	out = appendUvarint(out, uint64(len(body.Brokers)+1))
+	for _, br := range body.Brokers {
+		out = appendInt32(out, br.BrokerID)
+		out = appendCompactString(out, br.Host)
+		out = appendInt32(out, br.Port)
+		out = appendCompactNullableString(out, br.Rack)
+		out = appendUvarint(out, 0)
+	}
+
+	out = appendInt32(out, body.ClusterAuthorizedOperations)
+	out = appendUvarint(out, 0)
+
+	return wrapWithSize(out), nil
+}
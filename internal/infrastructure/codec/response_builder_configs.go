@@ -0,0 +1,112 @@
+package codec
+
+import (
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/response"
+)
+
+func (b *BinaryResponseBuilder) buildDescribeConfigs(
+	correlationID uint32,
+	body *response.DescribeConfigsResponseBody,
+) ([]byte, error) {
+
+	flexible := body.Version >= 4
+
+	out := make([]byte, 0)
+	out = appendUint32(out, correlationID)
+	if flexible {
+		out = appendUvarint(out, 0)
+	}
+
+	out = appendInt32(out, body.ThrottleTimeMs)
+
+	out = appendArrayLen(out, len(body.Results), flexible)
+	for _, r := range body.Results {
+		out = appendInt16(out, r.ErrorCode)
+		out = appendConfigString(out, r.ErrorMessage, flexible)
+		out = append(out, byte(r.ResourceType))
+		out = appendConfigName(out, r.ResourceName, flexible)
+
+		out = appendArrayLen(out, len(r.Configs), flexible)
+		for _, c := range r.Configs {
+			out = appendConfigName(out, c.Name, flexible)
+			out = appendConfigString(out, c.Value, flexible)
+			out = appendBool(out, c.ReadOnly)
+			out = append(out, byte(c.ConfigSource))
+			out = appendBool(out, c.IsSensitive)
+
+			out = appendArrayLen(out, len(c.Synonyms), flexible)
+			for _, s := range c.Synonyms {
+				out = appendConfigName(out, s.Name, flexible)
+				out = appendConfigString(out, s.Value, flexible)
+				out = append(out, byte(s.Source))
+				if flexible {
+					out = appendUvarint(out, 0)
+				}
+			}
+
+			if body.Version >= 3 {
+				out = append(out, byte(c.ConfigType))
+				out = appendConfigString(out, c.Documentation, flexible)
+			}
+			if flexible {
+				out = appendUvarint(out, 0)
+			}
+		}
+		if flexible {
+			out = appendUvarint(out, 0)
+		}
+	}
+
+	if flexible {
+		out = appendUvarint(out, 0)
+	}
+
+	return wrapWithSize(out), nil
+}
+
+func (b *BinaryResponseBuilder) buildAlterConfigs(
+	correlationID uint32,
+	flexible bool,
+	throttleTimeMs int32,
+	responses []response.AlterConfigsResourceResponse,
+) ([]byte, error) {
+
+	out := make([]byte, 0)
+	out = appendUint32(out, correlationID)
+	if flexible {
+		out = appendUvarint(out, 0)
+	}
+
+	out = appendInt32(out, throttleTimeMs)
+
+	out = appendArrayLen(out, len(responses), flexible)
+	for _, r := range responses {
+		out = appendInt16(out, r.ErrorCode)
+		out = appendConfigString(out, r.ErrorMessage, flexible)
+		out = append(out, byte(r.ResourceType))
+		out = appendConfigName(out, r.ResourceName, flexible)
+		if flexible {
+			out = appendUvarint(out, 0)
+		}
+	}
+
+	if flexible {
+		out = appendUvarint(out, 0)
+	}
+
+	return wrapWithSize(out), nil
+}
+
+func appendConfigName(out []byte, s string, flexible bool) []byte {
+	if flexible {
+		return appendCompactString(out, s)
+	}
+	return appendString(out, s)
+}
+
+func appendConfigString(out []byte, s *string, flexible bool) []byte {
+	if flexible {
+		return appendCompactNullableString(out, s)
+	}
+	return appendNullableString(out, s)
+}
@@ -0,0 +1,31 @@
+package codec
+
+import (
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/response"
+)
+
+func (b *BinaryResponseBuilder) buildUpdateFeatures(
+	correlationID uint32,
+	body *response.UpdateFeaturesResponseBody,
+) ([]byte, error) {
+
+	out := make([]byte, 0)
+	out = appendUint32(out, correlationID)
+	out = appendUvarint(out, 0)
+
+	out = appendInt32(out, body.ThrottleTimeMs)
+	out = appendInt16(out, body.ErrorCode)
+	out = appendCompactNullableString(out, body.ErrorMessage)
+
+	out = appendUvarint(out, uint64(len(body.Results)+1))
+	for _, r := range body.Results {
+		out = appendCompactString(out, r.Feature)
+		out = appendInt16(out, r.ErrorCode)
+		out = appendCompactNullableString(out, r.ErrorMessage)
+		out = appendUvarint(out, 0)
+	}
+
+	out = appendUvarint(out, 0)
+
+	return wrapWithSize(out), nil
+}
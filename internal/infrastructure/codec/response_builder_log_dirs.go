@@ -0,0 +1,83 @@
+package codec
+
+import (
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/response"
+)
+
+func (b *BinaryResponseBuilder) buildDescribeLogDirs(
+	correlationID uint32,
+	body *response.DescribeLogDirsResponseBody,
+) ([]byte, error) {
+
+	out := make([]byte, 0)
+	out = appendUint32(out, correlationID)
+	out = appendUvarint(out, 0)
+
+	out = appendInt32(out, body.ThrottleTimeMs)
+	if body.Version >= 3 {
+		out = appendInt16(out, body.ErrorCode)
+	}
+
+	out = appendUvarint(out, uint64(len(body.Results)+1))
+	for _, r := range body.Results {
+		out = appendInt16(out, r.ErrorCode)
+		out = appendCompactString(out, r.LogDir)
+
+		out = appendUvarint(out, uint64(len(r.Topics)+1))
+		for _, t := range r.Topics {
+			out = appendCompactString(out, t.Name)
+
+			out = appendUvarint(out, uint64(len(t.Partitions)+1))
+			for _, p := range t.Partitions {
+				out = appendInt32(out, p.PartitionIndex)
+				out = appendInt64(out, p.PartitionSize)
+				out = appendInt64(out, p.OffsetLag)
+				out = appendBool(out, p.IsFutureKey)
+				out = appendUvarint(out, 0)
+			}
+
+			out = appendUvarint(out, 0)
+		}
+
+		if body.Version >= 4 {
+			out = appendInt64(out, r.TotalBytes)
+			out = appendInt64(out, r.UsableBytes)
+		}
+
+		out = appendUvarint(out, 0)
+	}
+
+	out = appendUvarint(out, 0)
+
+	return wrapWithSize(out), nil
+}
+
+func (b *BinaryResponseBuilder) buildAlterReplicaLogDirs(
+	correlationID uint32,
+	body *response.AlterReplicaLogDirsResponseBody,
+) ([]byte, error) {
+
+	out := make([]byte, 0)
+	out = appendUint32(out, correlationID)
+	out = appendUvarint(out, 0)
+
+	out = appendInt32(out, body.ThrottleTimeMs)
+
+	out = appendUvarint(out, uint64(len(body.Results)+1))
+	for _, t := range body.Results {
+		out = appendCompactString(out, t.TopicName)
+
+		out = appendUvarint(out, uint64(len(t.Partitions)+1))
+		for _, p := range t.Partitions {
+			out = appendInt32(out, p.PartitionIndex)
+			out = appendInt16(out, p.ErrorCode)
+			out = appendUvarint(out, 0)
+		}
+
+		out = appendUvarint(out, 0)
+	}
+
+	out = appendUvarint(out, 0)
+
+	return wrapWithSize(out), nil
+}
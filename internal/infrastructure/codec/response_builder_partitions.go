@@ -0,0 +1,212 @@
+package codec
+
+import (
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/response"
+)
+
+func (b *BinaryResponseBuilder) buildAlterPartition(
+	correlationID uint32,
+	body *response.AlterPartitionResponseBody,
+) ([]byte, error) {
+
+	out := make([]byte, 0)
+	out = appendUint32(out, correlationID)
+	out = appendUvarint(out, 0)
+
+	out = appendInt32(out, body.ThrottleTimeMs)
+	out = appendInt16(out, body.ErrorCode)
+
+	out = appendUvarint(out, uint64(len(body.Topics)+1))
+	for _, t := range body.Topics {
+		if body.Version >= 2 {
+			out = append(out, t.TopicID[:]...)
+		} else {
+			out = appendCompactString(out, t.TopicName)
+		}
+
+		out = appendUvarint(out, uint64(len(t.Partitions)+1))
+		for _, p := range t.Partitions {
+			out = appendInt32(out, p.PartitionIndex)
+			out = appendInt16(out, p.ErrorCode)
+			out = appendInt32(out, p.LeaderID)
+			out = appendInt32(out, p.LeaderEpoch)
+			out = appendCompactInt32Array(out, p.ISR)
+			if body.Version >= 1 {
+				out = append(out, byte(p.LeaderRecoveryState))
+			}
+			out = appendInt32(out, p.PartitionEpoch)
+			out = appendUvarint(out, 0)
+		}
+		out = appendUvarint(out, 0)
+	}
+
+	out = appendUvarint(out, 0)
+
+	return wrapWithSize(out), nil
+}
+
+func (b *BinaryResponseBuilder) buildElectLeaders(
+	correlationID uint32,
+	body *response.ElectLeadersResponseBody,
+) ([]byte, error) {
+
+	flexible := body.Version >= 2
+
+	out := make([]byte, 0)
+	out = appendUint32(out, correlationID)
+	if flexible {
+		out = appendUvarint(out, 0)
+	}
+
+	out = appendInt32(out, body.ThrottleTimeMs)
+	if body.Version >= 1 {
+		out = appendInt16(out, body.ErrorCode)
+	}
+
+	out = appendArrayLen(out, len(body.ReplicaElectionResults), flexible)
+	for _, t := range body.ReplicaElectionResults {
+		if flexible {
+			out = appendCompactString(out, t.Topic)
+		} else {
+			out = appendString(out, t.Topic)
+		}
+
+		out = appendArrayLen(out, len(t.PartitionResult), flexible)
+		for _, p := range t.PartitionResult {
+			out = appendInt32(out, p.PartitionID)
+			out = appendInt16(out, p.ErrorCode)
+			if flexible {
+				out = appendCompactNullableString(out, p.ErrorMessage)
+				out = appendUvarint(out, 0)
+			} else {
+				out = appendNullableString(out, p.ErrorMessage)
+			}
+		}
+		if flexible {
+			out = appendUvarint(out, 0)
+		}
+	}
+
+	if flexible {
+		out = appendUvarint(out, 0)
+	}
+
+	return wrapWithSize(out), nil
+}
+
+func (b *BinaryResponseBuilder) buildOffsetForLeaderEpoch(
+	correlationID uint32,
+	body *response.OffsetForLeaderEpochResponseBody,
+) ([]byte, error) {
+
+	flexible := body.Version >= 4
+
+	out := make([]byte, 0)
+	out = appendUint32(out, correlationID)
+	if flexible {
+		out = appendUvarint(out, 0)
+	}
+
+	if body.Version >= 2 {
+		out = appendInt32(out, body.ThrottleTimeMs)
+	}
+
+	out = appendArrayLen(out, len(body.Topics), flexible)
+	for _, t := range body.Topics {
+		if flexible {
+			out = appendCompactString(out, t.Topic)
+		} else {
+			out = appendString(out, t.Topic)
+		}
+
+		out = appendArrayLen(out, len(t.Partitions), flexible)
+		for _, p := range t.Partitions {
+			out = appendInt16(out, p.ErrorCode)
+			out = appendInt32(out, p.Partition)
+			if body.Version >= 1 {
+				out = appendInt32(out, p.LeaderEpoch)
+			}
+			out = appendInt64(out, p.EndOffset)
+			if flexible {
+				out = appendUvarint(out, 0)
+			}
+		}
+		if flexible {
+			out = appendUvarint(out, 0)
+		}
+	}
+
+	if flexible {
+		out = appendUvarint(out, 0)
+	}
+
+	return wrapWithSize(out), nil
+}
+
+func (b *BinaryResponseBuilder) buildAlterPartitionReassignments(
+	correlationID uint32,
+	body *response.AlterPartitionReassignmentsResponseBody,
+) ([]byte, error) {
+
+	out := make([]byte, 0)
+	out = appendUint32(out, correlationID)
+	out = appendUvarint(out, 0)
+
+	out = appendInt32(out, body.ThrottleTimeMs)
+	out = appendInt16(out, body.ErrorCode)
+	out = appendCompactNullableString(out, body.ErrorMessage)
+
+	out = appendUvarint(out, uint64(len(body.Responses)+1))
+	for _, t := range body.Responses {
+		out = appendCompactString(out, t.Name)
+		out = appendUvarint(out, uint64(len(t.Partitions)+1))
+		for _, p := range t.Partitions {
+			out = appendInt32(out, p.PartitionIndex)
+			out = appendInt16(out, p.ErrorCode)
+			out = appendCompactNullableString(out, p.ErrorMessage)
+			out = appendUvarint(out, 0)
+		}
+		out = appendUvarint(out, 0)
+	}
+	out = appendUvarint(out, 0)
+
+	return wrapWithSize(out), nil
+}
+
+func (b *BinaryResponseBuilder) buildListPartitionReassignments(
+	correlationID uint32,
+	body *response.ListPartitionReassignmentsResponseBody,
+) ([]byte, error) {
+
+	out := make([]byte, 0)
+	out = appendUint32(out, correlationID)
+	out = appendUvarint(out, 0)
+
+	out = appendInt32(out, body.ThrottleTimeMs)
+	out = appendInt16(out, body.ErrorCode)
+	out = appendCompactNullableString(out, body.ErrorMessage)
+
+	out = appendUvarint(out, uint64(len(body.Topics)+1))
+	for _, t := range body.Topics {
+		out = appendCompactString(out, t.Name)
+		out = appendUvarint(out, uint64(len(t.Partitions)+1))
+		for _, p := range t.Partitions {
+			out = appendInt32(out, p.PartitionIndex)
+			out = appendCompactInt32Array(out, p.Replicas)
+			out = appendCompactInt32Array(out, p.AddingReplicas)
+			out = appendCompactInt32Array(out, p.RemovingReplicas)
+			out = appendUvarint(out, 0)
+		}
+		out = appendUvarint(out, 0)
+	}
+	out = appendUvarint(out, 0)
+
+	return wrapWithSize(out), nil
+}
+
+func appendArrayLen(out []byte, n int, flexible bool) []byte {
+	if flexible {
+		return appendUvarint(out, uint64(n+1))
+	}
+	return appendInt32(out, int32(n))
+}
@@ -0,0 +1,166 @@
+package codec
+
+import (
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/response"
+)
+
+func (b *BinaryResponseBuilder) buildVote(
+	correlationID uint32,
+	body *response.VoteResponseBody,
+) ([]byte, error) {
+
+	out := make([]byte, 0)
+	out = appendUint32(out, correlationID)
+	out = appendUvarint(out, 0)
+
+	out = appendInt16(out, body.ErrorCode)
+
+	p := body.Partition
+	out = appendUvarint(out, 2)
+	out = appendCompactString(out, p.TopicName)
+	out = appendUvarint(out, 2)
+	out = appendInt32(out, p.PartitionIndex)
+	out = appendInt16(out, p.ErrorCode)
+	out = appendInt32(out, p.LeaderID)
+	out = appendInt32(out, p.LeaderEpoch)
+	out = appendBool(out, body.VoteGranted)
+	out = appendUvarint(out, 0)
+	out = appendUvarint(out, 0)
+
+	out = appendUvarint(out, 0)
+
+	return wrapWithSize(out), nil
+}
+
+func (b *BinaryResponseBuilder) buildQuorumEpoch(
+	correlationID uint32,
+	errorCode int16,
+	p response.QuorumPartitionResult,
+) ([]byte, error) {
+
+	out := make([]byte, 0)
+	out = appendUint32(out, correlationID)
+
+	out = appendInt16(out, errorCode)
+
+	out = appendInt32(out, 1)
+	out = appendString(out, p.TopicName)
+	out = appendInt32(out, 1)
+	out = appendInt32(out, p.PartitionIndex)
+	out = appendInt16(out, p.ErrorCode)
+	out = appendInt32(out, p.LeaderID)
+	out = appendInt32(out, p.LeaderEpoch)
+
+	return wrapWithSize(out), nil
+}
+
+func (b *BinaryResponseBuilder) buildFetchSnapshot(
+	correlationID uint32,
+	body *response.FetchSnapshotResponseBody,
+) ([]byte, error) {
+
+	out := make([]byte, 0)
+	out = appendUint32(out, correlationID)
+	out = appendUvarint(out, 0)
+
+	out = appendInt32(out, body.ThrottleTimeMs)
+	out = appendInt16(out, body.ErrorCode)
+
+	p := body.Partition
+	out = appendUvarint(out, 2)
+	out = appendCompactString(out, p.TopicName)
+	out = appendUvarint(out, 2)
+	out = appendInt32(out, p.PartitionIndex)
+	out = appendInt16(out, p.ErrorCode)
+	out = appendSnapshotID(out, body.SnapshotID)
+	out = appendInt64(out, body.Size)
+	out = appendInt64(out, body.Position)
+	out = appendUvarint(out, uint64(len(body.UnalignedRecords)+1))
+	out = append(out, body.UnalignedRecords...)
+	out = appendTaggedFields(out, taggedField{
+		tag:  0,
+		data: appendLeaderAndEpoch(nil, domain.LeaderAndEpoch{LeaderID: p.LeaderID, Epoch: p.LeaderEpoch}),
+	})
+	out = appendUvarint(out, 0)
+
+	out = appendUvarint(out, 0)
+
+	return wrapWithSize(out), nil
+}
+
+func (b *BinaryResponseBuilder) buildDescribeQuorum(
+	correlationID uint32,
+	body *response.DescribeQuorumResponseBody,
+) ([]byte, error) {
+
+	out := make([]byte, 0)
+	out = appendUint32(out, correlationID)
+	out = appendUvarint(out, 0)
+
+	out = appendInt16(out, body.ErrorCode)
+
+	p := body.Partition
+	out = appendUvarint(out, 2)
+	out = appendCompactString(out, p.TopicName)
+	out = appendUvarint(out, 2)
+	out = appendInt32(out, p.PartitionIndex)
+	out = appendInt16(out, p.ErrorCode)
+	out = appendInt32(out, p.LeaderID)
+	out = appendInt32(out, p.LeaderEpoch)
+	out = appendInt64(out, body.HighWatermark)
+	out = appendReplicaStates(out, body.Version, body.CurrentVoters)
+	out = appendReplicaStates(out, body.Version, body.Observers)
+	out = appendUvarint(out, 0)
+	out = appendUvarint(out, 0)
+
+	out = appendUvarint(out, 0)
+
+	return wrapWithSize(out), nil
+}
+
+func appendReplicaStates(out []byte, version uint16, replicas []domain.QuorumReplicaState) []byte {
+	out = appendUvarint(out, uint64(len(replicas)+1))
+	for _, r := range replicas {
+		out = appendInt32(out, r.ReplicaID)
+		out = appendInt64(out, r.LogEndOffset)
+		if version >= 1 {
+			out = appendInt64(out, r.LastFetchTimestamp)
+			out = appendInt64(out, r.LastCaughtUpTimestamp)
+		}
+		out = appendUvarint(out, 0)
+	}
+	return out
+}
+
+type taggedField struct {
+	tag  uint64
+	data []byte
+}
+
+func appendTaggedFields(out []byte, fields ...taggedField) []byte {
+	out = appendUvarint(out, uint64(len(fields)))
+	for _, f := range fields {
+		out = appendUvarint(out, f.tag)
+		out = appendUvarint(out, uint64(len(f.data)))
+		out = append(out, f.data...)
+	}
+	return out
+}
+
+func appendSnapshotID(out []byte, id domain.SnapshotID) []byte {
+	out = appendInt64(out, id.EndOffset)
+	out = appendInt32(out, id.Epoch)
+	return appendUvarint(out, 0)
+}
+
+func appendLeaderAndEpoch(out []byte, l domain.LeaderAndEpoch) []byte {
+	out = appendInt32(out, l.LeaderID)
+	out = appendInt32(out, l.Epoch)
+	return appendUvarint(out, 0)
+}
+
+func appendString(out []byte, s string) []byte {
+	out = appendInt16(out, int16(len(s)))
+	return append(out, s...)
+}
@@ -0,0 +1,61 @@
+package codec
+
+import (
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/response"
+)
+
+func (b *BinaryResponseBuilder) buildCreateTopics(
+	correlationID uint32,
+	body *response.CreateTopicsResponseBody,
+) ([]byte, error) {
+
+	flexible := body.Version >= 5
+
+	out := make([]byte, 0)
+	out = appendUint32(out, correlationID)
+	if flexible {
+		out = appendUvarint(out, 0)
+	}
+
+	out = appendInt32(out, body.ThrottleTimeMs)
+
+	out = appendArrayLen(out, len(body.Topics), flexible)
+	for _, t := range body.Topics {
+		if !flexible {
+			out = appendString(out, t.Name)
+			out = appendInt16(out, t.ErrorCode)
+			out = appendNullableString(out, t.ErrorMessage)
+			continue
+		}
+
+		out = appendCompactString(out, t.Name)
+		if body.Version >= 7 {
+			out = append(out, t.TopicID[:]...)
+		}
+		out = appendInt16(out, t.ErrorCode)
+		out = appendCompactNullableString(out, t.ErrorMessage)
+		out = appendInt32(out, t.NumPartitions)
+		out = appendInt16(out, t.ReplicationFactor)
+
+		if t.Configs == nil {
+			out = appendUvarint(out, 0)
+		} else {
+			out = appendUvarint(out, uint64(len(t.Configs)+1))
+		}
+		for _, c := range t.Configs {
+			out = appendCompactString(out, c.Name)
+			out = appendCompactNullableString(out, c.Value)
+			out = appendBool(out, c.ReadOnly)
+			out = append(out, byte(c.ConfigSource))
+			out = appendBool(out, c.IsSensitive)
+			out = appendUvarint(out, 0)
+		}
+		out = appendUvarint(out, 0)
+	}
+
+	if flexible {
+		out = appendUvarint(out, 0)
+	}
+
+	return wrapWithSize(out), nil
+}
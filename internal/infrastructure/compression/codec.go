@@ -0,0 +1,147 @@
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+)
+
+var xerialMagic = []byte{0x82, 'S', 'N', 'A', 'P', 'P', 'Y', 0}
+
+var ErrTooLarge = errors.New("compression: decompressed size exceeds the limit")
+
+const xerialHeaderLen = 16
+
+var zstdEncoder, _ = zstd.NewWriter(nil)
+
+func Compress(c domain.CompressionType, data []byte) ([]byte, error) {
+	switch c {
+	case domain.CompressionNone:
+		return data, nil
+
+	case domain.CompressionGzip:
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+
+	case domain.CompressionSnappy:
+		return s2.EncodeSnappy(nil, data), nil
+
+	case domain.CompressionLz4:
+		var buf bytes.Buffer
+		w := lz4.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+
+	case domain.CompressionZstd:
+		return zstdEncoder.EncodeAll(data, nil), nil
+
+	default:
+		return nil, errors.New("compression: unknown codec")
+	}
+}
+
+func Decompress(c domain.CompressionType, data []byte, limit int) ([]byte, error) {
+	switch c {
+	case domain.CompressionNone:
+		return data, nil
+
+	case domain.CompressionGzip:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return readLimited(r, limit)
+
+	case domain.CompressionSnappy:
+		return decodeSnappy(data, limit)
+
+	case domain.CompressionLz4:
+		return readLimited(lz4.NewReader(bytes.NewReader(data)), limit)
+
+	case domain.CompressionZstd:
+		r, err := zstd.NewReader(bytes.NewReader(data), zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return readLimited(r, limit)
+
+	default:
+		return nil, errors.New("compression: unknown codec")
+	}
+}
+
+func readLimited(r io.Reader, limit int) ([]byte, error) {
+	out, err := io.ReadAll(io.LimitReader(r, int64(limit)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(out) > limit {
+		return nil, ErrTooLarge
+	}
+	return out, nil
+}
+
+func decodeSnappyBlock(data []byte, limit int) ([]byte, error) {
+	n, err := s2.DecodedLen(data)
+	if err != nil {
+		return nil, err
+	}
+	if n > limit {
+		return nil, ErrTooLarge
+	}
+	return s2.Decode(nil, data)
+}
+
+func decodeSnappy(data []byte, limit int) ([]byte, error) {
+	if !bytes.HasPrefix(data, xerialMagic) {
+		return decodeSnappyBlock(data, limit)
+	}
+
+	if len(data) < xerialHeaderLen {
+		return nil, errors.New("snappy: truncated xerial header")
+	}
+	data = data[xerialHeaderLen:]
+
+	out := make([]byte, 0)
+	for len(data) > 0 {
+		if len(data) < 4 {
+			return nil, errors.New("snappy: truncated xerial chunk length")
+		}
+		size := int(binary.BigEndian.Uint32(data[:4]))
+		data = data[4:]
+
+		if size < 0 || len(data) < size {
+			return nil, errors.New("snappy: truncated xerial chunk")
+		}
+		chunk, err := decodeSnappyBlock(data[:size], limit-len(out))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, chunk...)
+		data = data[size:]
+	}
+
+	return out, nil
+}
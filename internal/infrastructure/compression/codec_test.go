@@ -0,0 +1,88 @@
+package compression
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/klauspost/compress/s2"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+)
+
+func TestCompress_RoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte("kafka-record-"), 64)
+
+	codecs := []domain.CompressionType{
+		domain.CompressionNone,
+		domain.CompressionGzip,
+		domain.CompressionSnappy,
+		domain.CompressionLz4,
+		domain.CompressionZstd,
+	}
+
+	for _, c := range codecs {
+		compressed, err := Compress(c, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out, err := Decompress(c, compressed, len(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(out, data) {
+			t.Fatalf("codec %d: round trip mismatch", c)
+		}
+	}
+}
+
+func TestDecompress_XerialSnappy(t *testing.T) {
+	chunk := s2.EncodeSnappy(nil, []byte("hello"))
+
+	buf := append([]byte{}, xerialMagic...)
+	buf = binary.BigEndian.AppendUint32(buf, 1)
+	buf = binary.BigEndian.AppendUint32(buf, 1)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(chunk)))
+	buf = append(buf, chunk...)
+
+	out, err := Decompress(domain.CompressionSnappy, buf, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(out) != "hello" {
+		t.Fatal("wrong payload")
+	}
+}
+
+func TestDecompress_UnknownCodec(t *testing.T) {
+	_, err := Decompress(domain.CompressionType(7), []byte{0x01}, 1)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestDecompress_RejectsOutputOverLimit(t *testing.T) {
+	data := make([]byte, 1<<20)
+
+	codecs := []domain.CompressionType{
+		domain.CompressionGzip,
+		domain.CompressionSnappy,
+		domain.CompressionLz4,
+		domain.CompressionZstd,
+	}
+
+	for _, c := range codecs {
+		compressed, err := Compress(c, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := Decompress(c, compressed, 1024); !errors.Is(err, ErrTooLarge) {
+			t.Fatalf("codec %d: expected ErrTooLarge, got %v", c, err)
+		}
+	}
+}
@@ -0,0 +1,495 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+)
+
+const (
+	RoleBroker     = "broker"
+	RoleController = "controller"
+
+	SelectorRackAware = "rack-aware"
+	SelectorLeader    = "leader"
+
+	DefaultLogDir = "/tmp/kraft-combined-logs"
+
+	envPrefix = "KAFKA_"
+)
+
+type Listener struct {
+	Name string
+	Host string
+	Port int32
+}
+
+func (l Listener) Address() string {
+	return net.JoinHostPort(l.Host, strconv.Itoa(int(l.Port)))
+}
+
+type ServerConfig struct {
+	NodeID                              int32
+	ProcessRoles                        []string
+	Listeners                           []Listener
+	AdvertisedListeners                 []Listener
+	ControllerListenerNames             []string
+	ControllerQuorumVoters              string
+	ControllerQuorumBootstrapServers    []string
+	LogDirs                             []string
+	MetadataLogDir                      string
+	NumPartitions                       int32
+	DefaultReplicationFactor            int16
+	ReplicaLagTimeMaxMs                 int64
+	BrokerSessionTimeoutMs              int64
+	AutoLeaderRebalanceEnable           bool
+	LeaderImbalanceCheckIntervalSeconds int64
+	LeaderReplicationThrottledRate      int64
+	FollowerReplicationThrottledRate    int64
+	BrokerRack                          string
+	ReplicaSelector                     string
+	TopicDefaults                       map[string]string
+	Warnings                            []string
+
+	logDir     string
+	listeners  string
+	advertised string
+}
+
+type definition struct {
+	name string
+	def  string
+	set  func(c *ServerConfig, value string) error
+}
+
+var definitions = []definition{
+	{name: domain.BrokerConfigNodeID, def: "-1", set: intValue(32, -1, func(c *ServerConfig, n int64) { c.NodeID = int32(n) })},
+	{name: "process.roles", def: "broker,controller", set: setRoles},
+	{name: "listeners", def: "PLAINTEXT://0.0.0.0:9092", set: func(c *ServerConfig, v string) error {
+		c.listeners = v
+		return nil
+	}},
+	{name: "advertised.listeners", set: func(c *ServerConfig, v string) error {
+		c.advertised = v
+		return nil
+	}},
+	{name: "controller.listener.names", set: func(c *ServerConfig, v string) error {
+		c.ControllerListenerNames = listValue(strings.ToUpper(v))
+		return nil
+	}},
+	{name: "controller.quorum.voters", set: func(c *ServerConfig, v string) error {
+		c.ControllerQuorumVoters = strings.TrimSpace(v)
+		return nil
+	}},
+	{name: "controller.quorum.bootstrap.servers", set: func(c *ServerConfig, v string) error {
+		c.ControllerQuorumBootstrapServers = listValue(v)
+		return nil
+	}},
+	{name: "log.dir", def: DefaultLogDir, set: func(c *ServerConfig, v string) error {
+		c.logDir = strings.TrimSpace(v)
+		return nil
+	}},
+	{name: domain.BrokerConfigLogDirs, set: func(c *ServerConfig, v string) error {
+		c.LogDirs = listValue(v)
+		return nil
+	}},
+	{name: "metadata.log.dir", set: func(c *ServerConfig, v string) error {
+		c.MetadataLogDir = strings.TrimSpace(v)
+		return nil
+	}},
+	{name: "num.partitions", def: "1", set: intValue(32, 1, func(c *ServerConfig, n int64) { c.NumPartitions = int32(n) })},
+	{name: "default.replication.factor", def: "1", set: intValue(16, 1, func(c *ServerConfig, n int64) { c.DefaultReplicationFactor = int16(n) })},
+	{name: domain.BrokerConfigReplicaLagTimeMaxMs, def: "30000", set: intValue(64, 1, func(c *ServerConfig, n int64) { c.ReplicaLagTimeMaxMs = n })},
+	{name: "broker.session.timeout.ms", def: "9000", set: intValue(64, 1, func(c *ServerConfig, n int64) { c.BrokerSessionTimeoutMs = n })},
+	{name: "auto.leader.rebalance.enable", def: "true", set: func(c *ServerConfig, v string) error {
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			return errors.New("expected true or false")
+		}
+		c.AutoLeaderRebalanceEnable = b
+		return nil
+	}},
+	{name: "leader.imbalance.check.interval.seconds", def: "300", set: intValue(64, 1, func(c *ServerConfig, n int64) { c.LeaderImbalanceCheckIntervalSeconds = n })},
+	{name: domain.BrokerConfigLeaderThrottledRate, def: strconv.FormatInt(math.MaxInt64, 10), set: intValue(64, 0, func(c *ServerConfig, n int64) { c.LeaderReplicationThrottledRate = n })},
+	{name: domain.BrokerConfigFollowerThrottledRate, def: strconv.FormatInt(math.MaxInt64, 10), set: intValue(64, 0, func(c *ServerConfig, n int64) { c.FollowerReplicationThrottledRate = n })},
+	{name: domain.BrokerConfigRack, set: func(c *ServerConfig, v string) error {
+		c.BrokerRack = strings.TrimSpace(v)
+		return nil
+	}},
+	{name: "replica.selector.class", def: SelectorRackAware, set: setSelector},
+}
+
+var ignored = []string{
+	"inter.broker.listener.name",
+	"listener.security.protocol.map",
+	"num.network.threads",
+	"num.io.threads",
+	"socket.send.buffer.bytes",
+	"socket.receive.buffer.bytes",
+	"socket.request.max.bytes",
+	"num.recovery.threads.per.data.dir",
+	"offsets.topic.replication.factor",
+	"transaction.state.log.replication.factor",
+	"transaction.state.log.min.isr",
+	"share.coordinator.state.topic.replication.factor",
+	"share.coordinator.state.topic.min.isr",
+	"group.initial.rebalance.delay.ms",
+}
+
+var unenforced = []string{
+	"log.flush.interval.messages",
+	"log.flush.interval.ms",
+	"log.retention.hours",
+	"log.retention.minutes",
+	"log.retention.ms",
+	"log.retention.bytes",
+	"log.segment.bytes",
+	"log.retention.check.interval.ms",
+}
+
+var topicDefaults = []string{
+	domain.BrokerConfigCompressionType,
+	domain.BrokerConfigMessageTimestampType,
+	domain.BrokerConfigTimestampBeforeMaxMs,
+	domain.BrokerConfigTimestampAfterMaxMs,
+	domain.BrokerConfigMinInsyncReplicas,
+	domain.BrokerConfigUncleanLeaderElection,
+}
+
+var launcherVariables = []string{
+	"KAFKA_HEAP_OPTS",
+	"KAFKA_OPTS",
+	"KAFKA_JVM_PERFORMANCE_OPTS",
+	"KAFKA_JMX_OPTS",
+	"KAFKA_LOG4J_OPTS",
+	"KAFKA_GC_LOG_OPTS",
+	"KAFKA_DEBUG",
+	"KAFKA_HOME",
+	"KAFKA_CLUSTER_ID",
+}
+
+func Load(path string, environ []string, overrides []string) (*ServerConfig, error) {
+	c := &ServerConfig{}
+	for _, d := range definitions {
+		if err := d.set(c, d.def); err != nil {
+			return nil, fmt.Errorf("default %s: %w", d.name, err)
+		}
+	}
+
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		props, err := readProperties(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		for _, p := range props {
+			if err := c.set(p.Key, p.Value); err != nil {
+				return nil, fmt.Errorf("%s line %d: %w", path, p.Line, err)
+			}
+		}
+	}
+
+	for _, kv := range environ {
+		name, value, _ := strings.Cut(kv, "=")
+		key, ok := envKey(name)
+		if !ok {
+			continue
+		}
+		if err := c.set(key, value); err != nil {
+			return nil, fmt.Errorf("environment variable %s: %w", name, err)
+		}
+	}
+
+	for _, o := range overrides {
+		key, value, ok := strings.Cut(o, "=")
+		if !ok {
+			return nil, fmt.Errorf("override %q is not in key=value form", o)
+		}
+		if err := c.set(strings.TrimSpace(key), value); err != nil {
+			return nil, fmt.Errorf("override %s: %w", key, err)
+		}
+	}
+
+	if err := c.resolve(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *ServerConfig) HasRole(role string) bool {
+	return slices.Contains(c.ProcessRoles, role)
+}
+
+func (c *ServerConfig) QuorumVoters(nodeID int32) string {
+	if c.ControllerQuorumVoters != "" {
+		return c.ControllerQuorumVoters
+	}
+	if len(c.ControllerQuorumBootstrapServers) == 1 {
+		return fmt.Sprintf("%d@%s", nodeID, c.ControllerQuorumBootstrapServers[0])
+	}
+
+	l := c.AdvertisedListeners[0]
+	for _, cl := range c.Listeners {
+		if slices.Contains(c.ControllerListenerNames, cl.Name) {
+			l = cl
+			break
+		}
+	}
+	return fmt.Sprintf("%d@localhost:%d", nodeID, l.Port)
+}
+
+func (c *ServerConfig) set(key, value string) error {
+	for _, d := range definitions {
+		if d.name == key {
+			if err := d.set(c, value); err != nil {
+				return fmt.Errorf("invalid value %q for %s: %w", value, key, err)
+			}
+			return nil
+		}
+	}
+	if slices.Contains(ignored, key) {
+		return nil
+	}
+	if slices.Contains(unenforced, key) {
+		c.Warnings = append(c.Warnings, fmt.Sprintf("%s is not enforced, logs are kept in a single segment and never deleted or flushed on a schedule", key))
+		return nil
+	}
+	if slices.Contains(topicDefaults, key) {
+		value = strings.TrimSpace(value)
+		d, _ := domain.BrokerConfigDefinition(key)
+		if msg := d.Validate(value); msg != "" {
+			return errors.New(msg)
+		}
+		if c.TopicDefaults == nil {
+			c.TopicDefaults = map[string]string{}
+		}
+		c.TopicDefaults[key] = value
+		return nil
+	}
+
+	if s := suggest(key); s != "" {
+		return fmt.Errorf("unknown config %q, did you mean %q?", key, s)
+	}
+	return fmt.Errorf("unknown config %q", key)
+}
+
+func (c *ServerConfig) resolve() error {
+	if c.ControllerQuorumVoters == "" && len(c.ControllerQuorumBootstrapServers) > 1 {
+		return errors.New("controller.quorum.bootstrap.servers lists several controllers, name them with their node ids in controller.quorum.voters")
+	}
+
+	if len(c.LogDirs) == 0 {
+		c.LogDirs = listValue(c.logDir)
+	}
+	if len(c.LogDirs) == 0 {
+		return errors.New("log.dirs must name at least one directory")
+	}
+	if c.MetadataLogDir == "" {
+		c.MetadataLogDir = c.LogDirs[0]
+	}
+
+	listeners, err := parseListeners(c.listeners)
+	if err != nil {
+		return fmt.Errorf("listeners: %w", err)
+	}
+	if len(listeners) == 0 {
+		return errors.New("listeners must name at least one listener")
+	}
+	ports := map[int32]string{}
+	for _, l := range listeners {
+		if other, dup := ports[l.Port]; dup {
+			return fmt.Errorf("listeners: %s and %s both use port %d", other, l.Name, l.Port)
+		}
+		ports[l.Port] = l.Name
+	}
+	c.Listeners = listeners
+
+	names := make([]string, 0, len(listeners))
+	for _, l := range listeners {
+		names = append(names, l.Name)
+	}
+	if c.HasRole(RoleController) {
+		for _, n := range c.ControllerListenerNames {
+			if !slices.Contains(names, n) {
+				return fmt.Errorf("controller.listener.names: listener %s is not in listeners", n)
+			}
+		}
+	}
+
+	if c.advertised == "" {
+		for _, l := range listeners {
+			if slices.Contains(c.ControllerListenerNames, l.Name) {
+				continue
+			}
+			if l.Host == "" || l.Host == "0.0.0.0" || l.Host == "::" {
+				l.Host = "localhost"
+			}
+			c.AdvertisedListeners = append(c.AdvertisedListeners, l)
+		}
+		if len(c.AdvertisedListeners) == 0 {
+			return errors.New("listeners must include a listener that is not in controller.listener.names")
+		}
+		return nil
+	}
+
+	advertised, err := parseListeners(c.advertised)
+	if err != nil {
+		return fmt.Errorf("advertised.listeners: %w", err)
+	}
+	for _, l := range advertised {
+		if slices.Contains(c.ControllerListenerNames, l.Name) {
+			continue
+		}
+		if l.Host == "0.0.0.0" || l.Host == "::" {
+			return fmt.Errorf("advertised.listeners: %s uses the non-routable address %s", l.Name, l.Host)
+		}
+		if l.Host == "" {
+			l.Host = "localhost"
+		}
+		c.AdvertisedListeners = append(c.AdvertisedListeners, l)
+	}
+	if len(c.AdvertisedListeners) == 0 {
+		return errors.New("advertised.listeners must include a listener that is not in controller.listener.names")
+	}
+	return nil
+}
+
+func parseListeners(s string) ([]Listener, error) {
+	var out []Listener
+	for _, entry := range listValue(s) {
+		name, addr, ok := strings.Cut(entry, "://")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("listener %q is not in NAME://host:port form", entry)
+		}
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, fmt.Errorf("listener %q: %w", entry, err)
+		}
+		n, err := strconv.ParseUint(port, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("listener %q has an invalid port", entry)
+		}
+
+		l := Listener{Name: strings.ToUpper(name), Host: host, Port: int32(n)}
+		if slices.ContainsFunc(out, func(o Listener) bool { return o.Name == l.Name }) {
+			return nil, fmt.Errorf("listener %s listed more than once", l.Name)
+		}
+		out = append(out, l)
+	}
+	return out, nil
+}
+
+func setRoles(c *ServerConfig, v string) error {
+	roles := listValue(v)
+	if len(roles) == 0 {
+		return errors.New("at least one role is required")
+	}
+	for i, r := range roles {
+		if r != RoleBroker && r != RoleController {
+			return fmt.Errorf("unknown role %q, expected broker or controller", r)
+		}
+		if slices.Contains(roles[:i], r) {
+			return fmt.Errorf("role %s listed more than once", r)
+		}
+	}
+	if !slices.Contains(roles, RoleBroker) {
+		return errors.New("the broker role is required, controller-only nodes are not supported")
+	}
+	if !slices.Contains(roles, RoleController) {
+		return errors.New("the controller role is required, every node must be a voter in the metadata quorum since broker-only observers are not supported")
+	}
+	c.ProcessRoles = roles
+	return nil
+}
+
+func setSelector(c *ServerConfig, v string) error {
+	switch strings.TrimSpace(v) {
+	case SelectorRackAware, "org.apache.kafka.common.replica.RackAwareReplicaSelector":
+		c.ReplicaSelector = SelectorRackAware
+	case SelectorLeader, "", "org.apache.kafka.common.replica.LeaderSelector":
+		c.ReplicaSelector = SelectorLeader
+	default:
+		return errors.New("expected rack-aware or leader")
+	}
+	return nil
+}
+
+func intValue(bits int, lowest int64, assign func(c *ServerConfig, n int64)) func(*ServerConfig, string) error {
+	return func(c *ServerConfig, v string) error {
+		n, err := strconv.ParseInt(strings.TrimSpace(v), 10, bits)
+		if err != nil {
+			return fmt.Errorf("expected an integer of at most %d bits", bits)
+		}
+		if n < lowest {
+			return fmt.Errorf("must be at least %d", lowest)
+		}
+		assign(c, n)
+		return nil
+	}
+}
+
+func listValue(v string) []string {
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func envKey(name string) (string, bool) {
+	if !strings.HasPrefix(name, envPrefix) || slices.Contains(launcherVariables, name) {
+		return "", false
+	}
+
+	key := strings.ToLower(strings.TrimPrefix(name, envPrefix))
+	key = strings.ReplaceAll(key, "___", "\x00")
+	key = strings.ReplaceAll(key, "__", "\x01")
+	key = strings.ReplaceAll(key, "_", ".")
+	key = strings.ReplaceAll(key, "\x01", "_")
+	key = strings.ReplaceAll(key, "\x00", "-")
+	return key, true
+}
+
+func suggest(key string) string {
+	best, bestDistance := "", len(key)/3+2
+	for _, d := range definitions {
+		if dist := editDistance(key, d.name); dist < bestDistance {
+			best, bestDistance = d.name, dist
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
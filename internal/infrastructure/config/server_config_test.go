@@ -0,0 +1,305 @@
+package config
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func writeProperties(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "server.properties")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	c, err := Load("", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.NodeID != -1 || !c.HasRole(RoleBroker) || !c.HasRole(RoleController) {
+		t.Fatalf("unexpected identity %+v", c)
+	}
+	if !slices.Equal(c.LogDirs, []string{DefaultLogDir}) || c.MetadataLogDir != DefaultLogDir {
+		t.Fatalf("unexpected log dirs %v %q", c.LogDirs, c.MetadataLogDir)
+	}
+	if len(c.Listeners) != 1 || c.Listeners[0].Address() != "0.0.0.0:9092" {
+		t.Fatalf("unexpected listeners %+v", c.Listeners)
+	}
+	if a := c.AdvertisedListeners; len(a) != 1 || a[0] != (Listener{Name: "PLAINTEXT", Host: "localhost", Port: 9092}) {
+		t.Fatalf("unexpected advertised listeners %+v", a)
+	}
+	if c.NumPartitions != 1 || c.DefaultReplicationFactor != 1 || !c.AutoLeaderRebalanceEnable || c.ReplicaSelector != SelectorRackAware {
+		t.Fatalf("unexpected defaults %+v", c)
+	}
+	if v := c.QuorumVoters(1); v != "1@localhost:9092" {
+		t.Fatalf("unexpected default voters %q", v)
+	}
+}
+
+const kafka40ServerProperties = `############################# Server Basics #############################
+
+# The role of this server. Setting this puts us in KRaft mode
+process.roles=broker,controller
+
+# The node id associated with this instance's roles
+node.id=1
+
+# List of controller endpoints used connect to the controller cluster
+controller.quorum.bootstrap.servers=localhost:9093
+
+############################# Socket Server Settings #############################
+
+listeners=PLAINTEXT://:9092,CONTROLLER://:9093
+
+# Name of listener used for communication between brokers.
+inter.broker.listener.name=PLAINTEXT
+
+# Listener name, hostname and port the broker or the controller will advertise to clients.
+advertised.listeners=PLAINTEXT://localhost:9092,CONTROLLER://localhost:9093
+
+controller.listener.names=CONTROLLER
+listener.security.protocol.map=CONTROLLER:PLAINTEXT,PLAINTEXT:PLAINTEXT,SSL:SSL,SASL_PLAINTEXT:SASL_PLAINTEXT,SASL_SSL:SASL_SSL
+
+num.network.threads=3
+num.io.threads=8
+socket.send.buffer.bytes=102400
+socket.receive.buffer.bytes=102400
+socket.request.max.bytes=104857600
+
+############################# Log Basics #############################
+
+log.dirs=/tmp/kraft-combined-logs
+num.partitions=1
+num.recovery.threads.per.data.dir=1
+
+############################# Internal Topic Settings  #############################
+
+offsets.topic.replication.factor=1
+share.coordinator.state.topic.replication.factor=1
+share.coordinator.state.topic.min.isr=1
+transaction.state.log.replication.factor=1
+transaction.state.log.min.isr=1
+
+############################# Log Flush Policy #############################
+
+#log.flush.interval.messages=10000
+#log.flush.interval.ms=1000
+
+############################# Log Retention Policy #############################
+
+log.retention.hours=168
+#log.retention.bytes=1073741824
+log.segment.bytes=1073741824
+log.retention.check.interval.ms=300000
+`
+
+const kafka39ServerProperties = `############################# Server Basics #############################
+
+process.roles=broker,controller
+node.id=1
+controller.quorum.voters=1@localhost:9093
+
+############################# Socket Server Settings #############################
+
+listeners=PLAINTEXT://:9092,CONTROLLER://:9093
+inter.broker.listener.name=PLAINTEXT
+advertised.listeners=PLAINTEXT://localhost:9092
+controller.listener.names=CONTROLLER
+listener.security.protocol.map=CONTROLLER:PLAINTEXT,PLAINTEXT:PLAINTEXT,SSL:SSL,SASL_PLAINTEXT:SASL_PLAINTEXT,SASL_SSL:SASL_SSL
+num.network.threads=3
+num.io.threads=8
+socket.send.buffer.bytes=102400
+socket.receive.buffer.bytes=102400
+socket.request.max.bytes=104857600
+
+############################# Log Basics #############################
+
+log.dirs=/tmp/kraft-combined-logs
+num.partitions=1
+num.recovery.threads.per.data.dir=1
+offsets.topic.replication.factor=1
+transaction.state.log.replication.factor=1
+transaction.state.log.min.isr=1
+
+#log.flush.interval.messages=10000
+#log.flush.interval.ms=1000
+
+log.retention.hours=168
+log.segment.bytes=1073741824
+log.retention.check.interval.ms=300000
+`
+
+func TestLoad_KafkaSampleProperties(t *testing.T) {
+	tests := map[string]string{
+		"4.0 config/server.properties":       kafka40ServerProperties,
+		"3.9 config/kraft/server.properties": kafka39ServerProperties,
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			c, err := Load(writeProperties(t, content), nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if c.NodeID != 1 || !slices.Equal(c.LogDirs, []string{"/tmp/kraft-combined-logs"}) {
+				t.Fatalf("unexpected config %+v", c)
+			}
+			if a := c.AdvertisedListeners; len(a) != 1 || a[0] != (Listener{Name: "PLAINTEXT", Host: "localhost", Port: 9092}) {
+				t.Fatalf("expected only the PLAINTEXT listener to be advertised, got %+v", a)
+			}
+			if v := c.QuorumVoters(1); v != "1@localhost:9093" {
+				t.Fatalf("unexpected voters %q", v)
+			}
+			for _, key := range []string{"log.retention.hours", "log.segment.bytes", "log.retention.check.interval.ms"} {
+				if !slices.ContainsFunc(c.Warnings, func(w string) bool { return strings.HasPrefix(w, key+" ") }) {
+					t.Fatalf("expected a warning that %s is not enforced, got %q", key, c.Warnings)
+				}
+			}
+			if len(c.Warnings) != 3 {
+				t.Fatalf("expected warnings only for unenforced keys, got %q", c.Warnings)
+			}
+		})
+	}
+}
+
+func TestLoad_PropertiesFile(t *testing.T) {
+	path := writeProperties(t, `# KRaft combined mode
+process.roles=broker,controller
+node.id = 3
+listeners=PLAINTEXT://:9092,CONTROLLER://:9093
+controller.listener.names=CONTROLLER
+listener.security.protocol.map=CONTROLLER:PLAINTEXT,PLAINTEXT:PLAINTEXT
+! legacy comment
+controller.quorum.voters: 3@localhost:9093
+log.dirs=/data/a,\
+         /data/b
+num.partitions 4
+broker.rack=rack-1
+`)
+
+	c, err := Load(path, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.NodeID != 3 || c.NumPartitions != 4 || c.BrokerRack != "rack-1" || c.ControllerQuorumVoters != "3@localhost:9093" {
+		t.Fatalf("unexpected config %+v", c)
+	}
+	if !slices.Equal(c.LogDirs, []string{"/data/a", "/data/b"}) || c.MetadataLogDir != "/data/a" {
+		t.Fatalf("unexpected log dirs %v", c.LogDirs)
+	}
+	if len(c.Listeners) != 2 || c.Listeners[1].Name != "CONTROLLER" || c.Listeners[1].Port != 9093 {
+		t.Fatalf("unexpected listeners %+v", c.Listeners)
+	}
+	if a := c.AdvertisedListeners; len(a) != 1 || a[0] != (Listener{Name: "PLAINTEXT", Host: "localhost", Port: 9092}) {
+		t.Fatalf("controller listeners must not be advertised, got %+v", a)
+	}
+}
+
+func TestLoad_StaticTopicDefaults(t *testing.T) {
+	path := writeProperties(t, "compression.type=lz4\nmin.insync.replicas = 2\nunclean.leader.election.enable=true\n")
+
+	c, err := Load(path, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"compression.type":               "lz4",
+		"min.insync.replicas":            "2",
+		"unclean.leader.election.enable": "true",
+	}
+	if !maps.Equal(c.TopicDefaults, want) {
+		t.Fatalf("unexpected topic defaults %v", c.TopicDefaults)
+	}
+}
+
+func TestLoad_EnvironmentAndOverrides(t *testing.T) {
+	path := writeProperties(t, "num.partitions=2\nreplica.selector.class=leader\n")
+	environ := []string{
+		"PATH=/usr/bin",
+		"KAFKA_HEAP_OPTS=-Xmx1G",
+		"KAFKA_NUM_PARTITIONS=5",
+		"KAFKA_LOG_DIRS=/env/logs",
+		"KAFKA_ADVERTISED_LISTENERS=PLAINTEXT://broker-1:9092",
+	}
+
+	c, err := Load(path, environ, []string{"num.partitions=7", "replica.selector.class=org.apache.kafka.common.replica.RackAwareReplicaSelector"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.NumPartitions != 7 || c.ReplicaSelector != SelectorRackAware {
+		t.Fatalf("overrides must win, got %+v", c)
+	}
+	if !slices.Equal(c.LogDirs, []string{"/env/logs"}) || c.AdvertisedListeners[0].Host != "broker-1" {
+		t.Fatalf("environment must override the file, got %+v", c)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		file      string
+		environ   []string
+		overrides []string
+		want      string
+	}{
+		{name: "misspelled key", file: "num.partition=3\n", want: `line 1: unknown config "num.partition", did you mean "num.partitions"?`},
+		{name: "unknown key", file: "\n\nfoo.bar.baz=1\n", want: `line 3: unknown config "foo.bar.baz"`},
+		{name: "bad integer", file: "num.partitions=many\n", want: `invalid value "many" for num.partitions`},
+		{name: "below minimum", overrides: []string{"default.replication.factor=0"}, want: "must be at least 1"},
+		{name: "bad boolean", overrides: []string{"auto.leader.rebalance.enable=yes"}, want: "expected true or false"},
+		{name: "unknown env", environ: []string{"KAFKA_BROKER_RAK=a"}, want: `environment variable KAFKA_BROKER_RAK: unknown config "broker.rak", did you mean "broker.rack"?`},
+		{name: "malformed override", overrides: []string{"num.partitions"}, want: "not in key=value form"},
+		{name: "bad topic default", overrides: []string{"compression.type=brotli"}, want: "invalid value brotli for configuration compression.type"},
+		{name: "unknown role", overrides: []string{"process.roles=broker,zookeeper"}, want: `unknown role "zookeeper"`},
+		{name: "controller only", overrides: []string{"process.roles=controller"}, want: "the broker role is required"},
+		{name: "broker only", overrides: []string{"process.roles=broker"}, want: "the controller role is required"},
+		{name: "bootstrap servers without ids", overrides: []string{"controller.quorum.bootstrap.servers=a:9093,b:9093"}, want: "name them with their node ids in controller.quorum.voters"},
+		{name: "bad listener", overrides: []string{"listeners=localhost:9092"}, want: "NAME://host:port"},
+		{name: "shared port", overrides: []string{"listeners=PLAINTEXT://:9092,CONTROLLER://:9092"}, want: "both use port 9092"},
+		{name: "missing controller listener", overrides: []string{"controller.listener.names=CONTROLLER"}, want: "CONTROLLER is not in listeners"},
+		{name: "non-routable advertised", overrides: []string{"advertised.listeners=PLAINTEXT://0.0.0.0:9092"}, want: "non-routable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := ""
+			if tt.file != "" {
+				path = writeProperties(t, tt.file)
+			}
+			_, err := Load(path, tt.environ, tt.overrides)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestEnvKey(t *testing.T) {
+	tests := map[string]string{
+		"KAFKA_LOG_DIRS":                          "log.dirs",
+		"KAFKA_CONTROLLER_QUORUM_VOTERS":          "controller.quorum.voters",
+		"KAFKA_LISTENER_NAME_X__Y":                "listener.name.x_y",
+		"KAFKA_REPLICA___SELECTOR":                "replica-selector",
+		"KAFKA_LEADER_REPLICATION_THROTTLED_RATE": "leader.replication.throttled.rate",
+	}
+	for env, want := range tests {
+		if got, ok := envKey(env); !ok || got != want {
+			t.Fatalf("%s: expected %q, got %q", env, want, got)
+		}
+	}
+	if _, ok := envKey("KAFKA_OPTS"); ok {
+		t.Fatal("launcher variables must not map to configs")
+	}
+}
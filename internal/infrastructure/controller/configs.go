@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"fmt"
+	"maps"
+	"strconv"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+)
+
+func (c *Controller) AlterConfigs(
+	alterations []domain.ConfigAlteration,
+	validateOnly bool,
+) ([]domain.ConfigAlterationResult, error) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.livenessLocked(true); !ok {
+		return nil, domain.ErrNotController
+	}
+
+	requested := map[domain.ConfigResource]int{}
+	for _, a := range alterations {
+		requested[a.Resource]++
+	}
+
+	results := make([]domain.ConfigAlterationResult, 0, len(alterations))
+	changes := make([]domain.ConfigChange, 0)
+	changed := make([]int, 0)
+
+	for _, a := range alterations {
+		result := domain.ConfigAlterationResult{Resource: a.Resource}
+
+		var (
+			resourceChanges []domain.ConfigChange
+			code            int16
+			msg             string
+		)
+		if requested[a.Resource] > 1 {
+			code, msg = domain.ErrorInvalidRequest, "the request contains multiple entries for the resource"
+		} else {
+			resourceChanges, code, msg = c.alterResource(a)
+		}
+
+		if code != 0 {
+			result.ErrorCode = code
+			result.ErrorMessage = &msg
+		} else if !validateOnly && len(resourceChanges) > 0 {
+			changes = append(changes, resourceChanges...)
+			changed = append(changed, len(results))
+		}
+		results = append(results, result)
+	}
+
+	if len(changes) == 0 {
+		return results, nil
+	}
+
+	if err := c.writer.AlterConfigs(changes); err != nil {
+		msg := err.Error()
+		for _, i := range changed {
+			results[i].ErrorCode = domain.ErrorUnknownServerError
+			results[i].ErrorMessage = &msg
+		}
+	}
+	return results, nil
+}
+
+func (c *Controller) alterResource(a domain.ConfigAlteration) ([]domain.ConfigChange, int16, string) {
+	var (
+		current map[string]string
+		lookup  func(string) (domain.ConfigDefinition, bool)
+		kind    string
+	)
+
+	switch a.Resource.Type {
+	case domain.ConfigResourceTopic:
+		meta, err := c.repo.GetTopic(a.Resource.Name)
+		if err != nil || meta == nil {
+			return nil, domain.ErrorUnknownTopicOrPartition, fmt.Sprintf("topic '%s' does not exist", a.Resource.Name)
+		}
+		current, lookup, kind = meta.Configs, domain.TopicConfigDefinition, "topic"
+
+	case domain.ConfigResourceBroker:
+		if a.Resource.Name != "" {
+			if _, err := strconv.ParseInt(a.Resource.Name, 10, 32); err != nil {
+				return nil, domain.ErrorInvalidRequest, "broker resource name must be empty or a broker id, got " + a.Resource.Name
+			}
+		}
+		current, lookup, kind = c.repo.BrokerConfigs(a.Resource.Name), domain.BrokerConfigDefinition, "broker"
+
+	default:
+		return nil, domain.ErrorInvalidRequest, fmt.Sprintf("unsupported resource type %d", a.Resource.Type)
+	}
+
+	next := map[string]string{}
+	if a.Incremental {
+		maps.Copy(next, current)
+	}
+
+	seen := map[string]bool{}
+	for _, op := range a.Ops {
+		if seen[op.Name] {
+			return nil, domain.ErrorInvalidRequest, "the request contains multiple entries for config " + op.Name
+		}
+		seen[op.Name] = true
+
+		def, ok := lookup(op.Name)
+		if !ok {
+			return nil, domain.ErrorInvalidConfig, fmt.Sprintf("unknown %s config name: %s", kind, op.Name)
+		}
+		if def.ReadOnly {
+			return nil, domain.ErrorInvalidConfig, fmt.Sprintf("config %s is read-only and cannot be updated dynamically", op.Name)
+		}
+		if code, msg := def.Apply(next, op); code != 0 {
+			return nil, code, msg
+		}
+	}
+
+	return configChanges(a.Resource, current, next), 0, ""
+}
+
+func configChanges(resource domain.ConfigResource, current, next map[string]string) []domain.ConfigChange {
+	out := make([]domain.ConfigChange, 0)
+	for _, name := range domain.SortedConfigNames(next) {
+		if v, ok := current[name]; ok && v == next[name] {
+			continue
+		}
+		value := next[name]
+		out = append(out, domain.ConfigChange{Resource: resource, Name: name, Value: &value})
+	}
+	for _, name := range domain.SortedConfigNames(current) {
+		if _, ok := next[name]; !ok {
+			out = append(out, domain.ConfigChange{Resource: resource, Name: name})
+		}
+	}
+	return out
+}
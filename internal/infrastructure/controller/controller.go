@@ -0,0 +1,389 @@
+package controller
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+	"github.com/codecrafters-io/kafka-starter-go/internal/ports"
+)
+
+const (
+	defaultSessionTimeout    = 9 * time.Second
+	defaultRebalanceInterval = 5 * time.Minute
+)
+
+type Config struct {
+	NodeID            int32
+	SessionTimeout    time.Duration
+	AutoRebalance     bool
+	RebalanceInterval time.Duration
+	Rack              string
+
+	DefaultNumPartitions     int32
+	DefaultReplicationFactor int16
+}
+
+type Controller struct {
+	mu          sync.Mutex
+	cfg         Config
+	repo        ports.MetadataRepository
+	writer      ports.MetadataWriter
+	quorum      ports.MetadataQuorum
+	clock       func() time.Time
+	random      func(n int) int
+	leaderEpoch int32
+	leaderSince time.Time
+	stop        chan struct{}
+	closed      bool
+}
+
+func NewController(
+	cfg Config,
+	repo ports.MetadataRepository,
+	writer ports.MetadataWriter,
+	quorum ports.MetadataQuorum,
+) *Controller {
+
+	if cfg.SessionTimeout <= 0 {
+		cfg.SessionTimeout = defaultSessionTimeout
+	}
+	if cfg.RebalanceInterval <= 0 {
+		cfg.RebalanceInterval = defaultRebalanceInterval
+	}
+	if cfg.DefaultNumPartitions <= 0 {
+		cfg.DefaultNumPartitions = domain.DefaultNumPartitions
+	}
+	if cfg.DefaultReplicationFactor <= 0 {
+		cfg.DefaultReplicationFactor = domain.DefaultReplicationFactor
+	}
+
+	return &Controller{
+		cfg:         cfg,
+		repo:        repo,
+		writer:      writer,
+		quorum:      quorum,
+		clock:       time.Now,
+		random:      rand.IntN,
+		leaderEpoch: -1,
+		stop:        make(chan struct{}),
+	}
+}
+
+func (c *Controller) Start() {
+	go c.run()
+}
+
+func (c *Controller) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.closed {
+		c.closed = true
+		close(c.stop)
+	}
+}
+
+func (c *Controller) run() {
+	brokers := time.NewTicker(c.cfg.SessionTimeout / 3)
+	defer brokers.Stop()
+	rebalance := time.NewTicker(c.cfg.RebalanceInterval)
+	defer rebalance.Stop()
+
+	for {
+		var err error
+		select {
+		case <-c.stop:
+			return
+		case <-brokers.C:
+			err = c.checkBrokers()
+		case <-rebalance.C:
+			if c.cfg.AutoRebalance {
+				err = c.rebalanceLeaders()
+			}
+		}
+		if err != nil {
+			fmt.Println("controller:", err)
+		}
+	}
+}
+
+func (c *Controller) checkBrokers() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	alive, ok := c.livenessLocked(false)
+	if !ok {
+		return nil
+	}
+
+	fencing := make([]domain.BrokerFencing, 0)
+	for _, b := range c.repo.Brokers() {
+		if live := alive(b.ID); live == b.Fenced {
+			fencing = append(fencing, domain.BrokerFencing{BrokerID: b.ID, Epoch: b.Epoch, Fenced: !live})
+		}
+	}
+
+	changes := make([]domain.PartitionChange, 0)
+	for _, t := range c.repo.Topics() {
+		for _, pm := range t.Partitions {
+			if change, ok := electForLiveness(t, pm, alive); ok {
+				changes = append(changes, change)
+			}
+		}
+	}
+
+	if len(fencing) == 0 && len(changes) == 0 {
+		return nil
+	}
+	return c.writer.FenceBrokers(fencing, changes)
+}
+
+func (c *Controller) rebalanceLeaders() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	alive, ok := c.livenessLocked(false)
+	if !ok {
+		return nil
+	}
+
+	changes := make([]domain.PartitionChange, 0)
+	for _, t := range c.repo.Topics() {
+		for _, pm := range t.Partitions {
+			if electPreferred(pm, alive) == 0 {
+				changes = append(changes, leaderChange(t, pm, domain.PreferredLeader(pm), nil))
+			}
+		}
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+	return c.writer.AlterPartitions(changes)
+}
+
+func (c *Controller) ElectLeaders(
+	electionType domain.ElectionType,
+	targets []domain.ElectionTarget,
+) ([]domain.ElectionResult, error) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	alive, ok := c.livenessLocked(true)
+	if !ok {
+		return nil, domain.ErrNotController
+	}
+
+	if targets == nil {
+		for _, t := range c.repo.Topics() {
+			target := domain.ElectionTarget{Topic: t.Name}
+			for _, pm := range t.Partitions {
+				target.Partitions = append(target.Partitions, pm.PartitionIndex)
+			}
+			targets = append(targets, target)
+		}
+	}
+
+	results := make([]domain.ElectionResult, 0)
+	changes := make([]domain.PartitionChange, 0)
+	changed := make([]int, 0)
+
+	for _, target := range targets {
+		meta, err := c.repo.GetTopic(target.Topic)
+		for _, index := range target.Partitions {
+			result := domain.ElectionResult{Topic: target.Topic, Partition: index}
+
+			var (
+				pm    domain.PartitionMetadata
+				found bool
+			)
+			if err == nil && meta != nil {
+				pm, found = findPartition(meta, index)
+			}
+			if !found {
+				result.ErrorCode = domain.ErrorUnknownTopicOrPartition
+				results = append(results, result)
+				continue
+			}
+
+			switch electionType {
+			case domain.ElectionPreferred:
+				result.ErrorCode = electPreferred(pm, alive)
+				if result.ErrorCode == 0 {
+					changes = append(changes, leaderChange(meta, pm, domain.PreferredLeader(pm), nil))
+				}
+
+			case domain.ElectionUnclean:
+				var change domain.PartitionChange
+				change, result.ErrorCode = electUnclean(meta, pm, alive)
+				if result.ErrorCode == 0 {
+					changes = append(changes, change)
+				}
+
+			default:
+				result.ErrorCode = domain.ErrorInvalidRequest
+			}
+
+			if result.ErrorCode == 0 {
+				changed = append(changed, len(results))
+			}
+			results = append(results, result)
+		}
+	}
+
+	if len(changes) == 0 {
+		return results, nil
+	}
+
+	if err := c.writer.AlterPartitions(changes); err != nil {
+		msg := err.Error()
+		for _, i := range changed {
+			results[i].ErrorCode = domain.ErrorUnknownServerError
+			results[i].ErrorMessage = &msg
+		}
+	}
+	return results, nil
+}
+
+func (c *Controller) livenessLocked(grace bool) (func(int32) bool, bool) {
+	desc := c.quorum.DescribeQuorum()
+	if desc.ErrorCode != 0 || desc.Leader.LeaderID != c.cfg.NodeID {
+		c.leaderEpoch = -1
+		return nil, false
+	}
+
+	now := c.clock()
+	if desc.Leader.Epoch != c.leaderEpoch {
+		c.leaderEpoch = desc.Leader.Epoch
+		c.leaderSince = now
+	}
+
+	if now.Sub(c.leaderSince) < c.cfg.SessionTimeout {
+		if !grace {
+			return nil, false
+		}
+
+		fenced := map[int32]bool{}
+		for _, b := range c.repo.Brokers() {
+			fenced[b.ID] = b.Fenced
+		}
+		return func(id int32) bool {
+			return id == c.cfg.NodeID || !fenced[id]
+		}, true
+	}
+
+	lastFetch := map[int32]int64{}
+	for _, r := range append(desc.Voters, desc.Observers...) {
+		lastFetch[r.ReplicaID] = r.LastFetchTimestamp
+	}
+	deadline := now.Add(-c.cfg.SessionTimeout).UnixMilli()
+
+	return func(id int32) bool {
+		if id == c.cfg.NodeID {
+			return true
+		}
+		ts, ok := lastFetch[id]
+		return ok && ts >= deadline
+	}, true
+}
+
+func electForLiveness(
+	meta *domain.TopicMetadata,
+	pm domain.PartitionMetadata,
+	alive func(int32) bool,
+) (domain.PartitionChange, bool) {
+
+	isr := make([]int32, 0, len(pm.ISR))
+	for _, id := range pm.ISR {
+		if alive(id) {
+			isr = append(isr, id)
+		}
+	}
+
+	leader := pm.LeaderID
+	if leader == domain.NoLeader || !alive(leader) {
+		leader, isr = domain.ElectLeader(pm, isr, alive, meta.UncleanLeaderElection())
+	}
+
+	change := leaderChange(meta, pm, leader, isr)
+	if change.Leader == domain.NoLeaderChange && change.ISR == nil && change.ELR == nil && change.LastKnownELR == nil {
+		return domain.PartitionChange{}, false
+	}
+	return change, true
+}
+
+func electPreferred(pm domain.PartitionMetadata, alive func(int32) bool) int16 {
+	preferred := domain.PreferredLeader(pm)
+	switch {
+	case preferred == domain.NoLeader:
+		return domain.ErrorPreferredLeaderNotAvailable
+	case pm.LeaderID == preferred:
+		return domain.ErrorElectionNotNeeded
+	case !alive(preferred) || !slices.Contains(pm.ISR, preferred):
+		return domain.ErrorPreferredLeaderNotAvailable
+	}
+	return 0
+}
+
+func electUnclean(meta *domain.TopicMetadata, pm domain.PartitionMetadata, alive func(int32) bool) (domain.PartitionChange, int16) {
+	if pm.LeaderID != domain.NoLeader && alive(pm.LeaderID) {
+		return domain.PartitionChange{}, domain.ErrorElectionNotNeeded
+	}
+
+	leader, isr := domain.ElectLeader(pm, pm.ISR, alive, true)
+	if leader == domain.NoLeader {
+		return domain.PartitionChange{}, domain.ErrorEligibleLeadersNotAvailable
+	}
+	return leaderChange(meta, pm, leader, isr), 0
+}
+
+func leaderChange(meta *domain.TopicMetadata, pm domain.PartitionMetadata, leader int32, isr []int32) domain.PartitionChange {
+	change := domain.PartitionChange{
+		TopicID:   meta.TopicID,
+		Partition: pm.PartitionIndex,
+		Leader:    domain.NoLeaderChange,
+	}
+	if leader != pm.LeaderID {
+		change.Leader = leader
+	}
+	if isr == nil {
+		return change
+	}
+	if !slices.Equal(isr, pm.ISR) {
+		change.ISR = isr
+	}
+
+	elr := domain.EligibleLeaders(pm, isr, meta.MinInsyncReplicas())
+	if leader != domain.NoLeader && !slices.Contains(pm.ISR, leader) && !slices.Contains(pm.EligibleLeaderReplicas, leader) {
+		elr = elr[:0]
+	}
+	if !slices.Equal(elr, pm.EligibleLeaderReplicas) {
+		change.ELR = elr
+	}
+
+	lastKnown := make([]int32, 0)
+	if leader == domain.NoLeader {
+		lastKnown = slices.Clone(pm.LastKnownELR)
+		if len(elr) > 0 {
+			lastKnown = slices.Clone(elr)
+		}
+	}
+	if !slices.Equal(lastKnown, pm.LastKnownELR) {
+		change.LastKnownELR = lastKnown
+	}
+	return change
+}
+
+func findPartition(meta *domain.TopicMetadata, index int32) (domain.PartitionMetadata, bool) {
+	for _, pm := range meta.Partitions {
+		if pm.PartitionIndex == index {
+			return pm, true
+		}
+	}
+	return domain.PartitionMetadata{}, false
+}
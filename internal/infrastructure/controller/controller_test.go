@@ -0,0 +1,556 @@
+package controller
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+	"github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/metadata/parser"
+	"github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/repository"
+)
+
+type testRepo struct {
+	topics  []*domain.TopicMetadata
+	brokers []domain.BrokerRegistration
+	configs map[string]map[string]string
+}
+
+func (r *testRepo) GetTopic(name string) (*domain.TopicMetadata, error) {
+	for _, t := range r.topics {
+		if t.Name == name {
+			return t, nil
+		}
+	}
+	return nil, errors.New("not found")
+}
+
+func (r *testRepo) GetTopicByID(id [16]byte) (*domain.TopicMetadata, error) {
+	for _, t := range r.topics {
+		if t.TopicID == id {
+			return t, nil
+		}
+	}
+	return nil, errors.New("not found")
+}
+
+func (r *testRepo) Topics() []*domain.TopicMetadata              { return r.topics }
+func (r *testRepo) Brokers() []domain.BrokerRegistration         { return r.brokers }
+func (r *testRepo) Controllers() []domain.ControllerRegistration { return nil }
+func (r *testRepo) ControllerID() int32                          { return 1 }
+func (r *testRepo) FinalizedFeatures() domain.FinalizedFeatures  { return domain.FinalizedFeatures{} }
+func (r *testRepo) Version() domain.MetadataVersion              { return domain.MetadataVersion{} }
+func (r *testRepo) BrokerConfigs(name string) map[string]string  { return r.configs[name] }
+func (r *testRepo) Subscribe(func(domain.MetadataChange)) func() { return func() {} }
+
+type testWriter struct {
+	fencing []domain.BrokerFencing
+	changes []domain.PartitionChange
+	topics  []*domain.TopicMetadata
+	configs []domain.ConfigChange
+}
+
+func (w *testWriter) UpdateFeatures(map[string]int16) error { return nil }
+
+func (w *testWriter) AlterPartitions(changes []domain.PartitionChange) error {
+	w.changes = append(w.changes, changes...)
+	return nil
+}
+
+func (w *testWriter) CreateTopics(topics []*domain.TopicMetadata) error {
+	w.topics = append(w.topics, topics...)
+	return nil
+}
+
+func (w *testWriter) AlterConfigs(changes []domain.ConfigChange) error {
+	w.configs = append(w.configs, changes...)
+	return nil
+}
+
+func (w *testWriter) FenceBrokers(fencing []domain.BrokerFencing, changes []domain.PartitionChange) error {
+	w.fencing = append(w.fencing, fencing...)
+	w.changes = append(w.changes, changes...)
+	return nil
+}
+
+type testQuorum struct {
+	desc domain.QuorumDescription
+}
+
+func (q *testQuorum) HandleVote(domain.QuorumVote) domain.QuorumVoteResult {
+	return domain.QuorumVoteResult{}
+}
+func (q *testQuorum) HandleBeginQuorumEpoch(domain.LeaderAndEpoch) domain.QuorumEpochResult {
+	return domain.QuorumEpochResult{}
+}
+func (q *testQuorum) HandleEndQuorumEpoch(domain.LeaderAndEpoch, []int32) domain.QuorumEpochResult {
+	return domain.QuorumEpochResult{}
+}
+func (q *testQuorum) HandleFetch(domain.QuorumFetch) domain.QuorumFetchResult {
+	return domain.QuorumFetchResult{}
+}
+func (q *testQuorum) HandleFetchSnapshot(domain.QuorumSnapshotFetch) domain.QuorumSnapshotChunk {
+	return domain.QuorumSnapshotChunk{}
+}
+func (q *testQuorum) DescribeQuorum() domain.QuorumDescription { return q.desc }
+
+var testTopicID = [16]byte{3}
+
+func newTestController(pm domain.PartitionMetadata, configs map[string]string, fetched ...int32) (*Controller, *testWriter, *time.Time) {
+	repo := &testRepo{
+		topics: []*domain.TopicMetadata{{
+			Name:       "t",
+			TopicID:    testTopicID,
+			Partitions: []domain.PartitionMetadata{pm},
+			Configs:    configs,
+		}},
+		brokers: []domain.BrokerRegistration{{ID: 1, Epoch: 5}, {ID: 2, Epoch: 6}, {ID: 3, Epoch: 7}},
+	}
+
+	now := time.UnixMilli(100_000)
+	quorum := &testQuorum{desc: domain.QuorumDescription{Leader: domain.LeaderAndEpoch{LeaderID: 1, Epoch: 1}}}
+	for _, id := range fetched {
+		quorum.desc.Voters = append(quorum.desc.Voters, domain.QuorumReplicaState{ReplicaID: id, LastFetchTimestamp: now.UnixMilli()})
+	}
+
+	writer := &testWriter{}
+	c := NewController(Config{NodeID: 1, SessionTimeout: time.Second}, repo, writer, quorum)
+	c.clock = func() time.Time { return now }
+	c.leaderEpoch = 1
+	c.leaderSince = now.Add(-time.Minute)
+	return c, writer, &now
+}
+
+func TestController_FencesDeadLeaderAndElectsFromISR(t *testing.T) {
+	pm := domain.PartitionMetadata{LeaderID: 2, Replicas: []int32{2, 3, 1}, ISR: []int32{2, 3, 1}}
+	c, writer, _ := newTestController(pm, nil, 3)
+
+	if err := c.checkBrokers(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(writer.fencing) != 1 || writer.fencing[0] != (domain.BrokerFencing{BrokerID: 2, Epoch: 6, Fenced: true}) {
+		t.Fatalf("expected broker 2 to be fenced, got %+v", writer.fencing)
+	}
+	if len(writer.changes) != 1 {
+		t.Fatalf("expected one partition change, got %+v", writer.changes)
+	}
+	if ch := writer.changes[0]; ch.Leader != 3 || !slices.Equal(ch.ISR, []int32{3, 1}) {
+		t.Fatalf("expected leader 3 with isr [3 1], got %+v", ch)
+	}
+}
+
+func TestController_UncleanElectionOnlyWhenEnabled(t *testing.T) {
+	pm := domain.PartitionMetadata{LeaderID: 2, Replicas: []int32{2, 3}, ISR: []int32{2}}
+
+	c, writer, _ := newTestController(pm, nil, 3)
+	if err := c.checkBrokers(); err != nil {
+		t.Fatal(err)
+	}
+	if ch := writer.changes[0]; ch.Leader != domain.NoLeader || len(ch.ISR) != 0 || !slices.Equal(ch.ELR, []int32{2}) {
+		t.Fatalf("expected partition to go offline with 2 eligible, got %+v", ch)
+	}
+
+	c, writer, _ = newTestController(pm, map[string]string{domain.TopicConfigUncleanLeaderElection: "true"}, 3)
+	if err := c.checkBrokers(); err != nil {
+		t.Fatal(err)
+	}
+	if ch := writer.changes[0]; ch.Leader != 3 || !slices.Equal(ch.ISR, []int32{3}) {
+		t.Fatalf("expected unclean leader 3, got %+v", ch)
+	}
+}
+
+func TestController_UncleanElectionFromConfigRecord(t *testing.T) {
+	enabled := "true"
+	delta := repository.NewMetadataDelta(repository.EmptyMetadataImage())
+	delta.Replay(parser.Record{Value: parser.RecordTopic{TopicName: "t", TopicUUID: testTopicID}})
+	delta.Replay(parser.Record{Value: parser.RecordPartition{
+		TopicUUID: testTopicID, Leader: 2, ReplicaArray: []int32{2, 3}, SyncReplicaArray: []int32{2},
+	}})
+	delta.Replay(parser.Record{Value: parser.RecordConfig{
+		ResourceType: parser.ConfigResourceTopic, ResourceName: "t", Name: domain.TopicConfigUncleanLeaderElection, Value: &enabled,
+	}})
+
+	c, writer, _ := newTestController(domain.PartitionMetadata{}, nil, 3)
+	c.repo.(*testRepo).topics = []*domain.TopicMetadata{delta.Apply().ByName["t"]}
+	if err := c.checkBrokers(); err != nil {
+		t.Fatal(err)
+	}
+	if len(writer.changes) != 1 || writer.changes[0].Leader != 3 || !slices.Equal(writer.changes[0].ISR, []int32{3}) {
+		t.Fatalf("expected the replayed config to allow an unclean election of 3, got %+v", writer.changes)
+	}
+}
+
+func TestController_ElectsFromELRWhenISREmpty(t *testing.T) {
+	pm := domain.PartitionMetadata{LeaderID: 2, Replicas: []int32{2, 3, 1}, ISR: []int32{2, 3}}
+	configs := map[string]string{domain.TopicConfigMinInsyncReplicas: "2"}
+
+	c, writer, _ := newTestController(pm, configs, 3)
+	if err := c.checkBrokers(); err != nil {
+		t.Fatal(err)
+	}
+	if ch := writer.changes[0]; ch.Leader != 3 || !slices.Equal(ch.ISR, []int32{3}) || !slices.Equal(ch.ELR, []int32{2}) {
+		t.Fatalf("expected leader 3 with 2 moved to the ELR, got %+v", ch)
+	}
+
+	pm = domain.PartitionMetadata{LeaderID: domain.NoLeader, Replicas: []int32{2, 3, 1}, EligibleLeaderReplicas: []int32{2, 3}, LastKnownELR: []int32{2, 3}}
+	c, writer, _ = newTestController(pm, configs, 3)
+	if err := c.checkBrokers(); err != nil {
+		t.Fatal(err)
+	}
+	ch := writer.changes[0]
+	if ch.Leader != 3 || !slices.Equal(ch.ISR, []int32{3}) || !slices.Equal(ch.ELR, []int32{2}) || ch.LastKnownELR == nil || len(ch.LastKnownELR) != 0 {
+		t.Fatalf("expected ELR member 3 elected and last known ELR cleared, got %+v", ch)
+	}
+}
+
+func TestController_WaitsOutSessionTimeoutAfterElection(t *testing.T) {
+	pm := domain.PartitionMetadata{LeaderID: 2, Replicas: []int32{2, 1}, ISR: []int32{2, 1}}
+	c, writer, now := newTestController(pm, nil)
+	c.leaderEpoch = 0
+
+	if err := c.checkBrokers(); err != nil {
+		t.Fatal(err)
+	}
+	if len(writer.fencing) != 0 || len(writer.changes) != 0 {
+		t.Fatalf("controller acted before brokers could reconnect: %+v %+v", writer.fencing, writer.changes)
+	}
+
+	*now = now.Add(2 * time.Second)
+	if err := c.checkBrokers(); err != nil {
+		t.Fatal(err)
+	}
+	if len(writer.changes) != 1 || writer.changes[0].Leader != 1 {
+		t.Fatalf("expected leader moved to 1, got %+v", writer.changes)
+	}
+}
+
+func TestController_ElectLeadersPreferred(t *testing.T) {
+	pm := domain.PartitionMetadata{LeaderID: 3, Replicas: []int32{2, 3}, ISR: []int32{2, 3}}
+	c, writer, _ := newTestController(pm, nil, 2, 3)
+
+	results, err := c.ElectLeaders(domain.ElectionPreferred, []domain.ElectionTarget{{Topic: "t", Partitions: []int32{0, 5}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].ErrorCode != 0 || results[1].ErrorCode != domain.ErrorUnknownTopicOrPartition {
+		t.Fatalf("unexpected results %+v", results)
+	}
+	if len(writer.changes) != 1 || writer.changes[0].Leader != 2 || writer.changes[0].ISR != nil {
+		t.Fatalf("expected leader moved to preferred replica 2, got %+v", writer.changes)
+	}
+
+	c, _, _ = newTestController(domain.PartitionMetadata{LeaderID: 2, Replicas: []int32{2, 3}, ISR: []int32{2, 3}}, nil, 2, 3)
+	results, _ = c.ElectLeaders(domain.ElectionPreferred, nil)
+	if results[0].ErrorCode != domain.ErrorElectionNotNeeded {
+		t.Fatalf("expected ELECTION_NOT_NEEDED, got %+v", results)
+	}
+
+	c, _, _ = newTestController(domain.PartitionMetadata{LeaderID: 3, Replicas: []int32{2, 3}, ISR: []int32{3}}, nil, 2, 3)
+	results, _ = c.ElectLeaders(domain.ElectionPreferred, nil)
+	if results[0].ErrorCode != domain.ErrorPreferredLeaderNotAvailable {
+		t.Fatalf("expected PREFERRED_LEADER_NOT_AVAILABLE, got %+v", results)
+	}
+}
+
+func TestController_ElectLeadersUnclean(t *testing.T) {
+	pm := domain.PartitionMetadata{LeaderID: domain.NoLeader, Replicas: []int32{2, 3}, ISR: []int32{2}}
+	c, writer, _ := newTestController(pm, nil, 3)
+
+	results, err := c.ElectLeaders(domain.ElectionUnclean, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].ErrorCode != 0 || writer.changes[0].Leader != 3 || !slices.Equal(writer.changes[0].ISR, []int32{3}) {
+		t.Fatalf("expected unclean election of 3, got %+v %+v", results, writer.changes)
+	}
+
+	c, _, _ = newTestController(pm, nil)
+	results, _ = c.ElectLeaders(domain.ElectionUnclean, nil)
+	if results[0].ErrorCode != domain.ErrorEligibleLeadersNotAvailable {
+		t.Fatalf("expected ELIGIBLE_LEADERS_NOT_AVAILABLE, got %+v", results)
+	}
+}
+
+func TestController_ElectLeadersRequiresActiveController(t *testing.T) {
+	c, _, _ := newTestController(domain.PartitionMetadata{}, nil)
+	c.quorum.(*testQuorum).desc.Leader.LeaderID = 2
+
+	if _, err := c.ElectLeaders(domain.ElectionPreferred, nil); !errors.Is(err, domain.ErrNotController) {
+		t.Fatalf("expected not controller, got %v", err)
+	}
+}
+
+func TestController_AlterPartitionReassignments(t *testing.T) {
+	pm := domain.PartitionMetadata{LeaderID: 1, Replicas: []int32{1, 2}, ISR: []int32{1, 2}}
+	c, writer, _ := newTestController(pm, nil, 2, 3)
+
+	results, err := c.AlterPartitionReassignments([]domain.ReassignmentTarget{
+		{Topic: "t", Partition: 0, Replicas: []int32{2, 3}},
+		{Topic: "t", Partition: 1, Replicas: []int32{2}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].ErrorCode != 0 || results[1].ErrorCode != domain.ErrorUnknownTopicOrPartition {
+		t.Fatalf("unexpected results %+v", results)
+	}
+	ch := writer.changes[0]
+	if !slices.Equal(ch.Replicas, []int32{2, 3, 1}) || !slices.Equal(ch.AddingReplicas, []int32{3}) || !slices.Equal(ch.RemovingReplicas, []int32{1}) {
+		t.Fatalf("expected 3 added and 1 removing, got %+v", ch)
+	}
+	if ch.Leader != domain.NoLeaderChange || ch.ISR != nil {
+		t.Fatalf("leader and isr must not change until 3 catches up, got %+v", ch)
+	}
+
+	c, writer, _ = newTestController(pm, nil, 2)
+	results, _ = c.AlterPartitionReassignments([]domain.ReassignmentTarget{{Topic: "t", Partition: 0, Replicas: []int32{2}}})
+	ch = writer.changes[0]
+	if results[0].ErrorCode != 0 || ch.Leader != 2 || !slices.Equal(ch.Replicas, []int32{2}) || !slices.Equal(ch.ISR, []int32{2}) || len(ch.RemovingReplicas) != 0 {
+		t.Fatalf("expected removal to complete at once with 2 leading, got %+v %+v", results, ch)
+	}
+
+	results, _ = c.AlterPartitionReassignments([]domain.ReassignmentTarget{
+		{Topic: "t", Partition: 0, Replicas: []int32{2, 4}},
+		{Topic: "t", Partition: 0, Replicas: []int32{2, 2}},
+		{Topic: "t", Partition: 0},
+	})
+	if results[0].ErrorCode != domain.ErrorInvalidReplicaAssignment || results[1].ErrorCode != domain.ErrorInvalidReplicaAssignment {
+		t.Fatalf("expected invalid replica assignment, got %+v", results)
+	}
+	if results[2].ErrorCode != domain.ErrorNoReassignmentInProgress {
+		t.Fatalf("expected no reassignment in progress, got %+v", results[2])
+	}
+}
+
+func TestController_CancelPartitionReassignment(t *testing.T) {
+	pm := domain.PartitionMetadata{
+		LeaderID:         1,
+		Replicas:         []int32{2, 3, 1},
+		ISR:              []int32{1, 2, 3},
+		AddingReplicas:   []int32{3},
+		RemovingReplicas: []int32{1},
+	}
+	c, writer, _ := newTestController(pm, nil, 2, 3)
+
+	results, err := c.AlterPartitionReassignments([]domain.ReassignmentTarget{{Topic: "t", Partition: 0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch := writer.changes[0]
+	if results[0].ErrorCode != 0 || !slices.Equal(ch.Replicas, []int32{2, 1}) || !slices.Equal(ch.ISR, []int32{1, 2}) {
+		t.Fatalf("expected original replicas restored, got %+v", ch)
+	}
+	if ch.AddingReplicas == nil || len(ch.AddingReplicas) != 0 || ch.RemovingReplicas == nil || len(ch.RemovingReplicas) != 0 {
+		t.Fatalf("expected reassignment state cleared, got %+v", ch)
+	}
+}
+
+func TestController_CreateTopicsSpreadsReplicasAcrossRacks(t *testing.T) {
+	c, writer, _ := newTestController(domain.PartitionMetadata{}, nil, 2, 3, 4, 5, 6)
+	racks := []string{"a", "a", "b", "b", "c", "c"}
+	repo := c.repo.(*testRepo)
+	repo.brokers = nil
+	for i, rack := range racks {
+		rack := rack
+		repo.brokers = append(repo.brokers, domain.BrokerRegistration{ID: int32(i + 1), Rack: &rack})
+	}
+
+	value := "2"
+	results, err := c.CreateTopics([]domain.TopicCreation{{
+		Name:              "orders",
+		NumPartitions:     6,
+		ReplicationFactor: 3,
+		Configs:           []domain.ConfigEntry{{Name: domain.TopicConfigMinInsyncReplicas, Value: &value}},
+	}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := results[0]; r.ErrorCode != 0 || r.NumPartitions != 6 || r.ReplicationFactor != 3 || r.TopicID == ([16]byte{}) {
+		t.Fatalf("unexpected result %+v", r)
+	}
+
+	meta := writer.topics[0]
+	if meta.Configs[domain.TopicConfigMinInsyncReplicas] != "2" || len(meta.Partitions) != 6 {
+		t.Fatalf("unexpected topic %+v", meta)
+	}
+	leaders := map[int32]int{}
+	for _, pm := range meta.Partitions {
+		seen := map[string]bool{}
+		for _, id := range pm.Replicas {
+			seen[racks[id-1]] = true
+		}
+		if len(seen) != 3 || pm.LeaderID != pm.Replicas[0] || !slices.Equal(pm.ISR, pm.Replicas) {
+			t.Fatalf("expected one replica per rack led by the first replica, got %+v", pm)
+		}
+		leaders[pm.LeaderID]++
+	}
+	if len(leaders) != 6 {
+		t.Fatalf("expected leadership spread over every broker, got %v", leaders)
+	}
+}
+
+func TestController_CreateTopicsUsesConfiguredDefaults(t *testing.T) {
+	c, writer, _ := newTestController(domain.PartitionMetadata{}, nil, 2, 3)
+	c.cfg.DefaultNumPartitions = 3
+	c.cfg.DefaultReplicationFactor = 2
+
+	results, err := c.CreateTopics([]domain.TopicCreation{{Name: "events", NumPartitions: -1, ReplicationFactor: -1}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := results[0]; r.ErrorCode != 0 || r.NumPartitions != 3 || r.ReplicationFactor != 2 {
+		t.Fatalf("unexpected result %+v", r)
+	}
+	if meta := writer.topics[0]; len(meta.Partitions) != 3 || len(meta.Partitions[0].Replicas) != 2 {
+		t.Fatalf("unexpected topic %+v", meta)
+	}
+}
+
+func TestController_CreateTopicsValidation(t *testing.T) {
+	c, writer, _ := newTestController(domain.PartitionMetadata{}, nil, 2, 3)
+
+	zero := "0"
+	results, err := c.CreateTopics([]domain.TopicCreation{
+		{Name: "t", NumPartitions: 1, ReplicationFactor: 1},
+		{Name: "bad/name", NumPartitions: 1, ReplicationFactor: 1},
+		{Name: "zero", NumPartitions: 0, ReplicationFactor: 1},
+		{Name: "wide", NumPartitions: 1, ReplicationFactor: 4},
+		{Name: "manual", NumPartitions: -1, ReplicationFactor: -1, Assignments: map[int32][]int32{0: {3, 2}, 1: {2, 9}}},
+		{Name: "dup", NumPartitions: 1, ReplicationFactor: 1},
+		{Name: "dup", NumPartitions: 1, ReplicationFactor: 1},
+		{Name: "ok", NumPartitions: -1, ReplicationFactor: -1, Assignments: map[int32][]int32{0: {3, 2}}},
+		{Name: "cfg", NumPartitions: 1, ReplicationFactor: 1, Configs: []domain.ConfigEntry{{Name: domain.TopicConfigMinInsyncReplicas, Value: &zero}}},
+	}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []int16{
+		domain.ErrorTopicAlreadyExists,
+		domain.ErrorInvalidTopic,
+		domain.ErrorInvalidPartitions,
+		domain.ErrorInvalidReplicationFactor,
+		domain.ErrorInvalidReplicaAssignment,
+		domain.ErrorInvalidRequest,
+		domain.ErrorInvalidRequest,
+		0,
+		domain.ErrorInvalidConfig,
+	}
+	for i, code := range expected {
+		if results[i].ErrorCode != code {
+			t.Fatalf("topic %d: expected error %d, got %+v", i, code, results[i])
+		}
+	}
+	if r := results[7]; r.NumPartitions != 1 || r.ReplicationFactor != 2 || r.TopicID != ([16]byte{}) {
+		t.Fatalf("unexpected validate-only result %+v", r)
+	}
+	if len(writer.topics) != 0 {
+		t.Fatalf("validate only must not write topics, got %+v", writer.topics)
+	}
+}
+
+func TestController_AlterConfigs(t *testing.T) {
+	c, writer, _ := newTestController(domain.PartitionMetadata{}, map[string]string{
+		domain.TopicConfigMinInsyncReplicas:       "2",
+		domain.TopicConfigLeaderThrottledReplicas: "0:1,0:2",
+	})
+	c.repo.(*testRepo).configs = map[string]map[string]string{"": {domain.BrokerConfigCompressionType: "gzip"}}
+
+	one, two, lz4, bad := "0:1", "0:2", "lz4", "x"
+	results, err := c.AlterConfigs([]domain.ConfigAlteration{
+		{
+			Resource:    domain.ConfigResource{Type: domain.ConfigResourceTopic, Name: "t"},
+			Incremental: true,
+			Ops: []domain.ConfigOp{
+				{Name: domain.TopicConfigMinInsyncReplicas, Op: domain.ConfigOpDelete},
+				{Name: domain.TopicConfigLeaderThrottledReplicas, Op: domain.ConfigOpSubtract, Value: &two},
+				{Name: domain.TopicConfigFollowerThrottledReplicas, Op: domain.ConfigOpAppend, Value: &two},
+			},
+		},
+		{
+			Resource: domain.ConfigResource{Type: domain.ConfigResourceBroker, Name: ""},
+			Ops:      []domain.ConfigOp{{Name: domain.BrokerConfigMinInsyncReplicas, Value: &two}},
+		},
+		{
+			Resource: domain.ConfigResource{Type: domain.ConfigResourceBroker, Name: "1"},
+			Ops:      []domain.ConfigOp{{Name: domain.BrokerConfigCompressionType, Value: &lz4}},
+		},
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []int16{0, domain.ErrorInvalidConfig, 0}
+	for i, code := range expected {
+		if results[i].ErrorCode != code {
+			t.Fatalf("resource %d: expected error %d, got %+v", i, code, results[i])
+		}
+	}
+
+	topic := domain.ConfigResource{Type: domain.ConfigResourceTopic, Name: "t"}
+	broker := domain.ConfigResource{Type: domain.ConfigResourceBroker, Name: "1"}
+	want := []domain.ConfigChange{
+		{Resource: topic, Name: domain.TopicConfigFollowerThrottledReplicas, Value: &two},
+		{Resource: topic, Name: domain.TopicConfigLeaderThrottledReplicas, Value: &one},
+		{Resource: topic, Name: domain.TopicConfigMinInsyncReplicas},
+		{Resource: broker, Name: domain.BrokerConfigCompressionType, Value: &lz4},
+	}
+	if len(writer.configs) != len(want) {
+		t.Fatalf("unexpected config records %+v", writer.configs)
+	}
+	for i, w := range want {
+		got := writer.configs[i]
+		if got.Resource != w.Resource || got.Name != w.Name || (got.Value == nil) != (w.Value == nil) {
+			t.Fatalf("record %d: expected %+v, got %+v", i, w, got)
+		}
+	}
+	if v := *writer.configs[1].Value; v != one {
+		t.Fatalf("expected 0:2 to be subtracted from the leader throttle list, got %q", v)
+	}
+
+	results, err = c.AlterConfigs([]domain.ConfigAlteration{
+		{Resource: domain.ConfigResource{Type: domain.ConfigResourceTopic, Name: "missing"}, Incremental: true},
+		{
+			Resource:    topic,
+			Incremental: true,
+			Ops:         []domain.ConfigOp{{Name: domain.TopicConfigMinInsyncReplicas, Op: domain.ConfigOpAppend, Value: &two}},
+		},
+		{
+			Resource:    domain.ConfigResource{Type: domain.ConfigResourceBroker, Name: "1"},
+			Incremental: true,
+			Ops:         []domain.ConfigOp{{Name: domain.BrokerConfigNodeID, Value: &two}},
+		},
+		{
+			Resource:    domain.ConfigResource{Type: domain.ConfigResourceBroker, Name: "b"},
+			Incremental: true,
+		},
+		{
+			Resource:    domain.ConfigResource{Type: domain.ConfigResourceBroker, Name: ""},
+			Incremental: true,
+			Ops:         []domain.ConfigOp{{Name: "no.such.config", Value: &bad}},
+		},
+		{Resource: domain.ConfigResource{Type: 3, Name: "g"}, Incremental: true},
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = []int16{
+		domain.ErrorUnknownTopicOrPartition,
+		domain.ErrorInvalidConfig,
+		domain.ErrorInvalidConfig,
+		domain.ErrorInvalidRequest,
+		domain.ErrorInvalidConfig,
+		domain.ErrorInvalidRequest,
+	}
+	for i, code := range expected {
+		if results[i].ErrorCode != code {
+			t.Fatalf("resource %d: expected error %d, got %+v", i, code, results[i])
+		}
+	}
+	if len(writer.configs) != len(want) {
+		t.Fatalf("failed alterations must not write records, got %+v", writer.configs)
+	}
+}
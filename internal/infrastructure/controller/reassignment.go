@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"slices"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+)
+
+func (c *Controller) AlterPartitionReassignments(
+	targets []domain.ReassignmentTarget,
+) ([]domain.ReassignmentResult, error) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.livenessLocked(true); !ok {
+		return nil, domain.ErrNotController
+	}
+
+	registered := map[int32]bool{}
+	for _, b := range c.repo.Brokers() {
+		registered[b.ID] = true
+	}
+
+	results := make([]domain.ReassignmentResult, 0, len(targets))
+	changes := make([]domain.PartitionChange, 0)
+	changed := make([]int, 0)
+
+	for _, target := range targets {
+		result := domain.ReassignmentResult{Topic: target.Topic, Partition: target.Partition}
+
+		var (
+			pm    domain.PartitionMetadata
+			found bool
+		)
+		meta, err := c.repo.GetTopic(target.Topic)
+		if err == nil && meta != nil {
+			pm, found = findPartition(meta, target.Partition)
+		}
+
+		var next domain.PartitionMetadata
+		switch {
+		case !found:
+			result.ErrorCode = domain.ErrorUnknownTopicOrPartition
+		case target.Replicas == nil && !pm.Reassigning():
+			result.ErrorCode = domain.ErrorNoReassignmentInProgress
+		case target.Replicas == nil:
+			next = domain.CancelReassignment(pm)
+		default:
+			if msg := validateReplicas(target.Replicas, registered); msg != "" {
+				result.ErrorCode = domain.ErrorInvalidReplicaAssignment
+				result.ErrorMessage = &msg
+				break
+			}
+			next = domain.Reassign(pm, target.Replicas)
+		}
+
+		if result.ErrorCode == 0 {
+			if change, ok := domain.DiffPartition(meta, pm, next); ok {
+				changes = append(changes, change)
+				changed = append(changed, len(results))
+			}
+		}
+		results = append(results, result)
+	}
+
+	if len(changes) == 0 {
+		return results, nil
+	}
+
+	if err := c.writer.AlterPartitions(changes); err != nil {
+		msg := err.Error()
+		for _, i := range changed {
+			results[i].ErrorCode = domain.ErrorUnknownServerError
+			results[i].ErrorMessage = &msg
+		}
+	}
+	return results, nil
+}
+
+func validateReplicas(replicas []int32, registered map[int32]bool) string {
+	if len(replicas) == 0 {
+		return "replica assignment is empty"
+	}
+	for i, id := range replicas {
+		if slices.Contains(replicas[:i], id) {
+			return "replica assignment contains duplicate brokers"
+		}
+		if !registered[id] {
+			return "replica assignment contains unregistered brokers"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,215 @@
+package controller
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+)
+
+func (c *Controller) CreateTopics(
+	topics []domain.TopicCreation,
+	validateOnly bool,
+) ([]domain.TopicCreationResult, error) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	alive, ok := c.livenessLocked(true)
+	if !ok {
+		return nil, domain.ErrNotController
+	}
+	brokers, registered := c.placementBrokersLocked(alive)
+
+	requested := map[string]int{}
+	for _, t := range topics {
+		requested[t.Name]++
+	}
+
+	results := make([]domain.TopicCreationResult, 0, len(topics))
+	created := make([]*domain.TopicMetadata, 0)
+	changed := make([]int, 0)
+
+	for _, t := range topics {
+		result := domain.TopicCreationResult{Name: t.Name, NumPartitions: -1, ReplicationFactor: -1}
+
+		var (
+			meta *domain.TopicMetadata
+			code int16
+			msg  string
+		)
+		if requested[t.Name] > 1 {
+			code, msg = domain.ErrorInvalidRequest, "create topics request contains multiple entries for the topic"
+		} else {
+			meta, code, msg = c.newTopic(t, brokers, registered)
+		}
+
+		if code != 0 {
+			result.ErrorCode = code
+			result.ErrorMessage = &msg
+			results = append(results, result)
+			continue
+		}
+
+		result.NumPartitions = int32(len(meta.Partitions))
+		result.ReplicationFactor = int16(len(meta.Partitions[0].Replicas))
+		result.Configs = meta.Configs
+		if !validateOnly {
+			result.TopicID = meta.TopicID
+			created = append(created, meta)
+			changed = append(changed, len(results))
+		}
+		results = append(results, result)
+	}
+
+	if len(created) == 0 {
+		return results, nil
+	}
+
+	if err := c.writer.CreateTopics(created); err != nil {
+		msg := err.Error()
+		for _, i := range changed {
+			results[i].ErrorCode = domain.ErrorUnknownServerError
+			results[i].ErrorMessage = &msg
+			results[i].TopicID = [16]byte{}
+		}
+	}
+	return results, nil
+}
+
+func (c *Controller) newTopic(
+	t domain.TopicCreation,
+	brokers []domain.BrokerPlacement,
+	registered map[int32]bool,
+) (*domain.TopicMetadata, int16, string) {
+
+	if msg := domain.ValidateTopicName(t.Name); msg != "" {
+		return nil, domain.ErrorInvalidTopic, msg
+	}
+	if meta, err := c.repo.GetTopic(t.Name); err == nil && meta != nil {
+		return nil, domain.ErrorTopicAlreadyExists, fmt.Sprintf("topic '%s' already exists", t.Name)
+	}
+
+	configs := map[string]string{}
+	for _, cfg := range t.Configs {
+		if cfg.Value == nil {
+			return nil, domain.ErrorInvalidConfig, "null value not supported for topic config " + cfg.Name
+		}
+		def, ok := domain.TopicConfigDefinition(cfg.Name)
+		if !ok {
+			return nil, domain.ErrorInvalidConfig, "unknown topic config name: " + cfg.Name
+		}
+		if msg := def.Validate(*cfg.Value); msg != "" {
+			return nil, domain.ErrorInvalidConfig, msg
+		}
+		configs[cfg.Name] = *cfg.Value
+	}
+
+	var assignment [][]int32
+	if len(t.Assignments) > 0 {
+		if t.NumPartitions != -1 || t.ReplicationFactor != -1 {
+			return nil, domain.ErrorInvalidRequest,
+				"both numPartitions or replicationFactor and replicasAssignments were set, both cannot be used at the same time"
+		}
+
+		var msg string
+		if assignment, msg = domain.ManualAssignment(t.Assignments); msg != "" {
+			return nil, domain.ErrorInvalidReplicaAssignment, msg
+		}
+		for index, replicas := range assignment {
+			for _, id := range replicas {
+				if !registered[id] {
+					return nil, domain.ErrorInvalidReplicaAssignment,
+						fmt.Sprintf("partition %d is assigned to broker %d, but no such broker is registered", index, id)
+				}
+			}
+		}
+	} else {
+		partitions := t.NumPartitions
+		if partitions == -1 {
+			partitions = c.cfg.DefaultNumPartitions
+		}
+		if partitions <= 0 {
+			return nil, domain.ErrorInvalidPartitions, "number of partitions must be larger than 0"
+		}
+
+		factor := t.ReplicationFactor
+		if factor == -1 {
+			factor = c.cfg.DefaultReplicationFactor
+		}
+		if factor <= 0 {
+			return nil, domain.ErrorInvalidReplicationFactor, "replication factor must be larger than 0"
+		}
+		if int(factor) > len(brokers) {
+			return nil, domain.ErrorInvalidReplicationFactor,
+				fmt.Sprintf("unable to replicate the partition %d time(s): only %d broker(s) are available", factor, len(brokers))
+		}
+
+		assignment = domain.PlaceReplicas(brokers, partitions, factor, c.random(len(brokers)), c.random(len(brokers)))
+	}
+
+	id, err := newTopicID()
+	if err != nil {
+		return nil, domain.ErrorUnknownServerError, err.Error()
+	}
+
+	live := make(map[int32]bool, len(brokers))
+	for _, b := range brokers {
+		live[b.ID] = true
+	}
+
+	meta := &domain.TopicMetadata{Name: t.Name, TopicID: id, Configs: configs}
+	for index, replicas := range assignment {
+		isr := make([]int32, 0, len(replicas))
+		for _, r := range replicas {
+			if live[r] {
+				isr = append(isr, r)
+			}
+		}
+		if len(isr) == 0 {
+			return nil, domain.ErrorInvalidReplicaAssignment,
+				fmt.Sprintf("all brokers assigned to partition %d are unavailable", index)
+		}
+
+		meta.Partitions = append(meta.Partitions, domain.PartitionMetadata{
+			PartitionIndex: int32(index),
+			LeaderID:       isr[0],
+			Replicas:       replicas,
+			ISR:            isr,
+		})
+	}
+	return meta, 0, ""
+}
+
+func (c *Controller) placementBrokersLocked(alive func(int32) bool) ([]domain.BrokerPlacement, map[int32]bool) {
+	brokers := make([]domain.BrokerPlacement, 0)
+	registered := map[int32]bool{}
+
+	for _, b := range c.repo.Brokers() {
+		registered[b.ID] = true
+		if b.Fenced || !alive(b.ID) {
+			continue
+		}
+		rack := ""
+		if b.Rack != nil {
+			rack = *b.Rack
+		}
+		brokers = append(brokers, domain.BrokerPlacement{ID: b.ID, Rack: rack})
+	}
+
+	if !registered[c.cfg.NodeID] {
+		registered[c.cfg.NodeID] = true
+		brokers = append(brokers, domain.BrokerPlacement{ID: c.cfg.NodeID, Rack: c.cfg.Rack})
+	}
+	return brokers, registered
+}
+
+func newTopicID() ([16]byte, error) {
+	var id [16]byte
+	for id == ([16]byte{}) || id == domain.MetadataTopicID {
+		if _, err := rand.Read(id[:]); err != nil {
+			return id, err
+		}
+	}
+	return id, nil
+}
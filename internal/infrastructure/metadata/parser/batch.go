@@ -3,6 +3,16 @@ package parser
 import (
 	"encoding/binary"
 	"errors"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+	"github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/compression"
+)
+
+const (
+	batchLogOverhead     = 12
+	batchHeaderAfterSize = 49
+	batchCompressionMask = 0x07
+	batchControlFlag     = 0x20
 )
 
 type RecordBatch struct {
@@ -74,20 +84,58 @@ func parseRecordBatch(b []byte) (*RecordBatch, int, error) {
 		return nil, 0, errors.New("recordBatch: negative recordsLength")
 	}
 
+	codec := domain.CompressionType(rb.Attributes & batchCompressionMask)
+	if codec != domain.CompressionNone {
+		compressedLen := int(rb.BatchLength) - batchHeaderAfterSize
+		if compressedLen < 0 || len(b) < compressedLen {
+			return nil, 0, errors.New("recordBatch: buffer too small (compressed records)")
+		}
+
+		records, err := compression.Decompress(codec, b[:compressedLen], domain.MaxRequestSize)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if _, err := parseBatchRecords(&rb, records); err != nil {
+			return nil, 0, err
+		}
+		return &rb, batchLogOverhead + int(rb.BatchLength), nil
+	}
+
+	readBytes, err := parseBatchRecords(&rb, b)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	nbBufferBytesRead := lengthBufferBegin - len(b) + readBytes
+	return &rb, nbBufferBytesRead, nil
+}
+
+func parseBatchRecords(rb *RecordBatch, b []byte) (int, error) {
+	lengthBufferBegin := len(b)
+
 	rb.Records = make([]Record, 0, rb.RecordsLength)
 
+	parse := parseRecord
+	if rb.IsControl() {
+		parse = parseControlRecord
+	}
+
 	for i := 0; i < int(rb.RecordsLength); i++ {
-		rec, readBytes, err := parseRecord(b)
+		rec, readBytes, err := parse(b)
 		if err != nil {
-			return nil, 0, err
+			return 0, err
 		}
 		rb.Records = append(rb.Records, *rec)
 		if readBytes <= 0 || readBytes > len(b) {
-			return nil, 0, errors.New("recordBatch: invalid record size")
+			return 0, errors.New("recordBatch: invalid record size")
 		}
 		b = b[readBytes:]
 	}
 
-	nbBufferBytesRead := lengthBufferBegin - len(b)
-	return &rb, nbBufferBytesRead, nil
+	return lengthBufferBegin - len(b), nil
+}
+
+func (rb *RecordBatch) IsControl() bool {
+	return rb.Attributes&batchControlFlag != 0
 }
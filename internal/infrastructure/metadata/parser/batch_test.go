@@ -3,6 +3,9 @@ package parser
 import (
 	"encoding/binary"
 	"testing"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+	"github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/compression"
 )
 
 func TestParseRecordBatch_BufferTooSmall(t *testing.T) {
@@ -65,3 +68,81 @@ func TestParseRecordBatch_NoRecords(t *testing.T) {
 		t.Fatal("wrong bytes read")
 	}
 }
+
+func TestParseRecordBatch_Compressed(t *testing.T) {
+	value := []byte{0x01, topicRecordType, 0x00, byte(len("foo") + 1)}
+	value = append(value, []byte("foo")...)
+	value = append(value, make([]byte, 16)...)
+	value = append(value, 0x00)
+
+	rec := []byte{0x00}
+	rec = binary.AppendVarint(rec, 0)
+	rec = binary.AppendVarint(rec, 0)
+	rec = binary.AppendVarint(rec, -1)
+	rec = binary.AppendVarint(rec, int64(len(value)))
+	rec = append(rec, value...)
+	rec = binary.AppendUvarint(rec, 0)
+	records := binary.AppendVarint(nil, int64(len(rec)))
+	records = append(records, rec...)
+
+	compressed, err := compression.Compress(domain.CompressionGzip, records)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 8+4+4+1+4+2+4+8+8+8+2+4+4)
+	binary.BigEndian.PutUint32(buf[8:12], uint32(len(buf)-12+len(compressed)))
+	buf[16] = 2
+	binary.BigEndian.PutUint16(buf[21:23], uint16(domain.CompressionGzip))
+	binary.BigEndian.PutUint32(buf[57:61], 1)
+	buf = append(buf, compressed...)
+
+	rb, n, err := parseRecordBatch(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != len(buf) {
+		t.Fatal("wrong bytes read")
+	}
+
+	topic, ok := rb.Records[0].Value.(RecordTopic)
+	if !ok || topic.TopicName != "foo" {
+		t.Fatal("expected decompressed topic record")
+	}
+}
+
+func TestParseRecordBatch_ControlRecord(t *testing.T) {
+	key := []byte{0x00, 0x00, 0x00, 0x03}
+	value := []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01}
+
+	rec := []byte{0x00}
+	rec = binary.AppendVarint(rec, 0)
+	rec = binary.AppendVarint(rec, 0)
+	rec = binary.AppendVarint(rec, int64(len(key)))
+	rec = append(rec, key...)
+	rec = binary.AppendVarint(rec, int64(len(value)))
+	rec = append(rec, value...)
+	rec = binary.AppendUvarint(rec, 0)
+
+	buf := make([]byte, 8+4+4+1+4+2+4+8+8+8+2+4+4)
+	buf[16] = 2
+	binary.BigEndian.PutUint16(buf[21:23], batchControlFlag)
+	binary.BigEndian.PutUint32(buf[57:61], 1)
+	buf = binary.AppendVarint(buf, int64(len(rec)))
+	buf = append(buf, rec...)
+	binary.BigEndian.PutUint32(buf[8:12], uint32(len(buf)-12))
+
+	rb, _, err := parseRecordBatch(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctrl := rb.Records[0].Control
+	if ctrl == nil || ctrl.Type != 3 || len(ctrl.Value) != len(value) {
+		t.Fatal("expected snapshot header control record")
+	}
+	if rb.Records[0].Value != nil {
+		t.Fatal("control records carry no metadata value")
+	}
+}
@@ -0,0 +1,215 @@
+package parser
+
+type BrokerEndpoint struct {
+	Name             string
+	Host             string
+	Port             uint16
+	SecurityProtocol int16
+}
+
+type BrokerFeature struct {
+	Name                string
+	MinSupportedVersion int16
+	MaxSupportedVersion int16
+}
+
+type RecordRegisterBroker struct {
+	Header               recordHeader
+	Version              byte
+	BrokerID             int32
+	IsMigratingZkBroker  bool
+	IncarnationID        [16]byte
+	BrokerEpoch          int64
+	EndPoints            []BrokerEndpoint
+	Features             []BrokerFeature
+	Rack                 *string
+	Fenced               bool
+	InControlledShutdown bool
+	LogDirs              [][16]byte
+}
+
+type RecordUnregisterBroker struct {
+	Header      recordHeader
+	Version     byte
+	BrokerID    int32
+	BrokerEpoch int64
+}
+
+type RecordFenceBroker struct {
+	Header  recordHeader
+	Version byte
+	ID      int32
+	Epoch   int64
+}
+
+type RecordUnfenceBroker struct {
+	Header  recordHeader
+	Version byte
+	ID      int32
+	Epoch   int64
+}
+
+const (
+	BrokerRegistrationFenceNone    = 0
+	BrokerRegistrationFenced       = 1
+	BrokerRegistrationUnfenced     = -1
+	BrokerRegistrationShutdownNone = 0
+	BrokerRegistrationInShutdown   = 1
+)
+
+type RecordBrokerRegistrationChange struct {
+	Header               recordHeader
+	Version              byte
+	BrokerID             int32
+	BrokerEpoch          int64
+	Fenced               int8
+	InControlledShutdown int8
+	LogDirs              [][16]byte
+}
+
+type RecordRegisterController struct {
+	Header           recordHeader
+	Version          byte
+	ControllerID     int32
+	IncarnationID    [16]byte
+	ZkMigrationReady bool
+	EndPoints        []BrokerEndpoint
+	Features         []BrokerFeature
+}
+
+func readEndpoints(rd *reader) []BrokerEndpoint {
+	n := rd.arrayLen("endPoints")
+	out := make([]BrokerEndpoint, 0, max(n, 0))
+	for i := 0; i < n && rd.err == nil; i++ {
+		ep := BrokerEndpoint{
+			Name:             rd.compactString("endPoint name"),
+			Host:             rd.compactString("endPoint host"),
+			Port:             rd.uint16("endPoint port"),
+			SecurityProtocol: rd.int16("endPoint securityProtocol"),
+		}
+		rd.skipTaggedFields("endPoint taggedFields")
+		out = append(out, ep)
+	}
+	return out
+}
+
+func readFeatures(rd *reader) []BrokerFeature {
+	n := rd.arrayLen("features")
+	out := make([]BrokerFeature, 0, max(n, 0))
+	for i := 0; i < n && rd.err == nil; i++ {
+		f := BrokerFeature{
+			Name:                rd.compactString("feature name"),
+			MinSupportedVersion: rd.int16("feature minSupportedVersion"),
+			MaxSupportedVersion: rd.int16("feature maxSupportedVersion"),
+		}
+		rd.skipTaggedFields("feature taggedFields")
+		out = append(out, f)
+	}
+	return out
+}
+
+func newRecordRegisterBroker(header recordHeader, b []byte) (RecordRegisterBroker, error) {
+	rd := newReader(b, "registerBroker")
+	r := RecordRegisterBroker{Header: header, Fenced: true}
+
+	r.Version = rd.byte("version")
+	r.BrokerID = rd.int32("brokerId")
+	if r.Version >= 2 {
+		r.IsMigratingZkBroker = rd.bool("isMigratingZkBroker")
+	}
+	r.IncarnationID = rd.uuid("incarnationId")
+	r.BrokerEpoch = rd.int64("brokerEpoch")
+	r.EndPoints = readEndpoints(rd)
+	r.Features = readFeatures(rd)
+	r.Rack = rd.compactNullableString("rack")
+	r.Fenced = rd.bool("fenced")
+	if r.Version >= 1 {
+		r.InControlledShutdown = rd.bool("inControlledShutdown")
+	}
+	if r.Version >= 3 {
+		r.LogDirs = rd.compactUUIDArray("logDirs")
+	}
+	rd.skipTaggedFields("taggedFields")
+
+	return r, rd.err
+}
+
+func newRecordUnregisterBroker(header recordHeader, b []byte) (RecordUnregisterBroker, error) {
+	rd := newReader(b, "unregisterBroker")
+	r := RecordUnregisterBroker{Header: header}
+
+	r.Version = rd.byte("version")
+	r.BrokerID = rd.int32("brokerId")
+	r.BrokerEpoch = rd.int64("brokerEpoch")
+	rd.skipTaggedFields("taggedFields")
+
+	return r, rd.err
+}
+
+func newRecordFenceBroker(header recordHeader, b []byte) (RecordFenceBroker, error) {
+	rd := newReader(b, "fenceBroker")
+	r := RecordFenceBroker{Header: header}
+
+	r.Version = rd.byte("version")
+	r.ID = rd.int32("id")
+	r.Epoch = rd.int64("epoch")
+	rd.skipTaggedFields("taggedFields")
+
+	return r, rd.err
+}
+
+func newRecordUnfenceBroker(header recordHeader, b []byte) (RecordUnfenceBroker, error) {
+	rd := newReader(b, "unfenceBroker")
+	r := RecordUnfenceBroker{Header: header}
+
+	r.Version = rd.byte("version")
+	r.ID = rd.int32("id")
+	r.Epoch = rd.int64("epoch")
+	rd.skipTaggedFields("taggedFields")
+
+	return r, rd.err
+}
+
+func newRecordBrokerRegistrationChange(header recordHeader, b []byte) (RecordBrokerRegistrationChange, error) {
+	rd := newReader(b, "brokerRegistrationChange")
+	r := RecordBrokerRegistrationChange{Header: header}
+
+	r.Version = rd.byte("version")
+	r.BrokerID = rd.int32("brokerId")
+	r.BrokerEpoch = rd.int64("brokerEpoch")
+
+	rd.taggedFields("taggedFields", func(tag uint64, f *reader) {
+		switch tag {
+		case 0:
+			r.Fenced = f.int8("fenced")
+		case 1:
+			r.InControlledShutdown = f.int8("inControlledShutdown")
+		case 2:
+			r.LogDirs = f.compactUUIDArray("logDirs")
+		}
+	})
+
+	return r, rd.err
+}
+
+func newRecordRegisterController(header recordHeader, b []byte) (RecordRegisterController, error) {
+	rd := newReader(b, "registerController")
+	r := RecordRegisterController{Header: header}
+
+	r.Version = rd.byte("version")
+	r.ControllerID = rd.int32("controllerId")
+	r.IncarnationID = rd.uuid("incarnationId")
+	r.ZkMigrationReady = rd.bool("zkMigrationReady")
+	r.EndPoints = readEndpoints(rd)
+	r.Features = readFeatures(rd)
+	rd.skipTaggedFields("taggedFields")
+
+	return r, rd.err
+}
+
+func (r RecordRegisterBroker) GetRecordTypeId() byte           { return r.Header.GetRecordTypeId() }
+func (r RecordUnregisterBroker) GetRecordTypeId() byte         { return r.Header.GetRecordTypeId() }
+func (r RecordFenceBroker) GetRecordTypeId() byte              { return r.Header.GetRecordTypeId() }
+func (r RecordUnfenceBroker) GetRecordTypeId() byte            { return r.Header.GetRecordTypeId() }
+func (r RecordBrokerRegistrationChange) GetRecordTypeId() byte { return r.Header.GetRecordTypeId() }
+func (r RecordRegisterController) GetRecordTypeId() byte       { return r.Header.GetRecordTypeId() }
@@ -0,0 +1,70 @@
+package parser
+
+const (
+	ConfigResourceTopic  = 2
+	ConfigResourceBroker = 4
+)
+
+type RecordConfig struct {
+	Header       recordHeader
+	Version      byte
+	ResourceType int8
+	ResourceName string
+	Name         string
+	Value        *string
+}
+
+type QuotaEntity struct {
+	EntityType string
+	EntityName *string
+}
+
+type RecordClientQuota struct {
+	Header  recordHeader
+	Version byte
+	Entity  []QuotaEntity
+	Key     string
+	Value   float64
+	Remove  bool
+}
+
+func newRecordConfig(header recordHeader, b []byte) (RecordConfig, error) {
+	rd := newReader(b, "config")
+	r := RecordConfig{Header: header}
+
+	r.Version = rd.byte("version")
+	r.ResourceType = rd.int8("resourceType")
+	r.ResourceName = rd.compactString("resourceName")
+	r.Name = rd.compactString("name")
+	r.Value = rd.compactNullableString("value")
+	rd.skipTaggedFields("taggedFields")
+
+	return r, rd.err
+}
+
+func newRecordClientQuota(header recordHeader, b []byte) (RecordClientQuota, error) {
+	rd := newReader(b, "clientQuota")
+	r := RecordClientQuota{Header: header}
+
+	r.Version = rd.byte("version")
+
+	n := rd.arrayLen("entity")
+	for i := 0; i < n && rd.err == nil; i++ {
+		e := QuotaEntity{
+			EntityType: rd.compactString("entityType"),
+			EntityName: rd.compactNullableString("entityName"),
+		}
+		rd.skipTaggedFields("entity taggedFields")
+		r.Entity = append(r.Entity, e)
+	}
+
+	r.Key = rd.compactString("key")
+	r.Value = rd.float64("value")
+	r.Remove = rd.bool("remove")
+	rd.skipTaggedFields("taggedFields")
+
+	return r, rd.err
+}
+
+func (r RecordConfig) GetRecordTypeId() byte      { return r.Header.GetRecordTypeId() }
+func (r RecordClientQuota) GetRecordTypeId() byte { return r.Header.GetRecordTypeId() }
@@ -0,0 +1,74 @@
+package parser
+
+const (
+	ControlLeaderChange   = 2
+	ControlSnapshotHeader = 3
+	ControlSnapshotFooter = 4
+	ControlKRaftVersion   = 5
+	ControlKRaftVoters    = 6
+)
+
+type SnapshotHeader struct {
+	Version                   int16
+	LastContainedLogTimestamp int64
+}
+
+type SnapshotFooter struct {
+	Version int16
+}
+
+func ParseSnapshotHeader(c *ControlRecord) (SnapshotHeader, error) {
+	rd := newReader(c.Value, "snapshotHeader")
+
+	h := SnapshotHeader{
+		Version:                   rd.int16("version"),
+		LastContainedLogTimestamp: rd.int64("last contained log timestamp"),
+	}
+	rd.skipTaggedFields("tagged fields")
+
+	return h, rd.err
+}
+
+func ParseSnapshotFooter(c *ControlRecord) (SnapshotFooter, error) {
+	rd := newReader(c.Value, "snapshotFooter")
+
+	f := SnapshotFooter{Version: rd.int16("version")}
+	rd.skipTaggedFields("tagged fields")
+
+	return f, rd.err
+}
+
+type LeaderChange struct {
+	Version        int16
+	LeaderID       int32
+	Voters         []int32
+	GrantingVoters []int32
+}
+
+func ParseLeaderChange(c *ControlRecord) (LeaderChange, error) {
+	rd := newReader(c.Value, "leaderChange")
+
+	l := LeaderChange{
+		Version:  rd.int16("version"),
+		LeaderID: rd.int32("leader id"),
+	}
+	l.Voters = readVoters(rd, "voters")
+	l.GrantingVoters = readVoters(rd, "granting voters")
+	rd.skipTaggedFields("tagged fields")
+
+	return l, rd.err
+}
+
+func readVoters(rd *reader, what string) []int32 {
+	n := rd.arrayLen(what)
+	if n < 0 {
+		return nil
+	}
+
+	out := make([]int32, 0, n)
+	for i := 0; i < n && rd.err == nil; i++ {
+		out = append(out, rd.int32(what+" id"))
+		rd.skipTaggedFields(what + " tagged fields")
+	}
+	return out
+}
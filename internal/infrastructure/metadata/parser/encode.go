@@ -0,0 +1,282 @@
+package parser
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+)
+
+const (
+	recordFrameVersion = 1
+	controlKeyVersion  = 0
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+type ControlValue struct {
+	Type  int16
+	Value []byte
+}
+
+func EncodeFeatureLevel(name string, level int16) []byte {
+	w := newRecordWriter(featureLevelRecordType, 0)
+	w.compactString(name)
+	w.int16(level)
+	w.emptyTaggedFields()
+	return w.bytes()
+}
+
+func EncodeTopic(name string, id [16]byte) []byte {
+	w := newRecordWriter(topicRecordType, 0)
+	w.compactString(name)
+	w.uuid(id)
+	w.emptyTaggedFields()
+	return w.bytes()
+}
+
+func EncodeConfig(resourceType int8, resourceName string, name string, value *string) []byte {
+	w := newRecordWriter(configRecordType, 0)
+	w.int8(resourceType)
+	w.compactString(resourceName)
+	w.compactString(name)
+	w.compactNullableString(value)
+	w.emptyTaggedFields()
+	return w.bytes()
+}
+
+func EncodePartition(p RecordPartition) []byte {
+	version := byte(0)
+	if len(p.DirectoriesArray) > 0 {
+		version = 1
+	}
+
+	w := newRecordWriter(partitionRecordType, version)
+	w.int32(p.PartitionID)
+	w.uuid(p.TopicUUID)
+	w.compactInt32Array(p.ReplicaArray)
+	w.compactInt32Array(p.SyncReplicaArray)
+	w.compactInt32Array(p.RemovingReplicaArray)
+	w.compactInt32Array(p.AddingReplicaArray)
+	w.int32(p.Leader)
+	w.int32(p.LeaderEpoch)
+	w.int32(p.PartitionEpoch)
+	if version >= 1 {
+		w.compactUUIDArray(p.DirectoriesArray)
+	}
+
+	tags := make([]taggedField, 0, 3)
+	if p.LeaderRecoveryState != 0 {
+		tags = append(tags, taggedField{tag: 0, data: []byte{byte(p.LeaderRecoveryState)}})
+	}
+	if len(p.EligibleLeaderReplicas) > 0 {
+		tags = append(tags, taggedField{tag: 1, data: int32ArrayBytes(p.EligibleLeaderReplicas)})
+	}
+	if len(p.LastKnownELR) > 0 {
+		tags = append(tags, taggedField{tag: 2, data: int32ArrayBytes(p.LastKnownELR)})
+	}
+	w.taggedFields(tags)
+	return w.bytes()
+}
+
+func EncodePartitionChange(r RecordPartitionChange) []byte {
+	version := byte(0)
+	if len(r.Directories) > 0 {
+		version = 1
+	}
+
+	w := newRecordWriter(partitionChangeRecordType, version)
+	w.int32(r.PartitionID)
+	w.uuid(r.TopicUUID)
+
+	tags := make([]taggedField, 0, 9)
+	if r.ISR != nil {
+		tags = append(tags, taggedField{tag: 0, data: int32ArrayBytes(r.ISR)})
+	}
+	if r.Leader != NoLeaderChange {
+		leader := &writer{}
+		leader.int32(r.Leader)
+		tags = append(tags, taggedField{tag: 1, data: leader.bytes()})
+	}
+	if r.Replicas != nil {
+		tags = append(tags, taggedField{tag: 2, data: int32ArrayBytes(r.Replicas)})
+	}
+	if r.RemovingReplicas != nil {
+		tags = append(tags, taggedField{tag: 3, data: int32ArrayBytes(r.RemovingReplicas)})
+	}
+	if r.AddingReplicas != nil {
+		tags = append(tags, taggedField{tag: 4, data: int32ArrayBytes(r.AddingReplicas)})
+	}
+	if r.LeaderRecoveryState != NoLeaderRecoveryStateChange {
+		tags = append(tags, taggedField{tag: 5, data: []byte{byte(r.LeaderRecoveryState)}})
+	}
+	if r.EligibleLeaderReplicas != nil {
+		tags = append(tags, taggedField{tag: 6, data: int32ArrayBytes(r.EligibleLeaderReplicas)})
+	}
+	if r.LastKnownELR != nil {
+		tags = append(tags, taggedField{tag: 7, data: int32ArrayBytes(r.LastKnownELR)})
+	}
+	if version >= 1 {
+		dirs := &writer{}
+		dirs.compactUUIDArray(r.Directories)
+		tags = append(tags, taggedField{tag: 8, data: dirs.bytes()})
+	}
+	w.taggedFields(tags)
+	return w.bytes()
+}
+
+func EncodeRegisterBroker(r RecordRegisterBroker) []byte {
+	w := newRecordWriter(registerBrokerRecordType, 3)
+	w.int32(r.BrokerID)
+	w.bool(r.IsMigratingZkBroker)
+	w.uuid(r.IncarnationID)
+	w.int64(r.BrokerEpoch)
+	writeEndpoints(w, r.EndPoints)
+	writeFeatures(w, r.Features)
+	w.compactNullableString(r.Rack)
+	w.bool(r.Fenced)
+	w.bool(r.InControlledShutdown)
+	w.compactUUIDArray(r.LogDirs)
+	w.emptyTaggedFields()
+	return w.bytes()
+}
+
+func EncodeFenceBroker(id int32, epoch int64) []byte {
+	w := newRecordWriter(fenceBrokerRecordType, 0)
+	w.int32(id)
+	w.int64(epoch)
+	w.emptyTaggedFields()
+	return w.bytes()
+}
+
+func EncodeUnfenceBroker(id int32, epoch int64) []byte {
+	w := newRecordWriter(unfenceBrokerRecordType, 0)
+	w.int32(id)
+	w.int64(epoch)
+	w.emptyTaggedFields()
+	return w.bytes()
+}
+
+func EncodeRegisterController(r RecordRegisterController) []byte {
+	w := newRecordWriter(registerControllerRecordType, 0)
+	w.int32(r.ControllerID)
+	w.uuid(r.IncarnationID)
+	w.bool(r.ZkMigrationReady)
+	writeEndpoints(w, r.EndPoints)
+	writeFeatures(w, r.Features)
+	w.emptyTaggedFields()
+	return w.bytes()
+}
+
+func EncodeLeaderChange(leaderID int32, voters, grantingVoters []int32) ControlValue {
+	w := &writer{}
+	w.int16(0)
+	w.int32(leaderID)
+	writeVoters(w, voters)
+	writeVoters(w, grantingVoters)
+	w.emptyTaggedFields()
+	return ControlValue{Type: ControlLeaderChange, Value: w.bytes()}
+}
+
+func EncodeSnapshotHeader(lastContainedLogTimestamp int64) ControlValue {
+	w := &writer{}
+	w.int16(0)
+	w.int64(lastContainedLogTimestamp)
+	w.emptyTaggedFields()
+	return ControlValue{Type: ControlSnapshotHeader, Value: w.bytes()}
+}
+
+func EncodeSnapshotFooter() ControlValue {
+	w := &writer{}
+	w.int16(0)
+	w.emptyTaggedFields()
+	return ControlValue{Type: ControlSnapshotFooter, Value: w.bytes()}
+}
+
+func EncodeBatch(baseOffset int64, leaderEpoch int32, timestamp int64, values [][]byte) []byte {
+	keys := make([][]byte, len(values))
+	return encodeBatch(baseOffset, leaderEpoch, timestamp, 0, keys, values)
+}
+
+func EncodeControlBatch(baseOffset int64, leaderEpoch int32, timestamp int64, controls ...ControlValue) []byte {
+	keys := make([][]byte, 0, len(controls))
+	values := make([][]byte, 0, len(controls))
+	for _, c := range controls {
+		key := binary.BigEndian.AppendUint16(nil, controlKeyVersion)
+		key = binary.BigEndian.AppendUint16(key, uint16(c.Type))
+		keys = append(keys, key)
+		values = append(values, c.Value)
+	}
+	return encodeBatch(baseOffset, leaderEpoch, timestamp, batchControlFlag, keys, values)
+}
+
+func encodeBatch(baseOffset int64, leaderEpoch int32, timestamp int64, attributes uint16, keys, values [][]byte) []byte {
+	records := make([]byte, 0)
+	for i, v := range values {
+		rec := []byte{0}
+		rec = binary.AppendVarint(rec, 0)
+		rec = binary.AppendVarint(rec, int64(i))
+		if keys[i] == nil {
+			rec = binary.AppendVarint(rec, -1)
+		} else {
+			rec = binary.AppendVarint(rec, int64(len(keys[i])))
+			rec = append(rec, keys[i]...)
+		}
+		rec = binary.AppendVarint(rec, int64(len(v)))
+		rec = append(rec, v...)
+		rec = binary.AppendUvarint(rec, 0)
+
+		records = binary.AppendVarint(records, int64(len(rec)))
+		records = append(records, rec...)
+	}
+
+	body := binary.BigEndian.AppendUint16(nil, attributes)
+	body = binary.BigEndian.AppendUint32(body, uint32(len(values)-1))
+	body = binary.BigEndian.AppendUint64(body, uint64(timestamp))
+	body = binary.BigEndian.AppendUint64(body, uint64(timestamp))
+	body = binary.BigEndian.AppendUint64(body, ^uint64(0))
+	body = binary.BigEndian.AppendUint16(body, 0xffff)
+	body = binary.BigEndian.AppendUint32(body, 0xffffffff)
+	body = binary.BigEndian.AppendUint32(body, uint32(len(values)))
+	body = append(body, records...)
+
+	out := binary.BigEndian.AppendUint64(nil, uint64(baseOffset))
+	out = binary.BigEndian.AppendUint32(out, uint32(4+1+4+len(body)))
+	out = binary.BigEndian.AppendUint32(out, uint32(leaderEpoch))
+	out = append(out, 2)
+	out = binary.BigEndian.AppendUint32(out, crc32.Checksum(body, castagnoli))
+	return append(out, body...)
+}
+
+func writeEndpoints(w *writer, endpoints []BrokerEndpoint) {
+	w.uvarint(uint64(len(endpoints) + 1))
+	for _, ep := range endpoints {
+		w.compactString(ep.Name)
+		w.compactString(ep.Host)
+		w.int16(int16(ep.Port))
+		w.int16(ep.SecurityProtocol)
+		w.emptyTaggedFields()
+	}
+}
+
+func writeFeatures(w *writer, features []BrokerFeature) {
+	w.uvarint(uint64(len(features) + 1))
+	for _, f := range features {
+		w.compactString(f.Name)
+		w.int16(f.MinSupportedVersion)
+		w.int16(f.MaxSupportedVersion)
+		w.emptyTaggedFields()
+	}
+}
+
+func writeVoters(w *writer, voters []int32) {
+	w.uvarint(uint64(len(voters) + 1))
+	for _, id := range voters {
+		w.int32(id)
+		w.emptyTaggedFields()
+	}
+}
+
+func int32ArrayBytes(vs []int32) []byte {
+	w := &writer{}
+	w.compactInt32Array(vs)
+	return w.bytes()
+}
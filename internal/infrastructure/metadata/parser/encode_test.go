@@ -0,0 +1,118 @@
+package parser
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"testing"
+)
+
+func TestEncodeBatch_RoundTrip(t *testing.T) {
+	raw := EncodeBatch(7, 3, 1000, [][]byte{
+		EncodeFeatureLevel("metadata.version", 21),
+		EncodeFeatureLevel("kraft.version", 1),
+	})
+
+	if crc32.Checksum(raw[21:], castagnoli) != binary.BigEndian.Uint32(raw[17:21]) {
+		t.Fatal("invalid crc")
+	}
+
+	batches, err := Decode(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batches) != 1 || batches[0].BaseOffset != 7 || batches[0].PartitionLeaderEpoch != 3 {
+		t.Fatalf("unexpected batches %+v", batches)
+	}
+
+	recs := batches[0].Records
+	if len(recs) != 2 || recs[1].OffsetDelta != 1 {
+		t.Fatalf("unexpected records %+v", recs)
+	}
+
+	f, ok := recs[0].Value.(RecordFeatureLevel)
+	if !ok || f.Name != "metadata.version" || f.FeatureLevel != 21 {
+		t.Fatalf("unexpected record %+v", recs[0].Value)
+	}
+}
+
+func TestEncodePartition_TaggedFieldsRoundTrip(t *testing.T) {
+	raw := EncodeBatch(0, 1, 0, [][]byte{EncodePartition(RecordPartition{
+		PartitionID:            2,
+		TopicUUID:              [16]byte{7},
+		ReplicaArray:           []int32{1, 2, 3},
+		SyncReplicaArray:       []int32{1},
+		Leader:                 1,
+		LeaderRecoveryState:    1,
+		LeaderEpoch:            4,
+		PartitionEpoch:         5,
+		DirectoriesArray:       [][16]byte{{1}, {2}, {3}},
+		EligibleLeaderReplicas: []int32{2},
+		LastKnownELR:           []int32{3},
+	})})
+
+	batches, err := Decode(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, ok := batches[0].Records[0].Value.(RecordPartition)
+	if !ok {
+		t.Fatalf("unexpected record %+v", batches[0].Records[0].Value)
+	}
+	if p.Version != 1 || p.PartitionID != 2 || p.LeaderEpoch != 4 || len(p.DirectoriesArray) != 3 {
+		t.Fatalf("unexpected partition %+v", p)
+	}
+	if p.LeaderRecoveryState != 1 || len(p.EligibleLeaderReplicas) != 1 || p.LastKnownELR[0] != 3 {
+		t.Fatalf("unexpected tagged fields %+v", p)
+	}
+}
+
+func TestEncodePartitionChange_OmitsUnchangedFields(t *testing.T) {
+	raw := EncodeBatch(0, 1, 0, [][]byte{EncodePartitionChange(RecordPartitionChange{
+		PartitionID:         3,
+		TopicUUID:           [16]byte{7},
+		ISR:                 []int32{1, 2},
+		Leader:              NoLeaderChange,
+		LeaderRecoveryState: NoLeaderRecoveryStateChange,
+	})})
+
+	batches, err := Decode(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, ok := batches[0].Records[0].Value.(RecordPartitionChange)
+	if !ok {
+		t.Fatalf("unexpected record %+v", batches[0].Records[0].Value)
+	}
+	if c.PartitionID != 3 || c.TopicUUID != [16]byte{7} || len(c.ISR) != 2 || c.ISR[1] != 2 {
+		t.Fatalf("unexpected partition change %+v", c)
+	}
+	if c.Leader != NoLeaderChange || c.Replicas != nil || c.LeaderRecoveryState != NoLeaderRecoveryStateChange {
+		t.Fatalf("unexpected unchanged fields %+v", c)
+	}
+}
+
+func TestEncodeControlBatch_LeaderChange(t *testing.T) {
+	raw := EncodeControlBatch(12, 3, 1000, EncodeLeaderChange(1, []int32{1, 2, 3}, []int32{1, 2}))
+
+	batches, err := Decode(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !batches[0].IsControl() || batches[0].BaseOffset != 12 || batches[0].PartitionLeaderEpoch != 3 {
+		t.Fatalf("unexpected batch %+v", batches[0])
+	}
+
+	c := batches[0].Records[0].Control
+	if c == nil || c.Type != ControlLeaderChange {
+		t.Fatalf("unexpected control record %+v", batches[0].Records[0])
+	}
+	l, err := ParseLeaderChange(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.LeaderID != 1 || len(l.Voters) != 3 || len(l.GrantingVoters) != 2 {
+		t.Fatalf("unexpected leader change %+v", l)
+	}
+}
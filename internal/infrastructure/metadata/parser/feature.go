@@ -1,10 +1,22 @@
 package parser
 
-type recordFeatureLevel struct {
-	recordHeader      recordHeader
-	version           byte
-	nameLength        byte
-	name              string
-	featureLevel      int16
-	taggedFieldsCount byte
+type RecordFeatureLevel struct {
+	Header       recordHeader
+	Version      byte
+	Name         string
+	FeatureLevel int16
 }
+
+func newRecordFeatureLevel(header recordHeader, b []byte) (RecordFeatureLevel, error) {
+	rd := newReader(b, "featureLevel")
+	r := RecordFeatureLevel{Header: header}
+
+	r.Version = rd.byte("version")
+	r.Name = rd.compactString("name")
+	r.FeatureLevel = rd.int16("featureLevel")
+	rd.skipTaggedFields("taggedFieldsCount")
+
+	return r, rd.err
+}
+
+func (r RecordFeatureLevel) GetRecordTypeId() byte { return r.Header.GetRecordTypeId() }
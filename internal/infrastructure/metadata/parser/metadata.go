@@ -1,6 +1,9 @@
 package parser
 
-import "errors"
+import (
+	"encoding/binary"
+	"errors"
+)
 
 func parseMetadata(b []byte) ([]RecordBatch, error) {
 	res := make([]RecordBatch, 0)
@@ -23,3 +26,29 @@ func parseMetadata(b []byte) ([]RecordBatch, error) {
 func Decode(raw []byte) ([]RecordBatch, error) {
 	return parseMetadata(raw)
 }
+
+func DecodeComplete(raw []byte) ([]RecordBatch, int, error) {
+	res := make([]RecordBatch, 0)
+	consumed := 0
+
+	for len(raw)-consumed >= batchLogOverhead {
+		b := raw[consumed:]
+
+		size := batchLogOverhead + int(int32(binary.BigEndian.Uint32(b[8:12])))
+		if size <= batchLogOverhead {
+			return nil, 0, errors.New("parseMetadata: invalid batch size")
+		}
+		if len(b) < size {
+			break
+		}
+
+		rb, _, err := parseRecordBatch(b[:size])
+		if err != nil {
+			return nil, 0, err
+		}
+		res = append(res, *rb)
+		consumed += size
+	}
+
+	return res, consumed, nil
+}
@@ -81,3 +81,18 @@ func TestParseMetadata_Garbage(t *testing.T) {
 		t.Fatal("expected error")
 	}
 }
+
+func TestDecodeComplete_StopsAtPartialBatch(t *testing.T) {
+	batch := makeEmptyRecordBatch()
+	binary.BigEndian.PutUint32(batch[8:12], 49)
+
+	raw := append(append([]byte{}, batch...), batch[:30]...)
+
+	res, consumed, err := DecodeComplete(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 || consumed != len(batch) {
+		t.Fatalf("expected one complete batch, got %d consumed %d", len(res), consumed)
+	}
+}
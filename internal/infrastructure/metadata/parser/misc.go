@@ -0,0 +1,122 @@
+package parser
+
+type RecordProducerIds struct {
+	Header         recordHeader
+	Version        byte
+	BrokerID       int32
+	BrokerEpoch    int64
+	NextProducerID int64
+}
+
+type RecordNoOp struct {
+	Header  recordHeader
+	Version byte
+}
+
+type RecordZkMigrationState struct {
+	Header           recordHeader
+	Version          byte
+	ZkMigrationState int8
+}
+
+type RecordBeginTransaction struct {
+	Header  recordHeader
+	Version byte
+	Name    *string
+}
+
+type RecordEndTransaction struct {
+	Header  recordHeader
+	Version byte
+}
+
+type RecordAbortTransaction struct {
+	Header  recordHeader
+	Version byte
+	Reason  *string
+}
+
+type RecordUnknown struct {
+	Header recordHeader
+	Data   []byte
+}
+
+func newRecordProducerIds(header recordHeader, b []byte) (RecordProducerIds, error) {
+	rd := newReader(b, "producerIds")
+	r := RecordProducerIds{Header: header}
+
+	r.Version = rd.byte("version")
+	r.BrokerID = rd.int32("brokerId")
+	r.BrokerEpoch = rd.int64("brokerEpoch")
+	r.NextProducerID = rd.int64("nextProducerId")
+	rd.skipTaggedFields("taggedFields")
+
+	return r, rd.err
+}
+
+func newRecordNoOp(header recordHeader, b []byte) (RecordNoOp, error) {
+	rd := newReader(b, "noOp")
+	r := RecordNoOp{Header: header}
+
+	r.Version = rd.byte("version")
+	rd.skipTaggedFields("taggedFields")
+
+	return r, rd.err
+}
+
+func newRecordZkMigrationState(header recordHeader, b []byte) (RecordZkMigrationState, error) {
+	rd := newReader(b, "zkMigrationState")
+	r := RecordZkMigrationState{Header: header}
+
+	r.Version = rd.byte("version")
+	r.ZkMigrationState = rd.int8("zkMigrationState")
+	rd.skipTaggedFields("taggedFields")
+
+	return r, rd.err
+}
+
+func newRecordBeginTransaction(header recordHeader, b []byte) (RecordBeginTransaction, error) {
+	rd := newReader(b, "beginTransaction")
+	r := RecordBeginTransaction{Header: header}
+
+	r.Version = rd.byte("version")
+	rd.taggedFields("taggedFields", func(tag uint64, f *reader) {
+		if tag == 0 {
+			r.Name = f.compactNullableString("name")
+		}
+	})
+
+	return r, rd.err
+}
+
+func newRecordEndTransaction(header recordHeader, b []byte) (RecordEndTransaction, error) {
+	rd := newReader(b, "endTransaction")
+	r := RecordEndTransaction{Header: header}
+
+	r.Version = rd.byte("version")
+	rd.skipTaggedFields("taggedFields")
+
+	return r, rd.err
+}
+
+func newRecordAbortTransaction(header recordHeader, b []byte) (RecordAbortTransaction, error) {
+	rd := newReader(b, "abortTransaction")
+	r := RecordAbortTransaction{Header: header}
+
+	r.Version = rd.byte("version")
+	rd.taggedFields("taggedFields", func(tag uint64, f *reader) {
+		if tag == 0 {
+			r.Reason = f.compactNullableString("reason")
+		}
+	})
+
+	return r, rd.err
+}
+
+func (r RecordProducerIds) GetRecordTypeId() byte      { return r.Header.GetRecordTypeId() }
+func (r RecordNoOp) GetRecordTypeId() byte             { return r.Header.GetRecordTypeId() }
+func (r RecordZkMigrationState) GetRecordTypeId() byte { return r.Header.GetRecordTypeId() }
+func (r RecordBeginTransaction) GetRecordTypeId() byte { return r.Header.GetRecordTypeId() }
+func (r RecordEndTransaction) GetRecordTypeId() byte   { return r.Header.GetRecordTypeId() }
+func (r RecordAbortTransaction) GetRecordTypeId() byte { return r.Header.GetRecordTypeId() }
+func (r RecordUnknown) GetRecordTypeId() byte          { return r.Header.GetRecordTypeId() }
@@ -0,0 +1,207 @@
+package parser
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+type reader struct {
+	b   []byte
+	ctx string
+	err error
+}
+
+func newReader(b []byte, ctx string) *reader {
+	return &reader{b: b, ctx: ctx}
+}
+
+func (r *reader) fail(what string) {
+	if r.err == nil {
+		r.err = errors.New(r.ctx + ": buffer too small (" + what + ")")
+	}
+}
+
+func (r *reader) take(n int, what string) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || len(r.b) < n {
+		r.fail(what)
+		return nil
+	}
+	out := r.b[:n]
+	r.b = r.b[n:]
+	return out
+}
+
+func (r *reader) remaining() int {
+	return len(r.b)
+}
+
+func (r *reader) uvarint(what string) uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n, err := readUvarint(r.b)
+	if err != nil {
+		r.fail(what)
+		return 0
+	}
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *reader) int8(what string) int8 {
+	b := r.take(1, what)
+	if b == nil {
+		return 0
+	}
+	return int8(b[0])
+}
+
+func (r *reader) byte(what string) byte {
+	return byte(r.int8(what))
+}
+
+func (r *reader) bool(what string) bool {
+	return r.int8(what) != 0
+}
+
+func (r *reader) int16(what string) int16 {
+	b := r.take(2, what)
+	if b == nil {
+		return 0
+	}
+	return int16(binary.BigEndian.Uint16(b))
+}
+
+func (r *reader) uint16(what string) uint16 {
+	return uint16(r.int16(what))
+}
+
+func (r *reader) int32(what string) int32 {
+	b := r.take(4, what)
+	if b == nil {
+		return 0
+	}
+	return int32(binary.BigEndian.Uint32(b))
+}
+
+func (r *reader) int64(what string) int64 {
+	b := r.take(8, what)
+	if b == nil {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(b))
+}
+
+func (r *reader) float64(what string) float64 {
+	return math.Float64frombits(uint64(r.int64(what)))
+}
+
+func (r *reader) uuid(what string) [16]byte {
+	var id [16]byte
+	copy(id[:], r.take(16, what))
+	return id
+}
+
+func (r *reader) arrayLen(what string) int {
+	n := r.uvarint(what)
+	if r.err != nil {
+		return -1
+	}
+	if n > uint64(len(r.b))+1 {
+		r.fail(what)
+		return -1
+	}
+	return int(n) - 1
+}
+
+func (r *reader) compactString(what string) string {
+	s := r.compactNullableString(what)
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func (r *reader) compactNullableString(what string) *string {
+	b := r.compactBytes(what)
+	if b == nil {
+		return nil
+	}
+	s := string(b)
+	return &s
+}
+
+func (r *reader) compactBytes(what string) []byte {
+	n := r.arrayLen(what)
+	if n < 0 {
+		return nil
+	}
+	b := r.take(n, what)
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
+
+func (r *reader) compactInt32Array(what string) []int32 {
+	n := r.arrayLen(what)
+	if n < 0 {
+		return nil
+	}
+	out := make([]int32, n)
+	for i := range out {
+		out[i] = r.int32(what)
+	}
+	return out
+}
+
+func (r *reader) compactUUIDArray(what string) [][16]byte {
+	n := r.arrayLen(what)
+	if n < 0 {
+		return nil
+	}
+	out := make([][16]byte, n)
+	for i := range out {
+		out[i] = r.uuid(what)
+	}
+	return out
+}
+
+func (r *reader) compactStringArray(what string) []string {
+	n := r.arrayLen(what)
+	if n < 0 {
+		return nil
+	}
+	out := make([]string, n)
+	for i := range out {
+		out[i] = r.compactString(what)
+	}
+	return out
+}
+
+func (r *reader) taggedFields(what string, field func(tag uint64, data *reader)) {
+	count := r.uvarint(what)
+	for i := uint64(0); i < count && r.err == nil; i++ {
+		tag := r.uvarint(what)
+		size := r.uvarint(what)
+		data := r.take(int(size), what)
+		if r.err != nil {
+			return
+		}
+		if field != nil {
+			sub := newReader(data, r.ctx)
+			field(tag, sub)
+			if sub.err != nil {
+				r.err = sub.err
+			}
+		}
+	}
+}
+
+func (r *reader) skipTaggedFields(what string) {
+	r.taggedFields(what, nil)
+}
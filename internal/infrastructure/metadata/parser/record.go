@@ -7,7 +7,15 @@ type recordI interface {
 }
 
 type Record struct {
-	Value recordI
+	OffsetDelta int32
+	Value       recordI
+	Control     *ControlRecord
+}
+
+type ControlRecord struct {
+	Version int16
+	Type    int16
+	Value   []byte
 }
 
 func parseRecordValue(b []byte) (recordI, error) {
@@ -22,87 +30,166 @@ func parseRecordValue(b []byte) (recordI, error) {
 	b = b[2:]
 
 	switch header.GetRecordTypeId() {
-	case featureLevelRecordType:
-		return newRecordFeatureLevel(header, b)
+	case registerBrokerRecordType:
+		return newRecordRegisterBroker(header, b)
+	case unregisterBrokerRecordType:
+		return newRecordUnregisterBroker(header, b)
 	case topicRecordType:
 		return newRecordTopic(header, b)
 	case partitionRecordType:
 		return newRecordPartition(header, b)
+	case configRecordType:
+		return newRecordConfig(header, b)
+	case partitionChangeRecordType:
+		return newRecordPartitionChange(header, b)
+	case accessControlEntryRecordType:
+		return newRecordAccessControlEntry(header, b)
+	case fenceBrokerRecordType:
+		return newRecordFenceBroker(header, b)
+	case unfenceBrokerRecordType:
+		return newRecordUnfenceBroker(header, b)
+	case removeTopicRecordType:
+		return newRecordRemoveTopic(header, b)
+	case delegationTokenRecordType:
+		return newRecordDelegationToken(header, b)
+	case userScramCredentialRecordType:
+		return newRecordUserScramCredential(header, b)
+	case featureLevelRecordType:
+		return newRecordFeatureLevel(header, b)
+	case clientQuotaRecordType:
+		return newRecordClientQuota(header, b)
+	case producerIdsRecordType:
+		return newRecordProducerIds(header, b)
+	case brokerRegistrationChangeRecordType:
+		return newRecordBrokerRegistrationChange(header, b)
+	case removeAccessControlEntryRecordType:
+		return newRecordRemoveAccessControlEntry(header, b)
+	case removeDelegationTokenRecordType:
+		return newRecordRemoveDelegationToken(header, b)
+	case noOpRecordType:
+		return newRecordNoOp(header, b)
+	case zkMigrationStateRecordType:
+		return newRecordZkMigrationState(header, b)
+	case removeUserScramCredentialRecordType:
+		return newRecordRemoveUserScramCredential(header, b)
+	case beginTransactionRecordType:
+		return newRecordBeginTransaction(header, b)
+	case endTransactionRecordType:
+		return newRecordEndTransaction(header, b)
+	case abortTransactionRecordType:
+		return newRecordAbortTransaction(header, b)
+	case registerControllerRecordType:
+		return newRecordRegisterController(header, b)
 	default:
-		return nil, nil
+		return RecordUnknown{Header: header, Data: append([]byte{}, b...)}, nil
 	}
 }
 
 func parseRecord(b []byte) (*Record, int, error) {
+	fields, n, err := readRecordFields(b)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	val, err := parseRecordValue(fields.value)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return &Record{OffsetDelta: fields.offsetDelta, Value: val}, n, nil
+}
+
+func parseControlRecord(b []byte) (*Record, int, error) {
+	fields, n, err := readRecordFields(b)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rd := newReader(fields.key, "controlRecord")
+	ctrl := &ControlRecord{
+		Version: rd.int16("key version"),
+		Type:    rd.int16("key type"),
+		Value:   append([]byte{}, fields.value...),
+	}
+	if rd.err != nil {
+		return nil, 0, rd.err
+	}
+
+	return &Record{OffsetDelta: fields.offsetDelta, Control: ctrl}, n, nil
+}
+
+type recordFields struct {
+	offsetDelta int32
+	key         []byte
+	value       []byte
+}
+
+func readRecordFields(b []byte) (recordFields, int, error) {
 	lengthBufferBegin := len(b)
 
 	if len(b) < 9 {
-		return nil, 0, errors.New("record: buffer too small")
+		return recordFields{}, 0, errors.New("record: buffer too small")
 	}
 
 	_, n, err := readVarint(b)
 	if err != nil {
-		return nil, 0, err
+		return recordFields{}, 0, err
 	}
 	b = b[n:]
 
 	if len(b) < 1 {
-		return nil, 0, errors.New("record: buffer too small (attributes)")
+		return recordFields{}, 0, errors.New("record: buffer too small (attributes)")
 	}
 	b = b[1:]
 
 	_, n, err = readVarint(b)
 	if err != nil {
-		return nil, 0, err
+		return recordFields{}, 0, err
 	}
 	b = b[n:]
 
-	_, n, err = readVarint(b)
+	offsetDelta, n, err := readVarint(b)
 	if err != nil {
-		return nil, 0, err
+		return recordFields{}, 0, err
 	}
 	b = b[n:]
 
 	keyLen, n, err := readVarint(b)
 	if err != nil {
-		return nil, 0, err
+		return recordFields{}, 0, err
 	}
 	b = b[n:]
 
+	var key []byte
 	if keyLen != -1 {
-		if len(b) < int(keyLen) {
-			return nil, 0, errors.New("record: buffer too small (key)")
+		if keyLen < 0 || len(b) < int(keyLen) {
+			return recordFields{}, 0, errors.New("record: buffer too small (key)")
 		}
+		key = b[:keyLen]
 		b = b[keyLen:]
 	}
 
 	valueLen, n, err := readVarint(b)
 	if err != nil {
-		return nil, 0, err
+		return recordFields{}, 0, err
 	}
 	b = b[n:]
 
 	if valueLen < 0 {
-		return nil, 0, errors.New("record: negative valueLength")
+		return recordFields{}, 0, errors.New("record: negative valueLength")
 	}
 	if len(b) < int(valueLen) {
-		return nil, 0, errors.New("record: buffer too small (value)")
+		return recordFields{}, 0, errors.New("record: buffer too small (value)")
 	}
 
-	valueBytes := b[:valueLen]
+	value := b[:valueLen]
 	b = b[valueLen:]
 
-	val, err := parseRecordValue(valueBytes)
-	if err != nil {
-		return nil, 0, err
-	}
-
 	_, n, err = readUvarint(b)
 	if err != nil {
-		return nil, 0, err
+		return recordFields{}, 0, err
 	}
 	b = b[n:]
 
-	nbBufferBytesRead := lengthBufferBegin - len(b)
-	return &Record{Value: val}, nbBufferBytesRead, nil
+	return recordFields{offsetDelta: int32(offsetDelta), key: key, value: value}, lengthBufferBegin - len(b), nil
 }
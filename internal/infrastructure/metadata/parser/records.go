@@ -1,238 +1,114 @@
 package parser
 
-import (
-	"encoding/binary"
-	"errors"
-)
-
 type RecordPartition struct {
 	Header                 recordHeader
 	Version                byte
 	PartitionID            int32
 	TopicUUID              [16]byte
-	LengthReplicaArray     byte
 	ReplicaArray           []int32
-	LengthSyncReplicaArray byte
 	SyncReplicaArray       []int32
-	LengthRemovingReplicas byte
 	RemovingReplicaArray   []int32
-	LengthAddingReplicas   byte
 	AddingReplicaArray     []int32
 	Leader                 int32
+	LeaderRecoveryState    int8
 	LeaderEpoch            int32
 	PartitionEpoch         int32
-	LengthDirectoriesArray byte
 	DirectoriesArray       [][16]byte
-	TaggedFieldsCount      byte
-}
-
-func newRecordFeatureLevel(header recordHeader, b []byte) (recordFeatureLevel, error) {
-	r := recordFeatureLevel{recordHeader: header}
-
-	if len(b) < 1 {
-		return r, errors.New("featureLevel: buffer too small (version)")
-	}
-	r.version = b[0]
-	b = b[1:]
-
-	if len(b) < 1 {
-		return r, errors.New("featureLevel: buffer too small (nameLength)")
-	}
-	r.nameLength = b[0] - 1
-	b = b[1:]
-
-	if len(b) < int(r.nameLength) {
-		return r, errors.New("featureLevel: buffer too small (name)")
-	}
-	r.name = string(b[:r.nameLength])
-	b = b[r.nameLength:]
-
-	if len(b) < 2 {
-		return r, errors.New("featureLevel: buffer too small (featureLevel)")
-	}
-	r.featureLevel = int16(binary.BigEndian.Uint16(b[:2]))
-	b = b[2:]
-
-	if len(b) < 1 {
-		return r, errors.New("featureLevel: buffer too small (taggedFieldsCount)")
-	}
-	r.taggedFieldsCount = b[0]
-
-	return r, nil
-}
-
-func newRecordTopic(header recordHeader, b []byte) (RecordTopic, error) {
-	r := RecordTopic{Header: header}
-
-	if len(b) < 1 {
-		return r, errors.New("topic: buffer too small (version)")
-	}
-	r.Version = b[0]
-	b = b[1:]
-
-	if len(b) < 1 {
-		return r, errors.New("topic: buffer too small (nameLength)")
-	}
-	r.NameLength = b[0] - 1
-	b = b[1:]
-
-	if len(b) < int(r.NameLength) {
-		return r, errors.New("topic: buffer too small (name)")
-	}
-	r.TopicName = string(b[:r.NameLength])
-	b = b[r.NameLength:]
-
-	if len(b) < 16 {
-		return r, errors.New("topic: buffer too small (UUID)")
-	}
-	copy(r.TopicUUID[:], b[:16])
-	b = b[16:]
-
-	if len(b) < 1 {
-		return r, errors.New("topic: buffer too small (taggedFieldsCount)")
-	}
-	r.TaggedFieldsCnt = b[0]
-
-	return r, nil
+	EligibleLeaderReplicas []int32
+	LastKnownELR           []int32
 }
 
 func newRecordPartition(header recordHeader, b []byte) (RecordPartition, error) {
+	rd := newReader(b, "partition")
 	r := RecordPartition{Header: header}
 
-	if len(b) < 1 {
-		return r, errors.New("partition: buffer too small (version)")
-	}
-	r.Version = b[0]
-	b = b[1:]
-
-	if len(b) < 4 {
-		return r, errors.New("partition: buffer too small (partitionId)")
-	}
-	r.PartitionID = int32(binary.BigEndian.Uint32(b[:4]))
-	b = b[4:]
-
-	if len(b) < 16 {
-		return r, errors.New("partition: buffer too small (topicUUID)")
-	}
-	copy(r.TopicUUID[:], b[:16])
-	b = b[16:]
-
-	if len(b) < 1 {
-		return r, errors.New("partition: buffer too small (lengthReplicaArray)")
-	}
-	r.LengthReplicaArray = b[0] - 1
-	b = b[1:]
-
-	if r.LengthReplicaArray < 0 {
-		r.LengthReplicaArray = 0
-	}
-	r.ReplicaArray = make([]int32, r.LengthReplicaArray)
-	for i := range r.ReplicaArray {
-		if len(b) < 4 {
-			return r, errors.New("partition: buffer too small (replicaArray)")
+	r.Version = rd.byte("version")
+	r.PartitionID = rd.int32("partitionId")
+	r.TopicUUID = rd.uuid("topicUUID")
+	r.ReplicaArray = rd.compactInt32Array("replicaArray")
+	r.SyncReplicaArray = rd.compactInt32Array("syncReplicaArray")
+	r.RemovingReplicaArray = rd.compactInt32Array("removingReplicaArray")
+	r.AddingReplicaArray = rd.compactInt32Array("addingReplicaArray")
+	r.Leader = rd.int32("leader")
+	r.LeaderEpoch = rd.int32("leaderEpoch")
+	r.PartitionEpoch = rd.int32("partitionEpoch")
+
+	if r.Version >= 1 {
+		r.DirectoriesArray = rd.compactUUIDArray("directoriesArray")
+	}
+
+	rd.taggedFields("taggedFieldsCount", func(tag uint64, f *reader) {
+		switch tag {
+		case 0:
+			r.LeaderRecoveryState = f.int8("leaderRecoveryState")
+		case 1:
+			r.EligibleLeaderReplicas = f.compactInt32Array("eligibleLeaderReplicas")
+		case 2:
+			r.LastKnownELR = f.compactInt32Array("lastKnownElr")
 		}
-		r.ReplicaArray[i] = int32(binary.BigEndian.Uint32(b[:4]))
-		b = b[4:]
-	}
-
-	if len(b) < 1 {
-		return r, errors.New("partition: buffer too small (lengthSyncReplicaArray)")
-	}
-	r.LengthSyncReplicaArray = b[0] - 1
-	b = b[1:]
-
-	if r.LengthSyncReplicaArray < 0 {
-		r.LengthSyncReplicaArray = 0
-	}
-	r.SyncReplicaArray = make([]int32, r.LengthSyncReplicaArray)
-	for i := range r.SyncReplicaArray {
-		if len(b) < 4 {
-			return r, errors.New("partition: buffer too small (syncReplicaArray)")
-		}
-		r.SyncReplicaArray[i] = int32(binary.BigEndian.Uint32(b[:4]))
-		b = b[4:]
-	}
-
-	if len(b) < 1 {
-		return r, errors.New("partition: buffer too small (lengthRemovingReplicasArray)")
-	}
-	r.LengthRemovingReplicas = b[0] - 1
-	b = b[1:]
-
-	if r.LengthRemovingReplicas < 0 {
-		r.LengthRemovingReplicas = 0
-	}
-	r.RemovingReplicaArray = make([]int32, r.LengthRemovingReplicas)
-	for i := range r.RemovingReplicaArray {
-		if len(b) < 4 {
-			return r, errors.New("partition: buffer too small (removingReplicaArray)")
-		}
-		r.RemovingReplicaArray[i] = int32(binary.BigEndian.Uint32(b[:4]))
-		b = b[4:]
-	}
-
-	if len(b) < 1 {
-		return r, errors.New("partition: buffer too small (lengthAddingReplicasArray)")
-	}
-	r.LengthAddingReplicas = b[0] - 1
-	b = b[1:]
-
-	if r.LengthAddingReplicas < 0 {
-		r.LengthAddingReplicas = 0
-	}
-	r.AddingReplicaArray = make([]int32, r.LengthAddingReplicas)
-	for i := range r.AddingReplicaArray {
-		if len(b) < 4 {
-			return r, errors.New("partition: buffer too small (addingReplicaArray)")
-		}
-		r.AddingReplicaArray[i] = int32(binary.BigEndian.Uint32(b[:4]))
-		b = b[4:]
-	}
-
-	if len(b) < 4 {
-		return r, errors.New("partition: buffer too small (leader)")
-	}
-	r.Leader = int32(binary.BigEndian.Uint32(b[:4]))
-	b = b[4:]
+	})
 
-	if len(b) < 4 {
-		return r, errors.New("partition: buffer too small (leaderEpoch)")
-	}
-	r.LeaderEpoch = int32(binary.BigEndian.Uint32(b[:4]))
-	b = b[4:]
+	return r, rd.err
+}
 
-	if len(b) < 4 {
-		return r, errors.New("partition: buffer too small (partitionEpoch)")
-	}
-	r.PartitionEpoch = int32(binary.BigEndian.Uint32(b[:4]))
-	b = b[4:]
+type RecordPartitionChange struct {
+	Header                 recordHeader
+	Version                byte
+	PartitionID            int32
+	TopicUUID              [16]byte
+	ISR                    []int32
+	Leader                 int32
+	Replicas               []int32
+	RemovingReplicas       []int32
+	AddingReplicas         []int32
+	LeaderRecoveryState    int8
+	EligibleLeaderReplicas []int32
+	LastKnownELR           []int32
+	Directories            [][16]byte
+}
 
-	if len(b) < 1 {
-		return r, errors.New("partition: buffer too small (lengthDirectoriesArray)")
-	}
-	r.LengthDirectoriesArray = b[0] - 1
-	b = b[1:]
+const (
+	NoLeaderChange              = -2
+	NoLeaderRecoveryStateChange = -1
+)
 
-	if r.LengthDirectoriesArray < 0 {
-		r.LengthDirectoriesArray = 0
-	}
-	r.DirectoriesArray = make([][16]byte, r.LengthDirectoriesArray)
-	for i := range r.DirectoriesArray {
-		if len(b) < 16 {
-			return r, errors.New("partition: buffer too small (directoriesArray)")
+func newRecordPartitionChange(header recordHeader, b []byte) (RecordPartitionChange, error) {
+	rd := newReader(b, "partitionChange")
+	r := RecordPartitionChange{
+		Header:              header,
+		Leader:              NoLeaderChange,
+		LeaderRecoveryState: NoLeaderRecoveryStateChange,
+	}
+
+	r.Version = rd.byte("version")
+	r.PartitionID = rd.int32("partitionId")
+	r.TopicUUID = rd.uuid("topicUUID")
+
+	rd.taggedFields("taggedFields", func(tag uint64, f *reader) {
+		switch tag {
+		case 0:
+			r.ISR = f.compactInt32Array("isr")
+		case 1:
+			r.Leader = f.int32("leader")
+		case 2:
+			r.Replicas = f.compactInt32Array("replicas")
+		case 3:
+			r.RemovingReplicas = f.compactInt32Array("removingReplicas")
+		case 4:
+			r.AddingReplicas = f.compactInt32Array("addingReplicas")
+		case 5:
+			r.LeaderRecoveryState = f.int8("leaderRecoveryState")
+		case 6:
+			r.EligibleLeaderReplicas = f.compactInt32Array("eligibleLeaderReplicas")
+		case 7:
+			r.LastKnownELR = f.compactInt32Array("lastKnownElr")
+		case 8:
+			r.Directories = f.compactUUIDArray("directories")
 		}
-		copy(r.DirectoriesArray[i][:], b[:16])
-		b = b[16:]
-	}
-
-	if len(b) < 1 {
-		return r, errors.New("partition: buffer too small (taggedFieldsCount)")
-	}
-	r.TaggedFieldsCount = b[0]
+	})
 
-	return r, nil
+	return r, rd.err
 }
 
-func (r recordFeatureLevel) GetRecordTypeId() byte { return r.recordHeader.GetRecordTypeId() }
-func (r RecordTopic) GetRecordTypeId() byte        { return r.Header.GetRecordTypeId() }
-func (r RecordPartition) GetRecordTypeId() byte    { return r.Header.GetRecordTypeId() }
+func (r RecordPartition) GetRecordTypeId() byte       { return r.Header.GetRecordTypeId() }
+func (r RecordPartitionChange) GetRecordTypeId() byte { return r.Header.GetRecordTypeId() }
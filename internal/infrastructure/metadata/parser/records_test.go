@@ -20,7 +20,7 @@ func TestNewRecordFeatureLevel_OK(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if r.name != "test" {
+	if r.Name != "test" || r.FeatureLevel != 1 {
 		t.Fatal("wrong name")
 	}
 }
@@ -71,10 +71,7 @@ func TestNewRecordPartition_OK(t *testing.T) {
 
 	buf := []byte{}
 	buf = append(buf, 1)
-
-	binary.BigEndian.PutUint32(append(buf, 0, 0, 0, 0), 0)
-	buf = buf[:len(buf)+4]
-	binary.BigEndian.PutUint32(buf[len(buf)-4:], 1)
+	buf = binary.BigEndian.AppendUint32(buf, 1)
 
 	var uuid [16]byte
 	for i := range uuid {
@@ -111,3 +108,165 @@ func TestNewRecordPartition_BufferTooSmall(t *testing.T) {
 		t.Fatal("expected error")
 	}
 }
+
+type testWriter struct {
+	b []byte
+}
+
+func (w *testWriter) u8(v byte) *testWriter { w.b = append(w.b, v); return w }
+func (w *testWriter) i16(v int16) *testWriter {
+	w.b = binary.BigEndian.AppendUint16(w.b, uint16(v))
+	return w
+}
+func (w *testWriter) i32(v int32) *testWriter {
+	w.b = binary.BigEndian.AppendUint32(w.b, uint32(v))
+	return w
+}
+func (w *testWriter) i64(v int64) *testWriter {
+	w.b = binary.BigEndian.AppendUint64(w.b, uint64(v))
+	return w
+}
+func (w *testWriter) uvarint(v uint64) *testWriter {
+	w.b = binary.AppendUvarint(w.b, v)
+	return w
+}
+func (w *testWriter) str(s string) *testWriter {
+	w.uvarint(uint64(len(s) + 1))
+	w.b = append(w.b, s...)
+	return w
+}
+func (w *testWriter) uuid(id [16]byte) *testWriter { w.b = append(w.b, id[:]...); return w }
+func (w *testWriter) int32s(vs ...int32) *testWriter {
+	w.uvarint(uint64(len(vs) + 1))
+	for _, v := range vs {
+		w.i32(v)
+	}
+	return w
+}
+func (w *testWriter) tag(tag uint64, data []byte) *testWriter {
+	w.uvarint(tag)
+	w.uvarint(uint64(len(data)))
+	w.b = append(w.b, data...)
+	return w
+}
+
+func TestNewRecordPartition_TaggedFields(t *testing.T) {
+	w := &testWriter{}
+	w.u8(2).i32(0).uuid([16]byte{1})
+	w.int32s(1, 2, 3).int32s(1, 2).int32s().int32s()
+	w.i32(1).i32(5).i32(7)
+	w.uvarint(1)
+	w.uvarint(3)
+	w.tag(0, []byte{1})
+	w.tag(1, (&testWriter{}).int32s(3).b)
+	w.tag(2, (&testWriter{}).int32s(2).b)
+
+	r, err := newRecordPartition(recordHeader{typeId: partitionRecordType}, w.b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.LeaderRecoveryState != 1 {
+		t.Fatal("wrong leader recovery state")
+	}
+	if len(r.EligibleLeaderReplicas) != 1 || r.EligibleLeaderReplicas[0] != 3 {
+		t.Fatal("wrong eligible leader replicas")
+	}
+	if len(r.LastKnownELR) != 1 || r.LastKnownELR[0] != 2 {
+		t.Fatal("wrong last known elr")
+	}
+}
+
+func TestNewRecordPartitionChange_Defaults(t *testing.T) {
+	w := &testWriter{}
+	w.u8(0).i32(4).uuid([16]byte{9})
+	w.uvarint(1)
+	w.tag(0, (&testWriter{}).int32s(1).b)
+
+	r, err := newRecordPartitionChange(recordHeader{typeId: partitionChangeRecordType}, w.b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.PartitionID != 4 || len(r.ISR) != 1 {
+		t.Fatal("wrong partition change")
+	}
+	if r.Leader != NoLeaderChange || r.Replicas != nil {
+		t.Fatal("absent tagged fields must keep their defaults")
+	}
+	if r.LeaderRecoveryState != NoLeaderRecoveryStateChange {
+		t.Fatal("wrong default leader recovery state")
+	}
+}
+
+func TestNewRecordConfig_NullValue(t *testing.T) {
+	w := &testWriter{}
+	w.u8(0).u8(ConfigResourceTopic).str("orders").str("retention.ms").uvarint(0).uvarint(0)
+
+	r, err := newRecordConfig(recordHeader{typeId: configRecordType}, w.b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.ResourceName != "orders" || r.Name != "retention.ms" || r.Value != nil {
+		t.Fatal("wrong config record")
+	}
+}
+
+func TestParseRecordValue_AllTypes(t *testing.T) {
+	cases := map[byte][]byte{
+		registerBrokerRecordType: (&testWriter{}).u8(3).i32(1).u8(0).uuid([16]byte{}).i64(7).
+			uvarint(2).str("PLAINTEXT").str("localhost").i16(9092).i16(0).uvarint(0).
+			uvarint(2).str("metadata.version").i16(1).i16(20).uvarint(0).
+			str("rack-a").u8(0).u8(0).uvarint(1).uvarint(0).b,
+		unregisterBrokerRecordType:          (&testWriter{}).u8(0).i32(1).i64(2).uvarint(0).b,
+		removeTopicRecordType:               (&testWriter{}).u8(0).uuid([16]byte{1}).uvarint(0).b,
+		fenceBrokerRecordType:               (&testWriter{}).u8(0).i32(1).i64(2).uvarint(0).b,
+		unfenceBrokerRecordType:             (&testWriter{}).u8(0).i32(1).i64(2).uvarint(0).b,
+		producerIdsRecordType:               (&testWriter{}).u8(0).i32(1).i64(2).i64(1000).uvarint(0).b,
+		noOpRecordType:                      (&testWriter{}).u8(0).uvarint(0).b,
+		zkMigrationStateRecordType:          (&testWriter{}).u8(0).u8(1).uvarint(0).b,
+		removeUserScramCredentialRecordType: (&testWriter{}).u8(0).str("alice").u8(1).uvarint(0).b,
+		removeDelegationTokenRecordType:     (&testWriter{}).u8(0).str("token").uvarint(0).b,
+		removeAccessControlEntryRecordType:  (&testWriter{}).u8(0).uuid([16]byte{2}).uvarint(0).b,
+		endTransactionRecordType:            (&testWriter{}).u8(0).uvarint(0).b,
+		brokerRegistrationChangeRecordType: (&testWriter{}).u8(2).i32(1).i64(2).
+			uvarint(1).tag(0, []byte{0xff}).b,
+		clientQuotaRecordType: (&testWriter{}).u8(0).uvarint(2).str("user").str("alice").uvarint(0).
+			str("producer_byte_rate").i64(0).u8(0).uvarint(0).b,
+	}
+
+	for typ, body := range cases {
+		value := append([]byte{1, typ}, body...)
+
+		rec, err := parseRecordValue(value)
+		if err != nil {
+			t.Fatalf("type %d: %v", typ, err)
+		}
+		if rec == nil || rec.GetRecordTypeId() != typ {
+			t.Fatalf("type %d: not decoded", typ)
+		}
+	}
+
+	broker, _ := parseRecordValue(append([]byte{1, registerBrokerRecordType}, cases[registerBrokerRecordType]...))
+	rb := broker.(RecordRegisterBroker)
+	if rb.EndPoints[0].Port != 9092 || rb.Rack == nil || *rb.Rack != "rack-a" || rb.Fenced {
+		t.Fatal("wrong register broker record")
+	}
+
+	change, _ := parseRecordValue(append([]byte{1, brokerRegistrationChangeRecordType}, cases[brokerRegistrationChangeRecordType]...))
+	if change.(RecordBrokerRegistrationChange).Fenced != BrokerRegistrationUnfenced {
+		t.Fatal("wrong fenced state")
+	}
+}
+
+func TestParseRecordValue_UnknownType(t *testing.T) {
+	rec, err := parseRecordValue([]byte{1, 99, 0, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := rec.(RecordUnknown); !ok {
+		t.Fatal("expected unknown record")
+	}
+}
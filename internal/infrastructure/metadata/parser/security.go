@@ -0,0 +1,148 @@
+package parser
+
+type RecordAccessControlEntry struct {
+	Header         recordHeader
+	Version        byte
+	ID             [16]byte
+	ResourceType   int8
+	ResourceName   string
+	PatternType    int8
+	Principal      string
+	Host           string
+	Operation      int8
+	PermissionType int8
+}
+
+type RecordRemoveAccessControlEntry struct {
+	Header  recordHeader
+	Version byte
+	ID      [16]byte
+}
+
+type RecordUserScramCredential struct {
+	Header     recordHeader
+	Version    byte
+	Name       string
+	Mechanism  int8
+	Salt       []byte
+	StoredKey  []byte
+	ServerKey  []byte
+	Iterations int32
+}
+
+type RecordRemoveUserScramCredential struct {
+	Header    recordHeader
+	Version   byte
+	Name      string
+	Mechanism int8
+}
+
+type RecordDelegationToken struct {
+	Header              recordHeader
+	Version             byte
+	Owner               string
+	Requester           string
+	Renewers            []string
+	IssueTimestamp      int64
+	MaxTimestamp        int64
+	ExpirationTimestamp int64
+	TokenID             string
+}
+
+type RecordRemoveDelegationToken struct {
+	Header  recordHeader
+	Version byte
+	TokenID string
+}
+
+func newRecordAccessControlEntry(header recordHeader, b []byte) (RecordAccessControlEntry, error) {
+	rd := newReader(b, "accessControlEntry")
+	r := RecordAccessControlEntry{Header: header}
+
+	r.Version = rd.byte("version")
+	r.ID = rd.uuid("id")
+	r.ResourceType = rd.int8("resourceType")
+	r.ResourceName = rd.compactString("resourceName")
+	r.PatternType = rd.int8("patternType")
+	r.Principal = rd.compactString("principal")
+	r.Host = rd.compactString("host")
+	r.Operation = rd.int8("operation")
+	r.PermissionType = rd.int8("permissionType")
+	rd.skipTaggedFields("taggedFields")
+
+	return r, rd.err
+}
+
+func newRecordRemoveAccessControlEntry(header recordHeader, b []byte) (RecordRemoveAccessControlEntry, error) {
+	rd := newReader(b, "removeAccessControlEntry")
+	r := RecordRemoveAccessControlEntry{Header: header}
+
+	r.Version = rd.byte("version")
+	r.ID = rd.uuid("id")
+	rd.skipTaggedFields("taggedFields")
+
+	return r, rd.err
+}
+
+func newRecordUserScramCredential(header recordHeader, b []byte) (RecordUserScramCredential, error) {
+	rd := newReader(b, "userScramCredential")
+	r := RecordUserScramCredential{Header: header}
+
+	r.Version = rd.byte("version")
+	r.Name = rd.compactString("name")
+	r.Mechanism = rd.int8("mechanism")
+	r.Salt = rd.compactBytes("salt")
+	r.StoredKey = rd.compactBytes("storedKey")
+	r.ServerKey = rd.compactBytes("serverKey")
+	r.Iterations = rd.int32("iterations")
+	rd.skipTaggedFields("taggedFields")
+
+	return r, rd.err
+}
+
+func newRecordRemoveUserScramCredential(header recordHeader, b []byte) (RecordRemoveUserScramCredential, error) {
+	rd := newReader(b, "removeUserScramCredential")
+	r := RecordRemoveUserScramCredential{Header: header}
+
+	r.Version = rd.byte("version")
+	r.Name = rd.compactString("name")
+	r.Mechanism = rd.int8("mechanism")
+	rd.skipTaggedFields("taggedFields")
+
+	return r, rd.err
+}
+
+func newRecordDelegationToken(header recordHeader, b []byte) (RecordDelegationToken, error) {
+	rd := newReader(b, "delegationToken")
+	r := RecordDelegationToken{Header: header}
+
+	r.Version = rd.byte("version")
+	r.Owner = rd.compactString("owner")
+	r.Requester = rd.compactString("requester")
+	r.Renewers = rd.compactStringArray("renewers")
+	r.IssueTimestamp = rd.int64("issueTimestamp")
+	r.MaxTimestamp = rd.int64("maxTimestamp")
+	r.ExpirationTimestamp = rd.int64("expirationTimestamp")
+	r.TokenID = rd.compactString("tokenId")
+	rd.skipTaggedFields("taggedFields")
+
+	return r, rd.err
+}
+
+func newRecordRemoveDelegationToken(header recordHeader, b []byte) (RecordRemoveDelegationToken, error) {
+	rd := newReader(b, "removeDelegationToken")
+	r := RecordRemoveDelegationToken{Header: header}
+
+	r.Version = rd.byte("version")
+	r.TokenID = rd.compactString("tokenId")
+	rd.skipTaggedFields("taggedFields")
+
+	return r, rd.err
+}
+
+func (r RecordAccessControlEntry) GetRecordTypeId() byte        { return r.Header.GetRecordTypeId() }
+func (r RecordRemoveAccessControlEntry) GetRecordTypeId() byte  { return r.Header.GetRecordTypeId() }
+func (r RecordUserScramCredential) GetRecordTypeId() byte       { return r.Header.GetRecordTypeId() }
+func (r RecordRemoveUserScramCredential) GetRecordTypeId() byte { return r.Header.GetRecordTypeId() }
+func (r RecordDelegationToken) GetRecordTypeId() byte           { return r.Header.GetRecordTypeId() }
+func (r RecordRemoveDelegationToken) GetRecordTypeId() byte     { return r.Header.GetRecordTypeId() }
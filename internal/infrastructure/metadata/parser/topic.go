@@ -1,10 +1,40 @@
 package parser
 
 type RecordTopic struct {
-	Header          recordHeader
-	Version         byte
-	NameLength      byte
-	TopicName       string
-	TopicUUID       [16]byte
-	TaggedFieldsCnt byte
+	Header    recordHeader
+	Version   byte
+	TopicName string
+	TopicUUID [16]byte
 }
+
+type RecordRemoveTopic struct {
+	Header    recordHeader
+	Version   byte
+	TopicUUID [16]byte
+}
+
+func newRecordTopic(header recordHeader, b []byte) (RecordTopic, error) {
+	rd := newReader(b, "topic")
+	r := RecordTopic{Header: header}
+
+	r.Version = rd.byte("version")
+	r.TopicName = rd.compactString("name")
+	r.TopicUUID = rd.uuid("UUID")
+	rd.skipTaggedFields("taggedFields")
+
+	return r, rd.err
+}
+
+func newRecordRemoveTopic(header recordHeader, b []byte) (RecordRemoveTopic, error) {
+	rd := newReader(b, "removeTopic")
+	r := RecordRemoveTopic{Header: header}
+
+	r.Version = rd.byte("version")
+	r.TopicUUID = rd.uuid("UUID")
+	rd.skipTaggedFields("taggedFields")
+
+	return r, rd.err
+}
+
+func (r RecordTopic) GetRecordTypeId() byte       { return r.Header.GetRecordTypeId() }
+func (r RecordRemoveTopic) GetRecordTypeId() byte { return r.Header.GetRecordTypeId() }
@@ -1,7 +1,29 @@
 package parser
 
 const (
-	topicRecordType        = 2
-	partitionRecordType    = 3
-	featureLevelRecordType = 12
+	registerBrokerRecordType            = 0
+	unregisterBrokerRecordType          = 1
+	topicRecordType                     = 2
+	partitionRecordType                 = 3
+	configRecordType                    = 4
+	partitionChangeRecordType           = 5
+	accessControlEntryRecordType        = 6
+	fenceBrokerRecordType               = 7
+	unfenceBrokerRecordType             = 8
+	removeTopicRecordType               = 9
+	delegationTokenRecordType           = 10
+	userScramCredentialRecordType       = 11
+	featureLevelRecordType              = 12
+	clientQuotaRecordType               = 14
+	producerIdsRecordType               = 15
+	brokerRegistrationChangeRecordType  = 17
+	removeAccessControlEntryRecordType  = 18
+	removeDelegationTokenRecordType     = 19
+	noOpRecordType                      = 20
+	zkMigrationStateRecordType          = 21
+	removeUserScramCredentialRecordType = 22
+	beginTransactionRecordType          = 23
+	endTransactionRecordType            = 24
+	abortTransactionRecordType          = 25
+	registerControllerRecordType        = 27
 )
@@ -0,0 +1,92 @@
+package parser
+
+import "encoding/binary"
+
+type writer struct {
+	b []byte
+}
+
+func newRecordWriter(typeID byte, version byte) *writer {
+	return &writer{b: []byte{recordFrameVersion, typeID, version}}
+}
+
+func (w *writer) bytes() []byte {
+	return w.b
+}
+
+func (w *writer) int8(v int8) {
+	w.b = append(w.b, byte(v))
+}
+
+func (w *writer) bool(v bool) {
+	if v {
+		w.b = append(w.b, 1)
+		return
+	}
+	w.b = append(w.b, 0)
+}
+
+func (w *writer) int16(v int16) {
+	w.b = binary.BigEndian.AppendUint16(w.b, uint16(v))
+}
+
+func (w *writer) int32(v int32) {
+	w.b = binary.BigEndian.AppendUint32(w.b, uint32(v))
+}
+
+func (w *writer) int64(v int64) {
+	w.b = binary.BigEndian.AppendUint64(w.b, uint64(v))
+}
+
+func (w *writer) uvarint(v uint64) {
+	w.b = binary.AppendUvarint(w.b, v)
+}
+
+func (w *writer) uuid(id [16]byte) {
+	w.b = append(w.b, id[:]...)
+}
+
+func (w *writer) compactString(s string) {
+	w.uvarint(uint64(len(s) + 1))
+	w.b = append(w.b, s...)
+}
+
+func (w *writer) compactNullableString(s *string) {
+	if s == nil {
+		w.uvarint(0)
+		return
+	}
+	w.compactString(*s)
+}
+
+func (w *writer) compactInt32Array(vs []int32) {
+	w.uvarint(uint64(len(vs) + 1))
+	for _, v := range vs {
+		w.int32(v)
+	}
+}
+
+func (w *writer) compactUUIDArray(ids [][16]byte) {
+	w.uvarint(uint64(len(ids) + 1))
+	for _, id := range ids {
+		w.uuid(id)
+	}
+}
+
+func (w *writer) emptyTaggedFields() {
+	w.uvarint(0)
+}
+
+type taggedField struct {
+	tag  uint64
+	data []byte
+}
+
+func (w *writer) taggedFields(fields []taggedField) {
+	w.uvarint(uint64(len(fields)))
+	for _, f := range fields {
+		w.uvarint(f.tag)
+		w.uvarint(uint64(len(f.data)))
+		w.b = append(w.b, f.data...)
+	}
+}
@@ -0,0 +1,110 @@
+package netinfra
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/response"
+	"github.com/codecrafters-io/kafka-starter-go/internal/ports"
+)
+
+type clientConn struct {
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+type TCPClient struct {
+	mu          sync.Mutex
+	clientID    string
+	codec       ports.QuorumClientCodec
+	timeout     time.Duration
+	conns       map[string]*clientConn
+	correlation atomic.Uint32
+}
+
+func NewTCPClient(clientID string, codec ports.QuorumClientCodec, timeout time.Duration) *TCPClient {
+	return &TCPClient{
+		clientID: clientID,
+		codec:    codec,
+		timeout:  timeout,
+		conns:    map[string]*clientConn{},
+	}
+}
+
+func (c *TCPClient) RoundTrip(addr string, version uint16, body request.RequestBody) (*response.MessageResponse, error) {
+	header := request.RequestHeader{
+		ApiKey:        body.ApiKey(),
+		ApiVersion:    version,
+		CorrelationID: c.correlation.Add(1),
+		ClientID:      []byte(c.clientID),
+	}
+	frame, err := c.codec.EncodeRequest(header, body)
+	if err != nil {
+		return nil, err
+	}
+
+	cc := c.peer(addr)
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	if cc.conn == nil {
+		conn, err := net.DialTimeout("tcp", addr, c.timeout)
+		if err != nil {
+			return nil, err
+		}
+		cc.conn = conn
+	}
+
+	resp, err := c.exchange(cc.conn, header, frame)
+	if err != nil {
+		cc.conn.Close()
+		cc.conn = nil
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *TCPClient) exchange(conn net.Conn, header request.RequestHeader, frame []byte) (*response.MessageResponse, error) {
+	if err := conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(frame); err != nil {
+		return nil, err
+	}
+
+	var size [4]byte
+	if _, err := io.ReadFull(conn, size[:]); err != nil {
+		return nil, err
+	}
+	payload := make([]byte, binary.BigEndian.Uint32(size[:]))
+	if _, err := io.ReadFull(conn, payload); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.codec.DecodeResponse(header.ApiKey, payload)
+	if err != nil {
+		return nil, err
+	}
+	if resp.CorrelationID != header.CorrelationID {
+		return nil, fmt.Errorf("%s: correlation id %d, expected %d", c.clientID, resp.CorrelationID, header.CorrelationID)
+	}
+	return resp, nil
+}
+
+func (c *TCPClient) peer(addr string) *clientConn {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cc, ok := c.conns[addr]
+	if !ok {
+		cc = &clientConn{}
+		c.conns[addr] = cc
+	}
+	return cc
+}
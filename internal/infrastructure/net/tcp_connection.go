@@ -1,8 +1,11 @@
 package netinfra
 
 import (
+	"io"
 	"net"
 
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+
 	"github.com/codecrafters-io/kafka-starter-go/internal/ports"
 )
 
@@ -22,6 +25,18 @@ func (c *TCPConnection) Write(p []byte) (int, error) {
 	return c.conn.Write(p)
 }
 
+func (c *TCPConnection) WriteRegion(region *domain.FileRegion) (int64, error) {
+	if _, err := region.File.Seek(region.Offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	n, err := io.Copy(c.conn, &io.LimitedReader{R: region.File, N: region.Length})
+	if err == nil && n < region.Length {
+		err = io.ErrShortWrite
+	}
+	return n, err
+}
+
 func (c *TCPConnection) Close() error {
 	return c.conn.Close()
 }
@@ -0,0 +1,271 @@
+package raft
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+	"github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/metadata/parser"
+)
+
+func (n *Node) HandleVote(v domain.QuorumVote) domain.QuorumVoteResult {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if v.CandidateEpoch < n.epoch {
+		return domain.QuorumVoteResult{ErrorCode: domain.ErrorFencedLeaderEpoch, Leader: n.leaderLocked()}
+	}
+	if v.CandidateEpoch > n.epoch {
+		if err := n.becomeUnattachedLocked(v.CandidateEpoch); err != nil {
+			return domain.QuorumVoteResult{ErrorCode: domain.ErrorKafkaStorage, Leader: n.leaderLocked()}
+		}
+	}
+
+	if !n.isVoter(v.CandidateID) {
+		return domain.QuorumVoteResult{ErrorCode: domain.ErrorInconsistentVoterSet, Leader: n.leaderLocked()}
+	}
+
+	granted := n.role == roleUnattached &&
+		(n.votedID < 0 || n.votedID == v.CandidateID) &&
+		n.candidateLogUpToDate(v)
+
+	if granted && n.votedID != v.CandidateID {
+		n.votedID = v.CandidateID
+		if err := n.persistLocked(); err != nil {
+			n.votedID = -1
+			return domain.QuorumVoteResult{ErrorCode: domain.ErrorKafkaStorage, Leader: n.leaderLocked()}
+		}
+		n.electionDeadline = n.now().Add(n.randomElectionTimeout())
+	}
+
+	return domain.QuorumVoteResult{Leader: n.leaderLocked(), Granted: granted}
+}
+
+func (n *Node) HandleBeginQuorumEpoch(leader domain.LeaderAndEpoch) domain.QuorumEpochResult {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if leader.Epoch < n.epoch {
+		return domain.QuorumEpochResult{ErrorCode: domain.ErrorFencedLeaderEpoch, Leader: n.leaderLocked()}
+	}
+	if leader.Epoch == n.epoch && n.leaderID >= 0 && n.leaderID != leader.LeaderID {
+		return domain.QuorumEpochResult{ErrorCode: domain.ErrorInconsistentVoterSet, Leader: n.leaderLocked()}
+	}
+
+	if n.role != roleFollower || leader.Epoch > n.epoch {
+		if err := n.becomeFollowerLocked(leader); err != nil {
+			return domain.QuorumEpochResult{ErrorCode: domain.ErrorKafkaStorage, Leader: n.leaderLocked()}
+		}
+	}
+	return domain.QuorumEpochResult{Leader: n.leaderLocked()}
+}
+
+func (n *Node) HandleEndQuorumEpoch(leader domain.LeaderAndEpoch, successors []int32) domain.QuorumEpochResult {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if leader.Epoch < n.epoch {
+		return domain.QuorumEpochResult{ErrorCode: domain.ErrorFencedLeaderEpoch, Leader: n.leaderLocked()}
+	}
+	if leader.Epoch > n.epoch {
+		if err := n.becomeUnattachedLocked(leader.Epoch); err != nil {
+			return domain.QuorumEpochResult{ErrorCode: domain.ErrorKafkaStorage, Leader: n.leaderLocked()}
+		}
+	}
+
+	if n.role == roleFollower && n.leaderID == leader.LeaderID {
+		backoff := n.randomElectionTimeout()
+		for i, id := range successors {
+			if id == n.cfg.NodeID {
+				backoff = n.cfg.TickInterval * time.Duration(2*i)
+				break
+			}
+		}
+		n.fetchDeadline = n.now().Add(backoff)
+	}
+	return domain.QuorumEpochResult{Leader: n.leaderLocked()}
+}
+
+func (n *Node) Resign() {
+	n.mu.Lock()
+	if n.role != roleLeader {
+		n.mu.Unlock()
+		return
+	}
+
+	leader := n.leaderLocked()
+	successors := n.peers()
+	sort.SliceStable(successors, func(i, j int) bool {
+		return n.replicaEndOffset(successors[i]) > n.replicaEndOffset(successors[j])
+	})
+	n.leaderID = -1
+	n.role = roleUnattached
+	n.electionDeadline = n.now().Add(n.randomElectionTimeout())
+	n.wakeWaitersLocked()
+	n.mu.Unlock()
+
+	for _, id := range successors {
+		if _, err := n.transport.EndQuorumEpoch(id, leader, successors); err != nil {
+			fmt.Println("raft: end quorum epoch:", err)
+		}
+	}
+}
+
+func (n *Node) candidateLogUpToDate(v domain.QuorumVote) bool {
+	lastEpoch := n.log.LastEpoch()
+	if v.LastOffsetEpoch != lastEpoch {
+		return v.LastOffsetEpoch > lastEpoch
+	}
+	return v.LastOffset >= n.log.EndOffset()
+}
+
+func (n *Node) startElectionLocked() error {
+	n.epoch++
+	n.role = roleCandidate
+	n.leaderID = -1
+	n.votedID = n.cfg.NodeID
+	n.votes = map[int32]bool{n.cfg.NodeID: true}
+	n.electionDeadline = n.now().Add(n.randomElectionTimeout())
+
+	if err := n.persistLocked(); err != nil {
+		return err
+	}
+	if len(n.votes) >= n.majority() {
+		return n.becomeLeaderLocked()
+	}
+
+	req := domain.QuorumVote{
+		CandidateID:     n.cfg.NodeID,
+		CandidateEpoch:  n.epoch,
+		LastOffsetEpoch: n.log.LastEpoch(),
+		LastOffset:      n.log.EndOffset(),
+	}
+	for _, id := range n.peers() {
+		go n.requestVote(id, req)
+	}
+	return nil
+}
+
+func (n *Node) requestVote(id int32, req domain.QuorumVote) {
+	res, err := n.transport.Vote(id, req)
+	if err != nil {
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if res.Leader.Epoch > n.epoch {
+		n.observeLeaderLocked(res.Leader)
+		return
+	}
+	if n.role != roleCandidate || n.epoch != req.CandidateEpoch || !res.Granted {
+		return
+	}
+
+	n.votes[id] = true
+	if len(n.votes) >= n.majority() {
+		if err := n.becomeLeaderLocked(); err != nil {
+			fmt.Println("raft:", err)
+		}
+	}
+}
+
+func (n *Node) becomeLeaderLocked() error {
+	n.role = roleLeader
+	n.leaderID = n.cfg.NodeID
+	n.replicas = map[int32]*replicaState{}
+	if err := n.persistLocked(); err != nil {
+		return err
+	}
+
+	granting := make([]int32, 0, len(n.votes))
+	for id := range n.votes {
+		granting = append(granting, id)
+	}
+	sort.Slice(granting, func(i, j int) bool { return granting[i] < granting[j] })
+
+	epoch := n.epoch
+	now := n.now().UnixMilli()
+	n.epochStartOffset = n.log.EndOffset()
+	change := parser.EncodeLeaderChange(n.cfg.NodeID, n.voterIDs(), granting)
+	if _, err := n.log.Append(epoch, func(baseOffset int64) []byte {
+		return parser.EncodeControlBatch(baseOffset, epoch, now, change)
+	}); err != nil {
+		return err
+	}
+
+	fmt.Printf("raft: node %d elected leader for epoch %d\n", n.cfg.NodeID, epoch)
+	n.maybeAdvanceHighWatermarkLocked()
+	return nil
+}
+
+func (n *Node) becomeFollowerLocked(leader domain.LeaderAndEpoch) error {
+	if leader.Epoch > n.epoch {
+		n.votedID = -1
+	}
+	n.epoch = leader.Epoch
+	n.leaderID = leader.LeaderID
+	n.role = roleFollower
+	n.fetchDeadline = n.now().Add(n.cfg.FetchTimeout)
+	n.wakeWaitersLocked()
+	return n.persistLocked()
+}
+
+func (n *Node) becomeUnattachedLocked(epoch int32) error {
+	n.epoch = epoch
+	n.leaderID = -1
+	n.votedID = -1
+	n.role = roleUnattached
+	n.electionDeadline = n.now().Add(n.randomElectionTimeout())
+	n.wakeWaitersLocked()
+	return n.persistLocked()
+}
+
+func (n *Node) observeLeaderLocked(leader domain.LeaderAndEpoch) {
+	var err error
+	if leader.LeaderID >= 0 && leader.LeaderID != n.cfg.NodeID {
+		err = n.becomeFollowerLocked(leader)
+	} else {
+		err = n.becomeUnattachedLocked(leader.Epoch)
+	}
+	if err != nil {
+		fmt.Println("raft:", err)
+	}
+}
+
+func (n *Node) unacknowledgedVotersLocked(now time.Time) []int32 {
+	pending := make([]int32, 0)
+	for _, id := range n.peers() {
+		r := n.replica(id)
+		if r.acknowledged || now.Sub(r.beginSent) < n.cfg.ElectionTimeout/2 {
+			continue
+		}
+		r.beginSent = now
+		pending = append(pending, id)
+	}
+	return pending
+}
+
+func (n *Node) sendBeginQuorumEpoch(leader domain.LeaderAndEpoch, peers []int32) {
+	for _, id := range peers {
+		go func(id int32) {
+			res, err := n.transport.BeginQuorumEpoch(id, leader)
+			if err != nil {
+				return
+			}
+
+			n.mu.Lock()
+			defer n.mu.Unlock()
+
+			if res.Leader.Epoch > n.epoch {
+				n.observeLeaderLocked(res.Leader)
+				return
+			}
+			if res.ErrorCode == 0 && n.role == roleLeader && n.epoch == leader.Epoch {
+				n.replica(id).acknowledged = true
+			}
+		}(id)
+	}
+}
@@ -0,0 +1,315 @@
+package raft
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/storage"
+)
+
+const (
+	batchLogOverhead      = 12
+	batchEpochOffset      = 12
+	batchLastOffsetDelta  = 23
+	batchMinimumSize      = 61
+	snapshotRetentionKeep = 2
+)
+
+var ErrLogInconsistent = errors.New("raft log: appended batch does not continue the log")
+
+type batchEntry struct {
+	segment    storage.SegmentFile
+	position   int64
+	size       int64
+	baseOffset int64
+	lastOffset int64
+	epoch      int32
+}
+
+type Log struct {
+	dm          *storage.DiskManager
+	batches     []batchEntry
+	startOffset int64
+	snapshot    *storage.SnapshotFile
+}
+
+func OpenLog(dm *storage.DiskManager) (*Log, error) {
+	l := &Log{dm: dm}
+
+	snap, err := dm.LatestSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	l.snapshot = snap
+	if snap != nil {
+		l.startOffset = snap.EndOffset
+	}
+
+	segments, err := dm.LogSegments()
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) > 0 && (snap == nil || segments[0].BaseOffset <= snap.EndOffset) {
+		l.startOffset = segments[0].BaseOffset
+	}
+
+	for _, seg := range segments {
+		if err := l.indexSegment(seg); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+func (l *Log) indexSegment(seg storage.SegmentFile) error {
+	data, err := l.dm.LoadBytes(seg.Path)
+	if err != nil {
+		return err
+	}
+
+	pos := int64(0)
+	for int64(len(data))-pos >= batchMinimumSize {
+		b := data[pos:]
+		size := batchLogOverhead + int64(int32(binary.BigEndian.Uint32(b[8:12])))
+		if size < batchMinimumSize || int64(len(b)) < size {
+			break
+		}
+
+		base := int64(binary.BigEndian.Uint64(b[:8]))
+		l.batches = append(l.batches, batchEntry{
+			segment:    seg,
+			position:   pos,
+			size:       size,
+			baseOffset: base,
+			lastOffset: base + int64(int32(binary.BigEndian.Uint32(b[batchLastOffsetDelta:]))),
+			epoch:      int32(binary.BigEndian.Uint32(b[batchEpochOffset:])),
+		})
+		pos += size
+	}
+
+	if pos < int64(len(data)) {
+		return l.dm.TruncateSegment(seg.Path, pos)
+	}
+	return nil
+}
+
+func (l *Log) StartOffset() int64 {
+	return l.startOffset
+}
+
+func (l *Log) EndOffset() int64 {
+	if len(l.batches) == 0 {
+		return l.startOffset
+	}
+	return l.batches[len(l.batches)-1].lastOffset + 1
+}
+
+func (l *Log) LastEpoch() int32 {
+	if len(l.batches) > 0 {
+		return l.batches[len(l.batches)-1].epoch
+	}
+	if l.snapshot != nil {
+		return l.snapshot.Epoch
+	}
+	return 0
+}
+
+func (l *Log) LatestSnapshot() *storage.SnapshotFile {
+	return l.snapshot
+}
+
+func (l *Log) EndOffsetForEpoch(epoch int32) (int32, int64) {
+	found := int32(-1)
+	end := int64(-1)
+
+	if l.snapshot != nil && l.snapshot.Epoch <= epoch {
+		found, end = l.snapshot.Epoch, l.snapshot.EndOffset
+	}
+
+	for _, b := range l.batches {
+		if b.epoch > epoch {
+			if found >= 0 {
+				return found, min(end, b.baseOffset)
+			}
+			return found, end
+		}
+		found, end = b.epoch, b.lastOffset+1
+	}
+	return found, end
+}
+
+func (l *Log) Append(epoch int32, encode func(baseOffset int64) []byte) (int64, error) {
+	var size int64
+	pos, err := l.dm.AppendLogBatch(func(baseOffset int64) []byte {
+		data := encode(baseOffset)
+		size = int64(len(data))
+		return data
+	})
+	if err != nil {
+		return 0, err
+	}
+	if pos.BaseOffset != l.EndOffset() {
+		return 0, fmt.Errorf("%w: base %d, end %d", ErrLogInconsistent, pos.BaseOffset, l.EndOffset())
+	}
+
+	data, err := l.dm.ReadAt(pos.Segment.Path, pos.Position, int(size))
+	if err != nil {
+		return 0, err
+	}
+	l.batches = append(l.batches, batchEntry{
+		segment:    pos.Segment,
+		position:   pos.Position,
+		size:       size,
+		baseOffset: pos.BaseOffset,
+		lastOffset: pos.BaseOffset + int64(int32(binary.BigEndian.Uint32(data[batchLastOffsetDelta:]))),
+		epoch:      epoch,
+	})
+	return pos.BaseOffset, nil
+}
+
+func (l *Log) AppendRaw(data []byte) error {
+	for len(data) >= batchMinimumSize {
+		size := batchLogOverhead + int(int32(binary.BigEndian.Uint32(data[8:12])))
+		if size < batchMinimumSize || len(data) < size {
+			return nil
+		}
+
+		batch := data[:size]
+		base := int64(binary.BigEndian.Uint64(batch[:8]))
+		if base != l.EndOffset() {
+			return fmt.Errorf("%w: base %d, end %d", ErrLogInconsistent, base, l.EndOffset())
+		}
+
+		epoch := int32(binary.BigEndian.Uint32(batch[batchEpochOffset:]))
+		if _, err := l.Append(epoch, func(int64) []byte { return batch }); err != nil {
+			return err
+		}
+		data = data[size:]
+	}
+	return nil
+}
+
+func (l *Log) Read(offset int64, maxBytes int) ([]byte, error) {
+	first := -1
+	for i, b := range l.batches {
+		if b.lastOffset >= offset {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		return nil, nil
+	}
+
+	start := l.batches[first]
+	size := start.size
+	for _, b := range l.batches[first+1:] {
+		if b.segment.Path != start.segment.Path || size+b.size > int64(maxBytes) {
+			break
+		}
+		size += b.size
+	}
+
+	return l.dm.ReadAt(start.segment.Path, start.position, int(size))
+}
+
+func (l *Log) Truncate(offset int64) error {
+	keep := len(l.batches)
+	for keep > 0 && l.batches[keep-1].lastOffset >= offset {
+		keep--
+	}
+	if keep == len(l.batches) {
+		return nil
+	}
+
+	cut := map[string]int64{}
+	for _, b := range l.batches[keep:] {
+		if _, ok := cut[b.segment.Path]; !ok {
+			cut[b.segment.Path] = b.position
+		}
+	}
+	l.batches = l.batches[:keep]
+
+	segments, err := l.dm.LogSegments()
+	if err != nil {
+		return err
+	}
+
+	end := l.EndOffset()
+	for _, seg := range segments {
+		if seg.BaseOffset >= end && seg.BaseOffset > l.startOffset {
+			if err := l.dm.DeleteFile(seg.Path); err != nil {
+				return err
+			}
+			continue
+		}
+		if pos, ok := cut[seg.Path]; ok {
+			if err := l.dm.TruncateSegment(seg.Path, pos); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (l *Log) ResetToSnapshot(snap storage.SnapshotFile) error {
+	segments, err := l.dm.LogSegments()
+	if err != nil {
+		return err
+	}
+	for _, seg := range segments {
+		if err := l.dm.DeleteFile(seg.Path); err != nil {
+			return err
+		}
+	}
+
+	l.batches = nil
+	l.snapshot = &snap
+	l.startOffset = snap.EndOffset
+	return l.cleanSnapshots()
+}
+
+func (l *Log) AddSnapshot(snap storage.SnapshotFile) error {
+	l.snapshot = &snap
+
+	if end := l.EndOffset(); len(l.batches) > 0 && l.batches[len(l.batches)-1].segment.BaseOffset < end {
+		if _, err := l.dm.RollSegment(end); err != nil {
+			return err
+		}
+	}
+
+	segments, err := l.dm.LogSegments()
+	if err != nil {
+		return err
+	}
+	for i := 0; i+1 < len(segments) && segments[i+1].BaseOffset <= snap.EndOffset; i++ {
+		if err := l.dm.DeleteFile(segments[i].Path); err != nil {
+			return err
+		}
+		l.startOffset = segments[i+1].BaseOffset
+	}
+
+	kept := l.batches[:0]
+	for _, b := range l.batches {
+		if b.baseOffset >= l.startOffset {
+			kept = append(kept, b)
+		}
+	}
+	l.batches = kept
+
+	return l.cleanSnapshots()
+}
+
+func (l *Log) cleanSnapshots() error {
+	snapshots, err := l.dm.Snapshots()
+	if err != nil {
+		return err
+	}
+	for i := 0; i+snapshotRetentionKeep < len(snapshots); i++ {
+		if err := l.dm.DeleteFile(snapshots[i].Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,388 @@
+package raft
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+	"github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/metadata/parser"
+	"github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/storage"
+)
+
+const (
+	defaultElectionTimeout  = time.Second
+	defaultFetchTimeout     = 2 * time.Second
+	defaultTickInterval     = 50 * time.Millisecond
+	defaultAppendTimeout    = 5 * time.Second
+	defaultSnapshotInterval = 1000
+	defaultFetchMaxBytes    = 1 << 20
+)
+
+var (
+	ErrNotLeader     = fmt.Errorf("raft: not the quorum leader: %w", domain.ErrNotController)
+	ErrCommitTimeout = errors.New("raft: timed out waiting for commit")
+)
+
+type role int
+
+const (
+	roleUnattached role = iota
+	roleFollower
+	roleCandidate
+	roleLeader
+)
+
+func (r role) String() string {
+	switch r {
+	case roleFollower:
+		return "follower"
+	case roleCandidate:
+		return "candidate"
+	case roleLeader:
+		return "leader"
+	default:
+		return "unattached"
+	}
+}
+
+type Config struct {
+	NodeID           int32
+	ClusterID        string
+	Voters           map[int32]string
+	ElectionTimeout  time.Duration
+	FetchTimeout     time.Duration
+	TickInterval     time.Duration
+	AppendTimeout    time.Duration
+	SnapshotInterval int64
+}
+
+type Snapshotter interface {
+	SnapshotRecords() ([][]byte, domain.MetadataVersion)
+}
+
+type replicaState struct {
+	endOffset    int64
+	lastFetch    time.Time
+	lastCaughtUp time.Time
+	acknowledged bool
+	beginSent    time.Time
+}
+
+type Node struct {
+	mu        sync.Mutex
+	cfg       Config
+	dm        *storage.DiskManager
+	log       *Log
+	transport Transport
+	snapshots Snapshotter
+	now       func() time.Time
+	random    *rand.Rand
+
+	role             role
+	epoch            int32
+	leaderID         int32
+	votedID          int32
+	votes            map[int32]bool
+	highWatermark    int64
+	hwKnown          bool
+	epochStartOffset int64
+	replicas         map[int32]*replicaState
+	electionDeadline time.Time
+	fetchDeadline    time.Time
+	committed        chan struct{}
+	commitNotify     chan struct{}
+	snapshotting     bool
+}
+
+func NewNode(cfg Config, dm *storage.DiskManager, transport Transport) (*Node, error) {
+	if _, ok := cfg.Voters[cfg.NodeID]; !ok && len(cfg.Voters) > 0 {
+		return nil, fmt.Errorf("raft: node %d is not a voter", cfg.NodeID)
+	}
+	if cfg.ElectionTimeout <= 0 {
+		cfg.ElectionTimeout = defaultElectionTimeout
+	}
+	if cfg.FetchTimeout <= 0 {
+		cfg.FetchTimeout = defaultFetchTimeout
+	}
+	if cfg.TickInterval <= 0 {
+		cfg.TickInterval = defaultTickInterval
+	}
+	if cfg.AppendTimeout <= 0 {
+		cfg.AppendTimeout = defaultAppendTimeout
+	}
+	if cfg.SnapshotInterval <= 0 {
+		cfg.SnapshotInterval = defaultSnapshotInterval
+	}
+
+	log, err := OpenLog(dm)
+	if err != nil {
+		return nil, err
+	}
+
+	st, _, err := storage.LoadQuorumState(dm.Dir())
+	if err != nil {
+		return nil, err
+	}
+
+	n := &Node{
+		cfg:          cfg,
+		dm:           dm,
+		log:          log,
+		transport:    transport,
+		now:          time.Now,
+		random:       rand.New(rand.NewSource(time.Now().UnixNano() + int64(cfg.NodeID))),
+		epoch:        st.LeaderEpoch,
+		leaderID:     st.LeaderID,
+		votedID:      st.VotedID,
+		replicas:     map[int32]*replicaState{},
+		committed:    make(chan struct{}),
+		commitNotify: make(chan struct{}, 1),
+	}
+	if snap := log.LatestSnapshot(); snap != nil {
+		n.highWatermark = snap.EndOffset
+	}
+
+	switch {
+	case n.leaderID == cfg.NodeID:
+		n.leaderID = -1
+		n.role = roleUnattached
+		n.electionDeadline = n.now()
+	case n.leaderID >= 0:
+		n.role = roleFollower
+		n.fetchDeadline = n.now().Add(cfg.FetchTimeout)
+	default:
+		n.role = roleUnattached
+		n.electionDeadline = n.now().Add(n.randomElectionTimeout())
+	}
+	return n, nil
+}
+
+func (n *Node) SetSnapshotter(s Snapshotter) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.snapshots = s
+}
+
+func (n *Node) Start() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if len(n.cfg.Voters) == 1 && n.role != roleLeader {
+		return n.startElectionLocked()
+	}
+	return nil
+}
+
+func (n *Node) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(n.cfg.TickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			n.tick()
+		}
+	}
+}
+
+func (n *Node) OnCommit(fn func()) {
+	go func() {
+		for range n.commitNotify {
+			fn()
+		}
+	}()
+}
+
+func (n *Node) HighWatermark() int64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.highWatermark
+}
+
+func (n *Node) Leader() domain.LeaderAndEpoch {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.leaderLocked()
+}
+
+func (n *Node) WaitForHighWatermark(timeout time.Duration) bool {
+	deadline := time.After(timeout)
+	for {
+		n.mu.Lock()
+		known, ch := n.hwKnown, n.committed
+		n.mu.Unlock()
+
+		if known {
+			return true
+		}
+		select {
+		case <-ch:
+		case <-deadline:
+			return false
+		}
+	}
+}
+
+func (n *Node) Append(values [][]byte) (int64, error) {
+	if len(values) == 0 {
+		return 0, nil
+	}
+
+	n.mu.Lock()
+	if n.role != roleLeader {
+		n.mu.Unlock()
+		return 0, ErrNotLeader
+	}
+
+	epoch := n.epoch
+	now := n.now().UnixMilli()
+	base, err := n.log.Append(epoch, func(baseOffset int64) []byte {
+		return parser.EncodeBatch(baseOffset, epoch, now, values)
+	})
+	if err != nil {
+		n.mu.Unlock()
+		return 0, err
+	}
+	n.maybeAdvanceHighWatermarkLocked()
+	n.mu.Unlock()
+
+	last := base + int64(len(values)) - 1
+	return base, n.waitForCommit(epoch, last)
+}
+
+func (n *Node) waitForCommit(epoch int32, offset int64) error {
+	deadline := time.After(n.cfg.AppendTimeout)
+	for {
+		n.mu.Lock()
+		hw, ch := n.highWatermark, n.committed
+		stillLeader := n.role == roleLeader && n.epoch == epoch
+		n.mu.Unlock()
+
+		if hw > offset {
+			return nil
+		}
+		if !stillLeader {
+			return ErrNotLeader
+		}
+
+		select {
+		case <-ch:
+		case <-deadline:
+			return ErrCommitTimeout
+		}
+	}
+}
+
+func (n *Node) tick() {
+	n.mu.Lock()
+	now := n.now()
+
+	switch n.role {
+	case roleLeader:
+		pending := n.unacknowledgedVotersLocked(now)
+		leader := n.leaderLocked()
+		n.mu.Unlock()
+		n.sendBeginQuorumEpoch(leader, pending)
+
+	case roleFollower:
+		if now.After(n.fetchDeadline) {
+			if err := n.startElectionLocked(); err != nil {
+				fmt.Println("raft:", err)
+			}
+			n.mu.Unlock()
+			return
+		}
+		n.mu.Unlock()
+		n.fetchFromLeader()
+
+	default:
+		if now.After(n.electionDeadline) {
+			if err := n.startElectionLocked(); err != nil {
+				fmt.Println("raft:", err)
+			}
+		}
+		n.mu.Unlock()
+	}
+
+	n.maybeSnapshot()
+}
+
+func (n *Node) leaderLocked() domain.LeaderAndEpoch {
+	return domain.LeaderAndEpoch{LeaderID: n.leaderID, Epoch: n.epoch}
+}
+
+func (n *Node) isVoter(id int32) bool {
+	_, ok := n.cfg.Voters[id]
+	return ok
+}
+
+func (n *Node) majority() int {
+	return len(n.cfg.Voters)/2 + 1
+}
+
+func (n *Node) peers() []int32 {
+	ids := make([]int32, 0, len(n.cfg.Voters))
+	for id := range n.cfg.Voters {
+		if id != n.cfg.NodeID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func (n *Node) voterIDs() []int32 {
+	ids := make([]int32, 0, len(n.cfg.Voters))
+	for id := range n.cfg.Voters {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func (n *Node) randomElectionTimeout() time.Duration {
+	return n.cfg.ElectionTimeout + time.Duration(n.random.Int63n(int64(n.cfg.ElectionTimeout)))
+}
+
+func (n *Node) persistLocked() error {
+	voters := make([]storage.QuorumVoter, 0, len(n.cfg.Voters))
+	for _, id := range n.voterIDs() {
+		voters = append(voters, storage.QuorumVoter{VoterID: id})
+	}
+
+	return storage.WriteQuorumState(n.dm.Dir(), storage.QuorumState{
+		ClusterID:     n.cfg.ClusterID,
+		LeaderID:      n.leaderID,
+		LeaderEpoch:   n.epoch,
+		VotedID:       n.votedID,
+		CurrentVoters: voters,
+	})
+}
+
+func (n *Node) setHighWatermarkLocked(hw int64) {
+	if n.hwKnown && hw <= n.highWatermark {
+		return
+	}
+	n.hwKnown = true
+	n.highWatermark = max(n.highWatermark, hw)
+	n.wakeWaitersLocked()
+
+	select {
+	case n.commitNotify <- struct{}{}:
+	default:
+	}
+}
+
+func (n *Node) wakeWaitersLocked() {
+	close(n.committed)
+	n.committed = make(chan struct{})
+}
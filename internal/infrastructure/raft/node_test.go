@@ -0,0 +1,397 @@
+package raft
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+	"github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/metadata/parser"
+	"github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/storage"
+)
+
+type memTransport struct {
+	mu    sync.Mutex
+	nodes map[int32]*Node
+}
+
+func (m *memTransport) node(id int32) (*Node, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n, ok := m.nodes[id]
+	if !ok {
+		return nil, errors.New("unreachable")
+	}
+	return n, nil
+}
+
+func (m *memTransport) Vote(to int32, v domain.QuorumVote) (domain.QuorumVoteResult, error) {
+	n, err := m.node(to)
+	if err != nil {
+		return domain.QuorumVoteResult{}, err
+	}
+	return n.HandleVote(v), nil
+}
+
+func (m *memTransport) BeginQuorumEpoch(to int32, leader domain.LeaderAndEpoch) (domain.QuorumEpochResult, error) {
+	n, err := m.node(to)
+	if err != nil {
+		return domain.QuorumEpochResult{}, err
+	}
+	return n.HandleBeginQuorumEpoch(leader), nil
+}
+
+func (m *memTransport) EndQuorumEpoch(to int32, leader domain.LeaderAndEpoch, successors []int32) (domain.QuorumEpochResult, error) {
+	n, err := m.node(to)
+	if err != nil {
+		return domain.QuorumEpochResult{}, err
+	}
+	return n.HandleEndQuorumEpoch(leader, successors), nil
+}
+
+func (m *memTransport) Fetch(to int32, f domain.QuorumFetch) (domain.QuorumFetchResult, error) {
+	n, err := m.node(to)
+	if err != nil {
+		return domain.QuorumFetchResult{}, err
+	}
+	return n.HandleFetch(f), nil
+}
+
+func (m *memTransport) FetchSnapshot(to int32, f domain.QuorumSnapshotFetch) (domain.QuorumSnapshotChunk, error) {
+	n, err := m.node(to)
+	if err != nil {
+		return domain.QuorumSnapshotChunk{}, err
+	}
+	f.MaxBytes = 64
+	return n.HandleFetchSnapshot(f), nil
+}
+
+type staticSnapshotter struct {
+	node    *Node
+	records [][]byte
+}
+
+func (s *staticSnapshotter) SnapshotRecords() ([][]byte, domain.MetadataVersion) {
+	leader := s.node.Leader()
+	return s.records, domain.MetadataVersion{Offset: s.node.HighWatermark() - 1, Epoch: leader.Epoch}
+}
+
+func newTestCluster(t *testing.T, ids ...int32) (*memTransport, map[int32]*Node) {
+	t.Helper()
+
+	voters := make(map[int32]string, len(ids))
+	for _, id := range ids {
+		voters[id] = "localhost"
+	}
+
+	transport := &memTransport{nodes: map[int32]*Node{}}
+	nodes := make(map[int32]*Node, len(ids))
+	for _, id := range ids {
+		n, err := NewNode(Config{
+			NodeID:           id,
+			ClusterID:        "cluster",
+			Voters:           voters,
+			ElectionTimeout:  time.Hour,
+			FetchTimeout:     time.Hour,
+			AppendTimeout:    2 * time.Second,
+			SnapshotInterval: 4,
+		}, storage.NewDiskManager(t.TempDir()), transport)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nodes[id] = n
+		transport.nodes[id] = n
+	}
+	return transport, nodes
+}
+
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func elect(t *testing.T, nodes map[int32]*Node, id int32) domain.LeaderAndEpoch {
+	t.Helper()
+
+	n := nodes[id]
+	n.mu.Lock()
+	err := n.startElectionLocked()
+	n.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "election", func() bool { return n.Leader().LeaderID == id })
+
+	leader := n.Leader()
+	for peer, p := range nodes {
+		if peer == id {
+			continue
+		}
+		if res := p.HandleBeginQuorumEpoch(leader); res.ErrorCode != 0 {
+			t.Fatalf("node %d rejected leader: %d", peer, res.ErrorCode)
+		}
+	}
+	return leader
+}
+
+func endOffset(n *Node) int64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.log.EndOffset()
+}
+
+func appendAsync(n *Node, values ...[]byte) chan error {
+	done := make(chan error, 1)
+	go func() {
+		_, err := n.Append(values)
+		done <- err
+	}()
+	return done
+}
+
+func TestNode_SingleVoterElectsItselfAndCommits(t *testing.T) {
+	_, nodes := newTestCluster(t, 1)
+	n := nodes[1]
+
+	if err := n.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if leader := n.Leader(); leader.LeaderID != 1 || leader.Epoch != 1 {
+		t.Fatalf("unexpected leader %+v", leader)
+	}
+	if !n.WaitForHighWatermark(time.Second) || n.HighWatermark() != 1 {
+		t.Fatalf("leader change must commit, hw=%d", n.HighWatermark())
+	}
+
+	base, err := n.Append([][]byte{parser.EncodeFeatureLevel("metadata.version", 21)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if base != 1 || n.HighWatermark() != 2 {
+		t.Fatalf("unexpected base %d hw %d", base, n.HighWatermark())
+	}
+
+	st, ok, err := storage.LoadQuorumState(n.dm.Dir())
+	if err != nil || !ok {
+		t.Fatalf("quorum state not persisted: %v", err)
+	}
+	if st.LeaderID != 1 || st.LeaderEpoch != 1 || st.VotedID != 1 || len(st.CurrentVoters) != 1 {
+		t.Fatalf("unexpected quorum state %+v", st)
+	}
+}
+
+func TestNode_ReplicatesToMajority(t *testing.T) {
+	_, nodes := newTestCluster(t, 1, 2, 3)
+	leader := elect(t, nodes, 1)
+
+	if nodes[2].Leader() != leader || nodes[3].Leader() != leader {
+		t.Fatal("followers must learn the leader")
+	}
+	if _, err := nodes[2].Append([][]byte{parser.EncodeFeatureLevel("x", 1)}); !errors.Is(err, ErrNotLeader) {
+		t.Fatalf("followers must reject appends, got %v", err)
+	}
+
+	done := appendAsync(nodes[1], parser.EncodeFeatureLevel("metadata.version", 21))
+	eventually(t, "leader append", func() bool { return endOffset(nodes[1]) == 2 })
+
+	if nodes[1].HighWatermark() != 0 {
+		t.Fatal("high watermark must wait for a majority")
+	}
+
+	nodes[2].fetchFromLeader()
+	nodes[2].fetchFromLeader()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	if nodes[1].HighWatermark() != 2 || nodes[2].HighWatermark() != 2 {
+		t.Fatalf("unexpected high watermarks %d / %d", nodes[1].HighWatermark(), nodes[2].HighWatermark())
+	}
+	if endOffset(nodes[3]) != 0 {
+		t.Fatal("a lagging voter must not block commit")
+	}
+
+	nodes[3].fetchFromLeader()
+	if endOffset(nodes[3]) != 2 || nodes[3].HighWatermark() != 2 {
+		t.Fatalf("lagging voter must catch up, end=%d", endOffset(nodes[3]))
+	}
+}
+
+func TestNode_VoteRejectsStaleCandidate(t *testing.T) {
+	_, nodes := newTestCluster(t, 1, 2, 3)
+	leader := elect(t, nodes, 1)
+
+	done := appendAsync(nodes[1], parser.EncodeFeatureLevel("metadata.version", 21))
+	eventually(t, "leader append", func() bool { return endOffset(nodes[1]) == 2 })
+	nodes[2].fetchFromLeader()
+	nodes[2].fetchFromLeader()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	res := nodes[2].HandleVote(domain.QuorumVote{CandidateID: 3, CandidateEpoch: leader.Epoch + 1, LastOffsetEpoch: 0, LastOffset: 0})
+	if res.Granted {
+		t.Fatal("a candidate with a shorter log must not get a vote")
+	}
+
+	res = nodes[2].HandleVote(domain.QuorumVote{CandidateID: 1, CandidateEpoch: leader.Epoch + 1, LastOffsetEpoch: leader.Epoch, LastOffset: 2})
+	if !res.Granted {
+		t.Fatal("an up-to-date candidate must get the vote")
+	}
+	res = nodes[2].HandleVote(domain.QuorumVote{CandidateID: 3, CandidateEpoch: leader.Epoch + 1, LastOffsetEpoch: leader.Epoch, LastOffset: 2})
+	if res.Granted {
+		t.Fatal("only one vote per epoch")
+	}
+
+	res = nodes[2].HandleVote(domain.QuorumVote{CandidateID: 3, CandidateEpoch: leader.Epoch})
+	if res.ErrorCode != domain.ErrorFencedLeaderEpoch {
+		t.Fatalf("expected fenced epoch, got %d", res.ErrorCode)
+	}
+}
+
+func TestNode_FollowerTruncatesDivergingLog(t *testing.T) {
+	_, nodes := newTestCluster(t, 1, 2, 3)
+	elect(t, nodes, 1)
+	nodes[2].fetchFromLeader()
+	nodes[3].fetchFromLeader()
+
+	stale := appendAsync(nodes[1], parser.EncodeFeatureLevel("stale", 1))
+	eventually(t, "leader append", func() bool { return endOffset(nodes[1]) == 2 })
+
+	leader := elect(t, nodes, 2)
+	if err := <-stale; !errors.Is(err, ErrNotLeader) {
+		t.Fatalf("uncommitted append must fail once leadership is lost, got %v", err)
+	}
+
+	done := appendAsync(nodes[2], parser.EncodeFeatureLevel("metadata.version", 21))
+	eventually(t, "leader append", func() bool { return endOffset(nodes[2]) == 3 })
+
+	nodes[1].fetchFromLeader()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	if endOffset(nodes[1]) != 3 || nodes[1].log.LastEpoch() != leader.Epoch {
+		t.Fatalf("follower must truncate and refetch, end=%d epoch=%d", endOffset(nodes[1]), nodes[1].log.LastEpoch())
+	}
+	data, err := nodes[1].log.Read(2, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	batches, err := parser.Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := batches[0].Records[0].Value.(parser.RecordFeatureLevel); f.Name != "metadata.version" {
+		t.Fatalf("stale record survived truncation: %+v", f)
+	}
+}
+
+func TestNode_SnapshotsAndInstallsOnNewFollower(t *testing.T) {
+	_, nodes := newTestCluster(t, 1, 2, 3)
+	leader := elect(t, nodes, 1)
+	nodes[2].fetchFromLeader()
+
+	for i := 0; i < 4; i++ {
+		done := appendAsync(nodes[1], parser.EncodeFeatureLevel("metadata.version", int16(20+i)))
+		eventually(t, "leader append", func() bool { return endOffset(nodes[1]) == int64(i+2) })
+		nodes[2].fetchFromLeader()
+		nodes[2].fetchFromLeader()
+		if err := <-done; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	records := [][]byte{parser.EncodeFeatureLevel("metadata.version", 23)}
+	nodes[1].SetSnapshotter(&staticSnapshotter{node: nodes[1], records: records})
+	nodes[1].maybeSnapshot()
+
+	snap := nodes[1].log.LatestSnapshot()
+	if snap == nil || snap.EndOffset != 5 || snap.Epoch != leader.Epoch {
+		t.Fatalf("expected snapshot at 5, got %+v", snap)
+	}
+	if nodes[1].log.StartOffset() != 5 {
+		t.Fatalf("segments below the snapshot must be deleted, start=%d", nodes[1].log.StartOffset())
+	}
+
+	nodes[3].fetchFromLeader()
+	installed := nodes[3].log.LatestSnapshot()
+	if installed == nil || installed.EndOffset != 5 || endOffset(nodes[3]) != 5 || nodes[3].HighWatermark() != 5 {
+		t.Fatalf("new follower must install the leader snapshot, got %+v", installed)
+	}
+
+	data, err := nodes[3].dm.LoadBytes(installed.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	batches, err := parser.Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batches) != 3 || !batches[0].IsControl() || !batches[2].IsControl() {
+		t.Fatalf("snapshot must be framed by header and footer, got %d batches", len(batches))
+	}
+}
+
+func TestNode_ResignHandsOverToSuccessor(t *testing.T) {
+	_, nodes := newTestCluster(t, 1, 2, 3)
+	leader := elect(t, nodes, 1)
+	nodes[2].fetchFromLeader()
+	nodes[1].HandleFetch(domain.QuorumFetch{ReplicaID: 2, CurrentLeaderEpoch: leader.Epoch, FetchOffset: 1, LastFetchedEpoch: leader.Epoch})
+
+	nodes[1].Resign()
+	if nodes[1].Leader().LeaderID != -1 {
+		t.Fatal("resigned leader must give up leadership")
+	}
+
+	nodes[2].mu.Lock()
+	deadline := nodes[2].fetchDeadline
+	nodes[2].mu.Unlock()
+	if time.Until(deadline) > time.Second {
+		t.Fatal("the most caught-up successor must campaign immediately")
+	}
+
+	nodes[2].tick()
+	eventually(t, "successor election", func() bool { return nodes[2].Leader().LeaderID == 2 })
+	if nodes[2].Leader().Epoch != leader.Epoch+1 {
+		t.Fatalf("unexpected epoch %d", nodes[2].Leader().Epoch)
+	}
+}
+
+func TestNode_DescribeQuorum(t *testing.T) {
+	_, nodes := newTestCluster(t, 1, 2, 3)
+	leader := elect(t, nodes, 1)
+	nodes[2].fetchFromLeader()
+	nodes[1].HandleFetch(domain.QuorumFetch{ReplicaID: 7, CurrentLeaderEpoch: leader.Epoch, FetchOffset: 0})
+
+	desc := nodes[1].DescribeQuorum()
+	if desc.ErrorCode != 0 || desc.Leader != leader || desc.HighWatermark != 1 {
+		t.Fatalf("unexpected description %+v", desc)
+	}
+	if len(desc.Voters) != 3 || desc.Voters[0].ReplicaID != 1 || desc.Voters[0].LogEndOffset != 1 {
+		t.Fatalf("unexpected voters %+v", desc.Voters)
+	}
+	if v := desc.Voters[1]; v.LogEndOffset != 1 || v.LastFetchTimestamp < 0 || v.LastCaughtUpTimestamp < 0 {
+		t.Fatalf("caught-up voter must report fetch timestamps, got %+v", v)
+	}
+	if v := desc.Voters[2]; v.LogEndOffset != -1 || v.LastFetchTimestamp != -1 {
+		t.Fatalf("voter that never fetched must report unknown state, got %+v", v)
+	}
+	if len(desc.Observers) != 1 || desc.Observers[0].ReplicaID != 7 || desc.Observers[0].LastCaughtUpTimestamp != -1 {
+		t.Fatalf("unexpected observers %+v", desc.Observers)
+	}
+
+	if res := nodes[2].DescribeQuorum(); res.ErrorCode != domain.ErrorNotLeaderOrFollower || res.Leader != leader {
+		t.Fatalf("followers must report the leader, got %+v", res)
+	}
+}
@@ -0,0 +1,251 @@
+package raft
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+)
+
+const maxFetchRounds = 16
+
+func (n *Node) HandleFetch(f domain.QuorumFetch) domain.QuorumFetchResult {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	res := domain.QuorumFetchResult{
+		Leader:         n.leaderLocked(),
+		HighWatermark:  n.highWatermark,
+		LogStartOffset: n.log.StartOffset(),
+	}
+
+	switch {
+	case n.role != roleLeader:
+		res.ErrorCode = domain.ErrorNotLeaderOrFollower
+		return res
+	case f.CurrentLeaderEpoch < n.epoch:
+		res.ErrorCode = domain.ErrorFencedLeaderEpoch
+		return res
+	case f.CurrentLeaderEpoch > n.epoch:
+		res.ErrorCode = domain.ErrorUnknownLeaderEpoch
+		return res
+	}
+
+	if snap := n.log.LatestSnapshot(); snap != nil && f.FetchOffset < n.log.StartOffset() {
+		res.SnapshotID = &domain.SnapshotID{EndOffset: snap.EndOffset, Epoch: snap.Epoch}
+		return res
+	}
+
+	if f.FetchOffset > 0 || f.LastFetchedEpoch > 0 {
+		epoch, end := n.log.EndOffsetForEpoch(f.LastFetchedEpoch)
+		if epoch != f.LastFetchedEpoch || f.FetchOffset > end {
+			res.DivergingEpoch = &domain.EpochEndOffset{Epoch: epoch, EndOffset: end}
+			return res
+		}
+	}
+
+	if f.ReplicaID >= 0 {
+		now := n.now()
+		r := n.replica(f.ReplicaID)
+		r.endOffset = f.FetchOffset
+		r.lastFetch = now
+		r.acknowledged = true
+		if f.FetchOffset >= n.log.EndOffset() {
+			r.lastCaughtUp = now
+		}
+		if n.isVoter(f.ReplicaID) {
+			n.maybeAdvanceHighWatermarkLocked()
+			res.HighWatermark = n.highWatermark
+		}
+	}
+
+	maxBytes := int(f.MaxBytes)
+	if maxBytes <= 0 {
+		maxBytes = defaultFetchMaxBytes
+	}
+	records, err := n.log.Read(f.FetchOffset, maxBytes)
+	if err != nil {
+		res.ErrorCode = domain.ErrorKafkaStorage
+		return res
+	}
+	res.Records = records
+	return res
+}
+
+func (n *Node) DescribeQuorum() domain.QuorumDescription {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	res := domain.QuorumDescription{
+		Leader:        n.leaderLocked(),
+		HighWatermark: n.highWatermark,
+	}
+	if n.role != roleLeader {
+		res.ErrorCode = domain.ErrorNotLeaderOrFollower
+		return res
+	}
+
+	now := n.now().UnixMilli()
+	for _, id := range n.voterIDs() {
+		if id == n.cfg.NodeID {
+			res.Voters = append(res.Voters, domain.QuorumReplicaState{
+				ReplicaID:             id,
+				LogEndOffset:          n.log.EndOffset(),
+				LastFetchTimestamp:    now,
+				LastCaughtUpTimestamp: now,
+			})
+			continue
+		}
+		res.Voters = append(res.Voters, n.replicaStateLocked(id))
+	}
+
+	observers := make([]int32, 0)
+	for id := range n.replicas {
+		if !n.isVoter(id) {
+			observers = append(observers, id)
+		}
+	}
+	sort.Slice(observers, func(i, j int) bool { return observers[i] < observers[j] })
+	for _, id := range observers {
+		res.Observers = append(res.Observers, n.replicaStateLocked(id))
+	}
+
+	return res
+}
+
+func (n *Node) fetchFromLeader() {
+	for i := 0; i < maxFetchRounds; i++ {
+		n.mu.Lock()
+		if n.role != roleFollower {
+			n.mu.Unlock()
+			return
+		}
+		leader := n.leaderLocked()
+		req := domain.QuorumFetch{
+			ReplicaID:          n.cfg.NodeID,
+			CurrentLeaderEpoch: n.epoch,
+			FetchOffset:        n.log.EndOffset(),
+			LastFetchedEpoch:   n.log.LastEpoch(),
+			MaxBytes:           defaultFetchMaxBytes,
+		}
+		n.mu.Unlock()
+
+		res, err := n.transport.Fetch(leader.LeaderID, req)
+		if err != nil {
+			return
+		}
+
+		if res.SnapshotID != nil && res.ErrorCode == 0 {
+			if err := n.fetchSnapshot(leader, *res.SnapshotID); err != nil {
+				fmt.Println("raft: fetch snapshot:", err)
+				return
+			}
+			continue
+		}
+
+		more, err := n.handleFetchResponse(leader, req, res)
+		if err != nil {
+			fmt.Println("raft: fetch:", err)
+			return
+		}
+		if !more {
+			return
+		}
+	}
+}
+
+func (n *Node) handleFetchResponse(
+	leader domain.LeaderAndEpoch,
+	req domain.QuorumFetch,
+	res domain.QuorumFetchResult,
+) (bool, error) {
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if res.Leader.Epoch > n.epoch {
+		n.observeLeaderLocked(res.Leader)
+		return false, nil
+	}
+	if n.role != roleFollower || n.leaderLocked() != leader || res.ErrorCode != 0 {
+		return false, nil
+	}
+	n.fetchDeadline = n.now().Add(n.cfg.FetchTimeout)
+
+	if res.DivergingEpoch != nil {
+		end := min(res.DivergingEpoch.EndOffset, n.log.EndOffset())
+		if end < n.log.StartOffset() {
+			end = n.log.StartOffset()
+		}
+		return true, n.log.Truncate(end)
+	}
+
+	if req.FetchOffset != n.log.EndOffset() {
+		return false, nil
+	}
+	if err := n.log.AppendRaw(res.Records); err != nil {
+		return false, err
+	}
+
+	n.setHighWatermarkLocked(min(res.HighWatermark, n.log.EndOffset()))
+	return len(res.Records) > 0, nil
+}
+
+func (n *Node) maybeAdvanceHighWatermarkLocked() {
+	if n.role != roleLeader {
+		return
+	}
+
+	offsets := make([]int64, 0, len(n.cfg.Voters))
+	for id := range n.cfg.Voters {
+		if id == n.cfg.NodeID {
+			offsets = append(offsets, n.log.EndOffset())
+			continue
+		}
+		offsets = append(offsets, n.replicaEndOffset(id))
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] > offsets[j] })
+
+	candidate := offsets[n.majority()-1]
+	if candidate > n.epochStartOffset {
+		n.setHighWatermarkLocked(candidate)
+	}
+}
+
+func (n *Node) replica(id int32) *replicaState {
+	r, ok := n.replicas[id]
+	if !ok {
+		r = &replicaState{endOffset: -1}
+		n.replicas[id] = r
+	}
+	return r
+}
+
+func (n *Node) replicaStateLocked(id int32) domain.QuorumReplicaState {
+	st := domain.QuorumReplicaState{
+		ReplicaID:             id,
+		LogEndOffset:          -1,
+		LastFetchTimestamp:    -1,
+		LastCaughtUpTimestamp: -1,
+	}
+
+	r, ok := n.replicas[id]
+	if !ok {
+		return st
+	}
+	st.LogEndOffset = r.endOffset
+	if !r.lastFetch.IsZero() {
+		st.LastFetchTimestamp = r.lastFetch.UnixMilli()
+	}
+	if !r.lastCaughtUp.IsZero() {
+		st.LastCaughtUpTimestamp = r.lastCaughtUp.UnixMilli()
+	}
+	return st
+}
+
+func (n *Node) replicaEndOffset(id int32) int64 {
+	if r, ok := n.replicas[id]; ok {
+		return r.endOffset
+	}
+	return -1
+}
@@ -0,0 +1,188 @@
+package raft
+
+import (
+	"fmt"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+	"github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/metadata/parser"
+	"github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/storage"
+)
+
+const snapshotBatchRecords = 1000
+
+func (n *Node) HandleFetchSnapshot(f domain.QuorumSnapshotFetch) domain.QuorumSnapshotChunk {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	res := domain.QuorumSnapshotChunk{
+		Leader:     n.leaderLocked(),
+		SnapshotID: f.SnapshotID,
+		Position:   f.Position,
+	}
+
+	switch {
+	case n.role != roleLeader:
+		res.ErrorCode = domain.ErrorNotLeaderOrFollower
+		return res
+	case f.CurrentLeaderEpoch < n.epoch:
+		res.ErrorCode = domain.ErrorFencedLeaderEpoch
+		return res
+	case f.CurrentLeaderEpoch > n.epoch:
+		res.ErrorCode = domain.ErrorUnknownLeaderEpoch
+		return res
+	}
+
+	snapshots, err := n.dm.Snapshots()
+	if err != nil {
+		res.ErrorCode = domain.ErrorKafkaStorage
+		return res
+	}
+
+	var snap *storage.SnapshotFile
+	for i := range snapshots {
+		if snapshots[i].EndOffset == f.SnapshotID.EndOffset && snapshots[i].Epoch == f.SnapshotID.Epoch {
+			snap = &snapshots[i]
+		}
+	}
+	if snap == nil {
+		res.ErrorCode = domain.ErrorSnapshotNotFound
+		return res
+	}
+
+	size, err := n.dm.FileSize(snap.Path)
+	if err != nil {
+		res.ErrorCode = domain.ErrorKafkaStorage
+		return res
+	}
+	res.Size = size
+	if f.Position < 0 || f.Position > size {
+		res.ErrorCode = domain.ErrorPositionOutOfRange
+		return res
+	}
+
+	maxBytes := int64(f.MaxBytes)
+	if maxBytes <= 0 {
+		maxBytes = defaultFetchMaxBytes
+	}
+	chunk, err := n.dm.ReadAt(snap.Path, f.Position, int(min(maxBytes, size-f.Position)))
+	if err != nil {
+		res.ErrorCode = domain.ErrorKafkaStorage
+		return res
+	}
+	res.Bytes = chunk
+	return res
+}
+
+func (n *Node) fetchSnapshot(leader domain.LeaderAndEpoch, id domain.SnapshotID) error {
+	data := make([]byte, 0)
+
+	for {
+		chunk, err := n.transport.FetchSnapshot(leader.LeaderID, domain.QuorumSnapshotFetch{
+			ReplicaID:          n.cfg.NodeID,
+			CurrentLeaderEpoch: leader.Epoch,
+			SnapshotID:         id,
+			Position:           int64(len(data)),
+			MaxBytes:           defaultFetchMaxBytes,
+		})
+		if err != nil {
+			return err
+		}
+		if chunk.ErrorCode != 0 {
+			return fmt.Errorf("leader returned error code %d", chunk.ErrorCode)
+		}
+		if chunk.Position != int64(len(data)) {
+			return fmt.Errorf("unexpected snapshot position %d", chunk.Position)
+		}
+
+		data = append(data, chunk.Bytes...)
+		if int64(len(data)) >= chunk.Size {
+			break
+		}
+		if len(chunk.Bytes) == 0 {
+			return fmt.Errorf("empty snapshot chunk at %d of %d", len(data), chunk.Size)
+		}
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.role != roleFollower || n.leaderLocked() != leader {
+		return nil
+	}
+
+	snap, err := n.dm.WriteSnapshot(id.EndOffset, id.Epoch, data)
+	if err != nil {
+		return err
+	}
+	if err := n.log.ResetToSnapshot(snap); err != nil {
+		return err
+	}
+	n.fetchDeadline = n.now().Add(n.cfg.FetchTimeout)
+	n.setHighWatermarkLocked(snap.EndOffset)
+	return nil
+}
+
+func (n *Node) maybeSnapshot() {
+	n.mu.Lock()
+	source := n.snapshots
+	last := int64(0)
+	if snap := n.log.LatestSnapshot(); snap != nil {
+		last = snap.EndOffset
+	}
+	due := source != nil && !n.snapshotting && n.highWatermark-last >= n.cfg.SnapshotInterval
+	if due {
+		n.snapshotting = true
+	}
+	n.mu.Unlock()
+
+	if !due {
+		return
+	}
+	defer func() {
+		n.mu.Lock()
+		n.snapshotting = false
+		n.mu.Unlock()
+	}()
+
+	if err := n.writeSnapshot(source, last); err != nil {
+		fmt.Println("raft: snapshot:", err)
+	}
+}
+
+func (n *Node) writeSnapshot(source Snapshotter, last int64) error {
+	records, version := source.SnapshotRecords()
+	endOffset := version.Offset + 1
+	if endOffset-last < n.cfg.SnapshotInterval {
+		return nil
+	}
+
+	data := EncodeSnapshot(version, n.now().UnixMilli(), records)
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if endOffset > n.highWatermark {
+		return fmt.Errorf("snapshot end offset %d beyond high watermark %d", endOffset, n.highWatermark)
+	}
+
+	snap, err := n.dm.WriteSnapshot(endOffset, version.Epoch, data)
+	if err != nil {
+		return err
+	}
+	return n.log.AddSnapshot(snap)
+}
+
+func EncodeSnapshot(version domain.MetadataVersion, timestamp int64, records [][]byte) []byte {
+	epoch := max(version.Epoch, 0)
+
+	out := parser.EncodeControlBatch(0, epoch, timestamp, parser.EncodeSnapshotHeader(timestamp))
+	offset := int64(1)
+
+	for start := 0; start < len(records); start += snapshotBatchRecords {
+		end := min(start+snapshotBatchRecords, len(records))
+		out = append(out, parser.EncodeBatch(offset, epoch, timestamp, records[start:end])...)
+		offset += int64(end - start)
+	}
+
+	return append(out, parser.EncodeControlBatch(offset, epoch, timestamp, parser.EncodeSnapshotFooter())...)
+}
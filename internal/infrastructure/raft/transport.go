@@ -0,0 +1,217 @@
+package raft
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/response"
+	"github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/codec"
+	netinfra "github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/net"
+	"github.com/codecrafters-io/kafka-starter-go/internal/ports"
+)
+
+const defaultRequestTimeout = 2 * time.Second
+
+type Transport interface {
+	Vote(to int32, v domain.QuorumVote) (domain.QuorumVoteResult, error)
+	BeginQuorumEpoch(to int32, leader domain.LeaderAndEpoch) (domain.QuorumEpochResult, error)
+	EndQuorumEpoch(to int32, leader domain.LeaderAndEpoch, successors []int32) (domain.QuorumEpochResult, error)
+	Fetch(to int32, f domain.QuorumFetch) (domain.QuorumFetchResult, error)
+	FetchSnapshot(to int32, f domain.QuorumSnapshotFetch) (domain.QuorumSnapshotChunk, error)
+}
+
+type TCPTransport struct {
+	clusterID string
+	voters    map[int32]string
+	client    *netinfra.TCPClient
+}
+
+func NewTCPTransport(nodeID int32, clusterID string, voters map[int32]string, c ports.QuorumClientCodec) *TCPTransport {
+	return &TCPTransport{
+		clusterID: clusterID,
+		voters:    voters,
+		client:    netinfra.NewTCPClient(fmt.Sprintf("raft-client-%d", nodeID), c, defaultRequestTimeout),
+	}
+}
+
+func (t *TCPTransport) Vote(to int32, v domain.QuorumVote) (domain.QuorumVoteResult, error) {
+	resp, err := t.roundTrip(to, 0, &request.VoteRequest{
+		ClusterID:       t.clusterIDPtr(),
+		QuorumPartition: metadataPartition(),
+		Vote:            v,
+	})
+	if err != nil {
+		return domain.QuorumVoteResult{}, err
+	}
+
+	body, ok := resp.Body.(*response.VoteResponseBody)
+	if !ok {
+		return domain.QuorumVoteResult{}, fmt.Errorf("raft: unexpected vote response %T", resp.Body)
+	}
+	return domain.QuorumVoteResult{
+		ErrorCode: firstError(body.ErrorCode, body.Partition.ErrorCode),
+		Leader:    partitionLeader(body.Partition),
+		Granted:   body.VoteGranted,
+	}, nil
+}
+
+func (t *TCPTransport) BeginQuorumEpoch(to int32, leader domain.LeaderAndEpoch) (domain.QuorumEpochResult, error) {
+	resp, err := t.roundTrip(to, 0, &request.BeginQuorumEpochRequest{
+		ClusterID:       t.clusterIDPtr(),
+		QuorumPartition: metadataPartition(),
+		LeaderID:        leader.LeaderID,
+		LeaderEpoch:     leader.Epoch,
+	})
+	if err != nil {
+		return domain.QuorumEpochResult{}, err
+	}
+
+	body, ok := resp.Body.(*response.BeginQuorumEpochResponseBody)
+	if !ok {
+		return domain.QuorumEpochResult{}, fmt.Errorf("raft: unexpected begin quorum epoch response %T", resp.Body)
+	}
+	return domain.QuorumEpochResult{
+		ErrorCode: firstError(body.ErrorCode, body.Partition.ErrorCode),
+		Leader:    partitionLeader(body.Partition),
+	}, nil
+}
+
+func (t *TCPTransport) EndQuorumEpoch(
+	to int32,
+	leader domain.LeaderAndEpoch,
+	successors []int32,
+) (domain.QuorumEpochResult, error) {
+
+	resp, err := t.roundTrip(to, 0, &request.EndQuorumEpochRequest{
+		ClusterID:           t.clusterIDPtr(),
+		QuorumPartition:     metadataPartition(),
+		LeaderID:            leader.LeaderID,
+		LeaderEpoch:         leader.Epoch,
+		PreferredSuccessors: successors,
+	})
+	if err != nil {
+		return domain.QuorumEpochResult{}, err
+	}
+
+	body, ok := resp.Body.(*response.EndQuorumEpochResponseBody)
+	if !ok {
+		return domain.QuorumEpochResult{}, fmt.Errorf("raft: unexpected end quorum epoch response %T", resp.Body)
+	}
+	return domain.QuorumEpochResult{
+		ErrorCode: firstError(body.ErrorCode, body.Partition.ErrorCode),
+		Leader:    partitionLeader(body.Partition),
+	}, nil
+}
+
+func (t *TCPTransport) Fetch(to int32, f domain.QuorumFetch) (domain.QuorumFetchResult, error) {
+	resp, err := t.roundTrip(to, codec.QuorumFetchVersion, &request.FetchRequest{
+		ClusterID:    t.clusterIDPtr(),
+		ReplicaID:    f.ReplicaID,
+		ReplicaEpoch: -1,
+		MaxBytes:     f.MaxBytes,
+		SessionEpoch: -1,
+		Topics: []request.FetchTopic{{
+			TopicID: domain.MetadataTopicID,
+			Partitions: []request.FetchPartition{{
+				Partition:          domain.MetadataPartition,
+				CurrentLeaderEpoch: f.CurrentLeaderEpoch,
+				FetchOffset:        f.FetchOffset,
+				LastFetchedEpoch:   f.LastFetchedEpoch,
+				LogStartOffset:     -1,
+				PartitionMaxBytes:  f.MaxBytes,
+			}},
+		}},
+	})
+	if err != nil {
+		return domain.QuorumFetchResult{}, err
+	}
+
+	body, ok := resp.Body.(*response.FetchResponseBody)
+	if !ok {
+		return domain.QuorumFetchResult{}, fmt.Errorf("raft: unexpected fetch response %T", resp.Body)
+	}
+	if body.ErrorCode != 0 || len(body.Responses) != 1 || len(body.Responses[0].Partitions) != 1 {
+		return domain.QuorumFetchResult{ErrorCode: firstError(body.ErrorCode, domain.ErrorUnknownTopicOrPartition)}, nil
+	}
+
+	p := body.Responses[0].Partitions[0]
+	res := domain.QuorumFetchResult{
+		ErrorCode:      p.ErrorCode,
+		Leader:         domain.LeaderAndEpoch{LeaderID: -1, Epoch: -1},
+		HighWatermark:  p.HighWatermark,
+		LogStartOffset: p.LogStartOffset,
+		Records:        p.Records,
+		DivergingEpoch: p.DivergingEpoch,
+		SnapshotID:     p.SnapshotID,
+	}
+	if p.CurrentLeader != nil {
+		res.Leader = *p.CurrentLeader
+	}
+	return res, nil
+}
+
+func (t *TCPTransport) FetchSnapshot(to int32, f domain.QuorumSnapshotFetch) (domain.QuorumSnapshotChunk, error) {
+	resp, err := t.roundTrip(to, 0, &request.FetchSnapshotRequest{
+		ClusterID:          t.clusterIDPtr(),
+		ReplicaID:          f.ReplicaID,
+		MaxBytes:           f.MaxBytes,
+		QuorumPartition:    metadataPartition(),
+		CurrentLeaderEpoch: f.CurrentLeaderEpoch,
+		SnapshotID:         f.SnapshotID,
+		Position:           f.Position,
+	})
+	if err != nil {
+		return domain.QuorumSnapshotChunk{}, err
+	}
+
+	body, ok := resp.Body.(*response.FetchSnapshotResponseBody)
+	if !ok {
+		return domain.QuorumSnapshotChunk{}, fmt.Errorf("raft: unexpected fetch snapshot response %T", resp.Body)
+	}
+	return domain.QuorumSnapshotChunk{
+		ErrorCode:  firstError(body.ErrorCode, body.Partition.ErrorCode),
+		Leader:     partitionLeader(body.Partition),
+		SnapshotID: body.SnapshotID,
+		Size:       body.Size,
+		Position:   body.Position,
+		Bytes:      body.UnalignedRecords,
+	}, nil
+}
+
+func (t *TCPTransport) roundTrip(to int32, version uint16, body request.RequestBody) (*response.MessageResponse, error) {
+	addr, ok := t.voters[to]
+	if !ok {
+		return nil, fmt.Errorf("raft: unknown voter %d", to)
+	}
+	return t.client.RoundTrip(addr, version, body)
+}
+
+func (t *TCPTransport) clusterIDPtr() *string {
+	if t.clusterID == "" {
+		return nil
+	}
+	id := t.clusterID
+	return &id
+}
+
+func metadataPartition() request.QuorumPartition {
+	return request.QuorumPartition{
+		TopicName:      domain.MetadataTopicName,
+		PartitionIndex: domain.MetadataPartition,
+	}
+}
+
+func partitionLeader(p response.QuorumPartitionResult) domain.LeaderAndEpoch {
+	return domain.LeaderAndEpoch{LeaderID: p.LeaderID, Epoch: p.LeaderEpoch}
+}
+
+func firstError(codes ...int16) int16 {
+	for _, c := range codes {
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
@@ -0,0 +1,108 @@
+package replication
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/response"
+	"github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/codec"
+	netinfra "github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/net"
+	"github.com/codecrafters-io/kafka-starter-go/internal/ports"
+)
+
+const (
+	defaultRequestTimeout = 2 * time.Second
+	replicationListener   = "PLAINTEXT"
+)
+
+type Client interface {
+	Fetch(brokerID int32, req *request.FetchRequest) (*response.FetchResponseBody, error)
+	AlterPartition(req *request.AlterPartitionRequest) (*response.AlterPartitionResponseBody, error)
+}
+
+type TCPClient struct {
+	repo       ports.MetadataRepository
+	fallbacks  map[int32]string
+	controller func() int32
+	client     *netinfra.TCPClient
+}
+
+func NewTCPClient(
+	nodeID int32,
+	repo ports.MetadataRepository,
+	fallbacks map[int32]string,
+	controller func() int32,
+	c ports.QuorumClientCodec,
+) *TCPClient {
+
+	return &TCPClient{
+		repo:       repo,
+		fallbacks:  fallbacks,
+		controller: controller,
+		client:     netinfra.NewTCPClient(fmt.Sprintf("replica-fetcher-%d", nodeID), c, defaultRequestTimeout),
+	}
+}
+
+func (c *TCPClient) Fetch(brokerID int32, req *request.FetchRequest) (*response.FetchResponseBody, error) {
+	addr, err := c.address(brokerID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.RoundTrip(addr, codec.QuorumFetchVersion, req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, ok := resp.Body.(*response.FetchResponseBody)
+	if !ok {
+		return nil, fmt.Errorf("replication: unexpected fetch response %T", resp.Body)
+	}
+	return body, nil
+}
+
+func (c *TCPClient) AlterPartition(req *request.AlterPartitionRequest) (*response.AlterPartitionResponseBody, error) {
+	id := c.controller()
+	if id < 0 {
+		return nil, errors.New("replication: no active controller")
+	}
+
+	addr, ok := c.fallbacks[id]
+	if !ok {
+		var err error
+		if addr, err = c.address(id); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := c.client.RoundTrip(addr, codec.AlterPartitionVersion, req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, ok := resp.Body.(*response.AlterPartitionResponseBody)
+	if !ok {
+		return nil, fmt.Errorf("replication: unexpected alter partition response %T", resp.Body)
+	}
+	return body, nil
+}
+
+func (c *TCPClient) address(brokerID int32) (string, error) {
+	for _, b := range c.repo.Brokers() {
+		if b.ID != brokerID {
+			continue
+		}
+		if ep, ok := b.Endpoint(replicationListener); ok {
+			return net.JoinHostPort(ep.Host, strconv.Itoa(int(ep.Port))), nil
+		}
+	}
+
+	if addr, ok := c.fallbacks[brokerID]; ok {
+		return addr, nil
+	}
+	return "", fmt.Errorf("replication: no endpoint for broker %d", brokerID)
+}
@@ -0,0 +1,258 @@
+package replication
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/response"
+)
+
+type fetcher struct {
+	leaderID int32
+	stop     chan struct{}
+}
+
+type fetchTarget struct {
+	key         partitionKey
+	leaderEpoch int32
+	throttled   bool
+}
+
+func (rm *ReplicaManager) ensureFetcherLocked(leaderID int32) {
+	if rm.closed {
+		return
+	}
+	if _, ok := rm.fetchers[leaderID]; ok {
+		return
+	}
+
+	f := &fetcher{leaderID: leaderID, stop: make(chan struct{})}
+	rm.fetchers[leaderID] = f
+	go rm.runFetcher(f)
+}
+
+func (rm *ReplicaManager) runFetcher(f *fetcher) {
+	for {
+		req, targets := rm.buildFetch(f)
+		if req == nil {
+			return
+		}
+
+		progressed := false
+		if len(req.Topics) > 0 {
+			body, err := rm.client.Fetch(f.leaderID, req)
+			if err == nil {
+				progressed = rm.handleFetchResponse(targets, body)
+			}
+		}
+
+		if progressed {
+			continue
+		}
+		select {
+		case <-f.stop:
+			return
+		case <-time.After(rm.cfg.FetchBackoff):
+		}
+	}
+}
+
+func (rm *ReplicaManager) buildFetch(f *fetcher) (*request.FetchRequest, map[[16]byte]map[int32]fetchTarget) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	select {
+	case <-f.stop:
+		return nil, nil
+	default:
+	}
+
+	req := &request.FetchRequest{
+		ClusterID:    rm.clusterIDPtr(),
+		ReplicaID:    rm.cfg.NodeID,
+		ReplicaEpoch: -1,
+		MaxBytes:     rm.cfg.FetchMaxBytes,
+		SessionEpoch: -1,
+	}
+	targets := map[[16]byte]map[int32]fetchTarget{}
+	topics := map[[16]byte]int{}
+	now := time.Now()
+	deferred := false
+
+	for key, st := range rm.partitions {
+		if st.leaderID != f.leaderID || st.isLeader(rm.cfg.NodeID) || st.loading {
+			continue
+		}
+
+		throttled := rm.followerThrottledLocked(st)
+		if throttled && rm.followerThrottle.exceeded(now) {
+			deferred = true
+			continue
+		}
+
+		i, ok := topics[st.topicID]
+		if !ok {
+			i = len(req.Topics)
+			topics[st.topicID] = i
+			req.Topics = append(req.Topics, request.FetchTopic{TopicID: st.topicID})
+			targets[st.topicID] = map[int32]fetchTarget{}
+		}
+		req.Topics[i].Partitions = append(req.Topics[i].Partitions, request.FetchPartition{
+			Partition:          key.partition,
+			CurrentLeaderEpoch: st.leaderEpoch,
+			FetchOffset:        st.logEndOffset,
+			LastFetchedEpoch:   st.lastEpoch,
+			LogStartOffset:     -1,
+			PartitionMaxBytes:  rm.cfg.FetchMaxBytes,
+		})
+		targets[st.topicID][key.partition] = fetchTarget{key: key, leaderEpoch: st.leaderEpoch, throttled: throttled}
+	}
+
+	if len(req.Topics) == 0 && !deferred {
+		delete(rm.fetchers, f.leaderID)
+		return nil, nil
+	}
+	return req, targets
+}
+
+func (rm *ReplicaManager) handleFetchResponse(
+	targets map[[16]byte]map[int32]fetchTarget,
+	body *response.FetchResponseBody,
+) bool {
+
+	if body.ErrorCode != 0 {
+		return false
+	}
+
+	progressed := false
+	for _, t := range body.Responses {
+		for _, p := range t.Partitions {
+			target, ok := targets[t.TopicID][p.PartitionIndex]
+			if !ok {
+				continue
+			}
+
+			appended, err := rm.applyFetchedPartition(target, p)
+			if err != nil {
+				fmt.Printf("replication: %s-%d: %v\n", target.key.topic, target.key.partition, err)
+				continue
+			}
+			progressed = progressed || appended
+		}
+	}
+	return progressed
+}
+
+func (rm *ReplicaManager) applyFetchedPartition(target fetchTarget, p response.FetchPartitionResponse) (bool, error) {
+	key := target.key
+
+	if !rm.isFollowing(target) {
+		return false, nil
+	}
+
+	appended := false
+	switch {
+	case p.ErrorCode == 0 && p.DivergingEpoch != nil:
+		offset, err := rm.truncationOffset(key, *p.DivergingEpoch, p.HighWatermark)
+		if err != nil {
+			return false, err
+		}
+		if err := rm.logs.TruncateLog(key.topic, key.partition, offset); err != nil {
+			return false, err
+		}
+		appended = true
+
+	case p.ErrorCode == 0:
+		if len(p.Records) > 0 {
+			if _, err := rm.logs.AppendReplicaLog(key.topic, key.partition, p.Records); err != nil {
+				return false, err
+			}
+			appended = true
+			if target.throttled {
+				rm.recordFollowerBytes(int64(len(p.Records)))
+			}
+		}
+
+	case p.ErrorCode == domain.ErrorOffsetOutOfRange:
+		if err := rm.logs.TruncateLog(key.topic, key.partition, p.HighWatermark); err != nil {
+			return false, err
+		}
+
+	default:
+		return false, nil
+	}
+
+	end, err := rm.logs.LogEndOffset(key.topic, key.partition)
+	if err != nil {
+		return false, err
+	}
+	epoch, err := rm.logs.LatestEpoch(key.topic, key.partition)
+	if err != nil {
+		return false, err
+	}
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	st, ok := rm.partitions[key]
+	if !ok || st.leaderEpoch != target.leaderEpoch || st.isLeader(rm.cfg.NodeID) {
+		return appended, nil
+	}
+	st.logEndOffset = end
+	st.lastEpoch = epoch
+	if hw := min(p.HighWatermark, end); hw > st.highWatermark {
+		st.highWatermark = hw
+	}
+	return appended, nil
+}
+
+func (rm *ReplicaManager) truncationOffset(
+	key partitionKey,
+	diverging domain.EpochEndOffset,
+	highWatermark int64,
+) (int64, error) {
+
+	end, err := rm.logs.LogEndOffset(key.topic, key.partition)
+	if err != nil {
+		return 0, err
+	}
+	if diverging.EndOffset < 0 {
+		return min(highWatermark, end), nil
+	}
+
+	local, err := rm.logs.EndOffsetForEpoch(key.topic, key.partition, diverging.Epoch)
+	switch {
+	case err != nil:
+		return 0, err
+	case local.EndOffset < 0:
+		return min(diverging.EndOffset, end), nil
+	case local.Epoch != diverging.Epoch:
+		return min(local.EndOffset, end), nil
+	}
+	return min(diverging.EndOffset, local.EndOffset, end), nil
+}
+
+func (rm *ReplicaManager) recordFollowerBytes(n int64) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	rm.followerThrottle.record(n, time.Now())
+}
+
+func (rm *ReplicaManager) isFollowing(target fetchTarget) bool {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	st, ok := rm.partitions[target.key]
+	return ok && st.leaderEpoch == target.leaderEpoch && !st.isLeader(rm.cfg.NodeID)
+}
+
+func (rm *ReplicaManager) clusterIDPtr() *string {
+	if rm.cfg.ClusterID == "" {
+		return nil
+	}
+	id := rm.cfg.ClusterID
+	return &id
+}
@@ -0,0 +1,199 @@
+package replication
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/response"
+)
+
+type followerState struct {
+	logEndOffset       int64
+	lastCaughtUp       time.Time
+	lastFetchLeaderLEO int64
+	lastFetchTime      time.Time
+}
+
+func newFollowerStates(isr []int32, local int32, now time.Time) map[int32]*followerState {
+	followers := map[int32]*followerState{}
+	for _, id := range isr {
+		if id != local {
+			followers[id] = &followerState{logEndOffset: -1, lastCaughtUp: now}
+		}
+	}
+	return followers
+}
+
+func (f *followerState) recordFetch(fetchOffset, leaderEnd int64, now time.Time) {
+	switch {
+	case fetchOffset >= leaderEnd:
+		f.lastCaughtUp = now
+	case !f.lastFetchTime.IsZero() && fetchOffset >= f.lastFetchLeaderLEO:
+		f.lastCaughtUp = f.lastFetchTime
+	}
+	f.logEndOffset = fetchOffset
+	f.lastFetchLeaderLEO = leaderEnd
+	f.lastFetchTime = now
+}
+
+type isrProposal struct {
+	leaderEpoch    int32
+	partitionEpoch int32
+	isr            []int32
+}
+
+func (rm *ReplicaManager) runISRChecks() {
+	ticker := time.NewTicker(rm.cfg.ReplicaLagTimeMax / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-rm.stop:
+			return
+		case <-ticker.C:
+			rm.maybeShrinkISR(time.Now())
+		}
+	}
+}
+
+func (rm *ReplicaManager) maybeShrinkISR(now time.Time) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	for key, st := range rm.partitions {
+		if !st.isLeader(rm.cfg.NodeID) || st.proposal != nil {
+			continue
+		}
+
+		isr := make([]int32, 0, len(st.isr))
+		for _, id := range st.isr {
+			if id == rm.cfg.NodeID {
+				isr = append(isr, id)
+				continue
+			}
+
+			f, ok := st.followers[id]
+			if !ok {
+				f = &followerState{logEndOffset: -1, lastCaughtUp: now}
+				st.followers[id] = f
+			}
+			if now.Sub(f.lastCaughtUp) <= rm.cfg.ReplicaLagTimeMax {
+				isr = append(isr, id)
+			}
+		}
+
+		if len(isr) < len(st.isr) {
+			rm.proposeISRLocked(key, st, isr)
+		}
+	}
+}
+
+func (rm *ReplicaManager) maybeExpandISRLocked(key partitionKey, st *partitionState, replicaID int32) {
+	if st.proposal != nil || slices.Contains(st.isr, replicaID) {
+		return
+	}
+
+	f := st.followers[replicaID]
+	if f.logEndOffset < st.highWatermark {
+		return
+	}
+	rm.proposeISRLocked(key, st, append(slices.Clone(st.isr), replicaID))
+}
+
+func (rm *ReplicaManager) proposeISRLocked(key partitionKey, st *partitionState, isr []int32) {
+	if rm.closed {
+		return
+	}
+
+	p := &isrProposal{leaderEpoch: st.leaderEpoch, partitionEpoch: st.partitionEpoch, isr: isr}
+	st.proposal = p
+
+	req := &request.AlterPartitionRequest{
+		BrokerID:    rm.cfg.NodeID,
+		BrokerEpoch: rm.brokerEpoch(),
+		Topics: []request.AlterPartitionTopic{{
+			TopicName: key.topic,
+			TopicID:   st.topicID,
+			Partitions: []request.AlterPartitionPartition{{
+				PartitionIndex: key.partition,
+				LeaderEpoch:    p.leaderEpoch,
+				NewISR:         p.isr,
+				PartitionEpoch: p.partitionEpoch,
+			}},
+		}},
+	}
+
+	go func() {
+		body, err := rm.client.AlterPartition(req)
+		rm.completeProposal(key, p, body, err)
+	}()
+}
+
+func (rm *ReplicaManager) completeProposal(
+	key partitionKey,
+	p *isrProposal,
+	body *response.AlterPartitionResponseBody,
+	err error,
+) {
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	st, ok := rm.partitions[key]
+	if !ok || st.proposal != p {
+		return
+	}
+	st.proposal = nil
+
+	result, err := alterPartitionResult(key, body, err)
+	if err != nil {
+		fmt.Printf("replication: %s-%d: isr update to %v failed: %v\n", key.topic, key.partition, p.isr, err)
+		return
+	}
+	if result.LeaderEpoch != st.leaderEpoch || result.PartitionEpoch <= st.partitionEpoch {
+		return
+	}
+
+	st.isr = append([]int32(nil), result.ISR...)
+	st.partitionEpoch = result.PartitionEpoch
+	rm.maybeAdvanceHighWatermarkLocked(st)
+	rm.wakeWaitersLocked()
+}
+
+func alterPartitionResult(
+	key partitionKey,
+	body *response.AlterPartitionResponseBody,
+	err error,
+) (response.AlterPartitionPartitionResult, error) {
+
+	if err != nil {
+		return response.AlterPartitionPartitionResult{}, err
+	}
+	if body.ErrorCode != 0 {
+		return response.AlterPartitionPartitionResult{}, fmt.Errorf("error code %d", body.ErrorCode)
+	}
+
+	for _, t := range body.Topics {
+		for _, p := range t.Partitions {
+			if p.PartitionIndex != key.partition {
+				continue
+			}
+			if p.ErrorCode != 0 {
+				return p, fmt.Errorf("error code %d", p.ErrorCode)
+			}
+			return p, nil
+		}
+	}
+	return response.AlterPartitionPartitionResult{}, fmt.Errorf("no result for partition")
+}
+
+func (rm *ReplicaManager) brokerEpoch() int64 {
+	for _, b := range rm.repo.Brokers() {
+		if b.ID == rm.cfg.NodeID {
+			return b.Epoch
+		}
+	}
+	return -1
+}
@@ -0,0 +1,393 @@
+package replication
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+	"github.com/codecrafters-io/kafka-starter-go/internal/ports"
+)
+
+const (
+	defaultFetchBackoff      = 50 * time.Millisecond
+	defaultFetchMaxBytes     = 1 << 20
+	defaultReplicaLagTimeMax = 30 * time.Second
+)
+
+type Config struct {
+	NodeID            int32
+	ClusterID         string
+	FetchBackoff      time.Duration
+	FetchMaxBytes     int32
+	ReplicaLagTimeMax time.Duration
+	LeaderThrottle    int64
+	FollowerThrottle  int64
+	Rack              string
+	ReplicaSelector   ports.ReplicaSelector
+}
+
+type partitionKey struct {
+	topic     string
+	partition int32
+}
+
+type partitionState struct {
+	topicID           [16]byte
+	leaderID          int32
+	leaderEpoch       int32
+	partitionEpoch    int32
+	replicas          []int32
+	isr               []int32
+	logEndOffset      int64
+	lastEpoch         int32
+	highWatermark     int64
+	followers         map[int32]*followerState
+	proposal          *isrProposal
+	leaderThrottled   bool
+	followerThrottled bool
+	loading           bool
+}
+
+func (s *partitionState) isLeader(nodeID int32) bool {
+	return s.leaderID == nodeID
+}
+
+type ReplicaManager struct {
+	mu               sync.Mutex
+	cfg              Config
+	repo             ports.MetadataRepository
+	logs             ports.LogManager
+	client           Client
+	partitions       map[partitionKey]*partitionState
+	fetchers         map[int32]*fetcher
+	leaderThrottle   *throttle
+	followerThrottle *throttle
+	advanced         chan struct{}
+	stop             chan struct{}
+	closed           bool
+}
+
+func NewReplicaManager(cfg Config, repo ports.MetadataRepository, logs ports.LogManager, client Client) *ReplicaManager {
+	if cfg.FetchBackoff <= 0 {
+		cfg.FetchBackoff = defaultFetchBackoff
+	}
+	if cfg.FetchMaxBytes <= 0 {
+		cfg.FetchMaxBytes = defaultFetchMaxBytes
+	}
+	if cfg.ReplicaLagTimeMax <= 0 {
+		cfg.ReplicaLagTimeMax = defaultReplicaLagTimeMax
+	}
+	if cfg.ReplicaSelector == nil {
+		cfg.ReplicaSelector = RackAwareSelector{}
+	}
+
+	return &ReplicaManager{
+		cfg:              cfg,
+		repo:             repo,
+		logs:             logs,
+		client:           client,
+		partitions:       map[partitionKey]*partitionState{},
+		fetchers:         map[int32]*fetcher{},
+		leaderThrottle:   &throttle{rate: cfg.LeaderThrottle},
+		followerThrottle: &throttle{rate: cfg.FollowerThrottle},
+		advanced:         make(chan struct{}),
+		stop:             make(chan struct{}),
+	}
+}
+
+func (rm *ReplicaManager) Start() {
+	rm.repo.Subscribe(rm.onMetadataChange)
+	rm.refreshThrottleRates()
+
+	for _, t := range rm.repo.Topics() {
+		rm.syncTopic(t)
+	}
+	go rm.runISRChecks()
+}
+
+func (rm *ReplicaManager) Close() {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	if rm.closed {
+		return
+	}
+	rm.closed = true
+	close(rm.stop)
+	for id, f := range rm.fetchers {
+		close(f.stop)
+		delete(rm.fetchers, id)
+	}
+}
+
+func (rm *ReplicaManager) HighWatermark(topicName string, partition int32) int64 {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	st, ok := rm.partitions[partitionKey{topicName, partition}]
+	if !ok {
+		return 0
+	}
+	return st.highWatermark
+}
+
+func (rm *ReplicaManager) ISR(topicName string, partition int32) []int32 {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	st, ok := rm.partitions[partitionKey{topicName, partition}]
+	if !ok {
+		return nil
+	}
+	return slices.Clone(st.isr)
+}
+
+func (rm *ReplicaManager) RecordAppend(topicName string, partition int32, endOffset int64) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	st, ok := rm.partitions[partitionKey{topicName, partition}]
+	if !ok || !st.isLeader(rm.cfg.NodeID) {
+		return
+	}
+	st.logEndOffset = max(st.logEndOffset, endOffset)
+	rm.maybeAdvanceHighWatermarkLocked(st)
+}
+
+func (rm *ReplicaManager) RecordFollowerFetch(topicName string, partition int32, replicaID int32, fetchOffset int64) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	st, ok := rm.partitions[partitionKey{topicName, partition}]
+	if !ok || !st.isLeader(rm.cfg.NodeID) || !slices.Contains(st.replicas, replicaID) {
+		return
+	}
+	if fetchOffset > st.logEndOffset {
+		return
+	}
+
+	f, ok := st.followers[replicaID]
+	if !ok {
+		f = &followerState{logEndOffset: -1}
+		st.followers[replicaID] = f
+	}
+	f.recordFetch(fetchOffset, st.logEndOffset, time.Now())
+
+	rm.maybeAdvanceHighWatermarkLocked(st)
+	rm.maybeExpandISRLocked(partitionKey{topicName, partition}, st, replicaID)
+}
+
+func (rm *ReplicaManager) WaitForHighWatermark(
+	topicName string,
+	partition int32,
+	offset int64,
+	minISR int,
+	timeout time.Duration,
+) error {
+
+	key := partitionKey{topicName, partition}
+	deadline := time.After(timeout)
+	for {
+		rm.mu.Lock()
+		st, ok := rm.partitions[key]
+		leader := ok && st.isLeader(rm.cfg.NodeID)
+		reached := ok && st.highWatermark >= offset
+		inSync := ok && len(st.isr) >= minISR
+		ch := rm.advanced
+		rm.mu.Unlock()
+
+		if !leader {
+			return domain.ErrNotLeaderOrFollower
+		}
+		if reached && !inSync {
+			return domain.ErrNotEnoughReplicas
+		}
+		if reached {
+			return nil
+		}
+
+		select {
+		case <-ch:
+		case <-deadline:
+			return domain.ErrReplicationTimedOut
+		}
+	}
+}
+
+func (rm *ReplicaManager) onMetadataChange(c domain.MetadataChange) {
+	switch c.Type {
+	case domain.TopicDeleted:
+		rm.mu.Lock()
+		for key := range rm.partitions {
+			if key.topic == c.Topic {
+				delete(rm.partitions, key)
+			}
+		}
+		rm.wakeWaitersLocked()
+		rm.mu.Unlock()
+
+	case domain.BrokerConfigChanged:
+		rm.refreshThrottleRates()
+
+	default:
+		t, err := rm.repo.GetTopicByID(c.TopicID)
+		if err != nil || t == nil {
+			return
+		}
+		rm.syncTopic(t)
+	}
+}
+
+type partitionSync struct {
+	key         partitionKey
+	state       *partitionState
+	leaderEpoch int32
+	dir         [16]byte
+	assign      bool
+	remove      bool
+	load        bool
+}
+
+func (rm *ReplicaManager) syncTopic(t *domain.TopicMetadata) {
+	rm.mu.Lock()
+	syncs := make([]partitionSync, 0, len(t.Partitions))
+	for _, pm := range t.Partitions {
+		syncs = append(syncs, rm.syncPartitionLocked(t, pm))
+	}
+	rm.mu.Unlock()
+
+	type loaded struct {
+		end   int64
+		epoch int32
+		err   error
+	}
+	results := make([]loaded, len(syncs))
+	for i, s := range syncs {
+		if s.assign {
+			rm.logs.Assign(s.key.topic, s.key.partition, s.dir)
+		}
+		if s.remove {
+			if err := rm.logs.DeleteLog(s.key.topic, s.key.partition); err != nil {
+				fmt.Println("replication:", err)
+			}
+		}
+		if s.load {
+			results[i].end, results[i].err = rm.logs.LogEndOffset(s.key.topic, s.key.partition)
+			if results[i].err == nil {
+				results[i].epoch, results[i].err = rm.logs.LatestEpoch(s.key.topic, s.key.partition)
+			}
+		}
+	}
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	for i, s := range syncs {
+		if !s.load || rm.partitions[s.key] != s.state || s.state.leaderEpoch != s.leaderEpoch {
+			continue
+		}
+		st := s.state
+		st.loading = false
+		if err := results[i].err; err != nil {
+			fmt.Printf("replication: %s-%d: %v\n", s.key.topic, s.key.partition, err)
+			continue
+		}
+
+		end := results[i].end
+		if st.isLeader(rm.cfg.NodeID) {
+			end = max(end, st.logEndOffset)
+		}
+		st.logEndOffset = end
+		st.lastEpoch = results[i].epoch
+		st.highWatermark = min(st.highWatermark, end)
+		rm.wakeWaitersLocked()
+		rm.startRoleLocked(st)
+	}
+}
+
+func (rm *ReplicaManager) syncPartitionLocked(t *domain.TopicMetadata, pm domain.PartitionMetadata) partitionSync {
+	key := partitionKey{t.Name, pm.PartitionIndex}
+	local := rm.cfg.NodeID
+	sync := partitionSync{key: key, leaderEpoch: pm.LeaderEpoch}
+
+	if pm.LeaderID != local && !slices.Contains(pm.Replicas, local) {
+		if _, ok := rm.partitions[key]; ok {
+			delete(rm.partitions, key)
+			rm.wakeWaitersLocked()
+			sync.remove = true
+		}
+		return sync
+	}
+
+	if i := slices.Index(pm.Replicas, local); i >= 0 && i < len(pm.Directories) {
+		sync.dir, sync.assign = pm.Directories[i], true
+	}
+
+	st, ok := rm.partitions[key]
+	if !ok {
+		st = &partitionState{leaderID: -1, leaderEpoch: -1}
+		rm.partitions[key] = st
+	}
+	sync.state = st
+
+	roleChanged := !ok || st.leaderID != pm.LeaderID || st.leaderEpoch != pm.LeaderEpoch
+	st.topicID = t.TopicID
+	st.leaderID = pm.LeaderID
+	st.leaderEpoch = pm.LeaderEpoch
+	st.replicas = append([]int32(nil), pm.Replicas...)
+	st.leaderThrottled, st.followerThrottled = throttledRoles(t, pm, local)
+	if roleChanged || pm.PartitionEpoch >= st.partitionEpoch {
+		st.partitionEpoch = pm.PartitionEpoch
+		st.isr = append([]int32(nil), pm.ISR...)
+	}
+
+	if roleChanged {
+		st.loading = true
+		st.followers = newFollowerStates(st.isr, local, time.Now())
+		st.proposal = nil
+		rm.wakeWaitersLocked()
+		sync.load = true
+		return sync
+	}
+
+	if !st.loading {
+		rm.startRoleLocked(st)
+	}
+	return sync
+}
+
+func (rm *ReplicaManager) startRoleLocked(st *partitionState) {
+	if st.isLeader(rm.cfg.NodeID) {
+		rm.maybeAdvanceHighWatermarkLocked(st)
+		return
+	}
+	if st.leaderID >= 0 {
+		rm.ensureFetcherLocked(st.leaderID)
+	}
+}
+
+func (rm *ReplicaManager) maybeAdvanceHighWatermarkLocked(st *partitionState) {
+	hw := st.logEndOffset
+	for _, id := range st.isr {
+		if id == rm.cfg.NodeID {
+			continue
+		}
+		f, ok := st.followers[id]
+		if !ok || f.logEndOffset < 0 {
+			return
+		}
+		hw = min(hw, f.logEndOffset)
+	}
+
+	if hw > st.highWatermark {
+		st.highWatermark = hw
+		rm.wakeWaitersLocked()
+	}
+}
+
+func (rm *ReplicaManager) wakeWaitersLocked() {
+	close(rm.advanced)
+	rm.advanced = make(chan struct{})
+}
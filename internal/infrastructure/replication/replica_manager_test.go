@@ -0,0 +1,573 @@
+package replication
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/response"
+	"github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/storage"
+	"github.com/codecrafters-io/kafka-starter-go/internal/ports"
+)
+
+var testTopicID = [16]byte{9}
+
+type testRepo struct {
+	mu        sync.Mutex
+	topic     *domain.TopicMetadata
+	brokers   []domain.BrokerRegistration
+	configs   map[string]map[string]string
+	listeners []func(domain.MetadataChange)
+}
+
+func (r *testRepo) GetTopic(name string) (*domain.TopicMetadata, error) {
+	return r.GetTopicByID(testTopicID)
+}
+
+func (r *testRepo) GetTopicByID(id [16]byte) (*domain.TopicMetadata, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.topic == nil || r.topic.TopicID != id {
+		return nil, errors.New("not found")
+	}
+	return r.topic.Clone(), nil
+}
+
+func (r *testRepo) Topics() []*domain.TopicMetadata {
+	if t, err := r.GetTopicByID(testTopicID); err == nil {
+		return []*domain.TopicMetadata{t}
+	}
+	return nil
+}
+
+func (r *testRepo) Brokers() []domain.BrokerRegistration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.brokers
+}
+
+func (r *testRepo) Controllers() []domain.ControllerRegistration { return nil }
+func (r *testRepo) ControllerID() int32                          { return -1 }
+func (r *testRepo) FinalizedFeatures() domain.FinalizedFeatures  { return domain.FinalizedFeatures{} }
+func (r *testRepo) Version() domain.MetadataVersion              { return domain.MetadataVersion{} }
+
+func (r *testRepo) BrokerConfigs(name string) map[string]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.configs[name]
+}
+
+func (r *testRepo) Subscribe(listener func(domain.MetadataChange)) func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.listeners = append(r.listeners, listener)
+	return func() {}
+}
+
+func (r *testRepo) partition() domain.PartitionMetadata {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.topic.Partitions[0].Clone()
+}
+
+func (r *testRepo) setPartition(pm domain.PartitionMetadata) {
+	r.mu.Lock()
+	r.topic.Partitions = []domain.PartitionMetadata{pm}
+	listeners := append([]func(domain.MetadataChange){}, r.listeners...)
+	r.mu.Unlock()
+
+	for _, l := range listeners {
+		l(domain.MetadataChange{Type: domain.PartitionChanged, Topic: "t", TopicID: testTopicID})
+	}
+}
+
+type testBroker struct {
+	logs     *storage.LogManager
+	replicas *ReplicaManager
+}
+
+type memClient struct {
+	mu      sync.Mutex
+	repo    *testRepo
+	brokers map[int32]*testBroker
+}
+
+func (c *memClient) Fetch(brokerID int32, req *request.FetchRequest) (*response.FetchResponseBody, error) {
+	c.mu.Lock()
+	leader, ok := c.brokers[brokerID]
+	c.mu.Unlock()
+	if !ok {
+		return nil, errors.New("broker unavailable")
+	}
+
+	body := &response.FetchResponseBody{}
+	for _, t := range req.Topics {
+		tr := response.FetchTopicResponse{TopicID: t.TopicID}
+		for _, p := range t.Partitions {
+			pr := response.FetchPartitionResponse{
+				PartitionIndex: p.Partition,
+				HighWatermark:  leader.replicas.HighWatermark("t", p.Partition),
+			}
+			if p.LastFetchedEpoch >= 0 {
+				end, err := leader.logs.EndOffsetForEpoch("t", p.Partition, p.LastFetchedEpoch)
+				if err != nil {
+					return nil, err
+				}
+				if end.Epoch < p.LastFetchedEpoch || end.EndOffset < p.FetchOffset {
+					pr.DivergingEpoch = &end
+					tr.Partitions = append(tr.Partitions, pr)
+					continue
+				}
+			}
+			leader.replicas.RecordFollowerFetch("t", p.Partition, req.ReplicaID, p.FetchOffset)
+			pr.HighWatermark = leader.replicas.HighWatermark("t", p.Partition)
+
+			region, err := leader.logs.OpenLogRegion("t", p.Partition, p.FetchOffset, math.MaxInt64, p.PartitionMaxBytes)
+			switch {
+			case errors.Is(err, domain.ErrOffsetOutOfRange):
+				pr.ErrorCode = domain.ErrorOffsetOutOfRange
+			case err != nil:
+				return nil, err
+			case region.Length > 0:
+				pr.Records = make([]byte, region.Length)
+				if _, err := region.File.ReadAt(pr.Records, region.Offset); err != nil && err != io.EOF {
+					return nil, err
+				}
+				region.Close()
+			}
+			tr.Partitions = append(tr.Partitions, pr)
+		}
+		body.Responses = append(body.Responses, tr)
+	}
+	return body, nil
+}
+
+func (c *memClient) AlterPartition(req *request.AlterPartitionRequest) (*response.AlterPartitionResponseBody, error) {
+	part := req.Topics[0].Partitions[0]
+	pm := c.repo.partition()
+
+	result := response.AlterPartitionPartitionResult{PartitionIndex: part.PartitionIndex}
+	if part.LeaderEpoch != pm.LeaderEpoch || part.PartitionEpoch != pm.PartitionEpoch {
+		result.ErrorCode = domain.ErrorInvalidUpdateVersion
+	} else {
+		pm.ISR = append([]int32(nil), part.NewISR...)
+		pm.PartitionEpoch++
+		c.repo.setPartition(pm)
+	}
+
+	result.LeaderID = pm.LeaderID
+	result.LeaderEpoch = pm.LeaderEpoch
+	result.ISR = pm.ISR
+	result.PartitionEpoch = pm.PartitionEpoch
+	return &response.AlterPartitionResponseBody{
+		Topics: []response.AlterPartitionTopicResult{{TopicID: testTopicID, Partitions: []response.AlterPartitionPartitionResult{result}}},
+	}, nil
+}
+
+func newTestCluster(t *testing.T, pm domain.PartitionMetadata, ids ...int32) (*testRepo, map[int32]*testBroker) {
+	t.Helper()
+	return newTestClusterWithLag(t, time.Minute, pm, ids...)
+}
+
+func newTestClusterWithLag(
+	t *testing.T,
+	lag time.Duration,
+	pm domain.PartitionMetadata,
+	ids ...int32,
+) (*testRepo, map[int32]*testBroker) {
+
+	t.Helper()
+
+	repo := &testRepo{topic: &domain.TopicMetadata{Name: "t", TopicID: testTopicID}}
+	repo.topic.Partitions = []domain.PartitionMetadata{pm}
+	client := &memClient{repo: repo, brokers: map[int32]*testBroker{}}
+
+	brokers := map[int32]*testBroker{}
+	for _, id := range ids {
+		logs, err := storage.NewLogManager([]string{t.TempDir()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		b := &testBroker{
+			logs: logs,
+			replicas: NewReplicaManager(Config{
+				NodeID:            id,
+				FetchBackoff:      time.Millisecond,
+				ReplicaLagTimeMax: lag,
+			}, repo, logs, client),
+		}
+		brokers[id] = b
+		t.Cleanup(b.replicas.Close)
+	}
+
+	client.mu.Lock()
+	client.brokers = brokers
+	client.mu.Unlock()
+
+	for _, b := range brokers {
+		b.replicas.Start()
+	}
+	return repo, brokers
+}
+
+func appendAsLeader(t *testing.T, b *testBroker, records int32) domain.LogAppendInfo {
+	t.Helper()
+
+	info, err := b.logs.AppendLog("t", 0, testBatch(records), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.replicas.RecordAppend("t", 0, info.LastOffset+1)
+	return info
+}
+
+func eventually(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestReplicaManager_SingleReplicaAdvancesWithLog(t *testing.T) {
+	_, brokers := newTestCluster(t, domain.PartitionMetadata{LeaderID: 1, Replicas: []int32{1}, ISR: []int32{1}}, 1)
+	leader := brokers[1]
+
+	info := appendAsLeader(t, leader, 3)
+	if hw := leader.replicas.HighWatermark("t", 0); hw != 3 {
+		t.Fatalf("expected high watermark 3, got %d", hw)
+	}
+	if err := leader.replicas.WaitForHighWatermark("t", 0, info.LastOffset+1, 1, time.Second); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReplicaManager_FollowerReplicatesAndAdvancesHighWatermark(t *testing.T) {
+	pm := domain.PartitionMetadata{LeaderID: 1, LeaderEpoch: 1, Replicas: []int32{1, 2}, ISR: []int32{1, 2}}
+	_, brokers := newTestCluster(t, pm, 1, 2)
+	leader, follower := brokers[1], brokers[2]
+
+	info := appendAsLeader(t, leader, 2)
+	if err := leader.replicas.WaitForHighWatermark("t", 0, info.LastOffset+1, 1, 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	end, err := follower.logs.LogEndOffset("t", 0)
+	if err != nil || end != 2 {
+		t.Fatalf("expected follower log end offset 2, got %d", end)
+	}
+	eventually(t, func() bool { return follower.replicas.HighWatermark("t", 0) == 2 })
+}
+
+func TestReplicaManager_WaitTimesOutWithoutInSyncFollower(t *testing.T) {
+	pm := domain.PartitionMetadata{LeaderID: 1, Replicas: []int32{1, 3}, ISR: []int32{1, 3}}
+	_, brokers := newTestCluster(t, pm, 1)
+	leader := brokers[1]
+
+	info := appendAsLeader(t, leader, 1)
+	err := leader.replicas.WaitForHighWatermark("t", 0, info.LastOffset+1, 1, 20*time.Millisecond)
+	if !errors.Is(err, domain.ErrReplicationTimedOut) {
+		t.Fatalf("expected replication timeout, got %v", err)
+	}
+	if hw := leader.replicas.HighWatermark("t", 0); hw != 0 {
+		t.Fatalf("expected high watermark 0, got %d", hw)
+	}
+}
+
+func TestReplicaManager_FollowerTruncatesDivergentLog(t *testing.T) {
+	pm := domain.PartitionMetadata{LeaderID: 1, LeaderEpoch: 1, Replicas: []int32{1, 2}, ISR: []int32{1}}
+	repo, brokers := newTestCluster(t, domain.PartitionMetadata{LeaderID: -1, Replicas: []int32{1, 2}}, 1, 2)
+	leader, follower := brokers[1], brokers[2]
+
+	if _, err := follower.logs.AppendLog("t", 0, testBatch(4), 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := leader.logs.AppendLog("t", 0, testBatch(1), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	repo.setPartition(pm)
+
+	eventually(t, func() bool {
+		end, _ := follower.logs.LogEndOffset("t", 0)
+		return end == 1
+	})
+}
+
+func TestReplicaManager_FollowerTruncatesToDivergingEpoch(t *testing.T) {
+	pm := domain.PartitionMetadata{LeaderID: 1, LeaderEpoch: 1, Replicas: []int32{1, 2}, ISR: []int32{1}}
+	repo, brokers := newTestCluster(t, domain.PartitionMetadata{LeaderID: -1, Replicas: []int32{1, 2}}, 1, 2)
+	leader, follower := brokers[1], brokers[2]
+
+	if _, err := follower.logs.AppendLog("t", 0, testBatch(4), 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := leader.logs.AppendLog("t", 0, testBatch(2), 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := leader.logs.AppendLog("t", 0, testBatch(4), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	repo.setPartition(pm)
+
+	eventually(t, func() bool {
+		end, _ := follower.logs.LogEndOffset("t", 0)
+		return end == 6
+	})
+
+	got, err := follower.logs.EndOffsetForEpoch("t", 0, 0)
+	if err != nil || got != (domain.EpochEndOffset{Epoch: 0, EndOffset: 2}) {
+		t.Fatalf("expected epoch 0 to end at 2 on the follower, got %+v (%v)", got, err)
+	}
+}
+
+func TestReplicaManager_LeaderChangeFailsWaiters(t *testing.T) {
+	pm := domain.PartitionMetadata{LeaderID: 1, Replicas: []int32{1, 3}, ISR: []int32{1, 3}}
+	repo, brokers := newTestCluster(t, pm, 1)
+	leader := brokers[1]
+
+	info := appendAsLeader(t, leader, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- leader.replicas.WaitForHighWatermark("t", 0, info.LastOffset+1, 1, 5*time.Second)
+	}()
+
+	repo.setPartition(domain.PartitionMetadata{LeaderID: 3, LeaderEpoch: 1, Replicas: []int32{1, 3}, ISR: []int32{3}})
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, domain.ErrNotLeaderOrFollower) {
+			t.Fatalf("expected not leader error, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("waiter not woken by leader change")
+	}
+}
+
+func TestReplicaManager_ShrinksLaggingFollowerOutOfISR(t *testing.T) {
+	pm := domain.PartitionMetadata{LeaderID: 1, Replicas: []int32{1, 3}, ISR: []int32{1, 3}}
+	repo, brokers := newTestClusterWithLag(t, 20*time.Millisecond, pm, 1)
+	leader := brokers[1]
+
+	info := appendAsLeader(t, leader, 2)
+	eventually(t, func() bool { return slices.Equal(leader.replicas.ISR("t", 0), []int32{1}) })
+
+	if got := repo.partition(); got.PartitionEpoch != 1 || !slices.Equal(got.ISR, []int32{1}) {
+		t.Fatalf("expected committed isr [1], got %+v", got)
+	}
+	if hw := leader.replicas.HighWatermark("t", 0); hw != 2 {
+		t.Fatalf("expected high watermark 2 after shrink, got %d", hw)
+	}
+
+	err := leader.replicas.WaitForHighWatermark("t", 0, info.LastOffset+1, 2, time.Second)
+	if !errors.Is(err, domain.ErrNotEnoughReplicas) {
+		t.Fatalf("expected not enough replicas, got %v", err)
+	}
+}
+
+func TestReplicaManager_ExpandsCaughtUpFollowerIntoISR(t *testing.T) {
+	pm := domain.PartitionMetadata{LeaderID: 1, Replicas: []int32{1, 2}, ISR: []int32{1}}
+	repo, brokers := newTestCluster(t, pm, 1, 2)
+	leader := brokers[1]
+
+	appendAsLeader(t, leader, 3)
+	eventually(t, func() bool { return slices.Equal(leader.replicas.ISR("t", 0), []int32{1, 2}) })
+
+	if got := repo.partition(); !slices.Equal(got.ISR, []int32{1, 2}) {
+		t.Fatalf("expected committed isr [1 2], got %+v", got)
+	}
+}
+
+func TestReplicaManager_RemovedReplicaDeletesLog(t *testing.T) {
+	pm := domain.PartitionMetadata{LeaderID: 1, Replicas: []int32{1, 2}, ISR: []int32{1, 2}}
+	repo, brokers := newTestCluster(t, pm, 1, 2)
+	leader, follower := brokers[1], brokers[2]
+
+	appendAsLeader(t, leader, 3)
+	eventually(t, func() bool {
+		end, _ := follower.logs.LogEndOffset("t", 0)
+		return end == 3
+	})
+
+	repo.setPartition(domain.PartitionMetadata{LeaderID: 1, LeaderEpoch: 1, Replicas: []int32{1}, ISR: []int32{1}})
+
+	for _, dir := range follower.logs.DescribeLogDirs() {
+		if len(dir.Partitions) != 0 {
+			t.Fatalf("expected the removed replica's log to be deleted, got %+v", dir.Partitions)
+		}
+	}
+	if end, _ := leader.logs.LogEndOffset("t", 0); end != 3 {
+		t.Fatalf("expected the leader to keep its log, got end offset %d", end)
+	}
+}
+
+type reentrantLogs struct {
+	ports.LogManager
+	replicas *ReplicaManager
+}
+
+func (l *reentrantLogs) LogEndOffset(topicName string, partition int32) (int64, error) {
+	l.replicas.ISR(topicName, partition)
+	return l.LogManager.LogEndOffset(topicName, partition)
+}
+
+func (l *reentrantLogs) DeleteLog(topicName string, partition int32) error {
+	l.replicas.ISR(topicName, partition)
+	return l.LogManager.DeleteLog(topicName, partition)
+}
+
+func TestReplicaManager_SyncsLogsOutsideLock(t *testing.T) {
+	logs, err := storage.NewLogManager([]string{t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrapped := &reentrantLogs{LogManager: logs}
+	repo := &testRepo{topic: &domain.TopicMetadata{Name: "t", TopicID: testTopicID}}
+	b := &testBroker{logs: logs, replicas: NewReplicaManager(Config{NodeID: 1}, repo, wrapped, &memClient{repo: repo})}
+	wrapped.replicas = b.replicas
+	t.Cleanup(b.replicas.Close)
+	b.replicas.Start()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		repo.setPartition(domain.PartitionMetadata{LeaderID: 1, Replicas: []int32{1}, ISR: []int32{1}})
+		appendAsLeader(t, b, 2)
+		repo.setPartition(domain.PartitionMetadata{LeaderID: 2, LeaderEpoch: 1, Replicas: []int32{2}, ISR: []int32{2}})
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("metadata sync blocked on the replica manager lock")
+	}
+
+	if len(logs.DescribeLogDirs()[0].Partitions) != 0 {
+		t.Fatalf("expected the removed replica's log to be deleted, got %+v", logs.DescribeLogDirs())
+	}
+}
+
+func TestReplicaManager_AssignsLogDirectoryFromMetadata(t *testing.T) {
+	logs, err := storage.NewLogManager([]string{t.TempDir(), t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := logs.Dirs()[1]
+
+	repo := &testRepo{topic: &domain.TopicMetadata{Name: "t", TopicID: testTopicID}}
+	b := &testBroker{logs: logs, replicas: NewReplicaManager(Config{NodeID: 1}, repo, logs, &memClient{repo: repo})}
+	t.Cleanup(b.replicas.Close)
+	b.replicas.Start()
+
+	repo.setPartition(domain.PartitionMetadata{
+		LeaderID:    1,
+		Replicas:    []int32{1},
+		ISR:         []int32{1},
+		Directories: [][16]byte{target.ID},
+	})
+	appendAsLeader(t, b, 1)
+
+	for _, dir := range logs.DescribeLogDirs() {
+		if (len(dir.Partitions) == 1) != (dir.Path == target.Path) {
+			t.Fatalf("expected the new log in %s, got %+v", target.Path, logs.DescribeLogDirs())
+		}
+	}
+}
+
+func TestReplicaManager_ThrottlesReassigningReplicas(t *testing.T) {
+	pm := domain.PartitionMetadata{LeaderID: 1, Replicas: []int32{1, 2, 3}, ISR: []int32{1, 3}, AddingReplicas: []int32{2}}
+	_, brokers := newTestCluster(t, pm, 1)
+	leader := brokers[1].replicas
+
+	leader.mu.Lock()
+	leader.leaderThrottle.rate = 10
+	leader.mu.Unlock()
+
+	if leader.ThrottleLeaderFetch("t", 0, 2) {
+		t.Fatal("fetch throttled before any bytes were sent")
+	}
+	leader.RecordLeaderFetchBytes("t", 0, 2, 10)
+	if !leader.ThrottleLeaderFetch("t", 0, 2) {
+		t.Fatal("expected the adding replica to be throttled once the rate is used up")
+	}
+	if leader.ThrottleLeaderFetch("t", 0, 3) {
+		t.Fatal("in-sync replicas must never be throttled")
+	}
+
+	th := &throttle{rate: 10}
+	now := time.Now()
+	th.record(10, now)
+	if !th.exceeded(now) || th.exceeded(now.Add(throttleWindow)) {
+		t.Fatal("expected the throttle to reset after one window")
+	}
+}
+
+func TestReplicaManager_PreferredReadReplicaInClientRack(t *testing.T) {
+	pm := domain.PartitionMetadata{LeaderID: 1, Replicas: []int32{1, 2, 3}, ISR: []int32{1, 2, 3}}
+	repo, brokers := newTestCluster(t, pm, 1)
+	leader := brokers[1]
+
+	a, b := "a", "b"
+	repo.mu.Lock()
+	repo.brokers = []domain.BrokerRegistration{{ID: 1, Rack: &a}, {ID: 2, Rack: &b}, {ID: 3, Rack: &b}}
+	repo.mu.Unlock()
+	leader.replicas.mu.Lock()
+	leader.replicas.cfg.Rack = a
+	leader.replicas.mu.Unlock()
+
+	appendAsLeader(t, leader, 2)
+	leader.replicas.RecordFollowerFetch("t", 0, 2, 1)
+	leader.replicas.RecordFollowerFetch("t", 0, 3, 2)
+
+	cases := []struct {
+		rack   string
+		offset int64
+		want   int32
+	}{
+		{"b", 0, 3},
+		{"b", 2, 3},
+		{"a", 0, -1},
+		{"c", 0, -1},
+		{"", 0, -1},
+	}
+	for _, tc := range cases {
+		got := leader.replicas.PreferredReadReplica("t", 0, tc.offset, domain.ClientMetadata{RackID: tc.rack})
+		if got != tc.want {
+			t.Fatalf("rack %q offset %d: expected %d, got %d", tc.rack, tc.offset, tc.want, got)
+		}
+	}
+
+	repo.mu.Lock()
+	repo.brokers[2].Fenced = true
+	repo.mu.Unlock()
+	if got := leader.replicas.PreferredReadReplica("t", 0, 0, domain.ClientMetadata{RackID: b}); got != 2 {
+		t.Fatalf("fenced brokers must not be preferred, got %d", got)
+	}
+
+	leader.replicas.mu.Lock()
+	leader.replicas.cfg.ReplicaSelector = LeaderSelector{}
+	leader.replicas.mu.Unlock()
+	if got := leader.replicas.PreferredReadReplica("t", 0, 0, domain.ClientMetadata{RackID: b}); got != -1 {
+		t.Fatalf("leader selector must keep reads on the leader, got %d", got)
+	}
+}
+
+func testBatch(records int32) []byte {
+	b := make([]byte, 61)
+	binary.BigEndian.PutUint32(b[8:12], uint32(len(b)-12))
+	b[16] = 2
+	binary.BigEndian.PutUint32(b[23:27], uint32(records-1))
+	binary.BigEndian.PutUint32(b[57:61], uint32(records))
+	return b
+}
@@ -0,0 +1,111 @@
+package replication
+
+import (
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+)
+
+type LeaderSelector struct{}
+
+func (LeaderSelector) Select(
+	topicName string,
+	partition int32,
+	client domain.ClientMetadata,
+	view domain.PartitionView,
+) (domain.ReplicaView, bool) {
+
+	return view.Leader, true
+}
+
+type RackAwareSelector struct{}
+
+func (RackAwareSelector) Select(
+	topicName string,
+	partition int32,
+	client domain.ClientMetadata,
+	view domain.PartitionView,
+) (domain.ReplicaView, bool) {
+
+	if client.RackID == "" || view.Leader.Rack == client.RackID {
+		return view.Leader, true
+	}
+
+	var (
+		best  domain.ReplicaView
+		found bool
+	)
+	for _, r := range view.Replicas {
+		if r.Rack != client.RackID {
+			continue
+		}
+		if !found || r.LogEndOffset > best.LogEndOffset ||
+			(r.LogEndOffset == best.LogEndOffset && r.LastCaughtUp.After(best.LastCaughtUp)) {
+			best, found = r, true
+		}
+	}
+	if !found {
+		return view.Leader, true
+	}
+	return best, true
+}
+
+func (rm *ReplicaManager) PreferredReadReplica(
+	topicName string,
+	partition int32,
+	fetchOffset int64,
+	client domain.ClientMetadata,
+) int32 {
+
+	racks := rm.liveBrokerRacks()
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	st, ok := rm.partitions[partitionKey{topicName, partition}]
+	if !ok || !st.isLeader(rm.cfg.NodeID) {
+		return -1
+	}
+
+	now := time.Now()
+	leader := domain.ReplicaView{
+		BrokerID:     rm.cfg.NodeID,
+		Rack:         rm.cfg.Rack,
+		LogEndOffset: st.logEndOffset,
+		LastCaughtUp: now,
+	}
+	view := domain.PartitionView{Leader: leader, Replicas: []domain.ReplicaView{leader}}
+	for _, id := range st.isr {
+		rack, live := racks[id]
+		f, ok := st.followers[id]
+		if id == rm.cfg.NodeID || !live || !ok || f.logEndOffset < fetchOffset {
+			continue
+		}
+		view.Replicas = append(view.Replicas, domain.ReplicaView{
+			BrokerID:     id,
+			Rack:         rack,
+			LogEndOffset: f.logEndOffset,
+			LastCaughtUp: f.lastCaughtUp,
+		})
+	}
+
+	selected, ok := rm.cfg.ReplicaSelector.Select(topicName, partition, client, view)
+	if !ok || selected.BrokerID == rm.cfg.NodeID {
+		return -1
+	}
+	return selected.BrokerID
+}
+
+func (rm *ReplicaManager) liveBrokerRacks() map[int32]string {
+	racks := map[int32]string{}
+	for _, b := range rm.repo.Brokers() {
+		if b.Fenced {
+			continue
+		}
+		racks[b.ID] = ""
+		if b.Rack != nil {
+			racks[b.ID] = *b.Rack
+		}
+	}
+	return racks
+}
@@ -0,0 +1,97 @@
+package replication
+
+import (
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+)
+
+const throttleWindow = time.Second
+
+type throttle struct {
+	rate        int64
+	windowStart time.Time
+	bytes       int64
+}
+
+func (t *throttle) exceeded(now time.Time) bool {
+	if t.rate <= 0 {
+		return false
+	}
+	t.roll(now)
+	return t.bytes >= t.rate
+}
+
+func (t *throttle) record(n int64, now time.Time) {
+	if t.rate <= 0 {
+		return
+	}
+	t.roll(now)
+	t.bytes += n
+}
+
+func (t *throttle) roll(now time.Time) {
+	if now.Sub(t.windowStart) >= throttleWindow {
+		t.windowStart = now
+		t.bytes = 0
+	}
+}
+
+func (rm *ReplicaManager) refreshThrottleRates() {
+	own := rm.repo.BrokerConfigs(strconv.Itoa(int(rm.cfg.NodeID)))
+	cluster := rm.repo.BrokerConfigs("")
+	leader := throttleRate(rm.cfg.LeaderThrottle, domain.BrokerConfigLeaderThrottledRate, own, cluster)
+	follower := throttleRate(rm.cfg.FollowerThrottle, domain.BrokerConfigFollowerThrottledRate, own, cluster)
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	rm.leaderThrottle.rate = leader
+	rm.followerThrottle.rate = follower
+}
+
+func throttleRate(static int64, name string, layers ...map[string]string) int64 {
+	v, ok := domain.LookupConfig(name, layers...)
+	if !ok {
+		return static
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return static
+	}
+	return n
+}
+
+func (rm *ReplicaManager) ThrottleLeaderFetch(topicName string, partition int32, replicaID int32) bool {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	st, ok := rm.partitions[partitionKey{topicName, partition}]
+	if !ok || !st.leaderThrottled || slices.Contains(st.isr, replicaID) {
+		return false
+	}
+	return rm.leaderThrottle.exceeded(time.Now())
+}
+
+func (rm *ReplicaManager) RecordLeaderFetchBytes(topicName string, partition int32, replicaID int32, bytes int64) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	st, ok := rm.partitions[partitionKey{topicName, partition}]
+	if !ok || !st.leaderThrottled || slices.Contains(st.isr, replicaID) {
+		return
+	}
+	rm.leaderThrottle.record(bytes, time.Now())
+}
+
+func (rm *ReplicaManager) followerThrottledLocked(st *partitionState) bool {
+	return st.followerThrottled && !slices.Contains(st.isr, rm.cfg.NodeID)
+}
+
+func throttledRoles(t *domain.TopicMetadata, pm domain.PartitionMetadata, local int32) (bool, bool) {
+	leader := pm.Reassigning() || t.ThrottledReplica(domain.TopicConfigLeaderThrottledReplicas, pm.PartitionIndex, local)
+	follower := slices.Contains(pm.AddingReplicas, local) ||
+		t.ThrottledReplica(domain.TopicConfigFollowerThrottledReplicas, pm.PartitionIndex, local)
+	return leader, follower
+}
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"encoding/base64"
+	"fmt"
+	"slices"
+)
+
+func (img *MetadataImage) ValidateLocalBroker(nodeID int32, dirs [][16]byte) error {
+	for id, b := range img.Brokers {
+		if id == nodeID {
+			continue
+		}
+		for _, dir := range dirs {
+			if slices.Contains(b.LogDirs, dir) {
+				return fmt.Errorf("log directory %s is registered to broker %d, not node.id %d",
+					base64.RawURLEncoding.EncodeToString(dir[:]), id, nodeID)
+			}
+		}
+	}
+
+	b, ok := img.Brokers[nodeID]
+	if !ok || len(b.LogDirs) == 0 {
+		return nil
+	}
+
+	for _, dir := range dirs {
+		if slices.Contains(b.LogDirs, dir) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("none of the local log directories match the registration of broker %d", nodeID)
+}
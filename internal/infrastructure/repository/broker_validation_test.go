@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/metadata/parser"
+)
+
+func TestReplay_BrokerLifecycle(t *testing.T) {
+	rack := "r1"
+	image := buildDomainTopics([]parser.RecordBatch{
+		{
+			Records: []parser.Record{
+				{Value: parser.RecordRegisterBroker{
+					BrokerID:    1,
+					BrokerEpoch: 10,
+					Fenced:      true,
+					Rack:        &rack,
+					EndPoints:   []parser.BrokerEndpoint{{Name: "PLAINTEXT", Host: "localhost", Port: 9092}},
+				}},
+				{Value: parser.RecordUnfenceBroker{ID: 1, Epoch: 10}},
+				{Value: parser.RecordRegisterBroker{BrokerID: 2, BrokerEpoch: 11}},
+				{Value: parser.RecordUnregisterBroker{BrokerID: 2, BrokerEpoch: 11}},
+				{Value: parser.RecordFenceBroker{ID: 1, Epoch: 9}},
+			},
+		},
+	})
+
+	b, ok := image.Broker(1)
+	if !ok {
+		t.Fatal("expected broker 1")
+	}
+	if b.Fenced {
+		t.Fatal("stale fence must be ignored")
+	}
+	if b.Rack == nil || *b.Rack != "r1" || len(b.Endpoints) != 1 || b.Endpoints[0].Port != 9092 {
+		t.Fatalf("unexpected registration %+v", b)
+	}
+	if _, ok := image.Broker(2); ok {
+		t.Fatal("unregistered broker must be gone")
+	}
+
+	delta := NewMetadataDelta(image)
+	delta.Replay(parser.Record{Value: parser.RecordBrokerRegistrationChange{
+		BrokerID:    1,
+		BrokerEpoch: 10,
+		Fenced:      parser.BrokerRegistrationFenced,
+	}})
+	next := delta.Apply()
+
+	if !next.Brokers[1].Fenced || image.Brokers[1].Fenced {
+		t.Fatal("registration change must only affect the new image")
+	}
+}
+
+func TestValidateLocalBroker(t *testing.T) {
+	own := [16]byte{1}
+	other := [16]byte{2}
+
+	image := buildDomainTopics([]parser.RecordBatch{
+		{
+			Records: []parser.Record{
+				{Value: parser.RecordRegisterBroker{BrokerID: 1, LogDirs: [][16]byte{own}}},
+				{Value: parser.RecordRegisterBroker{BrokerID: 2, LogDirs: [][16]byte{other}}},
+			},
+		},
+	})
+
+	if err := image.ValidateLocalBroker(1, [][16]byte{own}); err != nil {
+		t.Fatal(err)
+	}
+	if err := image.ValidateLocalBroker(3, [][16]byte{{3}}); err != nil {
+		t.Fatal("an unregistered broker is valid")
+	}
+	if err := image.ValidateLocalBroker(1, [][16]byte{other}); err == nil {
+		t.Fatal("expected directory registered to another broker to fail")
+	}
+	if err := image.ValidateLocalBroker(1, [][16]byte{{9}}); err == nil {
+		t.Fatal("expected unknown directories to fail")
+	}
+}
+
+func TestReplay_ControllersAndLeaderChange(t *testing.T) {
+	leaderChange := []byte{0, 0, 0, 0, 0, 2}
+	leaderChange = append(leaderChange, 2, 0, 0, 0, 2, 0)
+	leaderChange = append(leaderChange, 1, 0)
+
+	image := buildDomainTopics([]parser.RecordBatch{
+		{
+			Records: []parser.Record{
+				{Value: parser.RecordRegisterController{
+					ControllerID: 2,
+					EndPoints:    []parser.BrokerEndpoint{{Name: "CONTROLLER", Host: "c", Port: 9093}},
+				}},
+			},
+		},
+		{
+			Records: []parser.Record{
+				{Control: &parser.ControlRecord{Type: parser.ControlLeaderChange, Value: leaderChange}},
+			},
+		},
+	})
+
+	if image.ControllerID != 2 {
+		t.Fatalf("unexpected controller id %d", image.ControllerID)
+	}
+	c, ok := image.Controllers[2]
+	if !ok || len(c.Endpoints) != 1 || c.Endpoints[0].Port != 9093 {
+		t.Fatalf("unexpected controller %+v", c)
+	}
+}
@@ -2,24 +2,116 @@ package repository
 
 import (
 	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
 
 	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
 )
 
 type KraftMetadataRepository struct {
-	topics map[string]*domain.TopicMetadata
-	byUUID map[[16]byte]*domain.TopicMetadata
+	image     atomic.Pointer[MetadataImage]
+	publishMu sync.Mutex
+
+	mu          sync.Mutex
+	subscribers map[int]func(domain.MetadataChange)
+	nextID      int
+}
+
+func NewKraftMetadataRepository(meta *MetadataImage) *KraftMetadataRepository {
+	r := &KraftMetadataRepository{
+		subscribers: make(map[int]func(domain.MetadataChange)),
+	}
+	r.image.Store(meta)
+	return r
+}
+
+func (r *KraftMetadataRepository) Publish(image *MetadataImage) {
+	r.publishMu.Lock()
+	defer r.publishMu.Unlock()
+
+	prev := r.image.Swap(image)
+
+	listeners := r.listeners()
+	if len(listeners) == 0 {
+		return
+	}
+
+	for _, change := range diffImages(prev, image) {
+		for _, listener := range listeners {
+			listener(change)
+		}
+	}
 }
 
-func NewKraftMetadataRepository(meta *LoadedMetadata) *KraftMetadataRepository {
-	return &KraftMetadataRepository{
-		topics: meta.ByName,
-		byUUID: meta.ByUUID,
+func (r *KraftMetadataRepository) Subscribe(listener func(domain.MetadataChange)) func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := r.nextID
+	r.nextID++
+	r.subscribers[id] = listener
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.subscribers, id)
 	}
 }
 
+func (r *KraftMetadataRepository) listeners() []func(domain.MetadataChange) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make([]func(domain.MetadataChange), 0, len(r.subscribers))
+	for _, listener := range r.subscribers {
+		out = append(out, listener)
+	}
+	return out
+}
+
+func (r *KraftMetadataRepository) Image() *MetadataImage {
+	return r.image.Load()
+}
+
+func (r *KraftMetadataRepository) Brokers() []domain.BrokerRegistration {
+	brokers := r.image.Load().Brokers
+	out := make([]domain.BrokerRegistration, 0, len(brokers))
+	for _, b := range brokers {
+		out = append(out, *b)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+func (r *KraftMetadataRepository) Controllers() []domain.ControllerRegistration {
+	controllers := r.image.Load().Controllers
+	out := make([]domain.ControllerRegistration, 0, len(controllers))
+	for _, c := range controllers {
+		out = append(out, *c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+func (r *KraftMetadataRepository) ControllerID() int32 {
+	return r.image.Load().ControllerID
+}
+
+func (r *KraftMetadataRepository) FinalizedFeatures() domain.FinalizedFeatures {
+	return r.image.Load().FinalizedFeatures()
+}
+
+func (r *KraftMetadataRepository) BrokerConfigs(resource string) map[string]string {
+	return r.image.Load().BrokerConfigs[resource]
+}
+
+func (r *KraftMetadataRepository) Version() domain.MetadataVersion {
+	return r.image.Load().Version
+}
+
 func (r *KraftMetadataRepository) GetTopic(name string) (*domain.TopicMetadata, error) {
-	t, ok := r.topics[name]
+	t, ok := r.image.Load().Topic(name)
 	if !ok {
 		return nil, errors.New("topic not found")
 	}
@@ -27,9 +119,13 @@ func (r *KraftMetadataRepository) GetTopic(name string) (*domain.TopicMetadata,
 }
 
 func (r *KraftMetadataRepository) GetTopicByID(id [16]byte) (*domain.TopicMetadata, error) {
-	t, ok := r.byUUID[id]
+	t, ok := r.image.Load().TopicByID(id)
 	if !ok {
 		return nil, errors.New("topic not found")
 	}
 	return t, nil
 }
+
+func (r *KraftMetadataRepository) Topics() []*domain.TopicMetadata {
+	return r.image.Load().Topics()
+}
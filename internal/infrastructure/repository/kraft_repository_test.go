@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+	"github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/metadata/parser"
+)
+
+func TestKraftMetadataRepository_PublishNotifiesSubscribers(t *testing.T) {
+	var keep, drop, changed [16]byte
+	keep[0], drop[0], changed[0] = 1, 2, 3
+
+	base := buildDomainTopics([]parser.RecordBatch{
+		{
+			Records: []parser.Record{
+				{Value: parser.RecordTopic{TopicName: "keep", TopicUUID: keep}},
+				{Value: parser.RecordPartition{PartitionID: 0, TopicUUID: keep, Leader: 1}},
+				{Value: parser.RecordTopic{TopicName: "drop", TopicUUID: drop}},
+				{Value: parser.RecordTopic{TopicName: "changed", TopicUUID: changed}},
+				{Value: parser.RecordPartition{PartitionID: 0, TopicUUID: changed, Leader: 1}},
+				{Value: parser.RecordPartition{PartitionID: 1, TopicUUID: changed, Leader: 1}},
+			},
+		},
+	})
+	repo := NewKraftMetadataRepository(base)
+
+	var got []domain.MetadataChange
+	unsubscribe := repo.Subscribe(func(c domain.MetadataChange) {
+		got = append(got, c)
+	})
+
+	var created [16]byte
+	created[0] = 4
+
+	delta := NewMetadataDelta(base)
+	delta.Replay(parser.Record{Value: parser.RecordRemoveTopic{TopicUUID: drop}})
+	delta.Replay(parser.Record{Value: parser.RecordPartitionChange{
+		PartitionID:         1,
+		TopicUUID:           changed,
+		Leader:              2,
+		LeaderRecoveryState: parser.NoLeaderRecoveryStateChange,
+	}})
+	delta.Replay(parser.Record{Value: parser.RecordTopic{TopicName: "new", TopicUUID: created}})
+	delta.Replay(parser.Record{Value: parser.RecordPartition{PartitionID: 0, TopicUUID: created}})
+	delta.SetVersion(domain.MetadataVersion{Offset: 42, Epoch: 3})
+	repo.Publish(delta.Apply())
+
+	expected := []domain.MetadataChange{
+		{Type: domain.PartitionChanged, Topic: "changed", TopicID: changed, Partition: 1},
+		{Type: domain.TopicDeleted, Topic: "drop", TopicID: drop, Partition: -1},
+		{Type: domain.TopicCreated, Topic: "new", TopicID: created, Partition: -1},
+		{Type: domain.PartitionChanged, Topic: "new", TopicID: created, Partition: 0},
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("unexpected changes %+v", got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatalf("change %d: got %+v want %+v", i, got[i], expected[i])
+		}
+	}
+
+	if v := repo.Version(); v.Offset != 42 || v.Epoch != 3 {
+		t.Fatalf("unexpected version %+v", v)
+	}
+
+	unsubscribe()
+	got = nil
+	repo.Publish(base)
+	if len(got) != 0 {
+		t.Fatal("unsubscribed listener was notified")
+	}
+	if _, err := repo.GetTopic("drop"); err != nil {
+		t.Fatal("expected republished base image")
+	}
+}
+
+func TestKraftMetadataRepository_PublishesConfigChanges(t *testing.T) {
+	topicID := [16]byte{1}
+	gzip, two := "gzip", "2"
+
+	base := buildDomainTopics([]parser.RecordBatch{
+		{
+			Records: []parser.Record{
+				{Value: parser.RecordTopic{TopicName: "t", TopicUUID: topicID}},
+				{Value: parser.RecordPartition{PartitionID: 0, TopicUUID: topicID, Leader: 1}},
+			},
+		},
+	})
+	repo := NewKraftMetadataRepository(base)
+
+	var got []domain.MetadataChange
+	repo.Subscribe(func(c domain.MetadataChange) {
+		got = append(got, c)
+	})
+
+	delta := NewMetadataDelta(base)
+	delta.Replay(parser.Record{Value: parser.RecordConfig{
+		ResourceType: parser.ConfigResourceBroker, Name: domain.BrokerConfigCompressionType, Value: &gzip,
+	}})
+	delta.Replay(parser.Record{Value: parser.RecordConfig{
+		ResourceType: parser.ConfigResourceBroker, ResourceName: "1", Name: domain.BrokerConfigMinInsyncReplicas, Value: &two,
+	}})
+	repo.Publish(delta.Apply())
+
+	expected := []domain.MetadataChange{
+		{Type: domain.BrokerConfigChanged, Partition: -1},
+		{Type: domain.TopicConfigChanged, Topic: "t", TopicID: topicID, Partition: -1},
+	}
+	if !slices.Equal(got, expected) {
+		t.Fatalf("unexpected changes %+v", got)
+	}
+
+	meta, _ := repo.GetTopic("t")
+	if v, _ := meta.Config(domain.TopicConfigCompressionType); v != "gzip" {
+		t.Fatalf("expected the cluster default to apply to the topic, got %q", v)
+	}
+	if meta.MinInsyncReplicas() != domain.DefaultMinInsyncReplicas {
+		t.Fatal("per-broker configs must not change topic defaults")
+	}
+	if repo.BrokerConfigs("1")[domain.BrokerConfigMinInsyncReplicas] != "2" {
+		t.Fatalf("unexpected broker configs %v", repo.BrokerConfigs("1"))
+	}
+	if _, ok := base.ByName["t"].Config(domain.TopicConfigCompressionType); ok {
+		t.Fatal("the base image must not see the new defaults")
+	}
+
+	got = nil
+	delta = NewMetadataDelta(repo.Image())
+	delta.Replay(parser.Record{Value: parser.RecordConfig{
+		ResourceType: parser.ConfigResourceBroker, Name: domain.BrokerConfigCompressionType,
+	}})
+	repo.Publish(delta.Apply())
+
+	meta, _ = repo.GetTopic("t")
+	if _, ok := meta.Config(domain.TopicConfigCompressionType); ok || len(got) != 2 {
+		t.Fatalf("expected the cluster default to be removed, got %v and changes %+v", meta.Defaults, got)
+	}
+	if _, ok := repo.Image().BrokerConfigs[""]; ok {
+		t.Fatal("an emptied resource must be dropped")
+	}
+}
+
+func TestKraftMetadataRepository_ConcurrentReadsDuringPublish(t *testing.T) {
+	var id [16]byte
+	id[0] = 1
+
+	image := buildDomainTopics([]parser.RecordBatch{
+		{Records: []parser.Record{{Value: parser.RecordTopic{TopicName: "t", TopicUUID: id}}}},
+	})
+	repo := NewKraftMetadataRepository(image)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				if _, err := repo.GetTopic("t"); err != nil {
+					t.Error(err)
+					return
+				}
+				repo.GetTopicByID(id)
+				repo.Version()
+			}
+		}()
+	}
+
+	for j := 0; j < 100; j++ {
+		delta := NewMetadataDelta(repo.Image())
+		delta.SetVersion(domain.MetadataVersion{Offset: int64(j)})
+		repo.Publish(delta.Apply())
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,349 @@
+package repository
+
+import (
+	"sort"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+	"github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/metadata/parser"
+)
+
+type MetadataDelta struct {
+	base    *MetadataImage
+	version domain.MetadataVersion
+	changed map[[16]byte]*domain.TopicMetadata
+	removed map[[16]byte]bool
+	brokers map[int32]*domain.BrokerRegistration
+	gone    map[int32]bool
+
+	controllers  map[int32]*domain.ControllerRegistration
+	controllerID int32
+
+	features      map[string]int16
+	brokerConfigs map[string]map[string]string
+}
+
+func NewMetadataDelta(base *MetadataImage) *MetadataDelta {
+	return &MetadataDelta{
+		base:    base,
+		version: base.Version,
+		changed: make(map[[16]byte]*domain.TopicMetadata),
+		removed: make(map[[16]byte]bool),
+		brokers: make(map[int32]*domain.BrokerRegistration),
+		gone:    make(map[int32]bool),
+
+		controllers:  make(map[int32]*domain.ControllerRegistration),
+		controllerID: base.ControllerID,
+	}
+}
+
+func (d *MetadataDelta) SetVersion(v domain.MetadataVersion) {
+	d.version = v
+}
+
+func (d *MetadataDelta) Replay(rec parser.Record) {
+	if rec.Control != nil {
+		d.replayControl(rec.Control)
+		return
+	}
+
+	switch v := rec.Value.(type) {
+	case parser.RecordTopic:
+		tm := d.mutableTopic(v.TopicUUID)
+		tm.Name = v.TopicName
+
+	case parser.RecordPartition:
+		tm := d.mutableTopic(v.TopicUUID)
+		upsertPartition(tm, domain.PartitionMetadata{
+			PartitionIndex:         v.PartitionID,
+			LeaderID:               v.Leader,
+			LeaderEpoch:            v.LeaderEpoch,
+			PartitionEpoch:         v.PartitionEpoch,
+			LeaderRecoveryState:    v.LeaderRecoveryState,
+			Replicas:               append([]int32(nil), v.ReplicaArray...),
+			ISR:                    append([]int32(nil), v.SyncReplicaArray...),
+			RemovingReplicas:       append([]int32(nil), v.RemovingReplicaArray...),
+			AddingReplicas:         append([]int32(nil), v.AddingReplicaArray...),
+			EligibleLeaderReplicas: append([]int32(nil), v.EligibleLeaderReplicas...),
+			LastKnownELR:           append([]int32(nil), v.LastKnownELR...),
+			Directories:            append([][16]byte(nil), v.DirectoriesArray...),
+		})
+
+	case parser.RecordPartitionChange:
+		if !d.exists(v.TopicUUID) {
+			return
+		}
+		tm := d.mutableTopic(v.TopicUUID)
+		for i := range tm.Partitions {
+			if tm.Partitions[i].PartitionIndex == v.PartitionID {
+				applyPartitionChange(&tm.Partitions[i], v)
+			}
+		}
+
+	case parser.RecordConfig:
+		switch v.ResourceType {
+		case parser.ConfigResourceTopic:
+			if id, ok := d.topicID(v.ResourceName); ok {
+				setTopicConfig(d.mutableTopic(id), v.Name, v.Value)
+			}
+		case parser.ConfigResourceBroker:
+			d.setBrokerConfig(v.ResourceName, v.Name, v.Value)
+		}
+
+	case parser.RecordRemoveTopic:
+		delete(d.changed, v.TopicUUID)
+		d.removed[v.TopicUUID] = true
+
+	case parser.RecordRegisterBroker:
+		d.registerBroker(v)
+
+	case parser.RecordUnregisterBroker:
+		if b := d.mutableBroker(v.BrokerID); b != nil && b.Epoch == v.BrokerEpoch {
+			delete(d.brokers, v.BrokerID)
+			d.gone[v.BrokerID] = true
+		}
+
+	case parser.RecordFenceBroker:
+		if b := d.mutableBroker(v.ID); b != nil && b.Epoch == v.Epoch {
+			b.Fenced = true
+		}
+
+	case parser.RecordUnfenceBroker:
+		if b := d.mutableBroker(v.ID); b != nil && b.Epoch == v.Epoch {
+			b.Fenced = false
+		}
+
+	case parser.RecordFeatureLevel:
+		d.setFeatureLevel(v.Name, v.FeatureLevel)
+
+	case parser.RecordRegisterController:
+		d.registerController(v)
+
+	case parser.RecordBrokerRegistrationChange:
+		if b := d.mutableBroker(v.BrokerID); b != nil && b.Epoch == v.BrokerEpoch {
+			applyRegistrationChange(b, v)
+		}
+
+	default:
+	}
+}
+
+func (d *MetadataDelta) Apply() *MetadataImage {
+	img := &MetadataImage{
+		Version: d.version,
+		ByName:  make(map[string]*domain.TopicMetadata, len(d.base.ByName)),
+		ByUUID:  make(map[[16]byte]*domain.TopicMetadata, len(d.base.ByUUID)),
+	}
+
+	for id, tm := range d.base.ByUUID {
+		if d.removed[id] {
+			continue
+		}
+		if _, ok := d.changed[id]; ok {
+			continue
+		}
+		img.ByUUID[id] = tm
+	}
+
+	for id, tm := range d.changed {
+		img.ByUUID[id] = tm
+	}
+
+	for _, tm := range img.ByUUID {
+		if tm.Name != "" {
+			img.ByName[tm.Name] = tm
+		}
+	}
+
+	img.Brokers = make(map[int32]*domain.BrokerRegistration, len(d.base.Brokers))
+	for id, b := range d.base.Brokers {
+		if !d.gone[id] {
+			img.Brokers[id] = b
+		}
+	}
+	for id, b := range d.brokers {
+		img.Brokers[id] = b
+	}
+
+	img.Features = d.base.Features
+	if d.features != nil {
+		img.Features = d.features
+	}
+
+	img.StaticConfigs = d.base.StaticConfigs
+	img.BrokerConfigs = d.base.BrokerConfigs
+	if d.brokerConfigs != nil {
+		img.BrokerConfigs = d.brokerConfigs
+	}
+	d.applyTopicDefaults(img)
+
+	img.ControllerID = d.controllerID
+	img.Controllers = make(map[int32]*domain.ControllerRegistration, len(d.base.Controllers))
+	for id, c := range d.base.Controllers {
+		img.Controllers[id] = c
+	}
+	for id, c := range d.controllers {
+		img.Controllers[id] = c
+	}
+
+	return img
+}
+
+func (d *MetadataDelta) setFeatureLevel(name string, level int16) {
+	if d.features == nil {
+		d.features = make(map[string]int16, len(d.base.Features)+1)
+		for k, v := range d.base.Features {
+			d.features[k] = v
+		}
+	}
+
+	if level == 0 {
+		delete(d.features, name)
+		return
+	}
+	d.features[name] = level
+}
+
+func (d *MetadataDelta) setBrokerConfig(resource, name string, value *string) {
+	if d.brokerConfigs == nil {
+		d.brokerConfigs = make(map[string]map[string]string, len(d.base.BrokerConfigs)+1)
+		for k, v := range d.base.BrokerConfigs {
+			d.brokerConfigs[k] = v
+		}
+	}
+
+	configs := make(map[string]string, len(d.brokerConfigs[resource])+1)
+	for k, v := range d.brokerConfigs[resource] {
+		configs[k] = v
+	}
+	if value == nil {
+		delete(configs, name)
+	} else {
+		configs[name] = *value
+	}
+
+	if len(configs) == 0 {
+		delete(d.brokerConfigs, resource)
+		return
+	}
+	d.brokerConfigs[resource] = configs
+}
+
+func (d *MetadataDelta) applyTopicDefaults(img *MetadataImage) {
+	defaults := domain.TopicConfigDefaults(img.BrokerConfigs[""], img.StaticConfigs)
+
+	for id, tm := range img.ByUUID {
+		if _, ok := d.changed[id]; ok {
+			tm.Defaults = defaults
+			continue
+		}
+		if d.brokerConfigs == nil {
+			continue
+		}
+		c := *tm
+		c.Defaults = defaults
+		img.ByUUID[id] = &c
+		if c.Name != "" {
+			img.ByName[c.Name] = &c
+		}
+	}
+}
+
+func (d *MetadataDelta) exists(id [16]byte) bool {
+	if _, ok := d.changed[id]; ok {
+		return true
+	}
+	if d.removed[id] {
+		return false
+	}
+	_, ok := d.base.ByUUID[id]
+	return ok
+}
+
+func (d *MetadataDelta) topicID(name string) ([16]byte, bool) {
+	for id, tm := range d.changed {
+		if tm.Name == name {
+			return id, true
+		}
+	}
+	if tm, ok := d.base.ByName[name]; ok && !d.removed[tm.TopicID] {
+		return tm.TopicID, true
+	}
+	return [16]byte{}, false
+}
+
+func (d *MetadataDelta) mutableTopic(id [16]byte) *domain.TopicMetadata {
+	if tm, ok := d.changed[id]; ok {
+		return tm
+	}
+
+	var tm *domain.TopicMetadata
+	if base, ok := d.base.ByUUID[id]; ok && !d.removed[id] {
+		tm = base.Clone()
+	} else {
+		tm = &domain.TopicMetadata{
+			TopicID:    id,
+			Partitions: []domain.PartitionMetadata{},
+		}
+	}
+
+	delete(d.removed, id)
+	d.changed[id] = tm
+	return tm
+}
+
+func setTopicConfig(tm *domain.TopicMetadata, name string, value *string) {
+	if value == nil {
+		delete(tm.Configs, name)
+		return
+	}
+	if tm.Configs == nil {
+		tm.Configs = map[string]string{}
+	}
+	tm.Configs[name] = *value
+}
+
+func upsertPartition(tm *domain.TopicMetadata, pm domain.PartitionMetadata) {
+	for i := range tm.Partitions {
+		if tm.Partitions[i].PartitionIndex == pm.PartitionIndex {
+			tm.Partitions[i] = pm
+			return
+		}
+	}
+
+	tm.Partitions = append(tm.Partitions, pm)
+	sort.Slice(tm.Partitions, func(i, j int) bool {
+		return tm.Partitions[i].PartitionIndex < tm.Partitions[j].PartitionIndex
+	})
+}
+
+func applyPartitionChange(pm *domain.PartitionMetadata, v parser.RecordPartitionChange) {
+	if v.ISR != nil {
+		pm.ISR = append([]int32(nil), v.ISR...)
+	}
+	if v.Leader != parser.NoLeaderChange {
+		pm.LeaderID = v.Leader
+		pm.LeaderEpoch++
+	}
+	if v.Replicas != nil {
+		pm.Replicas = append([]int32(nil), v.Replicas...)
+	}
+	if v.RemovingReplicas != nil {
+		pm.RemovingReplicas = append([]int32(nil), v.RemovingReplicas...)
+	}
+	if v.AddingReplicas != nil {
+		pm.AddingReplicas = append([]int32(nil), v.AddingReplicas...)
+	}
+	if v.LeaderRecoveryState != parser.NoLeaderRecoveryStateChange {
+		pm.LeaderRecoveryState = v.LeaderRecoveryState
+	}
+	if v.EligibleLeaderReplicas != nil {
+		pm.EligibleLeaderReplicas = append([]int32(nil), v.EligibleLeaderReplicas...)
+	}
+	if v.LastKnownELR != nil {
+		pm.LastKnownELR = append([]int32(nil), v.LastKnownELR...)
+	}
+	if v.Directories != nil {
+		pm.Directories = append([][16]byte(nil), v.Directories...)
+	}
+	pm.PartitionEpoch++
+}
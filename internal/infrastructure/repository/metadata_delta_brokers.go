@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+	"github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/metadata/parser"
+)
+
+func (d *MetadataDelta) registerBroker(v parser.RecordRegisterBroker) {
+	b := &domain.BrokerRegistration{
+		ID:                   v.BrokerID,
+		Epoch:                v.BrokerEpoch,
+		IncarnationID:        v.IncarnationID,
+		Fenced:               v.Fenced,
+		InControlledShutdown: v.InControlledShutdown,
+		LogDirs:              append([][16]byte(nil), v.LogDirs...),
+	}
+	if v.Rack != nil {
+		rack := *v.Rack
+		b.Rack = &rack
+	}
+	b.Endpoints = convertEndpoints(v.EndPoints)
+
+	delete(d.gone, v.BrokerID)
+	d.brokers[v.BrokerID] = b
+}
+
+func (d *MetadataDelta) registerController(v parser.RecordRegisterController) {
+	d.controllers[v.ControllerID] = &domain.ControllerRegistration{
+		ID:            v.ControllerID,
+		IncarnationID: v.IncarnationID,
+		Endpoints:     convertEndpoints(v.EndPoints),
+	}
+}
+
+func (d *MetadataDelta) replayControl(c *parser.ControlRecord) {
+	if c.Type != parser.ControlLeaderChange {
+		return
+	}
+
+	lc, err := parser.ParseLeaderChange(c)
+	if err != nil {
+		return
+	}
+	d.controllerID = lc.LeaderID
+}
+
+func convertEndpoints(endpoints []parser.BrokerEndpoint) []domain.BrokerEndpoint {
+	out := make([]domain.BrokerEndpoint, 0, len(endpoints))
+	for _, ep := range endpoints {
+		out = append(out, domain.BrokerEndpoint{
+			Listener:         ep.Name,
+			Host:             ep.Host,
+			Port:             int32(ep.Port),
+			SecurityProtocol: ep.SecurityProtocol,
+		})
+	}
+	return out
+}
+
+func (d *MetadataDelta) mutableBroker(id int32) *domain.BrokerRegistration {
+	if b, ok := d.brokers[id]; ok {
+		return b
+	}
+	if d.gone[id] {
+		return nil
+	}
+
+	b, ok := d.base.Brokers[id]
+	if !ok {
+		return nil
+	}
+
+	c := b.Clone()
+	d.brokers[id] = c
+	return c
+}
+
+func applyRegistrationChange(b *domain.BrokerRegistration, v parser.RecordBrokerRegistrationChange) {
+	switch v.Fenced {
+	case parser.BrokerRegistrationFenced:
+		b.Fenced = true
+	case parser.BrokerRegistrationUnfenced:
+		b.Fenced = false
+	}
+
+	if v.InControlledShutdown == parser.BrokerRegistrationInShutdown {
+		b.InControlledShutdown = true
+	}
+
+	if v.LogDirs != nil {
+		b.LogDirs = append([][16]byte(nil), v.LogDirs...)
+	}
+}
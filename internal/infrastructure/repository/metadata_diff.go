@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"maps"
+	"slices"
+	"sort"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+)
+
+func diffImages(prev, next *MetadataImage) []domain.MetadataChange {
+	changes := make([]domain.MetadataChange, 0)
+
+	for id, tm := range prev.ByUUID {
+		if _, ok := next.ByUUID[id]; !ok {
+			changes = append(changes, domain.MetadataChange{
+				Type:      domain.TopicDeleted,
+				Topic:     tm.Name,
+				TopicID:   id,
+				Partition: -1,
+			})
+		}
+	}
+
+	for id, tm := range next.ByUUID {
+		old, ok := prev.ByUUID[id]
+		if ok && old == tm {
+			continue
+		}
+
+		if !ok {
+			changes = append(changes, domain.MetadataChange{
+				Type:      domain.TopicCreated,
+				Topic:     tm.Name,
+				TopicID:   id,
+				Partition: -1,
+			})
+		}
+
+		if ok && (!maps.Equal(old.Configs, tm.Configs) || !maps.Equal(old.Defaults, tm.Defaults)) {
+			changes = append(changes, domain.MetadataChange{
+				Type:      domain.TopicConfigChanged,
+				Topic:     tm.Name,
+				TopicID:   id,
+				Partition: -1,
+			})
+		}
+
+		for _, pm := range tm.Partitions {
+			if ok && partitionUnchanged(old, pm) {
+				continue
+			}
+			changes = append(changes, domain.MetadataChange{
+				Type:      domain.PartitionChanged,
+				Topic:     tm.Name,
+				TopicID:   id,
+				Partition: pm.PartitionIndex,
+			})
+		}
+	}
+
+	if !maps.EqualFunc(prev.BrokerConfigs, next.BrokerConfigs, maps.Equal[map[string]string]) {
+		changes = append(changes, domain.MetadataChange{Type: domain.BrokerConfigChanged, Partition: -1})
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Topic != changes[j].Topic {
+			return changes[i].Topic < changes[j].Topic
+		}
+		if changes[i].Type != changes[j].Type {
+			return changes[i].Type < changes[j].Type
+		}
+		return changes[i].Partition < changes[j].Partition
+	})
+
+	return changes
+}
+
+func partitionUnchanged(tm *domain.TopicMetadata, pm domain.PartitionMetadata) bool {
+	for _, old := range tm.Partitions {
+		if old.PartitionIndex == pm.PartitionIndex {
+			return partitionEqual(old, pm)
+		}
+	}
+	return false
+}
+
+func partitionEqual(a, b domain.PartitionMetadata) bool {
+	return a.LeaderID == b.LeaderID &&
+		a.LeaderEpoch == b.LeaderEpoch &&
+		a.PartitionEpoch == b.PartitionEpoch &&
+		a.LeaderRecoveryState == b.LeaderRecoveryState &&
+		slices.Equal(a.Replicas, b.Replicas) &&
+		slices.Equal(a.ISR, b.ISR) &&
+		slices.Equal(a.RemovingReplicas, b.RemovingReplicas) &&
+		slices.Equal(a.AddingReplicas, b.AddingReplicas) &&
+		slices.Equal(a.EligibleLeaderReplicas, b.EligibleLeaderReplicas) &&
+		slices.Equal(a.LastKnownELR, b.LastKnownELR) &&
+		slices.Equal(a.Directories, b.Directories)
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"sort"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+)
+
+type MetadataImage struct {
+	Version domain.MetadataVersion
+	ByName  map[string]*domain.TopicMetadata
+	ByUUID  map[[16]byte]*domain.TopicMetadata
+	Brokers map[int32]*domain.BrokerRegistration
+
+	Controllers  map[int32]*domain.ControllerRegistration
+	ControllerID int32
+
+	Features      map[string]int16
+	BrokerConfigs map[string]map[string]string
+	StaticConfigs map[string]string
+}
+
+func EmptyMetadataImage() *MetadataImage {
+	return &MetadataImage{
+		Version: domain.MetadataVersion{Offset: -1, Epoch: -1},
+		ByName:  map[string]*domain.TopicMetadata{},
+		ByUUID:  map[[16]byte]*domain.TopicMetadata{},
+		Brokers: map[int32]*domain.BrokerRegistration{},
+
+		Controllers:  map[int32]*domain.ControllerRegistration{},
+		ControllerID: -1,
+
+		Features:      map[string]int16{},
+		BrokerConfigs: map[string]map[string]string{},
+	}
+}
+
+func (img *MetadataImage) Topic(name string) (*domain.TopicMetadata, bool) {
+	t, ok := img.ByName[name]
+	return t, ok
+}
+
+func (img *MetadataImage) TopicByID(id [16]byte) (*domain.TopicMetadata, bool) {
+	t, ok := img.ByUUID[id]
+	return t, ok
+}
+
+func (img *MetadataImage) Topics() []*domain.TopicMetadata {
+	out := make([]*domain.TopicMetadata, 0, len(img.ByUUID))
+	for _, t := range img.ByUUID {
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+func (img *MetadataImage) Broker(id int32) (*domain.BrokerRegistration, bool) {
+	b, ok := img.Brokers[id]
+	return b, ok
+}
+
+func (img *MetadataImage) FinalizedFeatures() domain.FinalizedFeatures {
+	if len(img.Features) == 0 {
+		return domain.FinalizedFeatures{Epoch: domain.FinalizedFeaturesEpochUnknown}
+	}
+	return domain.FinalizedFeatures{Epoch: img.Version.Offset, Levels: img.Features}
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"sort"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+	"github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/metadata/parser"
+)
+
+func (img *MetadataImage) Records() [][]byte {
+	records := make([][]byte, 0)
+
+	names := make([]string, 0, len(img.Features))
+	for name := range img.Features {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == domain.FeatureMetadataVersion) != (names[j] == domain.FeatureMetadataVersion) {
+			return names[i] == domain.FeatureMetadataVersion
+		}
+		return names[i] < names[j]
+	})
+	for _, name := range names {
+		records = append(records, parser.EncodeFeatureLevel(name, img.Features[name]))
+	}
+
+	for _, id := range sortedKeys(img.Brokers) {
+		b := img.Brokers[id]
+		records = append(records, parser.EncodeRegisterBroker(parser.RecordRegisterBroker{
+			BrokerID:             b.ID,
+			IncarnationID:        b.IncarnationID,
+			BrokerEpoch:          b.Epoch,
+			EndPoints:            parserEndpoints(b.Endpoints),
+			Rack:                 b.Rack,
+			Fenced:               b.Fenced,
+			InControlledShutdown: b.InControlledShutdown,
+			LogDirs:              b.LogDirs,
+		}))
+	}
+
+	for _, id := range sortedKeys(img.Controllers) {
+		c := img.Controllers[id]
+		records = append(records, parser.EncodeRegisterController(parser.RecordRegisterController{
+			ControllerID:  c.ID,
+			IncarnationID: c.IncarnationID,
+			EndPoints:     parserEndpoints(c.Endpoints),
+		}))
+	}
+
+	resources := make([]string, 0, len(img.BrokerConfigs))
+	for resource := range img.BrokerConfigs {
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+	for _, resource := range resources {
+		configs := img.BrokerConfigs[resource]
+		for _, name := range domain.SortedConfigNames(configs) {
+			value := configs[name]
+			records = append(records, parser.EncodeConfig(parser.ConfigResourceBroker, resource, name, &value))
+		}
+	}
+
+	for _, t := range img.Topics() {
+		records = append(records, parser.EncodeTopic(t.Name, t.TopicID))
+		records = appendTopicConfigs(records, t)
+		for _, p := range t.Partitions {
+			records = append(records, parser.EncodePartition(parser.RecordPartition{
+				PartitionID:            p.PartitionIndex,
+				TopicUUID:              t.TopicID,
+				ReplicaArray:           p.Replicas,
+				SyncReplicaArray:       p.ISR,
+				RemovingReplicaArray:   p.RemovingReplicas,
+				AddingReplicaArray:     p.AddingReplicas,
+				Leader:                 p.LeaderID,
+				LeaderRecoveryState:    p.LeaderRecoveryState,
+				LeaderEpoch:            p.LeaderEpoch,
+				PartitionEpoch:         p.PartitionEpoch,
+				DirectoriesArray:       p.Directories,
+				EligibleLeaderReplicas: p.EligibleLeaderReplicas,
+				LastKnownELR:           p.LastKnownELR,
+			}))
+		}
+	}
+
+	return records
+}
+
+func appendTopicConfigs(records [][]byte, t *domain.TopicMetadata) [][]byte {
+	for _, name := range domain.SortedConfigNames(t.Configs) {
+		value := t.Configs[name]
+		records = append(records, parser.EncodeConfig(parser.ConfigResourceTopic, t.Name, name, &value))
+	}
+	return records
+}
+
+func parserEndpoints(endpoints []domain.BrokerEndpoint) []parser.BrokerEndpoint {
+	out := make([]parser.BrokerEndpoint, 0, len(endpoints))
+	for _, ep := range endpoints {
+		out = append(out, parser.BrokerEndpoint{
+			Name:             ep.Listener,
+			Host:             ep.Host,
+			Port:             uint16(ep.Port),
+			SecurityProtocol: ep.SecurityProtocol,
+		})
+	}
+	return out
+}
+
+func sortedKeys[V any](m map[int32]V) []int32 {
+	ids := make([]int32, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
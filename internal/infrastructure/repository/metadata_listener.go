@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+)
+
+type MetadataPublisher interface {
+	Publish(image *MetadataImage)
+}
+
+type MetadataListener struct {
+	mu        sync.Mutex
+	loader    *MetadataLoader
+	publisher MetadataPublisher
+	interval  time.Duration
+	lastErr   string
+}
+
+func NewMetadataListener(loader *MetadataLoader, publisher MetadataPublisher, interval time.Duration) *MetadataListener {
+	return &MetadataListener{
+		loader:    loader,
+		publisher: publisher,
+		interval:  interval,
+	}
+}
+
+func (l *MetadataListener) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			l.poll()
+		}
+	}
+}
+
+func (l *MetadataListener) Refresh() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	image, changed, err := l.loader.Poll()
+	if changed {
+		l.publisher.Publish(image)
+	}
+	return err
+}
+
+func (l *MetadataListener) poll() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	image, changed, err := l.loader.Poll()
+	if err != nil && err.Error() != l.lastErr {
+		fmt.Println("metadata listener:", err)
+	}
+	l.lastErr = ""
+	if err != nil {
+		l.lastErr = err.Error()
+	}
+	if changed {
+		l.publisher.Publish(image)
+	}
+}
+
+func (l *MetadataListener) SnapshotRecords() ([][]byte, domain.MetadataVersion) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	image := l.loader.image
+	return image.Records(), image.Version
+}
@@ -1,85 +1,239 @@
 package repository
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
 	"github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/metadata/parser"
 	"github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/storage"
 )
 
-type LoadedMetadata struct {
-	ByName map[string]*domain.TopicMetadata
-	ByUUID map[[16]byte]*domain.TopicMetadata
-}
+const batchLogOverhead = 12
 
 type MetadataLoader struct {
-	dm *storage.DiskManager
+	dm         *storage.DiskManager
+	image      *MetadataImage
+	nextOffset int64
+	positions  map[string]int64
+	committed  func() int64
+	static     map[string]string
 }
 
 func NewMetadataLoader(dm *storage.DiskManager) *MetadataLoader {
-	return &MetadataLoader{dm: dm}
+	return &MetadataLoader{
+		dm:        dm,
+		image:     EmptyMetadataImage(),
+		positions: map[string]int64{},
+	}
 }
 
-func (l *MetadataLoader) Load() (*LoadedMetadata, error) {
-	data, err := l.dm.LoadBytes()
+func (l *MetadataLoader) LimitTo(committed func() int64) {
+	l.committed = committed
+}
+
+func (l *MetadataLoader) UseStaticConfigs(configs map[string]string) {
+	l.static = configs
+	l.image.StaticConfigs = configs
+}
+
+func (l *MetadataLoader) Load() (*MetadataImage, error) {
+	snap, err := l.dm.LatestSnapshot()
 	if err != nil {
 		return nil, err
 	}
 
-	batches, err := parser.Decode(data)
+	if snap != nil {
+		if err := l.loadSnapshot(snap); err != nil {
+			return nil, err
+		}
+	}
+
+	segments, err := l.dm.LogSegments()
 	if err != nil {
 		return nil, err
 	}
 
-	return buildDomainTopics(batches), nil
+	if snap == nil && len(segments) == 0 {
+		return nil, errors.New("metadata loader: no snapshot or log segment found")
+	}
+
+	if _, err := l.tail(segments); err != nil {
+		return nil, err
+	}
+
+	return l.image, nil
 }
 
-func buildDomainTopics(batches []parser.RecordBatch) *LoadedMetadata {
-	result := make(map[string]*domain.TopicMetadata)
+func (l *MetadataLoader) Poll() (*MetadataImage, bool, error) {
+	snap, err := l.dm.LatestSnapshot()
+	if err != nil {
+		return l.image, false, err
+	}
 
-	byUUID := make(map[[16]byte]*domain.TopicMetadata)
+	reloaded := false
+	if snap != nil && snap.EndOffset > l.nextOffset {
+		if err := l.loadSnapshot(snap); err != nil {
+			return l.image, false, err
+		}
+		reloaded = true
+	}
 
-	for _, batch := range batches {
-		for _, rec := range batch.Records {
-			switch v := rec.Value.(type) {
-			case parser.RecordTopic:
-				tm, ok := byUUID[v.TopicUUID]
-				if !ok {
-					tm = &domain.TopicMetadata{
-						TopicID:    v.TopicUUID,
-						Partitions: []domain.PartitionMetadata{},
-					}
-					byUUID[v.TopicUUID] = tm
-				}
+	segments, err := l.dm.LogSegments()
+	if err != nil {
+		return l.image, reloaded, err
+	}
 
-				tm.Name = v.TopicName
-				result[v.TopicName] = tm
-
-			case parser.RecordPartition:
-				tm, ok := byUUID[v.TopicUUID]
-				if !ok {
-					tm = &domain.TopicMetadata{
-						TopicID:    v.TopicUUID,
-						Partitions: []domain.PartitionMetadata{},
-					}
-					byUUID[v.TopicUUID] = tm
-				}
+	changed, err := l.tail(segments)
+	return l.image, reloaded || changed, err
+}
 
-				pm := domain.PartitionMetadata{
-					PartitionIndex: v.PartitionID,
-					LeaderID:       v.Leader,
-					LeaderEpoch:    v.LeaderEpoch,
-					Replicas:       append([]int32(nil), v.ReplicaArray...),
-					ISR:            append([]int32(nil), v.SyncReplicaArray...),
+func (l *MetadataLoader) loadSnapshot(snap *storage.SnapshotFile) error {
+	data, err := l.dm.LoadBytes(snap.Path)
+	if err != nil {
+		return err
+	}
+
+	batches, err := parser.Decode(data)
+	if err != nil {
+		return fmt.Errorf("snapshot %s: %w", snap.Path, err)
+	}
+
+	base := EmptyMetadataImage()
+	base.StaticConfigs = l.static
+	image, err := applySnapshot(base, batches)
+	if err != nil {
+		return fmt.Errorf("snapshot %s: %w", snap.Path, err)
+	}
+	image.Version = domain.MetadataVersion{Offset: snap.EndOffset - 1, Epoch: snap.Epoch}
+
+	l.image = image
+	l.nextOffset = snap.EndOffset
+	l.positions = map[string]int64{}
+	return nil
+}
+
+func (l *MetadataLoader) NextOffset() int64 {
+	return l.nextOffset
+}
+
+func (l *MetadataLoader) tail(segments []storage.SegmentFile) (bool, error) {
+	positions := make(map[string]int64, len(segments))
+	nextOffset := l.nextOffset
+	delta := NewMetadataDelta(l.image)
+	replayed := 0
+	limit := int64(-1)
+	if l.committed != nil {
+		limit = l.committed()
+	}
+
+	for _, seg := range segments {
+		pos := l.positions[seg.Path]
+		size, err := l.dm.FileSize(seg.Path)
+		if err != nil {
+			return false, err
+		}
+		if size < pos {
+			pos = 0
+		}
+
+		data, err := l.dm.LoadBytesFrom(seg.Path, pos)
+		if err != nil {
+			return false, err
+		}
+
+		batches, _, err := parser.DecodeComplete(data)
+		if err != nil {
+			return false, fmt.Errorf("segment %s: %w", seg.Path, err)
+		}
+
+		consumed := 0
+		uncommitted := false
+		for _, batch := range batches {
+			if limit >= 0 && batch.BaseOffset+int64(batch.LastOffsetDelta) >= limit {
+				uncommitted = true
+				break
+			}
+			consumed += batchLogOverhead + int(batch.BatchLength)
+
+			for _, rec := range batch.Records {
+				offset := batch.BaseOffset + int64(rec.OffsetDelta)
+				if offset < nextOffset {
+					continue
 				}
-				tm.Partitions = append(tm.Partitions, pm)
+				delta.Replay(rec)
+				delta.SetVersion(domain.MetadataVersion{Offset: offset, Epoch: batch.PartitionLeaderEpoch})
+				nextOffset = offset + 1
+				replayed++
+			}
+		}
+
+		positions[seg.Path] = pos + int64(consumed)
+		if uncommitted {
+			break
+		}
+	}
+
+	l.positions = positions
+	l.nextOffset = nextOffset
 
-			default:
+	if replayed == 0 {
+		return false, nil
+	}
+
+	l.image = delta.Apply()
+	return true, nil
+}
+
+func applySnapshot(base *MetadataImage, batches []parser.RecordBatch) (*MetadataImage, error) {
+	first, last, ok := snapshotBounds(batches)
+	if !ok || first.Control == nil || first.Control.Type != parser.ControlSnapshotHeader {
+		return nil, errors.New("missing snapshot header")
+	}
+	if _, err := parser.ParseSnapshotHeader(first.Control); err != nil {
+		return nil, err
+	}
+
+	if last.Control == nil || last.Control.Type != parser.ControlSnapshotFooter {
+		return nil, errors.New("missing snapshot footer")
+	}
+	if _, err := parser.ParseSnapshotFooter(last.Control); err != nil {
+		return nil, err
+	}
+
+	return replayBatches(base, batches), nil
+}
+
+func snapshotBounds(batches []parser.RecordBatch) (parser.Record, parser.Record, bool) {
+	var first, last *parser.Record
+
+	for i := range batches {
+		for j := range batches[i].Records {
+			if first == nil {
+				first = &batches[i].Records[j]
 			}
+			last = &batches[i].Records[j]
 		}
 	}
 
-	return &LoadedMetadata{
-		ByName: result,
-		ByUUID: byUUID,
+	if first == nil {
+		return parser.Record{}, parser.Record{}, false
 	}
+	return *first, *last, true
+}
+
+func buildDomainTopics(batches []parser.RecordBatch) *MetadataImage {
+	return replayBatches(EmptyMetadataImage(), batches)
+}
+
+func replayBatches(base *MetadataImage, batches []parser.RecordBatch) *MetadataImage {
+	delta := NewMetadataDelta(base)
+
+	for _, batch := range batches {
+		for _, rec := range batch.Records {
+			delta.Replay(rec)
+		}
+	}
+
+	return delta.Apply()
 }
@@ -200,3 +200,177 @@ func TestBuildDomainTopics_UnknownRecordIgnored(t *testing.T) {
 		t.Fatal("expected no topics")
 	}
 }
+
+func TestBuildDomainTopics_PartitionChange(t *testing.T) {
+	var uuid [16]byte
+	uuid[0] = 5
+
+	batches := []parser.RecordBatch{
+		{
+			Records: []parser.Record{
+				{Value: parser.RecordTopic{TopicName: "t", TopicUUID: uuid}},
+				{
+					Value: parser.RecordPartition{
+						PartitionID:      0,
+						TopicUUID:        uuid,
+						Leader:           1,
+						LeaderEpoch:      3,
+						ReplicaArray:     []int32{1, 2, 3},
+						SyncReplicaArray: []int32{1, 2, 3},
+					},
+				},
+				{
+					Value: parser.RecordPartitionChange{
+						PartitionID:         0,
+						TopicUUID:           uuid,
+						ISR:                 []int32{2, 3},
+						Leader:              2,
+						LeaderRecoveryState: parser.NoLeaderRecoveryStateChange,
+					},
+				},
+				{
+					Value: parser.RecordPartitionChange{
+						PartitionID:            0,
+						TopicUUID:              uuid,
+						ISR:                    []int32{2},
+						Leader:                 parser.NoLeaderChange,
+						LeaderRecoveryState:    parser.NoLeaderRecoveryStateChange,
+						EligibleLeaderReplicas: []int32{3},
+					},
+				},
+			},
+		},
+	}
+
+	res := buildDomainTopics(batches)
+
+	pm := res.ByName["t"].Partitions[0]
+	if pm.LeaderID != 2 || pm.LeaderEpoch != 4 {
+		t.Fatalf("wrong leader %d epoch %d", pm.LeaderID, pm.LeaderEpoch)
+	}
+	if len(pm.ISR) != 1 || pm.ISR[0] != 2 {
+		t.Fatal("wrong isr")
+	}
+	if len(pm.Replicas) != 3 {
+		t.Fatal("replicas must be unchanged")
+	}
+	if len(pm.EligibleLeaderReplicas) != 1 || pm.EligibleLeaderReplicas[0] != 3 {
+		t.Fatal("wrong eligible leader replicas")
+	}
+	if pm.PartitionEpoch != 2 {
+		t.Fatal("expected partition epoch bumped per change")
+	}
+}
+
+func TestBuildDomainTopics_RemoveAndRecreate(t *testing.T) {
+	var oldID, newID [16]byte
+	oldID[0] = 6
+	newID[0] = 7
+
+	batches := []parser.RecordBatch{
+		{
+			Records: []parser.Record{
+				{Value: parser.RecordTopic{TopicName: "t", TopicUUID: oldID}},
+				{Value: parser.RecordPartition{PartitionID: 0, TopicUUID: oldID}},
+				{Value: parser.RecordPartition{PartitionID: 1, TopicUUID: oldID}},
+				{Value: parser.RecordRemoveTopic{TopicUUID: oldID}},
+				{Value: parser.RecordTopic{TopicName: "t", TopicUUID: newID}},
+				{Value: parser.RecordPartition{PartitionID: 0, TopicUUID: newID, Leader: 9}},
+			},
+		},
+	}
+
+	res := buildDomainTopics(batches)
+
+	if _, ok := res.ByUUID[oldID]; ok {
+		t.Fatal("removed topic must be gone")
+	}
+
+	tm := res.ByName["t"]
+	if tm == nil || tm.TopicID != newID {
+		t.Fatal("expected re-created topic")
+	}
+	if len(tm.Partitions) != 1 || tm.Partitions[0].LeaderID != 9 {
+		t.Fatal("expected only partitions of the new topic")
+	}
+}
+
+func TestBuildDomainTopics_DuplicatePartitionReplaced(t *testing.T) {
+	var uuid [16]byte
+	uuid[0] = 8
+
+	batches := []parser.RecordBatch{
+		{
+			Records: []parser.Record{
+				{Value: parser.RecordTopic{TopicName: "t", TopicUUID: uuid}},
+				{Value: parser.RecordPartition{PartitionID: 0, TopicUUID: uuid, Leader: 1}},
+				{Value: parser.RecordPartition{PartitionID: 0, TopicUUID: uuid, Leader: 2}},
+			},
+		},
+	}
+
+	res := buildDomainTopics(batches)
+
+	tm := res.ByName["t"]
+	if len(tm.Partitions) != 1 || tm.Partitions[0].LeaderID != 2 {
+		t.Fatal("expected a single, latest partition registration")
+	}
+}
+
+func TestMetadataDelta_BaseImageUnchanged(t *testing.T) {
+	var uuid [16]byte
+	uuid[0] = 9
+
+	base := buildDomainTopics([]parser.RecordBatch{
+		{
+			Records: []parser.Record{
+				{Value: parser.RecordTopic{TopicName: "t", TopicUUID: uuid}},
+				{Value: parser.RecordPartition{PartitionID: 0, TopicUUID: uuid, Leader: 1, SyncReplicaArray: []int32{1, 2}}},
+			},
+		},
+	})
+
+	delta := NewMetadataDelta(base)
+	delta.Replay(parser.Record{Value: parser.RecordPartitionChange{
+		TopicUUID:           uuid,
+		ISR:                 []int32{1},
+		Leader:              parser.NoLeaderChange,
+		LeaderRecoveryState: parser.NoLeaderRecoveryStateChange,
+	}})
+	delta.Replay(parser.Record{Value: parser.RecordTopic{TopicName: "other", TopicUUID: [16]byte{1}}})
+	next := delta.Apply()
+
+	if len(base.ByName["t"].Partitions[0].ISR) != 2 {
+		t.Fatal("base image was mutated")
+	}
+	if _, ok := base.ByName["other"]; ok {
+		t.Fatal("base image gained a topic")
+	}
+	if len(next.ByName["t"].Partitions[0].ISR) != 1 || next.ByName["other"] == nil {
+		t.Fatal("delta not applied")
+	}
+}
+
+func TestBuildDomainTopics_FeatureLevels(t *testing.T) {
+	image := buildDomainTopics([]parser.RecordBatch{
+		{
+			Records: []parser.Record{
+				{Value: parser.RecordFeatureLevel{Name: "metadata.version", FeatureLevel: 20}},
+				{Value: parser.RecordFeatureLevel{Name: "transaction.version", FeatureLevel: 1}},
+				{Value: parser.RecordFeatureLevel{Name: "transaction.version", FeatureLevel: 0}},
+			},
+		},
+	})
+
+	features := image.FinalizedFeatures()
+	if features.MetadataVersion() != 20 {
+		t.Fatalf("unexpected metadata.version %d", features.MetadataVersion())
+	}
+	if _, ok := features.Levels["transaction.version"]; ok {
+		t.Fatal("level 0 must remove the feature")
+	}
+
+	if EmptyMetadataImage().FinalizedFeatures().Epoch != -1 {
+		t.Fatal("expected unknown epoch without features")
+	}
+}
@@ -0,0 +1,536 @@
+package repository
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+	"github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/metadata/parser"
+	"github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/storage"
+)
+
+type testRecord struct {
+	key   []byte
+	value []byte
+}
+
+func encodeTestBatch(baseOffset int64, control bool, records []testRecord) []byte {
+	body := make([]byte, 0)
+	for i, r := range records {
+		rec := []byte{0}
+		rec = binary.AppendVarint(rec, 0)
+		rec = binary.AppendVarint(rec, int64(i))
+		if r.key == nil {
+			rec = binary.AppendVarint(rec, -1)
+		} else {
+			rec = binary.AppendVarint(rec, int64(len(r.key)))
+			rec = append(rec, r.key...)
+		}
+		rec = binary.AppendVarint(rec, int64(len(r.value)))
+		rec = append(rec, r.value...)
+		rec = binary.AppendUvarint(rec, 0)
+
+		body = binary.AppendVarint(body, int64(len(rec)))
+		body = append(body, rec...)
+	}
+
+	var attributes uint16
+	if control {
+		attributes = 0x20
+	}
+
+	buf := binary.BigEndian.AppendUint64(nil, uint64(baseOffset))
+	buf = binary.BigEndian.AppendUint32(buf, uint32(49+len(body)))
+	buf = binary.BigEndian.AppendUint32(buf, 0)
+	buf = append(buf, 2)
+	buf = binary.BigEndian.AppendUint32(buf, 0)
+	buf = binary.BigEndian.AppendUint16(buf, attributes)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(records)-1))
+	buf = binary.BigEndian.AppendUint64(buf, 0)
+	buf = binary.BigEndian.AppendUint64(buf, 0)
+	buf = binary.BigEndian.AppendUint64(buf, ^uint64(0))
+	buf = binary.BigEndian.AppendUint16(buf, 0xffff)
+	buf = binary.BigEndian.AppendUint32(buf, 0xffffffff)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(records)))
+	return append(buf, body...)
+}
+
+func controlTestRecord(typ int16, value []byte) testRecord {
+	key := binary.BigEndian.AppendUint16(nil, 0)
+	key = binary.BigEndian.AppendUint16(key, uint16(typ))
+	return testRecord{key: key, value: value}
+}
+
+func snapshotHeaderValue() []byte {
+	v := binary.BigEndian.AppendUint16(nil, 0)
+	v = binary.BigEndian.AppendUint64(v, 0)
+	return append(v, 0)
+}
+
+func snapshotFooterValue() []byte {
+	return []byte{0, 0, 0}
+}
+
+func topicTestRecord(name string, id byte) testRecord {
+	v := []byte{1, 2, 0}
+	v = binary.AppendUvarint(v, uint64(len(name)+1))
+	v = append(v, name...)
+	var uuid [16]byte
+	uuid[0] = id
+	v = append(v, uuid[:]...)
+	return testRecord{value: append(v, 0)}
+}
+
+type diskAppender struct {
+	dm *storage.DiskManager
+}
+
+func (a diskAppender) Append(values [][]byte) (int64, error) {
+	return a.dm.AppendBatch(func(baseOffset int64) []byte {
+		return parser.EncodeBatch(baseOffset, 0, 0, values)
+	})
+}
+
+func writeSnapshot(t *testing.T, dir string, endOffset int64, epoch int32, records []testRecord) {
+	data := encodeTestBatch(0, true, []testRecord{controlTestRecord(parser.ControlSnapshotHeader, snapshotHeaderValue())})
+	data = append(data, encodeTestBatch(0, false, records)...)
+	data = append(data, encodeTestBatch(endOffset-1, true, []testRecord{controlTestRecord(parser.ControlSnapshotFooter, snapshotFooterValue())})...)
+
+	if err := os.WriteFile(filepath.Join(dir, storage.SnapshotName(endOffset, epoch)), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMetadataLoader_SnapshotThenLaterSegments(t *testing.T) {
+	dir := t.TempDir()
+
+	writeSnapshot(t, dir, 3, 1, []testRecord{topicTestRecord("stale", 1)})
+	writeSnapshot(t, dir, 5, 1, []testRecord{topicTestRecord("a", 2)})
+
+	segment := encodeTestBatch(0, false, []testRecord{
+		topicTestRecord("compacted", 3),
+		topicTestRecord("compacted", 3),
+		topicTestRecord("compacted", 3),
+		topicTestRecord("compacted", 3),
+		topicTestRecord("compacted", 3),
+		topicTestRecord("b", 4),
+	})
+	if err := os.WriteFile(filepath.Join(dir, "00000000000000000000.log"), segment, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	later := encodeTestBatch(6, false, []testRecord{topicTestRecord("c", 5)})
+	if err := os.WriteFile(filepath.Join(dir, "00000000000000000006.log"), later, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	image, err := NewMetadataLoader(storage.NewDiskManager(dir)).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"a", "b", "c"} {
+		if image.ByName[name] == nil {
+			t.Fatalf("expected topic %q", name)
+		}
+	}
+	if image.Version.Offset != 6 {
+		t.Fatalf("unexpected image offset %d", image.Version.Offset)
+	}
+	if image.ByName["stale"] != nil {
+		t.Fatal("older snapshot must not be applied")
+	}
+	if image.ByName["compacted"] != nil {
+		t.Fatal("records covered by the snapshot must be skipped")
+	}
+}
+
+func TestMetadataLoader_LogOnly(t *testing.T) {
+	dir := t.TempDir()
+
+	segment := encodeTestBatch(0, false, []testRecord{topicTestRecord("a", 1)})
+	if err := os.WriteFile(filepath.Join(dir, "00000000000000000000.log"), segment, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	image, err := NewMetadataLoader(storage.NewDiskManager(dir)).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if image.ByName["a"] == nil {
+		t.Fatal("expected topic from log segment")
+	}
+}
+
+func TestMetadataLoader_SnapshotWithoutFooter(t *testing.T) {
+	dir := t.TempDir()
+
+	data := encodeTestBatch(0, true, []testRecord{controlTestRecord(parser.ControlSnapshotHeader, snapshotHeaderValue())})
+	data = append(data, encodeTestBatch(1, false, []testRecord{topicTestRecord("a", 1)})...)
+	if err := os.WriteFile(filepath.Join(dir, storage.SnapshotName(2, 1)), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewMetadataLoader(storage.NewDiskManager(dir)).Load(); err == nil {
+		t.Fatal("expected error for truncated snapshot")
+	}
+}
+
+func TestMetadataLoader_EmptyDirectory(t *testing.T) {
+	if _, err := NewMetadataLoader(storage.NewDiskManager(t.TempDir())).Load(); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+type recordingPublisher struct {
+	images []*MetadataImage
+}
+
+func (p *recordingPublisher) Publish(image *MetadataImage) {
+	p.images = append(p.images, image)
+}
+
+func TestMetadataListener_PublishesAppendedBatches(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "00000000000000000000.log")
+
+	if err := os.WriteFile(first, encodeTestBatch(0, false, []testRecord{topicTestRecord("a", 1)}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	loader := NewMetadataLoader(storage.NewDiskManager(dir))
+	base, err := loader.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publisher := &recordingPublisher{}
+	listener := NewMetadataListener(loader, publisher, time.Millisecond)
+
+	listener.poll()
+	if len(publisher.images) != 0 {
+		t.Fatal("nothing appended, nothing to publish")
+	}
+
+	appended := encodeTestBatch(1, false, []testRecord{topicTestRecord("b", 2)})
+	f, err := os.OpenFile(first, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write(appended[:20]); err != nil {
+		t.Fatal(err)
+	}
+
+	listener.poll()
+	if len(publisher.images) != 0 {
+		t.Fatal("partial batch must not be applied")
+	}
+
+	if _, err := f.Write(appended[20:]); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	rolled := encodeTestBatch(2, false, []testRecord{topicTestRecord("c", 3)})
+	if err := os.WriteFile(filepath.Join(dir, "00000000000000000002.log"), rolled, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	listener.poll()
+	if len(publisher.images) != 1 {
+		t.Fatalf("expected one published image, got %d", len(publisher.images))
+	}
+
+	img := publisher.images[0]
+	if img.ByName["a"] == nil || img.ByName["b"] == nil || img.ByName["c"] == nil {
+		t.Fatal("expected appended topics")
+	}
+	if base.ByName["b"] != nil {
+		t.Fatal("previously loaded image must not change")
+	}
+	if img.Version.Offset != 2 {
+		t.Fatalf("unexpected image offset %d", img.Version.Offset)
+	}
+	if loader.NextOffset() != 3 {
+		t.Fatalf("unexpected next offset %d", loader.NextOffset())
+	}
+}
+
+func TestMetadataWriter_UpdateFeaturesIsVisibleImmediately(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "00000000000000000000.log"), encodeTestBatch(0, false, []testRecord{topicTestRecord("a", 1)}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	dm := storage.NewDiskManager(dir)
+	loader := NewMetadataLoader(dm)
+	image, err := loader.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := NewKraftMetadataRepository(image)
+	writer := NewMetadataWriter(diskAppender{dm: dm}, NewMetadataListener(loader, repo, time.Hour))
+
+	if err := writer.UpdateFeatures(map[string]int16{"metadata.version": 21, "kraft.version": 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	features := repo.FinalizedFeatures()
+	if features.Levels["metadata.version"] != 21 || features.Levels["kraft.version"] != 1 {
+		t.Fatalf("unexpected features %+v", features)
+	}
+	if features.Epoch != 2 {
+		t.Fatalf("unexpected features epoch %d", features.Epoch)
+	}
+
+	reloaded, err := NewMetadataLoader(dm).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.Features["metadata.version"] != 21 || reloaded.ByName["a"] == nil {
+		t.Fatal("feature levels must be persisted to the metadata log")
+	}
+}
+
+func TestMetadataWriter_CreateTopicsReplaysConfigs(t *testing.T) {
+	dm := storage.NewDiskManager(t.TempDir())
+	loader := NewMetadataLoader(dm)
+	repo := NewKraftMetadataRepository(EmptyMetadataImage())
+	writer := NewMetadataWriter(diskAppender{dm: dm}, NewMetadataListener(loader, repo, time.Hour))
+
+	err := writer.CreateTopics([]*domain.TopicMetadata{{
+		Name:    "orders",
+		TopicID: [16]byte{5},
+		Configs: map[string]string{domain.TopicConfigUncleanLeaderElection: "true"},
+		Partitions: []domain.PartitionMetadata{
+			{PartitionIndex: 0, LeaderID: 1, Replicas: []int32{1, 2}, ISR: []int32{1, 2}},
+			{PartitionIndex: 1, LeaderID: 2, Replicas: []int32{2, 1}, ISR: []int32{2, 1}},
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	meta, err := repo.GetTopic("orders")
+	if err != nil || meta == nil {
+		t.Fatalf("expected the topic to be visible, got %v", err)
+	}
+	if !meta.UncleanLeaderElection() || len(meta.Partitions) != 2 || meta.Partitions[1].LeaderID != 2 {
+		t.Fatalf("unexpected topic %+v", meta)
+	}
+
+	if _, err := (diskAppender{dm: dm}).Append([][]byte{
+		parser.EncodeConfig(parser.ConfigResourceTopic, "orders", domain.TopicConfigUncleanLeaderElection, nil),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := NewMetadataLoader(dm).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg := reloaded.ByName["orders"].Configs; len(cfg) != 0 {
+		t.Fatalf("a null config value must delete the config, got %v", cfg)
+	}
+}
+
+func TestMetadataLoader_StopsAtCommittedOffset(t *testing.T) {
+	dir := t.TempDir()
+
+	segment := encodeTestBatch(0, false, []testRecord{topicTestRecord("a", 1)})
+	segment = append(segment, encodeTestBatch(1, false, []testRecord{topicTestRecord("b", 2)})...)
+	if err := os.WriteFile(filepath.Join(dir, "00000000000000000000.log"), segment, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	committed := int64(1)
+	loader := NewMetadataLoader(storage.NewDiskManager(dir))
+	loader.LimitTo(func() int64 { return committed })
+
+	image, err := loader.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if image.ByName["a"] == nil || image.ByName["b"] != nil {
+		t.Fatalf("only committed records must be applied, got %v", image.ByName)
+	}
+
+	committed = 2
+	image, changed, err := loader.Poll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed || image.ByName["b"] == nil {
+		t.Fatal("record must be applied once committed")
+	}
+}
+
+func TestMetadataLoader_ReloadsNewerSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	dm := storage.NewDiskManager(dir)
+
+	segment := encodeTestBatch(0, false, []testRecord{topicTestRecord("a", 1)})
+	if err := os.WriteFile(filepath.Join(dir, "00000000000000000000.log"), segment, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	loader := NewMetadataLoader(dm)
+	if _, err := loader.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "00000000000000000000.log")); err != nil {
+		t.Fatal(err)
+	}
+	writeSnapshot(t, dir, 10, 2, []testRecord{topicTestRecord("b", 2)})
+
+	image, changed, err := loader.Poll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed || image.ByName["b"] == nil || image.ByName["a"] != nil {
+		t.Fatalf("expected snapshot image, got %v", image.ByName)
+	}
+	if loader.NextOffset() != 10 || image.Version.Epoch != 2 {
+		t.Fatalf("unexpected position %d / %+v", loader.NextOffset(), image.Version)
+	}
+}
+
+func TestMetadataImage_RecordsRoundTrip(t *testing.T) {
+	rack := "r1"
+	image := EmptyMetadataImage()
+	image.Features["metadata.version"] = 21
+	image.Features["kraft.version"] = 1
+	image.Brokers[1] = &domain.BrokerRegistration{
+		ID:        1,
+		Epoch:     7,
+		Endpoints: []domain.BrokerEndpoint{{Listener: "PLAINTEXT", Host: "localhost", Port: 9092}},
+		Rack:      &rack,
+		Fenced:    true,
+		LogDirs:   [][16]byte{{9}},
+	}
+	image.Controllers[3] = &domain.ControllerRegistration{
+		ID:        3,
+		Endpoints: []domain.BrokerEndpoint{{Listener: "CONTROLLER", Host: "localhost", Port: 9093}},
+	}
+	image.BrokerConfigs[""] = map[string]string{domain.BrokerConfigUncleanLeaderElection: "true"}
+	image.BrokerConfigs["1"] = map[string]string{domain.BrokerConfigLeaderThrottledRate: "1024"}
+	topic := &domain.TopicMetadata{
+		Name:    "events",
+		TopicID: [16]byte{4},
+		Configs: map[string]string{domain.TopicConfigMinInsyncReplicas: "2"},
+		Partitions: []domain.PartitionMetadata{{
+			PartitionIndex:         0,
+			LeaderID:               1,
+			LeaderEpoch:            2,
+			PartitionEpoch:         3,
+			Replicas:               []int32{1, 2},
+			ISR:                    []int32{1},
+			EligibleLeaderReplicas: []int32{2},
+			Directories:            [][16]byte{{9}, {8}},
+		}},
+	}
+	image.ByName[topic.Name] = topic
+	image.ByUUID[topic.TopicID] = topic
+
+	raw := parser.EncodeBatch(0, 1, 0, image.Records())
+	batches, err := parser.Decode(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := batches[0].Records[0].Value.(parser.RecordFeatureLevel); !ok {
+		t.Fatal("metadata.version must lead the snapshot records")
+	}
+
+	restored := replayBatches(EmptyMetadataImage(), batches)
+
+	if restored.Features["metadata.version"] != 21 || restored.Features["kraft.version"] != 1 {
+		t.Fatalf("unexpected features %v", restored.Features)
+	}
+	b := restored.Brokers[1]
+	if b == nil || b.Epoch != 7 || !b.Fenced || *b.Rack != "r1" || b.Endpoints[0].Port != 9092 || len(b.LogDirs) != 1 {
+		t.Fatalf("unexpected broker %+v", b)
+	}
+	if c := restored.Controllers[3]; c == nil || c.Endpoints[0].Port != 9093 {
+		t.Fatalf("unexpected controller %+v", c)
+	}
+	if restored.ByName["events"].MinInsyncReplicas() != 2 {
+		t.Fatalf("unexpected topic configs %v", restored.ByName["events"].Configs)
+	}
+	if !restored.ByName["events"].UncleanLeaderElection() || restored.BrokerConfigs["1"][domain.BrokerConfigLeaderThrottledRate] != "1024" {
+		t.Fatalf("unexpected broker configs %v", restored.BrokerConfigs)
+	}
+	p := restored.ByName["events"].Partitions[0]
+	if p.LeaderEpoch != 2 || len(p.ISR) != 1 || len(p.Directories) != 2 || len(p.EligibleLeaderReplicas) != 1 {
+		t.Fatalf("unexpected partition %+v", p)
+	}
+}
+
+func TestMetadataLoader_ReplaysTopicConfigRecords(t *testing.T) {
+	dir := t.TempDir()
+	id := [16]byte{7}
+	gzip, two := "gzip", "2"
+
+	segment := parser.EncodeBatch(0, 1, 0, [][]byte{
+		parser.EncodeTopic("orders", id),
+		parser.EncodePartition(parser.RecordPartition{TopicUUID: id, ReplicaArray: []int32{1}, SyncReplicaArray: []int32{1}, Leader: 1}),
+		parser.EncodeConfig(parser.ConfigResourceTopic, "orders", domain.TopicConfigCompressionType, &gzip),
+		parser.EncodeConfig(parser.ConfigResourceTopic, "orders", domain.TopicConfigMinInsyncReplicas, &two),
+		parser.EncodeConfig(parser.ConfigResourceTopic, "missing", domain.TopicConfigCompressionType, &gzip),
+	})
+	segment = append(segment, parser.EncodeBatch(5, 1, 0, [][]byte{
+		parser.EncodeConfig(parser.ConfigResourceTopic, "orders", domain.TopicConfigMinInsyncReplicas, nil),
+	})...)
+	if err := os.WriteFile(filepath.Join(dir, "00000000000000000000.log"), segment, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	image, err := NewMetadataLoader(storage.NewDiskManager(dir)).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	meta := image.ByName["orders"]
+	if meta == nil || len(meta.Configs) != 1 || meta.Configs[domain.TopicConfigCompressionType] != "gzip" {
+		t.Fatalf("expected compression.type from the config record and min.insync.replicas deleted, got %+v", meta)
+	}
+	if image.ByUUID[id] != meta || len(image.ByName) != 1 {
+		t.Fatalf("config records for unknown topics must be ignored, got %v", image.ByName)
+	}
+}
+
+func TestMetadataLoader_StaticConfigsBackTopicDefaults(t *testing.T) {
+	dir := t.TempDir()
+	id := [16]byte{7}
+	three := "3"
+
+	segment := parser.EncodeBatch(0, 1, 0, [][]byte{
+		parser.EncodeTopic("orders", id),
+		parser.EncodePartition(parser.RecordPartition{TopicUUID: id, ReplicaArray: []int32{1}, SyncReplicaArray: []int32{1}, Leader: 1}),
+		parser.EncodeConfig(parser.ConfigResourceBroker, "", domain.BrokerConfigMinInsyncReplicas, &three),
+	})
+	if err := os.WriteFile(filepath.Join(dir, "00000000000000000000.log"), segment, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	loader := NewMetadataLoader(storage.NewDiskManager(dir))
+	loader.UseStaticConfigs(map[string]string{
+		domain.BrokerConfigMinInsyncReplicas:     "2",
+		domain.BrokerConfigUncleanLeaderElection: "true",
+	})
+	image, err := loader.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	meta := image.ByName["orders"]
+	if meta.MinInsyncReplicas() != 3 || !meta.UncleanLeaderElection() {
+		t.Fatalf("expected the cluster default over the static config, then the static config, got %+v", meta.Defaults)
+	}
+	if _, ok := image.BrokerConfigs[""][domain.BrokerConfigUncleanLeaderElection]; ok {
+		t.Fatal("static configs must not leak into the replicated broker configs")
+	}
+}
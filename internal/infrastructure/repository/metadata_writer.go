@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"sort"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+	"github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/metadata/parser"
+)
+
+type MetadataAppender interface {
+	Append(values [][]byte) (int64, error)
+}
+
+type MetadataWriter struct {
+	appender MetadataAppender
+	listener *MetadataListener
+}
+
+func NewMetadataWriter(appender MetadataAppender, listener *MetadataListener) *MetadataWriter {
+	return &MetadataWriter{
+		appender: appender,
+		listener: listener,
+	}
+}
+
+func (w *MetadataWriter) UpdateFeatures(levels map[string]int16) error {
+	names := make([]string, 0, len(levels))
+	for name := range levels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	values := make([][]byte, 0, len(names))
+	for _, name := range names {
+		values = append(values, parser.EncodeFeatureLevel(name, levels[name]))
+	}
+
+	return w.append(values)
+}
+
+func (w *MetadataWriter) AlterPartitions(changes []domain.PartitionChange) error {
+	return w.append(encodePartitionChanges(nil, changes))
+}
+
+func (w *MetadataWriter) CreateTopics(topics []*domain.TopicMetadata) error {
+	values := make([][]byte, 0)
+	for _, t := range topics {
+		values = append(values, parser.EncodeTopic(t.Name, t.TopicID))
+		values = appendTopicConfigs(values, t)
+		for _, p := range t.Partitions {
+			values = append(values, parser.EncodePartition(parser.RecordPartition{
+				PartitionID:      p.PartitionIndex,
+				TopicUUID:        t.TopicID,
+				ReplicaArray:     p.Replicas,
+				SyncReplicaArray: p.ISR,
+				Leader:           p.LeaderID,
+				LeaderEpoch:      p.LeaderEpoch,
+				PartitionEpoch:   p.PartitionEpoch,
+			}))
+		}
+	}
+
+	return w.append(values)
+}
+
+func (w *MetadataWriter) AlterConfigs(changes []domain.ConfigChange) error {
+	values := make([][]byte, 0, len(changes))
+	for _, c := range changes {
+		values = append(values, parser.EncodeConfig(c.Resource.Type, c.Resource.Name, c.Name, c.Value))
+	}
+
+	return w.append(values)
+}
+
+func (w *MetadataWriter) FenceBrokers(fencing []domain.BrokerFencing, changes []domain.PartitionChange) error {
+	values := make([][]byte, 0, len(fencing)+len(changes))
+	for _, f := range fencing {
+		if f.Fenced {
+			values = append(values, parser.EncodeFenceBroker(f.BrokerID, f.Epoch))
+		} else {
+			values = append(values, parser.EncodeUnfenceBroker(f.BrokerID, f.Epoch))
+		}
+	}
+
+	return w.append(encodePartitionChanges(values, changes))
+}
+
+func encodePartitionChanges(values [][]byte, changes []domain.PartitionChange) [][]byte {
+	for _, c := range changes {
+		values = append(values, parser.EncodePartitionChange(parser.RecordPartitionChange{
+			PartitionID:            c.Partition,
+			TopicUUID:              c.TopicID,
+			ISR:                    c.ISR,
+			Leader:                 c.Leader,
+			Replicas:               c.Replicas,
+			RemovingReplicas:       c.RemovingReplicas,
+			AddingReplicas:         c.AddingReplicas,
+			LeaderRecoveryState:    parser.NoLeaderRecoveryStateChange,
+			EligibleLeaderReplicas: c.ELR,
+			LastKnownELR:           c.LastKnownELR,
+		}))
+	}
+	return values
+}
+
+func (w *MetadataWriter) append(values [][]byte) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	if _, err := w.appender.Append(values); err != nil {
+		return err
+	}
+	return w.listener.Refresh()
+}
@@ -1,17 +1,306 @@
 package storage
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 )
 
+const (
+	snapshotSuffix = ".checkpoint"
+	segmentSuffix  = ".log"
+)
+
+type SnapshotFile struct {
+	Path      string
+	EndOffset int64
+	Epoch     int32
+}
+
+type SegmentFile struct {
+	Path       string
+	BaseOffset int64
+}
+
+type LogPosition struct {
+	Segment    SegmentFile
+	Position   int64
+	BaseOffset int64
+}
+
 type DiskManager struct {
-	path string
+	dir string
+}
+
+func NewDiskManager(dir string) *DiskManager {
+	return &DiskManager{dir: dir}
+}
+
+func (d *DiskManager) Dir() string {
+	return d.dir
+}
+
+func (d *DiskManager) LatestSnapshot() (*SnapshotFile, error) {
+	snapshots, err := d.Snapshots()
+	if err != nil || len(snapshots) == 0 {
+		return nil, err
+	}
+	return &snapshots[len(snapshots)-1], nil
+}
+
+func (d *DiskManager) Snapshots() ([]SnapshotFile, error) {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]SnapshotFile, 0)
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), snapshotSuffix) {
+			continue
+		}
+
+		snap, ok := parseSnapshotName(e.Name())
+		if !ok {
+			continue
+		}
+		snap.Path = filepath.Join(d.dir, e.Name())
+		snapshots = append(snapshots, snap)
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		if snapshots[i].EndOffset != snapshots[j].EndOffset {
+			return snapshots[i].EndOffset < snapshots[j].EndOffset
+		}
+		return snapshots[i].Epoch < snapshots[j].Epoch
+	})
+
+	return snapshots, nil
 }
 
-func NewDiskManager(path string) *DiskManager {
-	return &DiskManager{path: path}
+func (d *DiskManager) LogSegments() ([]SegmentFile, error) {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	segments := make([]SegmentFile, 0)
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), segmentSuffix) {
+			continue
+		}
+
+		base, err := strconv.ParseInt(strings.TrimSuffix(e.Name(), segmentSuffix), 10, 64)
+		if err != nil || base < 0 {
+			continue
+		}
+
+		segments = append(segments, SegmentFile{
+			Path:       filepath.Join(d.dir, e.Name()),
+			BaseOffset: base,
+		})
+	}
+
+	sort.Slice(segments, func(i, j int) bool {
+		return segments[i].BaseOffset < segments[j].BaseOffset
+	})
+
+	return segments, nil
 }
 
-func (d *DiskManager) LoadBytes() ([]byte, error) {
-	return os.ReadFile(d.path)
+func (d *DiskManager) LoadBytes(path string) ([]byte, error) {
+	return os.ReadFile(path)
+}
+
+func (d *DiskManager) LoadBytesFrom(path string, offset int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if offset >= info.Size() {
+		return nil, nil
+	}
+
+	buf := make([]byte, info.Size()-offset)
+	n, err := f.ReadAt(buf, offset)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+func (d *DiskManager) FileSize(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+func (d *DiskManager) ReadAt(path string, offset int64, n int) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	buf := make([]byte, n)
+	read, err := f.ReadAt(buf, offset)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return buf[:read], nil
+}
+
+func (d *DiskManager) AppendBatch(encode func(baseOffset int64) []byte) (int64, error) {
+	pos, err := d.AppendLogBatch(encode)
+	return pos.BaseOffset, err
+}
+
+func (d *DiskManager) AppendLogBatch(encode func(baseOffset int64) []byte) (LogPosition, error) {
+	segments, err := d.LogSegments()
+	if err != nil {
+		return LogPosition{}, err
+	}
+
+	var seg SegmentFile
+	var base int64
+
+	if len(segments) == 0 {
+		snap, err := d.LatestSnapshot()
+		if err != nil {
+			return LogPosition{}, err
+		}
+		if snap != nil {
+			base = snap.EndOffset
+		}
+		seg = SegmentFile{Path: d.segmentPath(base), BaseOffset: base}
+	} else {
+		seg = segments[len(segments)-1]
+
+		end, err := logEndOffset(seg.Path)
+		if err != nil {
+			return LogPosition{}, err
+		}
+		base = max(end, seg.BaseOffset)
+	}
+
+	f, err := os.OpenFile(seg.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return LogPosition{}, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return LogPosition{}, err
+	}
+
+	if _, err := f.Write(encode(base)); err != nil {
+		return LogPosition{}, err
+	}
+	pos := LogPosition{Segment: seg, Position: info.Size(), BaseOffset: base}
+	return pos, f.Sync()
+}
+
+func (d *DiskManager) RollSegment(baseOffset int64) (SegmentFile, error) {
+	seg := SegmentFile{Path: d.segmentPath(baseOffset), BaseOffset: baseOffset}
+
+	f, err := os.OpenFile(seg.Path, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return SegmentFile{}, err
+	}
+	return seg, f.Close()
+}
+
+func (d *DiskManager) TruncateSegment(path string, size int64) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := f.Truncate(size); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+func (d *DiskManager) DeleteFile(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (d *DiskManager) WriteSnapshot(endOffset int64, epoch int32, data []byte) (SnapshotFile, error) {
+	snap := SnapshotFile{
+		Path:      filepath.Join(d.dir, SnapshotName(endOffset, epoch)),
+		EndOffset: endOffset,
+		Epoch:     epoch,
+	}
+
+	if err := writeFileAtomic(snap.Path, data); err != nil {
+		return SnapshotFile{}, err
+	}
+	return snap, nil
+}
+
+func (d *DiskManager) segmentPath(baseOffset int64) string {
+	return filepath.Join(d.dir, fmt.Sprintf("%020d%s", baseOffset, segmentSuffix))
+}
+
+func parseSnapshotName(name string) (SnapshotFile, bool) {
+	offset, epoch, ok := strings.Cut(strings.TrimSuffix(name, snapshotSuffix), "-")
+	if !ok {
+		return SnapshotFile{}, false
+	}
+
+	end, err := strconv.ParseInt(offset, 10, 64)
+	if err != nil || end < 0 {
+		return SnapshotFile{}, false
+	}
+
+	e, err := strconv.ParseInt(epoch, 10, 32)
+	if err != nil || e < 0 {
+		return SnapshotFile{}, false
+	}
+
+	return SnapshotFile{EndOffset: end, Epoch: int32(e)}, true
+}
+
+func SnapshotName(endOffset int64, epoch int32) string {
+	return fmt.Sprintf("%020d-%010d%s", endOffset, epoch, snapshotSuffix)
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
 }
@@ -0,0 +1,231 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+)
+
+const (
+	leaderEpochCheckpointFile    = "leader-epoch-checkpoint"
+	leaderEpochCheckpointVersion = 0
+)
+
+type epochEntry struct {
+	epoch       int32
+	startOffset int64
+}
+
+func (m *LogManager) EndOffsetForEpoch(topicName string, partition int32, epoch int32) (domain.EpochEndOffset, error) {
+	undefined := domain.EpochEndOffset{Epoch: -1, EndOffset: -1}
+
+	key := partitionKey{topicName, partition}
+	lock := m.partitionLock(key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	dir, err := m.locate(topicName, partition)
+	if err != nil {
+		return undefined, err
+	}
+
+	entries, err := m.leaderEpochs(dir, key)
+	if err != nil || len(entries) == 0 || epoch < 0 {
+		return undefined, err
+	}
+
+	if latest := entries[len(entries)-1]; latest.epoch == epoch {
+		end, err := m.endOffset(dir, key)
+		if err != nil {
+			return undefined, err
+		}
+		return domain.EpochEndOffset{Epoch: epoch, EndOffset: end}, nil
+	}
+
+	for i, e := range entries {
+		if e.epoch <= epoch {
+			continue
+		}
+		if i == 0 {
+			return domain.EpochEndOffset{Epoch: epoch, EndOffset: e.startOffset}, nil
+		}
+		return domain.EpochEndOffset{Epoch: entries[i-1].epoch, EndOffset: e.startOffset}, nil
+	}
+	return undefined, nil
+}
+
+func (m *LogManager) LatestEpoch(topicName string, partition int32) (int32, error) {
+	key := partitionKey{topicName, partition}
+	lock := m.partitionLock(key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	dir, err := m.locate(topicName, partition)
+	if err != nil {
+		return -1, err
+	}
+
+	entries, err := m.leaderEpochs(dir, key)
+	if err != nil || len(entries) == 0 {
+		return -1, err
+	}
+	return entries[len(entries)-1].epoch, nil
+}
+
+func (m *LogManager) leaderEpochs(dir *LogDir, key partitionKey) ([]epochEntry, error) {
+	m.mu.RLock()
+	entries, ok := m.epochs[key]
+	m.mu.RUnlock()
+	if ok {
+		return entries, nil
+	}
+
+	path := filepath.Join(partitionPath(dir, key.topic, key.partition), leaderEpochCheckpointFile)
+	entries, err := readLeaderEpochCheckpoint(path)
+	if err != nil {
+		entries, err = m.rebuildLeaderEpochs(dir, key)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	m.epochs[key] = entries
+	m.mu.Unlock()
+	return entries, nil
+}
+
+func (m *LogManager) rebuildLeaderEpochs(dir *LogDir, key partitionKey) ([]epochEntry, error) {
+	f, err := os.Open(logPath(dir, key.topic, key.partition))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, m.ioError(dir, err)
+	}
+	defer f.Close()
+
+	var entries []epochEntry
+	err = scanBatches(f, func(h batchHeader) bool {
+		entries = appendEpoch(entries, h)
+		return true
+	})
+	if err != nil {
+		return nil, m.ioError(dir, err)
+	}
+
+	if len(entries) > 0 {
+		if err := m.writeLeaderEpochs(dir, key, entries); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+func (m *LogManager) recordLeaderEpochs(dir *LogDir, key partitionKey, data []byte) error {
+	entries, err := m.leaderEpochs(dir, key)
+	if err != nil {
+		return err
+	}
+
+	updated := entries
+	walkBatches(data, func(b []byte) {
+		updated = appendEpoch(updated, readBatchHeader(b, 0))
+	})
+	if len(updated) == len(entries) {
+		return nil
+	}
+	return m.writeLeaderEpochs(dir, key, updated)
+}
+
+func (m *LogManager) truncateLeaderEpochs(dir *LogDir, key partitionKey, end int64) error {
+	entries, err := m.leaderEpochs(dir, key)
+	if err != nil {
+		return err
+	}
+
+	n := len(entries)
+	for n > 0 && entries[n-1].startOffset >= end {
+		n--
+	}
+	if n == len(entries) {
+		return nil
+	}
+	return m.writeLeaderEpochs(dir, key, entries[:n:n])
+}
+
+func (m *LogManager) writeLeaderEpochs(dir *LogDir, key partitionKey, entries []epochEntry) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%d\n%d\n", leaderEpochCheckpointVersion, len(entries))
+	for _, e := range entries {
+		fmt.Fprintf(&buf, "%d %d\n", e.epoch, e.startOffset)
+	}
+
+	path := filepath.Join(partitionPath(dir, key.topic, key.partition), leaderEpochCheckpointFile)
+	if err := writeFileAtomic(path, buf.Bytes()); err != nil {
+		return m.ioError(dir, err)
+	}
+
+	m.mu.Lock()
+	m.epochs[key] = entries
+	m.mu.Unlock()
+	return nil
+}
+
+func appendEpoch(entries []epochEntry, h batchHeader) []epochEntry {
+	if h.LeaderEpoch < 0 {
+		return entries
+	}
+	if n := len(entries); n > 0 && entries[n-1].epoch >= h.LeaderEpoch {
+		return entries
+	}
+	return append(entries, epochEntry{epoch: h.LeaderEpoch, startOffset: h.BaseOffset})
+}
+
+func readLeaderEpochCheckpoint(path string) ([]epochEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	if len(lines) < 2 || lines[0] != strconv.Itoa(leaderEpochCheckpointVersion) {
+		return nil, fmt.Errorf("%s: unsupported checkpoint", path)
+	}
+	count, err := strconv.Atoi(lines[1])
+	if err != nil || count != len(lines)-2 {
+		return nil, fmt.Errorf("%s: expected %s entries, found %d", path, lines[1], len(lines)-2)
+	}
+
+	entries := make([]epochEntry, 0, count)
+	for _, line := range lines[2:] {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s: malformed entry %q", path, line)
+		}
+		epoch, err := strconv.ParseInt(fields[0], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%s: malformed entry %q", path, line)
+		}
+		offset, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: malformed entry %q", path, line)
+		}
+		entries = append(entries, epochEntry{epoch: int32(epoch), startOffset: offset})
+	}
+	return entries, nil
+}
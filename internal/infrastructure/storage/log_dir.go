@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"errors"
+	"os"
+)
+
+type LogDir struct {
+	Path    string
+	ID      [16]byte
+	Offline bool
+}
+
+func openLogDir(path string) (*LogDir, error) {
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, err
+	}
+
+	props, err := readMetaProperties(path)
+	if errors.Is(err, os.ErrNotExist) {
+		props = map[string]string{metaPropertyVersion: "1"}
+	} else if err != nil {
+		return nil, err
+	}
+
+	if raw, ok := props[metaPropertyDirectoryID]; ok {
+		id, err := decodeUUID(raw)
+		if err != nil {
+			return nil, err
+		}
+		return &LogDir{Path: path, ID: id}, nil
+	}
+
+	id, err := newUUID()
+	if err != nil {
+		return nil, err
+	}
+	props[metaPropertyDirectoryID] = encodeUUID(id)
+
+	if err := writeMetaProperties(path, props); err != nil {
+		return nil, err
+	}
+
+	return &LogDir{Path: path, ID: id}, nil
+}
+
+func (d *LogDir) partitionCount() int {
+	entries, err := os.ReadDir(d.Path)
+	if err != nil {
+		return 0
+	}
+
+	n := 0
+	for _, e := range entries {
+		if e.IsDir() && isPartitionDirName(e.Name()) {
+			n++
+		}
+	}
+	return n
+}
+
+func isPartitionDirName(name string) bool {
+	_, _, future, ok := parsePartitionDirName(name)
+	return ok && !future
+}
+
+var (
+	unassignedDirectoryID = [16]byte{}
+	lostDirectoryID       = [16]byte{15: 1}
+	migratingDirectoryID  = [16]byte{15: 2}
+)
+
+func isAssignedDirectory(id [16]byte) bool {
+	return id != unassignedDirectoryID && id != lostDirectoryID && id != migratingDirectoryID
+}
@@ -0,0 +1,318 @@
+package storage
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+)
+
+const (
+	futureDirSuffix = "-future"
+	deleteDirSuffix = "-delete"
+)
+
+func (m *LogManager) DescribeLogDirs() []domain.LogDirDescription {
+	out := make([]domain.LogDirDescription, 0)
+
+	for _, d := range m.Dirs() {
+		desc := domain.LogDirDescription{Path: d.Path, Offline: d.Offline}
+		if d.Offline {
+			out = append(out, desc)
+			continue
+		}
+
+		desc.TotalBytes, desc.UsableBytes = diskUsage(d.Path)
+
+		entries, err := os.ReadDir(d.Path)
+		if err != nil {
+			out = append(out, desc)
+			continue
+		}
+
+		for _, e := range entries {
+			if !e.IsDir() {
+				continue
+			}
+
+			topic, partition, future, ok := parsePartitionDirName(e.Name())
+			if !ok {
+				continue
+			}
+
+			path := filepath.Join(d.Path, e.Name())
+			lp := domain.LogDirPartition{
+				Topic:     topic,
+				Partition: partition,
+				Size:      segmentsSize(path),
+				IsFuture:  future,
+			}
+
+			if future {
+				lp.OffsetLag = m.futureLag(topic, partition, path)
+			}
+
+			desc.Partitions = append(desc.Partitions, lp)
+		}
+
+		out = append(out, desc)
+	}
+
+	return out
+}
+
+func (m *LogManager) MoveReplica(topicName string, partition int32, path string) error {
+	key := partitionKey{topicName, partition}
+
+	m.mu.RLock()
+	target := m.dirByPath(path)
+	m.mu.RUnlock()
+
+	if target == nil {
+		return domain.ErrLogDirNotFound
+	}
+	if target.Offline {
+		return domain.ErrLogDirOffline
+	}
+
+	source := m.existingDir(topicName, partition)
+	if source == nil {
+		m.Assign(topicName, partition, target.ID)
+		return nil
+	}
+	if source.Offline {
+		return domain.ErrLogDirOffline
+	}
+	if source == target {
+		return nil
+	}
+
+	m.mu.Lock()
+	if _, moving := m.futures[key]; moving {
+		m.mu.Unlock()
+		return nil
+	}
+	m.futures[key] = target
+	m.mu.Unlock()
+
+	go func() {
+		if err := m.moveReplica(key, source, target); err != nil {
+			fmt.Printf("storage: move %s-%d to %s: %v\n", key.topic, key.partition, target.Path, err)
+		}
+	}()
+	return nil
+}
+
+func (m *LogManager) moveReplica(key partitionKey, source *LogDir, target *LogDir) error {
+	defer func() {
+		m.mu.Lock()
+		delete(m.futures, key)
+		m.mu.Unlock()
+	}()
+
+	srcPath := partitionPath(source, key.topic, key.partition)
+	futurePath := futurePartitionPath(target, key.topic, key.partition)
+
+	if err := os.RemoveAll(futurePath); err != nil {
+		return m.ioError(target, err)
+	}
+	if err := os.MkdirAll(futurePath, 0755); err != nil {
+		return m.ioError(target, err)
+	}
+
+	m.mu.RLock()
+	generation := m.truncations[key]
+	m.mu.RUnlock()
+
+	copied, err := copyPartitionFiles(srcPath, futurePath, nil)
+	if err != nil {
+		os.RemoveAll(futurePath)
+		return m.ioError(target, err)
+	}
+
+	lock := m.partitionLock(key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	m.mu.RLock()
+	truncated := m.truncations[key] != generation
+	m.mu.RUnlock()
+
+	if truncated {
+		copied = nil
+		if err := os.RemoveAll(futurePath); err != nil {
+			return m.ioError(target, err)
+		}
+		if err := os.MkdirAll(futurePath, 0755); err != nil {
+			return m.ioError(target, err)
+		}
+	}
+
+	if _, err := copyPartitionFiles(srcPath, futurePath, copied); err != nil {
+		os.RemoveAll(futurePath)
+		return m.ioError(target, err)
+	}
+
+	if err := os.Rename(futurePath, partitionPath(target, key.topic, key.partition)); err != nil {
+		os.RemoveAll(futurePath)
+		return m.ioError(target, err)
+	}
+
+	m.mu.Lock()
+	m.assignments[key] = target.ID
+	m.mu.Unlock()
+
+	return m.removePartitionDir(source, key)
+}
+
+func (m *LogManager) DeleteLog(topicName string, partition int32) error {
+	key := partitionKey{topicName, partition}
+	lock := m.partitionLock(key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	m.mu.Lock()
+	delete(m.endOffsets, key)
+	delete(m.epochs, key)
+	delete(m.assignments, key)
+	m.truncations[key]++
+	m.mu.Unlock()
+
+	dir := m.existingDir(topicName, partition)
+	if dir == nil {
+		return nil
+	}
+	if dir.Offline {
+		return domain.ErrLogDirOffline
+	}
+	return m.removePartitionDir(dir, key)
+}
+
+func (m *LogManager) removePartitionDir(dir *LogDir, key partitionKey) error {
+	path := partitionPath(dir, key.topic, key.partition)
+	deletePath := path + "." + hex.EncodeToString(dir.ID[:]) + deleteDirSuffix
+	if err := os.Rename(path, deletePath); err != nil {
+		return m.ioError(dir, err)
+	}
+	return os.RemoveAll(deletePath)
+}
+
+func (m *LogManager) futureLag(topic string, partition int32, futurePath string) int64 {
+	source := m.existingDir(topic, partition)
+	if source == nil {
+		return 0
+	}
+
+	current, err := logEndOffset(logPath(source, topic, partition))
+	if err != nil {
+		return 0
+	}
+	future, err := logEndOffset(filepath.Join(futurePath, logSegmentName))
+	if err != nil {
+		return current
+	}
+	return current - future
+}
+
+func (m *LogManager) existingDir(topic string, partition int32) *LogDir {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, d := range m.dirs {
+		if _, err := os.Stat(partitionPath(d, topic, partition)); err == nil {
+			return d
+		}
+	}
+	return nil
+}
+
+func (m *LogManager) dirByPath(path string) *LogDir {
+	clean := filepath.Clean(path)
+	for _, d := range m.dirs {
+		if filepath.Clean(d.Path) == clean {
+			return d
+		}
+	}
+	return nil
+}
+
+func copyPartitionFiles(src string, dst string, from map[string]int64) (map[string]int64, error) {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return nil, err
+	}
+
+	copied := make(map[string]int64, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || e.Name() == leaderEpochCheckpointFile {
+			continue
+		}
+
+		n, err := copyFileTail(filepath.Join(src, e.Name()), filepath.Join(dst, e.Name()), from[e.Name()])
+		if err != nil {
+			return nil, err
+		}
+		copied[e.Name()] = n
+	}
+	return copied, nil
+}
+
+func copyFileTail(src string, dst string, from int64) (int64, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := copyRange(out, in, from, info.Size()); err != nil {
+		out.Close()
+		return 0, err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return 0, err
+	}
+	return info.Size(), out.Close()
+}
+
+func futurePartitionPath(dir *LogDir, topic string, partition int32) string {
+	name := fmt.Sprintf("%s-%d.%s%s", topic, partition, hex.EncodeToString(dir.ID[:]), futureDirSuffix)
+	return filepath.Join(dir.Path, name)
+}
+
+func parsePartitionDirName(name string) (string, int32, bool, bool) {
+	future := false
+	if strings.HasSuffix(name, futureDirSuffix) {
+		dot := strings.LastIndex(name, ".")
+		if dot < 0 {
+			return "", 0, false, false
+		}
+		name = name[:dot]
+		future = true
+	}
+
+	i := strings.LastIndex(name, "-")
+	if i <= 0 {
+		return "", 0, false, false
+	}
+
+	partition, err := strconv.ParseInt(name[i+1:], 10, 32)
+	if err != nil {
+		return "", 0, false, false
+	}
+	return name[:i], int32(partition), future, true
+}
@@ -1,41 +1,386 @@
 package storage
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
 )
 
+const logSegmentName = "00000000000000000000.log"
+
+type partitionKey struct {
+	topic     string
+	partition int32
+}
+
 type LogManager struct {
-	base string
+	mu          sync.RWMutex
+	dirs        []*LogDir
+	assignments map[partitionKey][16]byte
+	futures     map[partitionKey]*LogDir
+	locks       map[partitionKey]*sync.Mutex
+	endOffsets  map[partitionKey]int64
+	truncations map[partitionKey]uint64
+	epochs      map[partitionKey][]epochEntry
+}
+
+func NewLogManager(paths []string) (*LogManager, error) {
+	if len(paths) == 0 {
+		return nil, errors.New("log manager: no log directories")
+	}
+
+	m := &LogManager{
+		assignments: make(map[partitionKey][16]byte),
+		futures:     make(map[partitionKey]*LogDir),
+		locks:       make(map[partitionKey]*sync.Mutex),
+		endOffsets:  make(map[partitionKey]int64),
+		truncations: make(map[partitionKey]uint64),
+		epochs:      make(map[partitionKey][]epochEntry),
+	}
+
+	for _, p := range paths {
+		dir, err := openLogDir(p)
+		if err != nil {
+			return nil, fmt.Errorf("log manager: %s: %w", p, err)
+		}
+		m.dirs = append(m.dirs, dir)
+	}
+
+	return m, nil
+}
+
+func (m *LogManager) Dirs() []LogDir {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	out := make([]LogDir, 0, len(m.dirs))
+	for _, d := range m.dirs {
+		out = append(out, *d)
+	}
+	return out
 }
 
-func NewLogManager(base string) *LogManager {
-	return &LogManager{base: base}
+func (m *LogManager) Assign(topicName string, partition int32, dirID [16]byte) {
+	if !isAssignedDirectory(dirID) {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, d := range m.dirs {
+		if d.ID == dirID {
+			m.assignments[partitionKey{topicName, partition}] = dirID
+			return
+		}
+	}
 }
 
 func (m *LogManager) LoadLog(topicName string, partition int32) ([]byte, error) {
-	path := m.logPath(topicName, partition)
+	dir, err := m.locate(topicName, partition)
+	if err != nil {
+		return nil, err
+	}
 
-	return os.ReadFile(path)
+	data, err := os.ReadFile(logPath(dir, topicName, partition))
+	if err != nil {
+		return nil, m.ioError(dir, err)
+	}
+	return data, nil
 }
-func (m *LogManager) logPath(topic string, partition int32) string {
-	return fmt.Sprintf(
-		"%s/%s-%d/00000000000000000000.log",
-		m.base,
-		topic,
-		partition,
-	)
+
+func (m *LogManager) OpenLogRegion(
+	topicName string,
+	partition int32,
+	fromOffset int64,
+	maxOffset int64,
+	maxBytes int32,
+) (*domain.FileRegion, error) {
+
+	if fromOffset < 0 {
+		return nil, domain.ErrOffsetOutOfRange
+	}
+
+	dir, err := m.locate(topicName, partition)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(logPath(dir, topicName, partition))
+	if errors.Is(err, os.ErrNotExist) {
+		if fromOffset > 0 {
+			return nil, domain.ErrOffsetOutOfRange
+		}
+		return &domain.FileRegion{}, nil
+	}
+	if err != nil {
+		return nil, m.ioError(dir, err)
+	}
+
+	start, length := int64(-1), int64(0)
+	end := int64(0)
+	err = scanBatches(f, func(h batchHeader) bool {
+		end = h.LastOffset + 1
+		if h.LastOffset < fromOffset {
+			return true
+		}
+		if h.LastOffset >= maxOffset {
+			return false
+		}
+		if start < 0 {
+			start = h.Position
+		} else if maxBytes > 0 && length+h.Size > int64(maxBytes) {
+			return false
+		}
+		length += h.Size
+		return true
+	})
+	if err != nil {
+		f.Close()
+		return nil, m.ioError(dir, err)
+	}
+
+	if start < 0 && fromOffset > end {
+		f.Close()
+		return nil, domain.ErrOffsetOutOfRange
+	}
+	if start < 0 {
+		start = 0
+	}
+	return &domain.FileRegion{File: f, Offset: start, Length: length}, nil
+}
+
+func (m *LogManager) AppendLog(
+	topicName string,
+	partition int32,
+	data []byte,
+	leaderEpoch int32,
+) (domain.LogAppendInfo, error) {
+
+	return m.appendLog(partitionKey{topicName, partition}, func(end int64) ([]byte, domain.LogAppendInfo, error) {
+		out := append([]byte(nil), data...)
+		info, err := assignOffsets(out, end, leaderEpoch)
+		return out, info, err
+	})
+}
+
+func (m *LogManager) AppendReplicaLog(topicName string, partition int32, data []byte) (domain.LogAppendInfo, error) {
+	return m.appendLog(partitionKey{topicName, partition}, func(end int64) ([]byte, domain.LogAppendInfo, error) {
+		info, err := batchOffsets(data)
+		if err == nil && info.BaseOffset < end {
+			err = fmt.Errorf("%w: replica batch at %d below log end %d", domain.ErrOffsetOutOfRange, info.BaseOffset, end)
+		}
+		return data, info, err
+	})
 }
 
-func (m *LogManager) AppendLog(topicName string, partition int32, data []byte) error {
-	path := m.logPath(topicName, partition)
+func (m *LogManager) LogEndOffset(topicName string, partition int32) (int64, error) {
+	key := partitionKey{topicName, partition}
+	lock := m.partitionLock(key)
+	lock.Lock()
+	defer lock.Unlock()
 
-	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	dir, err := m.locate(topicName, partition)
+	if err != nil {
+		return 0, err
+	}
+	return m.endOffset(dir, key)
+}
+
+func (m *LogManager) TruncateLog(topicName string, partition int32, offset int64) error {
+	key := partitionKey{topicName, partition}
+	lock := m.partitionLock(key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	dir, err := m.locate(topicName, partition)
 	if err != nil {
 		return err
 	}
+
+	f, err := os.OpenFile(logPath(dir, topicName, partition), os.O_RDWR, 0644)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return m.ioError(dir, err)
+	}
+	defer f.Close()
+
+	size, end := int64(-1), int64(0)
+	err = scanBatches(f, func(h batchHeader) bool {
+		if h.LastOffset >= offset {
+			size, end = h.Position, h.BaseOffset
+			return false
+		}
+		end = h.LastOffset + 1
+		return true
+	})
+	if err != nil {
+		return m.ioError(dir, err)
+	}
+	if size < 0 {
+		return nil
+	}
+
+	if err := f.Truncate(size); err != nil {
+		return m.ioError(dir, err)
+	}
+	if err := f.Sync(); err != nil {
+		return m.ioError(dir, err)
+	}
+
+	m.mu.Lock()
+	m.endOffsets[key] = end
+	m.truncations[key]++
+	m.mu.Unlock()
+	return m.truncateLeaderEpochs(dir, key, end)
+}
+
+func (m *LogManager) appendLog(
+	key partitionKey,
+	encode func(end int64) ([]byte, domain.LogAppendInfo, error),
+) (domain.LogAppendInfo, error) {
+
+	lock := m.partitionLock(key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	dir, err := m.locate(key.topic, key.partition)
+	if err != nil {
+		return domain.LogAppendInfo{}, err
+	}
+
+	if err := os.MkdirAll(partitionPath(dir, key.topic, key.partition), 0755); err != nil {
+		return domain.LogAppendInfo{}, m.ioError(dir, err)
+	}
+
+	end, err := m.endOffset(dir, key)
+	if err != nil {
+		return domain.LogAppendInfo{}, err
+	}
+
+	data, info, err := encode(end)
+	if err != nil {
+		return info, err
+	}
+
+	f, err := os.OpenFile(logPath(dir, key.topic, key.partition), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return info, m.ioError(dir, err)
+	}
 	defer f.Close()
 
-	_, err = f.Write(data)
-	return err
+	if _, err := f.Write(data); err != nil {
+		return info, m.ioError(dir, err)
+	}
+
+	m.mu.Lock()
+	m.endOffsets[key] = info.LastOffset + 1
+	m.mu.Unlock()
+	return info, m.recordLeaderEpochs(dir, key, data)
+}
+
+func (m *LogManager) endOffset(dir *LogDir, key partitionKey) (int64, error) {
+	m.mu.RLock()
+	end, ok := m.endOffsets[key]
+	m.mu.RUnlock()
+	if ok {
+		return end, nil
+	}
+
+	end, truncated, err := recoverLogEnd(logPath(dir, key.topic, key.partition))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, m.ioError(dir, err)
+	}
+
+	m.mu.Lock()
+	m.endOffsets[key] = end
+	if truncated {
+		m.truncations[key]++
+	}
+	m.mu.Unlock()
+	return end, nil
+}
+
+func (m *LogManager) locate(topicName string, partition int32) (*LogDir, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, d := range m.dirs {
+		if _, err := os.Stat(partitionPath(d, topicName, partition)); err == nil {
+			return onlineDir(d)
+		}
+	}
+
+	if id, ok := m.assignments[partitionKey{topicName, partition}]; ok {
+		for _, d := range m.dirs {
+			if d.ID == id {
+				return onlineDir(d)
+			}
+		}
+	}
+
+	var best *LogDir
+	bestCount := 0
+	for _, d := range m.dirs {
+		if d.Offline {
+			continue
+		}
+		count := d.partitionCount()
+		if best == nil || count < bestCount {
+			best, bestCount = d, count
+		}
+	}
+
+	if best == nil {
+		return nil, domain.ErrLogDirOffline
+	}
+	return best, nil
+}
+
+func (m *LogManager) partitionLock(key partitionKey) *sync.Mutex {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	lock, ok := m.locks[key]
+	if !ok {
+		lock = &sync.Mutex{}
+		m.locks[key] = lock
+	}
+	return lock
+}
+
+func (m *LogManager) ioError(dir *LogDir, err error) error {
+	if errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	m.mu.Lock()
+	dir.Offline = true
+	m.mu.Unlock()
+
+	return fmt.Errorf("%w: %s: %v", domain.ErrLogDirOffline, dir.Path, err)
+}
+
+func onlineDir(d *LogDir) (*LogDir, error) {
+	if d.Offline {
+		return nil, domain.ErrLogDirOffline
+	}
+	return d, nil
+}
+
+func partitionPath(dir *LogDir, topic string, partition int32) string {
+	return filepath.Join(dir.Path, fmt.Sprintf("%s-%d", topic, partition))
+}
+
+func logPath(dir *LogDir, topic string, partition int32) string {
+	return filepath.Join(partitionPath(dir, topic, partition), logSegmentName)
 }
@@ -0,0 +1,510 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+)
+
+func TestNewLogManager_CreatesStableDirectoryIDs(t *testing.T) {
+	base := t.TempDir()
+
+	m, err := NewLogManager([]string{base})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := m.Dirs()[0].ID
+
+	props, err := readMetaProperties(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if props[metaPropertyDirectoryID] != encodeUUID(id) {
+		t.Fatal("directory id not persisted")
+	}
+
+	again, err := NewLogManager([]string{base})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Dirs()[0].ID != id {
+		t.Fatal("directory id changed across restarts")
+	}
+}
+
+func TestAppendLog_PlacesOnLeastLoadedDirectory(t *testing.T) {
+	a, b := t.TempDir(), t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(a, "existing-0"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := NewLogManager([]string{a, b})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.AppendLog("new", 0, testBatch(1), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(b, "new-0", logSegmentName)); err != nil {
+		t.Fatal("expected partition on least loaded directory")
+	}
+
+	data, err := m.LoadLog("new", 0)
+	if err != nil || len(data) != len(testBatch(1)) {
+		t.Fatal("expected appended data to be readable")
+	}
+}
+
+func TestAppendLog_HonorsAssignment(t *testing.T) {
+	a, b := t.TempDir(), t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(b, "other-0"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := NewLogManager([]string{a, b})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.Assign("assigned", 3, m.Dirs()[1].ID)
+
+	if _, err := m.AppendLog("assigned", 3, testBatch(1), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(b, "assigned-3")); err != nil {
+		t.Fatal("expected partition on assigned directory")
+	}
+}
+
+func TestAppendLog_IOErrorMarksDirectoryOffline(t *testing.T) {
+	base := t.TempDir()
+
+	m, err := NewLogManager([]string{base})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(base, "broken-0"), []byte{}, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = m.AppendLog("broken", 0, testBatch(1), 0)
+	if !errors.Is(err, domain.ErrLogDirOffline) {
+		t.Fatalf("expected offline error, got %v", err)
+	}
+
+	if !m.Dirs()[0].Offline {
+		t.Fatal("expected directory to be offline")
+	}
+
+	if _, err := m.OpenLogRegion("healthy", 0, 0, math.MaxInt64, 0); !errors.Is(err, domain.ErrLogDirOffline) {
+		t.Fatal("expected partitions of offline directory to fail")
+	}
+}
+
+func TestMoveReplica_CopiesAndSwaps(t *testing.T) {
+	a, b := t.TempDir(), t.TempDir()
+
+	m, err := NewLogManager([]string{a, b})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.Assign("moving", 0, m.Dirs()[0].ID)
+	if _, err := m.AppendLog("moving", 0, testBatch(3), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	source, target := m.dirs[0], m.dirs[1]
+	if err := m.moveReplica(partitionKey{"moving", 0}, source, target); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(a, "moving-0")); !os.IsNotExist(err) {
+		t.Fatal("expected source replica removed")
+	}
+
+	data, err := os.ReadFile(filepath.Join(b, "moving-0", logSegmentName))
+	if err != nil || len(data) != len(testBatch(3)) {
+		t.Fatal("expected segment copied to target")
+	}
+
+	if _, err := m.AppendLog("moving", 0, testBatch(1), 0); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := m.LoadLog("moving", 0); len(data) != 2*len(testBatch(1)) {
+		t.Fatal("expected appends to follow the moved replica")
+	}
+}
+
+func TestMoveReplica_RecopiesAfterTruncation(t *testing.T) {
+	a, b := t.TempDir(), t.TempDir()
+
+	m, err := NewLogManager([]string{a, b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Assign("moving", 0, m.Dirs()[0].ID)
+	for _, n := range []int32{3, 3} {
+		if _, err := m.AppendLog("moving", 0, testBatch(n), 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	key := partitionKey{"moving", 0}
+	source, target := m.dirs[0], m.dirs[1]
+	srcLog := logPath(source, "moving", 0)
+	futureLog := filepath.Join(futurePartitionPath(target, "moving", 0), logSegmentName)
+
+	lock := m.partitionLock(key)
+	lock.Lock()
+	done := make(chan error, 1)
+	go func() { done <- m.moveReplica(key, source, target) }()
+
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		if info, err := os.Stat(futureLog); err == nil && info.Size() == int64(2*len(testBatch(1))) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("first copy pass did not finish")
+		}
+	}
+
+	if err := os.Truncate(srcLog, int64(len(testBatch(1)))); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(srcLog, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write(append(testBatch(1), testBatch(2)...))
+	f.Close()
+	m.mu.Lock()
+	m.truncations[key]++
+	m.mu.Unlock()
+
+	want, err := os.ReadFile(srcLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock.Unlock()
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(logPath(target, "moving", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatal("expected the moved log to match the truncated and rewritten source")
+	}
+}
+
+func TestMoveReplica_IgnoresPartitionAlreadyMoving(t *testing.T) {
+	a, b, c := t.TempDir(), t.TempDir(), t.TempDir()
+
+	m, err := NewLogManager([]string{a, b, c})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Assign("moving", 0, m.Dirs()[0].ID)
+	if _, err := m.AppendLog("moving", 0, testBatch(1), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	key := partitionKey{"moving", 0}
+	m.futures[key] = m.dirs[1]
+	if err := m.MoveReplica("moving", 0, c); err != nil {
+		t.Fatal(err)
+	}
+	if m.futures[key] != m.dirs[1] {
+		t.Fatal("a second move must not replace the one in progress")
+	}
+	if _, err := os.Stat(futurePartitionPath(m.dirs[2], "moving", 0)); !os.IsNotExist(err) {
+		t.Fatal("a second move must not start copying")
+	}
+}
+
+func TestMoveReplica_UnknownDirectory(t *testing.T) {
+	m, err := NewLogManager([]string{t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.MoveReplica("t", 0, "/does/not/exist"); !errors.Is(err, domain.ErrLogDirNotFound) {
+		t.Fatal("expected log dir not found")
+	}
+}
+
+func TestDescribeLogDirs_ReportsPartitionsAndFutures(t *testing.T) {
+	a, b := t.TempDir(), t.TempDir()
+
+	m, err := NewLogManager([]string{a, b})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.Assign("t", 0, m.Dirs()[0].ID)
+	if _, err := m.AppendLog("t", 0, testBatch(1), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(futurePartitionPath(m.dirs[1], "t", 0), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	dirs := m.DescribeLogDirs()
+	if len(dirs) != 2 {
+		t.Fatal("expected 2 log dirs")
+	}
+
+	current := dirs[0].Partitions
+	if len(current) != 1 || current[0].Topic != "t" || current[0].Size != int64(len(testBatch(1))) || current[0].IsFuture {
+		t.Fatalf("unexpected current replica %+v", current)
+	}
+
+	future := dirs[1].Partitions
+	if len(future) != 1 || !future[0].IsFuture {
+		t.Fatalf("unexpected future replica %+v", future)
+	}
+
+	if dirs[0].TotalBytes <= 0 {
+		t.Fatal("expected disk totals")
+	}
+}
+
+func TestAppendLog_AssignsOffsetsAndLeaderEpoch(t *testing.T) {
+	m, err := NewLogManager([]string{t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := m.AppendLog("t", 0, testBatch(3), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := m.AppendLog("t", 0, testBatch(2), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.BaseOffset != 0 || first.LastOffset != 2 || second.BaseOffset != 3 || second.LastOffset != 4 {
+		t.Fatalf("unexpected offsets %+v %+v", first, second)
+	}
+
+	data, err := m.LoadLog("t", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := data[len(testBatch(3)):]
+	if binary.BigEndian.Uint64(next[:8]) != 3 || binary.BigEndian.Uint32(next[12:16]) != 5 {
+		t.Fatal("expected rewritten base offset and leader epoch")
+	}
+
+	if end, err := m.LogEndOffset("t", 0); err != nil || end != 5 {
+		t.Fatalf("expected log end offset 5, got %d", end)
+	}
+	if _, err := m.AppendLog("t", 0, []byte{0x01}, 5); !errors.Is(err, domain.ErrCorruptRecords) {
+		t.Fatal("expected corrupt records error")
+	}
+}
+
+func TestAppendLog_TruncatesTornTail(t *testing.T) {
+	zeroed := make([]byte, 61)
+	overlong := testBatch(1)
+	binary.BigEndian.PutUint32(overlong[8:12], 1000)
+
+	for name, tail := range map[string][]byte{"zeroed length": zeroed, "past end of file": overlong} {
+		t.Run(name, func(t *testing.T) {
+			base := t.TempDir()
+			m, err := NewLogManager([]string{base})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := m.AppendLog("t", 0, testBatch(3), 0); err != nil {
+				t.Fatal(err)
+			}
+
+			f, err := os.OpenFile(logPath(m.dirs[0], "t", 0), os.O_WRONLY|os.O_APPEND, 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f.Write(tail)
+			f.Close()
+
+			m, err = NewLogManager([]string{base})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if end, err := m.LogEndOffset("t", 0); err != nil || end != 3 {
+				t.Fatalf("expected log end offset 3, got %d (%v)", end, err)
+			}
+			if info, err := m.AppendLog("t", 0, testBatch(1), 0); err != nil || info.BaseOffset != 3 {
+				t.Fatalf("unexpected append %+v (%v)", info, err)
+			}
+			if data, _ := m.LoadLog("t", 0); len(data) != len(testBatch(3))+len(testBatch(1)) {
+				t.Fatalf("expected the torn tail to be truncated, got %d bytes", len(data))
+			}
+		})
+	}
+}
+
+func TestOpenLogRegion_ReadsOffsetRange(t *testing.T) {
+	m, err := NewLogManager([]string{t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := m.AppendLog("t", 0, testBatch(2), 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	size := int64(len(testBatch(2)))
+
+	cases := []struct {
+		from, max int64
+		maxBytes  int32
+		offset    int64
+		length    int64
+	}{
+		{0, math.MaxInt64, 0, 0, 3 * size},
+		{3, math.MaxInt64, 0, size, 2 * size},
+		{0, 4, 0, 0, 2 * size},
+		{0, math.MaxInt64, 1, 0, size},
+		{6, math.MaxInt64, 0, 0, 0},
+	}
+	for _, c := range cases {
+		region, err := m.OpenLogRegion("t", 0, c.from, c.max, c.maxBytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		region.Close()
+		if region.Offset != c.offset || region.Length != c.length {
+			t.Fatalf("from %d max %d: unexpected region %d+%d", c.from, c.max, region.Offset, region.Length)
+		}
+	}
+
+	if _, err := m.OpenLogRegion("t", 0, 7, math.MaxInt64, 0); !errors.Is(err, domain.ErrOffsetOutOfRange) {
+		t.Fatal("expected offset out of range")
+	}
+}
+
+func TestOpenLogRegion_MissingLogIsEmpty(t *testing.T) {
+	m, err := NewLogManager([]string{t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	region, err := m.OpenLogRegion("t", 0, 0, math.MaxInt64, 0)
+	if err != nil || region.Length != 0 {
+		t.Fatalf("expected an empty region, got %+v %v", region, err)
+	}
+	if _, err := m.OpenLogRegion("t", 0, 1, math.MaxInt64, 0); !errors.Is(err, domain.ErrOffsetOutOfRange) {
+		t.Fatal("expected offset out of range past the empty log")
+	}
+}
+
+func TestTruncateLog_DropsBatchesAtOffset(t *testing.T) {
+	m, err := NewLogManager([]string{t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := m.AppendLog("t", 0, testBatch(2), 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := m.TruncateLog("t", 0, 3); err != nil {
+		t.Fatal(err)
+	}
+	if end, _ := m.LogEndOffset("t", 0); end != 2 {
+		t.Fatalf("expected truncation to batch boundary 2, got %d", end)
+	}
+
+	replica := testBatch(2)
+	binary.BigEndian.PutUint64(replica[:8], 2)
+	if _, err := m.AppendReplicaLog("t", 0, replica); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.AppendReplicaLog("t", 0, replica); !errors.Is(err, domain.ErrOffsetOutOfRange) {
+		t.Fatal("expected overlapping replica append to fail")
+	}
+	if end, _ := m.LogEndOffset("t", 0); end != 4 {
+		t.Fatalf("expected log end offset 4, got %d", end)
+	}
+}
+
+func TestLeaderEpochCheckpoint_TracksAppendsAndTruncation(t *testing.T) {
+	base := t.TempDir()
+	m, err := NewLogManager([]string{base})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, epoch := range []int32{1, 1, 3} {
+		if _, err := m.AppendLog("t", 0, testBatch(2), epoch); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(base, "t-0", leaderEpochCheckpointFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "0\n2\n1 0\n3 4\n" {
+		t.Fatalf("unexpected checkpoint %q", data)
+	}
+
+	for _, c := range []struct {
+		epoch int32
+		want  domain.EpochEndOffset
+	}{
+		{0, domain.EpochEndOffset{Epoch: 0, EndOffset: 0}},
+		{1, domain.EpochEndOffset{Epoch: 1, EndOffset: 4}},
+		{2, domain.EpochEndOffset{Epoch: 1, EndOffset: 4}},
+		{3, domain.EpochEndOffset{Epoch: 3, EndOffset: 6}},
+		{4, domain.EpochEndOffset{Epoch: -1, EndOffset: -1}},
+	} {
+		got, err := m.EndOffsetForEpoch("t", 0, c.epoch)
+		if err != nil || got != c.want {
+			t.Fatalf("epoch %d: expected %+v, got %+v (%v)", c.epoch, c.want, got, err)
+		}
+	}
+
+	if err := m.TruncateLog("t", 0, 4); err != nil {
+		t.Fatal(err)
+	}
+	if epoch, _ := m.LatestEpoch("t", 0); epoch != 1 {
+		t.Fatalf("expected latest epoch 1 after truncation, got %d", epoch)
+	}
+
+	os.Remove(filepath.Join(base, "t-0", leaderEpochCheckpointFile))
+	reopened, err := NewLogManager([]string{base})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := reopened.EndOffsetForEpoch("t", 0, 1); got != (domain.EpochEndOffset{Epoch: 1, EndOffset: 4}) {
+		t.Fatalf("expected epochs rebuilt from the log, got %+v", got)
+	}
+}
+
+func testBatch(records int32) []byte {
+	b := make([]byte, 61)
+	binary.BigEndian.PutUint32(b[8:12], uint32(len(b)-batchLogOverhead))
+	b[16] = 2
+	binary.BigEndian.PutUint32(b[23:27], uint32(records-1))
+	binary.BigEndian.PutUint32(b[57:61], uint32(records))
+	return b
+}
@@ -0,0 +1,201 @@
+package storage
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+)
+
+const metaPropertiesFile = "meta.properties"
+
+const (
+	metaPropertyVersion     = "version"
+	metaPropertyDirectoryID = "directory.id"
+	metaPropertyClusterID   = "cluster.id"
+	metaPropertyNodeID      = "node.id"
+)
+
+var ErrNotFormatted = errors.New("log directory is not formatted")
+
+func NewClusterID() (string, error) {
+	id, err := newUUID()
+	if err != nil {
+		return "", err
+	}
+	return encodeUUID(id), nil
+}
+
+func FormatLogDirs(paths []string, identity domain.ClusterIdentity) error {
+	if identity.ClusterID == "" {
+		return errors.New("format: cluster.id is required")
+	}
+	if _, err := decodeUUID(identity.ClusterID); err != nil {
+		return fmt.Errorf("format: invalid cluster.id %q: %w", identity.ClusterID, err)
+	}
+	if identity.NodeID < 0 {
+		return errors.New("format: node.id must not be negative")
+	}
+
+	for _, p := range paths {
+		if err := formatLogDir(p, identity); err != nil {
+			return fmt.Errorf("format: %s: %w", p, err)
+		}
+	}
+	return nil
+}
+
+func formatLogDir(path string, identity domain.ClusterIdentity) error {
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return err
+	}
+
+	props, err := readMetaProperties(path)
+	if errors.Is(err, os.ErrNotExist) {
+		props = map[string]string{}
+	} else if err != nil {
+		return err
+	}
+
+	if existing, ok := props[metaPropertyClusterID]; ok && existing != identity.ClusterID {
+		return fmt.Errorf("already formatted with cluster.id %s", existing)
+	}
+	if existing, ok := props[metaPropertyNodeID]; ok && existing != strconv.Itoa(int(identity.NodeID)) {
+		return fmt.Errorf("already formatted with node.id %s", existing)
+	}
+
+	if _, ok := props[metaPropertyDirectoryID]; !ok {
+		id, err := newUUID()
+		if err != nil {
+			return err
+		}
+		props[metaPropertyDirectoryID] = encodeUUID(id)
+	}
+
+	props[metaPropertyVersion] = "1"
+	props[metaPropertyClusterID] = identity.ClusterID
+	props[metaPropertyNodeID] = strconv.Itoa(int(identity.NodeID))
+
+	return writeMetaProperties(path, props)
+}
+
+func LoadClusterIdentity(paths []string) (domain.ClusterIdentity, error) {
+	var identity domain.ClusterIdentity
+	if len(paths) == 0 {
+		return identity, errors.New("no log directories")
+	}
+
+	for i, p := range paths {
+		props, err := readMetaProperties(p)
+		if errors.Is(err, os.ErrNotExist) {
+			return identity, fmt.Errorf("%s: %w", p, ErrNotFormatted)
+		} else if err != nil {
+			return identity, fmt.Errorf("%s: %w", p, err)
+		}
+
+		clusterID, ok := props[metaPropertyClusterID]
+		if !ok {
+			return identity, fmt.Errorf("%s: %w", p, ErrNotFormatted)
+		}
+
+		nodeID, err := strconv.ParseInt(props[metaPropertyNodeID], 10, 32)
+		if err != nil {
+			return identity, fmt.Errorf("%s: invalid node.id: %w", p, err)
+		}
+
+		if version := props[metaPropertyVersion]; version != "1" {
+			return identity, fmt.Errorf("%s: unsupported meta.properties version %q", p, version)
+		}
+
+		current := domain.ClusterIdentity{ClusterID: clusterID, NodeID: int32(nodeID)}
+		if i == 0 {
+			identity = current
+			continue
+		}
+		if current != identity {
+			return identity, fmt.Errorf("%s: cluster.id/node.id %s/%d does not match %s/%d",
+				p, current.ClusterID, current.NodeID, identity.ClusterID, identity.NodeID)
+		}
+	}
+
+	return identity, nil
+}
+
+func readMetaProperties(dir string) (map[string]string, error) {
+	f, err := os.Open(filepath.Join(dir, metaPropertiesFile))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	props := make(map[string]string)
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("meta.properties: malformed line %q", line)
+		}
+		props[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+
+	return props, scanner.Err()
+}
+
+func writeMetaProperties(dir string, props map[string]string) error {
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteString("#\n")
+	for _, k := range keys {
+		sb.WriteString(k + "=" + props[k] + "\n")
+	}
+
+	return writeFileAtomic(filepath.Join(dir, metaPropertiesFile), []byte(sb.String()))
+}
+
+func newUUID() ([16]byte, error) {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return id, err
+	}
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+	return id, nil
+}
+
+func encodeUUID(id [16]byte) string {
+	return base64.RawURLEncoding.EncodeToString(id[:])
+}
+
+func decodeUUID(s string) ([16]byte, error) {
+	var id [16]byte
+
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return id, err
+	}
+	if len(raw) != len(id) {
+		return id, errors.New("uuid: invalid length")
+	}
+
+	copy(id[:], raw)
+	return id, nil
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+)
+
+func TestFormatLogDirs_ThenLoadIdentity(t *testing.T) {
+	dirs := []string{t.TempDir(), filepath.Join(t.TempDir(), "nested")}
+
+	clusterID, err := NewClusterID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity := domain.ClusterIdentity{ClusterID: clusterID, NodeID: 3}
+
+	if err := FormatLogDirs(dirs, identity); err != nil {
+		t.Fatal(err)
+	}
+	if err := FormatLogDirs(dirs, identity); err != nil {
+		t.Fatal("formatting twice with the same identity must succeed")
+	}
+
+	got, err := LoadClusterIdentity(dirs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != identity {
+		t.Fatalf("unexpected identity %+v", got)
+	}
+
+	m, err := NewLogManager(dirs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ds := m.Dirs(); ds[0].ID == ds[1].ID {
+		t.Fatal("each directory needs its own directory.id")
+	}
+}
+
+func TestFormatLogDirs_RefusesDifferentCluster(t *testing.T) {
+	dir := t.TempDir()
+
+	first, _ := NewClusterID()
+	second, _ := NewClusterID()
+
+	if err := FormatLogDirs([]string{dir}, domain.ClusterIdentity{ClusterID: first, NodeID: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := FormatLogDirs([]string{dir}, domain.ClusterIdentity{ClusterID: second, NodeID: 1}); err == nil {
+		t.Fatal("expected cluster.id mismatch")
+	}
+	if err := FormatLogDirs([]string{dir}, domain.ClusterIdentity{ClusterID: first, NodeID: 2}); err == nil {
+		t.Fatal("expected node.id mismatch")
+	}
+}
+
+func TestLoadClusterIdentity_Errors(t *testing.T) {
+	unformatted := t.TempDir()
+	if _, err := LoadClusterIdentity([]string{unformatted}); !errors.Is(err, ErrNotFormatted) {
+		t.Fatalf("expected ErrNotFormatted, got %v", err)
+	}
+
+	if _, err := openLogDir(unformatted); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadClusterIdentity([]string{unformatted}); !errors.Is(err, ErrNotFormatted) {
+		t.Fatal("a directory.id alone does not make a formatted directory")
+	}
+
+	a, b := t.TempDir(), t.TempDir()
+	first, _ := NewClusterID()
+	second, _ := NewClusterID()
+	FormatLogDirs([]string{a}, domain.ClusterIdentity{ClusterID: first, NodeID: 1})
+	FormatLogDirs([]string{b}, domain.ClusterIdentity{ClusterID: second, NodeID: 1})
+
+	if _, err := LoadClusterIdentity([]string{a, b}); err == nil {
+		t.Fatal("expected mismatch between log directories")
+	}
+}
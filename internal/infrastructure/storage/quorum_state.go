@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+const quorumStateFile = "quorum-state"
+
+type QuorumState struct {
+	ClusterID     string        `json:"clusterId"`
+	LeaderID      int32         `json:"leaderId"`
+	LeaderEpoch   int32         `json:"leaderEpoch"`
+	VotedID       int32         `json:"votedId"`
+	AppliedOffset int64         `json:"appliedOffset"`
+	CurrentVoters []QuorumVoter `json:"currentVoters"`
+	DataVersion   int           `json:"data_version"`
+}
+
+type QuorumVoter struct {
+	VoterID int32 `json:"voterId"`
+}
+
+func LoadQuorumState(dir string) (QuorumState, bool, error) {
+	data, err := os.ReadFile(filepath.Join(dir, quorumStateFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return QuorumState{LeaderID: -1, VotedID: -1}, false, nil
+	}
+	if err != nil {
+		return QuorumState{}, false, err
+	}
+
+	var st QuorumState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return QuorumState{}, false, err
+	}
+	return st, true, nil
+}
+
+func WriteQuorumState(dir string, st QuorumState) error {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(dir, quorumStateFile), data)
+}
@@ -0,0 +1,185 @@
+package storage
+
+import (
+	"encoding/binary"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+)
+
+const (
+	batchLogOverhead = 12
+	batchHeaderSize  = 27
+	batchMinimumSize = 61
+)
+
+type batchHeader struct {
+	Position    int64
+	Size        int64
+	BaseOffset  int64
+	LastOffset  int64
+	LeaderEpoch int32
+}
+
+func logEndOffset(path string) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var end int64
+	err = scanBatches(f, func(h batchHeader) bool {
+		end = h.LastOffset + 1
+		return true
+	})
+	return end, err
+}
+
+func recoverLogEnd(path string) (int64, bool, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0644)
+	if err != nil {
+		return 0, false, err
+	}
+	defer f.Close()
+
+	var end int64
+	valid, size, err := scanLog(f, func(h batchHeader) bool {
+		end = h.LastOffset + 1
+		return true
+	})
+	if err != nil {
+		return 0, false, err
+	}
+	if valid == size {
+		return end, false, nil
+	}
+
+	if err := f.Truncate(valid); err != nil {
+		return 0, false, err
+	}
+	if err := f.Sync(); err != nil {
+		return 0, false, err
+	}
+	return end, true, nil
+}
+
+func scanBatches(f *os.File, visit func(h batchHeader) bool) error {
+	_, _, err := scanLog(f, visit)
+	return err
+}
+
+func scanLog(f *os.File, visit func(h batchHeader) bool) (int64, int64, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var (
+		header [batchHeaderSize]byte
+		pos    int64
+		size   = info.Size()
+	)
+
+	for size-pos >= batchMinimumSize {
+		if _, err := f.ReadAt(header[:], pos); err != nil {
+			return pos, size, err
+		}
+
+		h := readBatchHeader(header[:], pos)
+		if h.Size < batchMinimumSize || h.Size > size-pos {
+			break
+		}
+		if !visit(h) {
+			break
+		}
+		pos += h.Size
+	}
+	return pos, size, nil
+}
+
+func readBatchHeader(b []byte, pos int64) batchHeader {
+	baseOffset := int64(binary.BigEndian.Uint64(b[:8]))
+	length := int64(int32(binary.BigEndian.Uint32(b[8:12])))
+	delta := int64(int32(binary.BigEndian.Uint32(b[23:27])))
+
+	return batchHeader{
+		Position:    pos,
+		Size:        batchLogOverhead + length,
+		BaseOffset:  baseOffset,
+		LastOffset:  baseOffset + delta,
+		LeaderEpoch: int32(binary.BigEndian.Uint32(b[12:16])),
+	}
+}
+
+func assignOffsets(data []byte, base int64, leaderEpoch int32) (domain.LogAppendInfo, error) {
+	info := domain.LogAppendInfo{BaseOffset: base, LastOffset: base - 1}
+
+	err := walkBatches(data, func(b []byte) {
+		binary.BigEndian.PutUint64(b[:8], uint64(info.LastOffset+1))
+		binary.BigEndian.PutUint32(b[12:16], uint32(leaderEpoch))
+		info.LastOffset = readBatchHeader(b, 0).LastOffset
+	})
+	return info, err
+}
+
+func batchOffsets(data []byte) (domain.LogAppendInfo, error) {
+	info := domain.LogAppendInfo{BaseOffset: -1}
+
+	err := walkBatches(data, func(b []byte) {
+		h := readBatchHeader(b, 0)
+		if info.BaseOffset < 0 {
+			info.BaseOffset = h.BaseOffset
+		}
+		info.LastOffset = h.LastOffset
+	})
+	return info, err
+}
+
+func walkBatches(data []byte, visit func(b []byte)) error {
+	if len(data) == 0 {
+		return domain.ErrCorruptRecords
+	}
+
+	for pos := 0; pos < len(data); {
+		if len(data)-pos < batchHeaderSize {
+			return domain.ErrCorruptRecords
+		}
+		size := readBatchHeader(data[pos:], 0).Size
+		if size < batchHeaderSize || size > int64(len(data)-pos) {
+			return domain.ErrCorruptRecords
+		}
+		visit(data[pos : pos+int(size)])
+		pos += int(size)
+	}
+	return nil
+}
+
+func segmentsSize(path string) int64 {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return 0
+	}
+
+	var size int64
+	for _, e := range entries {
+		if !strings.HasSuffix(e.Name(), ".log") {
+			continue
+		}
+		info, err := e.Info()
+		if err == nil && !info.IsDir() {
+			size += info.Size()
+		}
+	}
+	return size
+}
+
+func copyRange(dst *os.File, src *os.File, from int64, to int64) error {
+	if to <= from {
+		return nil
+	}
+	_, err := io.Copy(dst, io.NewSectionReader(src, from, to-from))
+	return err
+}
@@ -0,0 +1,7 @@
+//go:build !linux && !darwin
+
+package storage
+
+func diskUsage(path string) (total int64, usable int64) {
+	return -1, -1
+}
@@ -0,0 +1,13 @@
+//go:build linux || darwin
+
+package storage
+
+import "syscall"
+
+func diskUsage(path string) (total int64, usable int64) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return -1, -1
+	}
+	return int64(st.Blocks) * int64(st.Bsize), int64(st.Bavail) * int64(st.Bsize)
+}
@@ -0,0 +1,7 @@
+package ports
+
+import "github.com/codecrafters-io/kafka-starter-go/internal/domain"
+
+type ConfigAlterer interface {
+	AlterConfigs(alterations []domain.ConfigAlteration, validateOnly bool) ([]domain.ConfigAlterationResult, error)
+}
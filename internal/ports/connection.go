@@ -1,7 +1,10 @@
 package ports
 
+import "github.com/codecrafters-io/kafka-starter-go/internal/domain"
+
 type Connection interface {
 	Read(p []byte) (int, error)
 	Write(p []byte) (int, error)
+	WriteRegion(region *domain.FileRegion) (int64, error)
 	Close() error
 }
@@ -0,0 +1,7 @@
+package ports
+
+import "github.com/codecrafters-io/kafka-starter-go/internal/domain"
+
+type LeaderElector interface {
+	ElectLeaders(electionType domain.ElectionType, targets []domain.ElectionTarget) ([]domain.ElectionResult, error)
+}
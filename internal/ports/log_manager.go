@@ -1,6 +1,18 @@
 package ports
 
+import "github.com/codecrafters-io/kafka-starter-go/internal/domain"
+
 type LogManager interface {
 	LoadLog(topicName string, partition int32) ([]byte, error)
-	AppendLog(topicName string, partition int32, data []byte) error
+	OpenLogRegion(topicName string, partition int32, fromOffset int64, maxOffset int64, maxBytes int32) (*domain.FileRegion, error)
+	AppendLog(topicName string, partition int32, data []byte, leaderEpoch int32) (domain.LogAppendInfo, error)
+	AppendReplicaLog(topicName string, partition int32, data []byte) (domain.LogAppendInfo, error)
+	LogEndOffset(topicName string, partition int32) (int64, error)
+	TruncateLog(topicName string, partition int32, offset int64) error
+	DeleteLog(topicName string, partition int32) error
+	EndOffsetForEpoch(topicName string, partition int32, leaderEpoch int32) (domain.EpochEndOffset, error)
+	LatestEpoch(topicName string, partition int32) (int32, error)
+	DescribeLogDirs() []domain.LogDirDescription
+	MoveReplica(topicName string, partition int32, path string) error
+	Assign(topicName string, partition int32, dirID [16]byte)
 }
@@ -0,0 +1,12 @@
+package ports
+
+import "github.com/codecrafters-io/kafka-starter-go/internal/domain"
+
+type MetadataQuorum interface {
+	HandleVote(vote domain.QuorumVote) domain.QuorumVoteResult
+	HandleBeginQuorumEpoch(leader domain.LeaderAndEpoch) domain.QuorumEpochResult
+	HandleEndQuorumEpoch(leader domain.LeaderAndEpoch, successors []int32) domain.QuorumEpochResult
+	HandleFetch(fetch domain.QuorumFetch) domain.QuorumFetchResult
+	HandleFetchSnapshot(fetch domain.QuorumSnapshotFetch) domain.QuorumSnapshotChunk
+	DescribeQuorum() domain.QuorumDescription
+}
@@ -5,4 +5,12 @@ import "github.com/codecrafters-io/kafka-starter-go/internal/domain"
 type MetadataRepository interface {
 	GetTopic(name string) (*domain.TopicMetadata, error)
 	GetTopicByID(id [16]byte) (*domain.TopicMetadata, error)
+	Topics() []*domain.TopicMetadata
+	Brokers() []domain.BrokerRegistration
+	Controllers() []domain.ControllerRegistration
+	ControllerID() int32
+	FinalizedFeatures() domain.FinalizedFeatures
+	BrokerConfigs(resource string) map[string]string
+	Version() domain.MetadataVersion
+	Subscribe(listener func(domain.MetadataChange)) (unsubscribe func())
 }
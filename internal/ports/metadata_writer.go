@@ -0,0 +1,11 @@
+package ports
+
+import "github.com/codecrafters-io/kafka-starter-go/internal/domain"
+
+type MetadataWriter interface {
+	UpdateFeatures(levels map[string]int16) error
+	AlterPartitions(changes []domain.PartitionChange) error
+	CreateTopics(topics []*domain.TopicMetadata) error
+	AlterConfigs(changes []domain.ConfigChange) error
+	FenceBrokers(fencing []domain.BrokerFencing, changes []domain.PartitionChange) error
+}
@@ -0,0 +1,7 @@
+package ports
+
+import "github.com/codecrafters-io/kafka-starter-go/internal/domain"
+
+type PartitionReassigner interface {
+	AlterPartitionReassignments(targets []domain.ReassignmentTarget) ([]domain.ReassignmentResult, error)
+}
@@ -0,0 +1,11 @@
+package ports
+
+import (
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/response"
+)
+
+type QuorumClientCodec interface {
+	EncodeRequest(header request.RequestHeader, body request.RequestBody) ([]byte, error)
+	DecodeResponse(apiKey uint16, payload []byte) (*response.MessageResponse, error)
+}
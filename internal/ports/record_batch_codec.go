@@ -0,0 +1,8 @@
+package ports
+
+import "github.com/codecrafters-io/kafka-starter-go/internal/domain"
+
+type RecordBatchCodec interface {
+	Decode(data []byte) ([]domain.RecordBatch, error)
+	Encode(batches []domain.RecordBatch) ([]byte, error)
+}
@@ -0,0 +1,17 @@
+package ports
+
+import (
+	"time"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+)
+
+type ReplicaManager interface {
+	HighWatermark(topicName string, partition int32) int64
+	RecordAppend(topicName string, partition int32, endOffset int64)
+	RecordFollowerFetch(topicName string, partition int32, replicaID int32, fetchOffset int64)
+	ThrottleLeaderFetch(topicName string, partition int32, replicaID int32) bool
+	RecordLeaderFetchBytes(topicName string, partition int32, replicaID int32, bytes int64)
+	PreferredReadReplica(topicName string, partition int32, fetchOffset int64, client domain.ClientMetadata) int32
+	WaitForHighWatermark(topicName string, partition int32, offset int64, minISR int, timeout time.Duration) error
+}
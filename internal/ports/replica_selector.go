@@ -0,0 +1,7 @@
+package ports
+
+import "github.com/codecrafters-io/kafka-starter-go/internal/domain"
+
+type ReplicaSelector interface {
+	Select(topicName string, partition int32, client domain.ClientMetadata, view domain.PartitionView) (domain.ReplicaView, bool)
+}
@@ -6,4 +6,5 @@ import (
 
 type ResponseBuilder interface {
 	Build(resp *response.MessageResponse) ([]byte, error)
+	BuildFrame(resp *response.MessageResponse) (*response.Frame, error)
 }
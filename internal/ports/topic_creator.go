@@ -0,0 +1,7 @@
+package ports
+
+import "github.com/codecrafters-io/kafka-starter-go/internal/domain"
+
+type TopicCreator interface {
+	CreateTopics(topics []domain.TopicCreation, validateOnly bool) ([]domain.TopicCreationResult, error)
+}
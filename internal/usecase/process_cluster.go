@@ -0,0 +1,98 @@
+package usecase
+
+import (
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/response"
+)
+
+func (p *RequestProcessor) processDescribeCluster(
+	h request.RequestHeader,
+	r *request.DescribeClusterRequest,
+) *response.MessageResponse {
+
+	body := &response.DescribeClusterResponseBody{
+		Version:                     h.ApiVersion,
+		EndpointType:                r.EndpointType,
+		ClusterID:                   p.local.ClusterID,
+		ControllerID:                p.metadataRepo.ControllerID(),
+		ClusterAuthorizedOperations: domain.AuthorizedOperationsOmitted,
+	}
+
+	if r.IncludeClusterAuthorizedOperations {
+		body.ClusterAuthorizedOperations = domain.ClusterAuthorizedOperationsAll
+	}
+
+	switch r.EndpointType {
+	case domain.EndpointTypeBroker:
+		body.Brokers = p.describeBrokers()
+
+	case domain.EndpointTypeController:
+		body.Brokers = p.describeControllers()
+
+	default:
+		msg := "unsupported endpoint type"
+		body.ErrorCode = domain.ErrorUnsupportedEndpointType
+		body.ErrorMessage = &msg
+	}
+
+	return &response.MessageResponse{
+		CorrelationID: h.CorrelationID,
+		HeaderVersion: 1,
+		Body:          body,
+	}
+}
+
+func (p *RequestProcessor) describeBrokers() []response.DescribeClusterBroker {
+	out := make([]response.DescribeClusterBroker, 0)
+	registered := false
+
+	for _, b := range p.metadataRepo.Brokers() {
+		if b.ID == p.local.NodeID {
+			registered = true
+		}
+		if b.Fenced {
+			continue
+		}
+
+		ep, ok := b.Endpoint(p.local.Endpoint.Listener)
+		if !ok {
+			continue
+		}
+		out = append(out, response.DescribeClusterBroker{
+			BrokerID: b.ID,
+			Host:     ep.Host,
+			Port:     ep.Port,
+			Rack:     b.Rack,
+		})
+	}
+
+	if !registered {
+		out = append(out, response.DescribeClusterBroker{
+			BrokerID: p.local.NodeID,
+			Host:     p.local.Endpoint.Host,
+			Port:     p.local.Endpoint.Port,
+			Rack:     p.local.Rack,
+		})
+	}
+
+	return out
+}
+
+func (p *RequestProcessor) describeControllers() []response.DescribeClusterBroker {
+	out := make([]response.DescribeClusterBroker, 0)
+
+	for _, c := range p.metadataRepo.Controllers() {
+		ep, ok := c.Endpoint(p.local.Endpoint.Listener)
+		if !ok {
+			continue
+		}
+		out = append(out, response.DescribeClusterBroker{
+			BrokerID: c.ID,
+			Host:     ep.Host,
+			Port:     ep.Port,
+		})
+	}
+
+	return out
+}
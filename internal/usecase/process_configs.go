@@ -0,0 +1,213 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/response"
+)
+
+func (p *RequestProcessor) processDescribeConfigs(
+	h request.RequestHeader,
+	r *request.DescribeConfigsRequest,
+) *response.MessageResponse {
+
+	body := &response.DescribeConfigsResponseBody{Version: h.ApiVersion}
+	resp := &response.MessageResponse{
+		CorrelationID: h.CorrelationID,
+		Body:          body,
+	}
+	if h.ApiVersion >= 4 {
+		resp.HeaderVersion = 1
+	}
+
+	for _, res := range r.Resources {
+		result := response.DescribeConfigsResult{
+			ResourceType: res.ResourceType,
+			ResourceName: res.ResourceName,
+			Configs:      []response.DescribeConfigsResourceResult{},
+		}
+
+		descriptions, code, msg := p.describeConfigs(res.ResourceType, res.ResourceName)
+		if code != 0 {
+			result.ErrorCode = code
+			result.ErrorMessage = &msg
+			body.Results = append(body.Results, result)
+			continue
+		}
+
+		for _, d := range descriptions {
+			if res.ConfigurationKeys != nil && !slices.Contains(res.ConfigurationKeys, d.Name) {
+				continue
+			}
+			result.Configs = append(result.Configs, describedConfig(d, r.IncludeSynonyms, r.IncludeDocumentation))
+		}
+		body.Results = append(body.Results, result)
+	}
+	return resp
+}
+
+func (p *RequestProcessor) describeConfigs(resourceType int8, name string) ([]domain.ConfigDescription, int16, string) {
+	layers := domain.ConfigLayers{
+		ClusterDefault: p.metadataRepo.BrokerConfigs(""),
+		Static:         p.local.Configs,
+	}
+
+	switch resourceType {
+	case domain.ConfigResourceTopic:
+		meta, err := p.metadataRepo.GetTopic(name)
+		if err != nil || meta == nil {
+			return nil, domain.ErrorUnknownTopicOrPartition, fmt.Sprintf("topic '%s' does not exist", name)
+		}
+		layers.Topic = meta.Configs
+
+		out := make([]domain.ConfigDescription, 0)
+		for _, def := range domain.TopicConfigDefinitions() {
+			out = append(out, domain.DescribeTopicConfig(def, layers))
+		}
+		return out, 0, ""
+
+	case domain.ConfigResourceBroker:
+		out := make([]domain.ConfigDescription, 0)
+		if name == "" {
+			for _, key := range domain.SortedConfigNames(layers.ClusterDefault) {
+				if def, ok := domain.BrokerConfigDefinition(key); ok {
+					out = append(out, domain.DescribeBrokerConfig(def, domain.ConfigLayers{ClusterDefault: layers.ClusterDefault}))
+				}
+			}
+			return out, 0, ""
+		}
+
+		if name != strconv.Itoa(int(p.local.NodeID)) {
+			return nil, domain.ErrorInvalidRequest,
+				fmt.Sprintf("unexpected broker id, expected %d or empty string, but received %s", p.local.NodeID, name)
+		}
+		layers.Broker = p.metadataRepo.BrokerConfigs(name)
+		for _, def := range domain.BrokerConfigDefinitions() {
+			out = append(out, domain.DescribeBrokerConfig(def, layers))
+		}
+		return out, 0, ""
+	}
+
+	return nil, domain.ErrorInvalidRequest, fmt.Sprintf("unsupported resource type %d", resourceType)
+}
+
+func describedConfig(d domain.ConfigDescription, synonyms, documentation bool) response.DescribeConfigsResourceResult {
+	c := response.DescribeConfigsResourceResult{
+		Name:         d.Name,
+		Value:        d.Value,
+		ReadOnly:     d.ReadOnly,
+		ConfigSource: d.Source,
+		ConfigType:   int8(d.Type),
+		Synonyms:     []response.DescribeConfigsSynonym{},
+	}
+	if synonyms {
+		for _, s := range d.Synonyms {
+			c.Synonyms = append(c.Synonyms, response.DescribeConfigsSynonym{
+				Name:   s.Name,
+				Value:  s.Value,
+				Source: s.Source,
+			})
+		}
+	}
+	if documentation {
+		doc := d.Documentation
+		c.Documentation = &doc
+	}
+	return c
+}
+
+func (p *RequestProcessor) processAlterConfigs(
+	h request.RequestHeader,
+	r *request.AlterConfigsRequest,
+) *response.MessageResponse {
+
+	body := &response.AlterConfigsResponseBody{
+		Version:   h.ApiVersion,
+		Responses: p.alterConfigs(r.Resources, false, r.ValidateOnly),
+	}
+	resp := &response.MessageResponse{
+		CorrelationID: h.CorrelationID,
+		Body:          body,
+	}
+	if h.ApiVersion >= 2 {
+		resp.HeaderVersion = 1
+	}
+	return resp
+}
+
+func (p *RequestProcessor) processIncrementalAlterConfigs(
+	h request.RequestHeader,
+	r *request.IncrementalAlterConfigsRequest,
+) *response.MessageResponse {
+
+	body := &response.IncrementalAlterConfigsResponseBody{
+		Version:   h.ApiVersion,
+		Responses: p.alterConfigs(r.Resources, true, r.ValidateOnly),
+	}
+	resp := &response.MessageResponse{
+		CorrelationID: h.CorrelationID,
+		Body:          body,
+	}
+	if h.ApiVersion >= 1 {
+		resp.HeaderVersion = 1
+	}
+	return resp
+}
+
+func (p *RequestProcessor) alterConfigs(
+	resources []request.AlterConfigsResource,
+	incremental bool,
+	validateOnly bool,
+) []response.AlterConfigsResourceResponse {
+
+	alterations := make([]domain.ConfigAlteration, 0, len(resources))
+	for _, res := range resources {
+		alteration := domain.ConfigAlteration{
+			Resource:    domain.ConfigResource{Type: res.ResourceType, Name: res.ResourceName},
+			Incremental: incremental,
+		}
+		for _, c := range res.Configs {
+			alteration.Ops = append(alteration.Ops, domain.ConfigOp{
+				Name:  c.Name,
+				Op:    c.ConfigOperation,
+				Value: c.Value,
+			})
+		}
+		alterations = append(alterations, alteration)
+	}
+
+	out := make([]response.AlterConfigsResourceResponse, 0, len(resources))
+
+	results, err := p.configs.AlterConfigs(alterations, validateOnly)
+	if err != nil {
+		code := int16(domain.ErrorUnknownServerError)
+		if errors.Is(err, domain.ErrNotController) {
+			code = domain.ErrorNotController
+		}
+		msg := err.Error()
+		for _, res := range resources {
+			out = append(out, response.AlterConfigsResourceResponse{
+				ErrorCode:    code,
+				ErrorMessage: &msg,
+				ResourceType: res.ResourceType,
+				ResourceName: res.ResourceName,
+			})
+		}
+		return out
+	}
+
+	for _, result := range results {
+		out = append(out, response.AlterConfigsResourceResponse{
+			ErrorCode:    result.ErrorCode,
+			ErrorMessage: result.ErrorMessage,
+			ResourceType: result.Resource.Type,
+			ResourceName: result.Resource.Name,
+		})
+	}
+	return out
+}
@@ -0,0 +1,115 @@
+package usecase
+
+import (
+	"fmt"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/response"
+)
+
+func (p *RequestProcessor) processUpdateFeatures(
+	h request.RequestHeader,
+	r *request.UpdateFeaturesRequest,
+) *response.MessageResponse {
+
+	body := &response.UpdateFeaturesResponseBody{}
+	current := p.metadataRepo.FinalizedFeatures().Levels
+
+	pending := make(map[string]int16, len(r.Updates))
+	failed := false
+
+	for _, u := range r.Updates {
+		result := response.UpdatableFeatureResult{Feature: u.Feature}
+
+		code, msg := validateFeatureUpdate(u, current[u.Feature])
+		if _, dup := pending[u.Feature]; dup && code == 0 {
+			code, msg = domain.ErrorInvalidRequest, "feature listed more than once"
+		}
+
+		if code != 0 {
+			failed = true
+			result.ErrorCode = code
+			result.ErrorMessage = &msg
+		} else {
+			pending[u.Feature] = u.MaxVersionLevel
+		}
+		body.Results = append(body.Results, result)
+	}
+
+	if failed {
+		markUnapplied(body.Results, "not applied because another feature update failed")
+	} else if !r.ValidateOnly {
+		if err := p.metadataWriter.UpdateFeatures(pending); err != nil {
+			for i := range body.Results {
+				msg := err.Error()
+				body.Results[i].ErrorCode = domain.ErrorFeatureUpdateFailed
+				body.Results[i].ErrorMessage = &msg
+			}
+		}
+	}
+
+	return &response.MessageResponse{
+		CorrelationID: h.CorrelationID,
+		Body:          body,
+	}
+}
+
+func validateFeatureUpdate(u request.FeatureUpdate, current int16) (int16, string) {
+	if u.UpgradeType < domain.FeatureUpgrade || u.UpgradeType > domain.FeatureUnsafeDowngrade {
+		return domain.ErrorInvalidRequest, fmt.Sprintf("unknown upgrade type %d", u.UpgradeType)
+	}
+
+	supported, ok := supportedFeature(u.Feature)
+	if !ok {
+		return domain.ErrorInvalidUpdateVersion, "feature is not supported by this broker"
+	}
+
+	if u.MaxVersionLevel == 0 {
+		if u.Feature == domain.FeatureMetadataVersion {
+			return domain.ErrorInvalidUpdateVersion, "metadata.version cannot be removed"
+		}
+	} else if u.MaxVersionLevel < supported.MinVersion || u.MaxVersionLevel > supported.MaxVersion {
+		return domain.ErrorInvalidUpdateVersion, fmt.Sprintf("level %d is outside the supported range [%d, %d]",
+			u.MaxVersionLevel, supported.MinVersion, supported.MaxVersion)
+	}
+
+	switch {
+	case u.MaxVersionLevel < current && u.UpgradeType == domain.FeatureUpgrade:
+		return domain.ErrorInvalidUpdateVersion, fmt.Sprintf("cannot downgrade from %d to %d without a downgrade type",
+			current, u.MaxVersionLevel)
+
+	case u.MaxVersionLevel > current && u.UpgradeType != domain.FeatureUpgrade:
+		return domain.ErrorInvalidUpdateVersion, fmt.Sprintf("cannot downgrade from %d to newer level %d",
+			current, u.MaxVersionLevel)
+
+	case u.MaxVersionLevel < current && u.UpgradeType == domain.FeatureSafeDowngrade &&
+		u.Feature == domain.FeatureMetadataVersion && isLossyMetadataDowngrade(current, u.MaxVersionLevel):
+		return domain.ErrorInvalidUpdateVersion, "downgrade would lose metadata, use an unsafe downgrade"
+	}
+
+	return 0, ""
+}
+
+func isLossyMetadataDowngrade(from, to int16) bool {
+	return domain.MetadataFeatureLevel(from).IsDirectoryAssignmentSupported() &&
+		!domain.MetadataFeatureLevel(to).IsDirectoryAssignmentSupported()
+}
+
+func supportedFeature(name string) (domain.SupportedFeature, bool) {
+	for _, f := range domain.SupportedFeatures() {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return domain.SupportedFeature{}, false
+}
+
+func markUnapplied(results []response.UpdatableFeatureResult, msg string) {
+	for i := range results {
+		if results[i].ErrorCode == 0 {
+			results[i].ErrorCode = domain.ErrorFeatureUpdateFailed
+			results[i].ErrorMessage = &msg
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package usecase
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/response"
+)
+
+func (p *RequestProcessor) processDescribeLogDirs(
+	h request.RequestHeader,
+	r *request.DescribeLogDirsRequest,
+) *response.MessageResponse {
+
+	wanted := requestedPartitions(r.Topics)
+
+	results := make([]response.LogDirResult, 0)
+
+	for _, dir := range p.logManager.DescribeLogDirs() {
+		result := response.LogDirResult{
+			LogDir:      dir.Path,
+			TotalBytes:  dir.TotalBytes,
+			UsableBytes: dir.UsableBytes,
+		}
+
+		if dir.Offline {
+			result.ErrorCode = domain.ErrorKafkaStorage
+			results = append(results, result)
+			continue
+		}
+
+		byTopic := make(map[string]*response.LogDirTopic)
+		names := make([]string, 0)
+
+		for _, part := range dir.Partitions {
+			if wanted != nil && !wanted[part.Topic][part.Partition] {
+				continue
+			}
+
+			t, ok := byTopic[part.Topic]
+			if !ok {
+				t = &response.LogDirTopic{Name: part.Topic}
+				byTopic[part.Topic] = t
+				names = append(names, part.Topic)
+			}
+
+			t.Partitions = append(t.Partitions, response.LogDirPartition{
+				PartitionIndex: part.Partition,
+				PartitionSize:  part.Size,
+				OffsetLag:      part.OffsetLag,
+				IsFutureKey:    part.IsFuture,
+			})
+		}
+
+		sort.Strings(names)
+		for _, name := range names {
+			result.Topics = append(result.Topics, *byTopic[name])
+		}
+
+		results = append(results, result)
+	}
+
+	body := &response.DescribeLogDirsResponseBody{
+		Version:        h.ApiVersion,
+		ThrottleTimeMs: 0,
+		ErrorCode:      0,
+		Results:        results,
+	}
+
+	return &response.MessageResponse{
+		CorrelationID: h.CorrelationID,
+		HeaderVersion: 1,
+		Body:          body,
+	}
+}
+
+func (p *RequestProcessor) processAlterReplicaLogDirs(
+	h request.RequestHeader,
+	r *request.AlterReplicaLogDirsRequest,
+) *response.MessageResponse {
+
+	results := make([]response.AlterReplicaLogDirTopic, 0)
+	supported := p.metadataRepo.FinalizedFeatures().MetadataVersion().IsDirectoryAssignmentSupported()
+
+	for _, dir := range r.Dirs {
+		for _, t := range dir.Topics {
+			topicResp := response.AlterReplicaLogDirTopic{TopicName: t.Name}
+
+			meta, err := p.metadataRepo.GetTopic(t.Name)
+			topicExists := err == nil && meta != nil
+
+			for _, idx := range t.Partitions {
+				partResp := response.AlterReplicaLogDirPartition{
+					PartitionIndex: idx,
+					ErrorCode:      domain.ErrorUnknownTopicOrPartition,
+				}
+
+				if !supported {
+					partResp.ErrorCode = domain.ErrorNotSupportedApiVersion
+				} else if topicExists && partitionExists(meta, idx) {
+					partResp.ErrorCode = moveReplicaErrorCode(
+						p.logManager.MoveReplica(t.Name, idx, dir.Path),
+					)
+				}
+
+				topicResp.Partitions = append(topicResp.Partitions, partResp)
+			}
+
+			results = append(results, topicResp)
+		}
+	}
+
+	body := &response.AlterReplicaLogDirsResponseBody{
+		ThrottleTimeMs: 0,
+		Results:        results,
+	}
+
+	return &response.MessageResponse{
+		CorrelationID: h.CorrelationID,
+		HeaderVersion: 1,
+		Body:          body,
+	}
+}
+
+func moveReplicaErrorCode(err error) int16 {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, domain.ErrLogDirNotFound):
+		return domain.ErrorLogDirNotFound
+	default:
+		return domain.ErrorKafkaStorage
+	}
+}
+
+func requestedPartitions(topics []request.TopicPartitions) map[string]map[int32]bool {
+	if topics == nil {
+		return nil
+	}
+
+	out := make(map[string]map[int32]bool, len(topics))
+	for _, t := range topics {
+		parts := make(map[int32]bool, len(t.Partitions))
+		for _, idx := range t.Partitions {
+			parts[idx] = true
+		}
+		out[t.Name] = parts
+	}
+	return out
+}
@@ -0,0 +1,413 @@
+package usecase
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/response"
+)
+
+type partitionResultRef struct {
+	topic     int
+	partition int
+	topicID   [16]byte
+}
+
+func (p *RequestProcessor) processAlterPartition(
+	h request.RequestHeader,
+	r *request.AlterPartitionRequest,
+) *response.MessageResponse {
+
+	body := &response.AlterPartitionResponseBody{Version: h.ApiVersion}
+	resp := &response.MessageResponse{
+		CorrelationID: h.CorrelationID,
+		HeaderVersion: 1,
+		Body:          body,
+	}
+
+	if p.quorum.DescribeQuorum().Leader.LeaderID != p.local.NodeID {
+		body.ErrorCode = domain.ErrorNotController
+		return resp
+	}
+
+	changes := make([]domain.PartitionChange, 0)
+	refs := make([]partitionResultRef, 0)
+
+	for i, t := range r.Topics {
+		meta := p.alterPartitionTopic(h.ApiVersion, t)
+		topic := response.AlterPartitionTopicResult{TopicName: t.TopicName, TopicID: t.TopicID}
+
+		for j, part := range t.Partitions {
+			result := response.AlterPartitionPartitionResult{
+				PartitionIndex: part.PartitionIndex,
+				LeaderID:       -1,
+				LeaderEpoch:    -1,
+			}
+
+			var (
+				pm domain.PartitionMetadata
+				ok bool
+			)
+			if meta != nil {
+				pm, ok = findPartition(meta, part.PartitionIndex)
+			}
+
+			switch {
+			case meta == nil && h.ApiVersion >= 2:
+				result.ErrorCode = domain.ErrorUnknownTopicId
+			case !ok:
+				result.ErrorCode = domain.ErrorUnknownTopicOrPartition
+			default:
+				result.ErrorCode = p.validateAlterPartition(r.BrokerID, pm, part)
+			}
+
+			var (
+				change  domain.PartitionChange
+				changed bool
+			)
+			if result.ErrorCode == 0 {
+				next := pm.Clone()
+				next.ISR = slices.Clone(part.NewISR)
+				change, changed = domain.DiffPartition(meta, pm, domain.AdvanceReassignment(next))
+			}
+
+			if changed {
+				changes = append(changes, change)
+				refs = append(refs, partitionResultRef{topic: i, partition: j, topicID: meta.TopicID})
+			} else if result.ErrorCode == 0 {
+				setAlterPartitionState(&result, pm)
+			}
+			topic.Partitions = append(topic.Partitions, result)
+		}
+		body.Topics = append(body.Topics, topic)
+	}
+
+	if len(changes) == 0 {
+		return resp
+	}
+
+	if err := p.metadataWriter.AlterPartitions(changes); err != nil {
+		code := int16(domain.ErrorUnknownServerError)
+		if errors.Is(err, domain.ErrNotController) {
+			code = domain.ErrorNotController
+		}
+		for _, ref := range refs {
+			body.Topics[ref.topic].Partitions[ref.partition].ErrorCode = code
+		}
+		return resp
+	}
+
+	for _, ref := range refs {
+		result := &body.Topics[ref.topic].Partitions[ref.partition]
+		meta, err := p.metadataRepo.GetTopicByID(ref.topicID)
+		if err != nil || meta == nil {
+			result.ErrorCode = domain.ErrorUnknownTopicOrPartition
+			continue
+		}
+		if pm, ok := findPartition(meta, result.PartitionIndex); ok {
+			setAlterPartitionState(result, pm)
+		}
+	}
+	return resp
+}
+
+func (p *RequestProcessor) alterPartitionTopic(version uint16, t request.AlterPartitionTopic) *domain.TopicMetadata {
+	var (
+		meta *domain.TopicMetadata
+		err  error
+	)
+	if version >= 2 {
+		meta, err = p.metadataRepo.GetTopicByID(t.TopicID)
+	} else {
+		meta, err = p.metadataRepo.GetTopic(t.TopicName)
+	}
+	if err != nil {
+		return nil
+	}
+	return meta
+}
+
+func (p *RequestProcessor) validateAlterPartition(
+	brokerID int32,
+	pm domain.PartitionMetadata,
+	part request.AlterPartitionPartition,
+) int16 {
+
+	switch {
+	case brokerID != pm.LeaderID:
+		return domain.ErrorInvalidRequest
+	case part.LeaderEpoch != pm.LeaderEpoch:
+		return domain.ErrorFencedLeaderEpoch
+	case part.PartitionEpoch != pm.PartitionEpoch:
+		return domain.ErrorInvalidUpdateVersion
+	case !slices.Contains(part.NewISR, pm.LeaderID):
+		return domain.ErrorInvalidRequest
+	}
+
+	seen := make(map[int32]bool, len(part.NewISR))
+	for _, id := range part.NewISR {
+		if seen[id] {
+			return domain.ErrorInvalidRequest
+		}
+		seen[id] = true
+
+		if !slices.Contains(pm.Replicas, id) {
+			return domain.ErrorIneligibleReplica
+		}
+		if !slices.Contains(pm.ISR, id) && p.isFencedBroker(id) {
+			return domain.ErrorIneligibleReplica
+		}
+	}
+	return 0
+}
+
+func (p *RequestProcessor) isFencedBroker(id int32) bool {
+	for _, b := range p.metadataRepo.Brokers() {
+		if b.ID == id {
+			return b.Fenced
+		}
+	}
+	return false
+}
+
+func setAlterPartitionState(result *response.AlterPartitionPartitionResult, pm domain.PartitionMetadata) {
+	result.LeaderID = pm.LeaderID
+	result.LeaderEpoch = pm.LeaderEpoch
+	result.ISR = slices.Clone(pm.ISR)
+	result.LeaderRecoveryState = pm.LeaderRecoveryState
+	result.PartitionEpoch = pm.PartitionEpoch
+}
+
+func (p *RequestProcessor) processElectLeaders(
+	h request.RequestHeader,
+	r *request.ElectLeadersRequest,
+) *response.MessageResponse {
+
+	body := &response.ElectLeadersResponseBody{Version: h.ApiVersion}
+	resp := &response.MessageResponse{
+		CorrelationID: h.CorrelationID,
+		Body:          body,
+	}
+	if h.ApiVersion >= 2 {
+		resp.HeaderVersion = 1
+	}
+
+	var targets []domain.ElectionTarget
+	if r.TopicPartitions != nil {
+		targets = make([]domain.ElectionTarget, 0, len(r.TopicPartitions))
+		for _, t := range r.TopicPartitions {
+			targets = append(targets, domain.ElectionTarget{Topic: t.Topic, Partitions: t.Partitions})
+		}
+	}
+
+	results, err := p.elector.ElectLeaders(domain.ElectionType(r.ElectionType), targets)
+	if err != nil {
+		code := int16(domain.ErrorUnknownServerError)
+		if errors.Is(err, domain.ErrNotController) {
+			code = domain.ErrorNotController
+		}
+		if h.ApiVersion >= 1 {
+			body.ErrorCode = code
+			return resp
+		}
+
+		results = results[:0]
+		for _, t := range targets {
+			for _, partition := range t.Partitions {
+				results = append(results, domain.ElectionResult{Topic: t.Topic, Partition: partition, ErrorCode: code})
+			}
+		}
+	}
+
+	topics := map[string]int{}
+	for _, result := range results {
+		i, ok := topics[result.Topic]
+		if !ok {
+			i = len(body.ReplicaElectionResults)
+			topics[result.Topic] = i
+			body.ReplicaElectionResults = append(body.ReplicaElectionResults, response.ReplicaElectionResult{Topic: result.Topic})
+		}
+		body.ReplicaElectionResults[i].PartitionResult = append(body.ReplicaElectionResults[i].PartitionResult,
+			response.PartitionElectionResult{
+				PartitionID:  result.Partition,
+				ErrorCode:    result.ErrorCode,
+				ErrorMessage: result.ErrorMessage,
+			})
+	}
+	return resp
+}
+
+func (p *RequestProcessor) processOffsetForLeaderEpoch(
+	h request.RequestHeader,
+	r *request.OffsetForLeaderEpochRequest,
+) *response.MessageResponse {
+
+	body := &response.OffsetForLeaderEpochResponseBody{Version: h.ApiVersion}
+	for _, t := range r.Topics {
+		meta, err := p.metadataRepo.GetTopic(t.Topic)
+		if err != nil {
+			meta = nil
+		}
+
+		topic := response.OffsetForLeaderTopicResult{Topic: t.Topic}
+		for _, part := range t.Partitions {
+			topic.Partitions = append(topic.Partitions, p.offsetForLeaderEpoch(meta, part))
+		}
+		body.Topics = append(body.Topics, topic)
+	}
+
+	resp := &response.MessageResponse{
+		CorrelationID: h.CorrelationID,
+		Body:          body,
+	}
+	if h.ApiVersion >= 4 {
+		resp.HeaderVersion = 1
+	}
+	return resp
+}
+
+func (p *RequestProcessor) offsetForLeaderEpoch(
+	meta *domain.TopicMetadata,
+	part request.OffsetForLeaderPartition,
+) response.EpochEndOffsetResult {
+
+	result := response.EpochEndOffsetResult{
+		ErrorCode:   domain.ErrorUnknownTopicOrPartition,
+		Partition:   part.Partition,
+		LeaderEpoch: -1,
+		EndOffset:   -1,
+	}
+	if meta == nil {
+		return result
+	}
+
+	pm, ok := findPartition(meta, part.Partition)
+	if !ok {
+		return result
+	}
+	if pm.LeaderID != p.local.NodeID {
+		result.ErrorCode = domain.ErrorNotLeaderOrFollower
+		return result
+	}
+	if code := checkLeaderEpoch(part.CurrentLeaderEpoch, pm.LeaderEpoch); code != 0 {
+		result.ErrorCode = code
+		return result
+	}
+
+	end, err := p.logManager.EndOffsetForEpoch(meta.Name, part.Partition, part.LeaderEpoch)
+	switch {
+	case err == nil:
+		result.ErrorCode = 0
+		result.LeaderEpoch = end.Epoch
+		result.EndOffset = end.EndOffset
+	case errors.Is(err, domain.ErrLogDirOffline):
+		result.ErrorCode = domain.ErrorKafkaStorage
+	default:
+		result.ErrorCode = domain.ErrorUnknownServerError
+	}
+	return result
+}
+
+func (p *RequestProcessor) processAlterPartitionReassignments(
+	h request.RequestHeader,
+	r *request.AlterPartitionReassignmentsRequest,
+) *response.MessageResponse {
+
+	body := &response.AlterPartitionReassignmentsResponseBody{}
+	resp := &response.MessageResponse{
+		CorrelationID: h.CorrelationID,
+		HeaderVersion: 1,
+		Body:          body,
+	}
+
+	targets := make([]domain.ReassignmentTarget, 0)
+	for _, t := range r.Topics {
+		for _, part := range t.Partitions {
+			targets = append(targets, domain.ReassignmentTarget{
+				Topic:     t.Name,
+				Partition: part.PartitionIndex,
+				Replicas:  part.Replicas,
+			})
+		}
+	}
+
+	results, err := p.reassigner.AlterPartitionReassignments(targets)
+	if err != nil {
+		msg := err.Error()
+		body.ErrorCode = domain.ErrorUnknownServerError
+		if errors.Is(err, domain.ErrNotController) {
+			body.ErrorCode = domain.ErrorNotController
+		}
+		body.ErrorMessage = &msg
+		return resp
+	}
+
+	topics := map[string]int{}
+	for _, result := range results {
+		i, ok := topics[result.Topic]
+		if !ok {
+			i = len(body.Responses)
+			topics[result.Topic] = i
+			body.Responses = append(body.Responses, response.ReassignableTopicResponse{Name: result.Topic})
+		}
+		body.Responses[i].Partitions = append(body.Responses[i].Partitions,
+			response.ReassignablePartitionResponse{
+				PartitionIndex: result.Partition,
+				ErrorCode:      result.ErrorCode,
+				ErrorMessage:   result.ErrorMessage,
+			})
+	}
+	return resp
+}
+
+func (p *RequestProcessor) processListPartitionReassignments(
+	h request.RequestHeader,
+	r *request.ListPartitionReassignmentsRequest,
+) *response.MessageResponse {
+
+	body := &response.ListPartitionReassignmentsResponseBody{}
+
+	topics := p.metadataRepo.Topics()
+	if r.Topics != nil {
+		topics = make([]*domain.TopicMetadata, 0, len(r.Topics))
+		for _, t := range r.Topics {
+			meta, err := p.metadataRepo.GetTopic(t.Name)
+			if err != nil || meta == nil {
+				continue
+			}
+			filtered := &domain.TopicMetadata{Name: meta.Name, TopicID: meta.TopicID}
+			for _, index := range t.PartitionIndexes {
+				if pm, ok := findPartition(meta, index); ok {
+					filtered.Partitions = append(filtered.Partitions, pm)
+				}
+			}
+			topics = append(topics, filtered)
+		}
+	}
+
+	for _, meta := range topics {
+		topic := response.OngoingTopicReassignment{Name: meta.Name}
+		for _, pm := range meta.Partitions {
+			if !pm.Reassigning() {
+				continue
+			}
+			topic.Partitions = append(topic.Partitions, response.OngoingPartitionReassignment{
+				PartitionIndex:   pm.PartitionIndex,
+				Replicas:         slices.Clone(pm.Replicas),
+				AddingReplicas:   slices.Clone(pm.AddingReplicas),
+				RemovingReplicas: slices.Clone(pm.RemovingReplicas),
+			})
+		}
+		if len(topic.Partitions) > 0 {
+			body.Topics = append(body.Topics, topic)
+		}
+	}
+
+	return &response.MessageResponse{
+		CorrelationID: h.CorrelationID,
+		HeaderVersion: 1,
+		Body:          body,
+	}
+}
@@ -0,0 +1,230 @@
+package usecase
+
+import (
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/response"
+)
+
+func (p *RequestProcessor) processVote(
+	h request.RequestHeader,
+	r *request.VoteRequest,
+) *response.MessageResponse {
+
+	body := &response.VoteResponseBody{
+		Partition: quorumPartitionResult(r.QuorumPartition),
+	}
+
+	if !p.matchesClusterID(r.ClusterID) {
+		body.ErrorCode = domain.ErrorInconsistentClusterID
+	} else if !p.servesQuorumPartition(r.QuorumPartition) {
+		body.Partition.ErrorCode = domain.ErrorUnknownTopicOrPartition
+	} else {
+		result := p.quorum.HandleVote(r.Vote)
+		body.Partition.ErrorCode = result.ErrorCode
+		body.Partition.LeaderID = result.Leader.LeaderID
+		body.Partition.LeaderEpoch = result.Leader.Epoch
+		body.VoteGranted = result.Granted
+	}
+
+	return &response.MessageResponse{
+		CorrelationID: h.CorrelationID,
+		HeaderVersion: 1,
+		Body:          body,
+	}
+}
+
+func (p *RequestProcessor) processBeginQuorumEpoch(
+	h request.RequestHeader,
+	r *request.BeginQuorumEpochRequest,
+) *response.MessageResponse {
+
+	body := &response.BeginQuorumEpochResponseBody{
+		Partition: quorumPartitionResult(r.QuorumPartition),
+	}
+
+	if !p.matchesClusterID(r.ClusterID) {
+		body.ErrorCode = domain.ErrorInconsistentClusterID
+	} else if !p.servesQuorumPartition(r.QuorumPartition) {
+		body.Partition.ErrorCode = domain.ErrorUnknownTopicOrPartition
+	} else {
+		result := p.quorum.HandleBeginQuorumEpoch(domain.LeaderAndEpoch{
+			LeaderID: r.LeaderID,
+			Epoch:    r.LeaderEpoch,
+		})
+		setQuorumEpochResult(&body.Partition, result)
+	}
+
+	return &response.MessageResponse{
+		CorrelationID: h.CorrelationID,
+		Body:          body,
+	}
+}
+
+func (p *RequestProcessor) processEndQuorumEpoch(
+	h request.RequestHeader,
+	r *request.EndQuorumEpochRequest,
+) *response.MessageResponse {
+
+	body := &response.EndQuorumEpochResponseBody{
+		Partition: quorumPartitionResult(r.QuorumPartition),
+	}
+
+	if !p.matchesClusterID(r.ClusterID) {
+		body.ErrorCode = domain.ErrorInconsistentClusterID
+	} else if !p.servesQuorumPartition(r.QuorumPartition) {
+		body.Partition.ErrorCode = domain.ErrorUnknownTopicOrPartition
+	} else {
+		result := p.quorum.HandleEndQuorumEpoch(domain.LeaderAndEpoch{
+			LeaderID: r.LeaderID,
+			Epoch:    r.LeaderEpoch,
+		}, r.PreferredSuccessors)
+		setQuorumEpochResult(&body.Partition, result)
+	}
+
+	return &response.MessageResponse{
+		CorrelationID: h.CorrelationID,
+		Body:          body,
+	}
+}
+
+func (p *RequestProcessor) processFetchSnapshot(
+	h request.RequestHeader,
+	r *request.FetchSnapshotRequest,
+) *response.MessageResponse {
+
+	body := &response.FetchSnapshotResponseBody{
+		Partition:  quorumPartitionResult(r.QuorumPartition),
+		SnapshotID: r.SnapshotID,
+	}
+
+	if !p.matchesClusterID(r.ClusterID) {
+		body.ErrorCode = domain.ErrorInconsistentClusterID
+	} else if !p.servesQuorumPartition(r.QuorumPartition) {
+		body.Partition.ErrorCode = domain.ErrorUnknownTopicOrPartition
+	} else {
+		chunk := p.quorum.HandleFetchSnapshot(domain.QuorumSnapshotFetch{
+			ReplicaID:          r.ReplicaID,
+			CurrentLeaderEpoch: r.CurrentLeaderEpoch,
+			SnapshotID:         r.SnapshotID,
+			Position:           r.Position,
+			MaxBytes:           r.MaxBytes,
+		})
+		body.Partition.ErrorCode = chunk.ErrorCode
+		body.Partition.LeaderID = chunk.Leader.LeaderID
+		body.Partition.LeaderEpoch = chunk.Leader.Epoch
+		body.Size = chunk.Size
+		body.Position = chunk.Position
+		body.UnalignedRecords = chunk.Bytes
+	}
+
+	return &response.MessageResponse{
+		CorrelationID: h.CorrelationID,
+		HeaderVersion: 1,
+		Body:          body,
+	}
+}
+
+func (p *RequestProcessor) processDescribeQuorum(
+	h request.RequestHeader,
+	r *request.DescribeQuorumRequest,
+) *response.MessageResponse {
+
+	body := &response.DescribeQuorumResponseBody{
+		Version:       h.ApiVersion,
+		Partition:     quorumPartitionResult(r.QuorumPartition),
+		HighWatermark: -1,
+		CurrentVoters: []domain.QuorumReplicaState{},
+		Observers:     []domain.QuorumReplicaState{},
+	}
+
+	if !p.servesQuorumPartition(r.QuorumPartition) {
+		body.Partition.ErrorCode = domain.ErrorUnknownTopicOrPartition
+	} else {
+		desc := p.quorum.DescribeQuorum()
+		body.Partition.ErrorCode = desc.ErrorCode
+		body.Partition.LeaderID = desc.Leader.LeaderID
+		body.Partition.LeaderEpoch = desc.Leader.Epoch
+		if desc.ErrorCode == 0 {
+			body.HighWatermark = desc.HighWatermark
+			body.CurrentVoters = append(body.CurrentVoters, desc.Voters...)
+			body.Observers = append(body.Observers, desc.Observers...)
+		}
+	}
+
+	return &response.MessageResponse{
+		CorrelationID: h.CorrelationID,
+		HeaderVersion: 1,
+		Body:          body,
+	}
+}
+
+func (p *RequestProcessor) fetchMetadataPartitions(
+	r *request.FetchRequest,
+	t request.FetchTopic,
+) response.FetchTopicResponse {
+
+	out := response.FetchTopicResponse{
+		TopicID:    t.TopicID,
+		Partitions: make([]response.FetchPartitionResponse, 0, len(t.Partitions)),
+	}
+
+	for _, part := range t.Partitions {
+		partitionResp := response.FetchPartitionResponse{
+			PartitionIndex:   part.Partition,
+			ErrorCode:        domain.ErrorUnknownTopicOrPartition,
+			HighWatermark:    -1,
+			LastStableOffset: -1,
+			LogStartOffset:   -1,
+		}
+
+		if p.quorum != nil && part.Partition == domain.MetadataPartition {
+			result := p.quorum.HandleFetch(domain.QuorumFetch{
+				ReplicaID:          r.ReplicaID,
+				CurrentLeaderEpoch: part.CurrentLeaderEpoch,
+				FetchOffset:        part.FetchOffset,
+				LastFetchedEpoch:   part.LastFetchedEpoch,
+				MaxBytes:           min(r.MaxBytes, part.PartitionMaxBytes),
+			})
+			leader := result.Leader
+
+			partitionResp.ErrorCode = result.ErrorCode
+			partitionResp.HighWatermark = result.HighWatermark
+			partitionResp.LastStableOffset = result.HighWatermark
+			partitionResp.LogStartOffset = result.LogStartOffset
+			partitionResp.Records = result.Records
+			partitionResp.DivergingEpoch = result.DivergingEpoch
+			partitionResp.CurrentLeader = &leader
+			partitionResp.SnapshotID = result.SnapshotID
+		}
+
+		out.Partitions = append(out.Partitions, partitionResp)
+	}
+
+	return out
+}
+
+func (p *RequestProcessor) matchesClusterID(clusterID *string) bool {
+	return clusterID == nil || *clusterID == p.local.ClusterID
+}
+
+func (p *RequestProcessor) servesQuorumPartition(part request.QuorumPartition) bool {
+	return p.quorum != nil &&
+		part.TopicName == domain.MetadataTopicName &&
+		part.PartitionIndex == domain.MetadataPartition
+}
+
+func quorumPartitionResult(part request.QuorumPartition) response.QuorumPartitionResult {
+	return response.QuorumPartitionResult{
+		TopicName:      part.TopicName,
+		PartitionIndex: part.PartitionIndex,
+		LeaderID:       -1,
+		LeaderEpoch:    -1,
+	}
+}
+
+func setQuorumEpochResult(out *response.QuorumPartitionResult, result domain.QuorumEpochResult) {
+	out.ErrorCode = result.ErrorCode
+	out.LeaderID = result.Leader.LeaderID
+	out.LeaderEpoch = result.Leader.Epoch
+}
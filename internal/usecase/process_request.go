@@ -1,7 +1,12 @@
 package usecase
 
 import (
+	"errors"
+	"math"
+	"slices"
 	"sort"
+	"strconv"
+	"time"
 
 	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
 	"github.com/codecrafters-io/kafka-starter-go/internal/domain/request"
@@ -10,17 +15,48 @@ import (
 )
 
 type RequestProcessor struct {
-	metadataRepo ports.MetadataRepository
-	logManager   ports.LogManager
+	metadataRepo   ports.MetadataRepository
+	logManager     ports.LogManager
+	batchCodec     ports.RecordBatchCodec
+	metadataWriter ports.MetadataWriter
+	quorum         ports.MetadataQuorum
+	replicas       ports.ReplicaManager
+	elector        ports.LeaderElector
+	reassigner     ports.PartitionReassigner
+	creator        ports.TopicCreator
+	configs        ports.ConfigAlterer
+	local          domain.LocalBroker
+	clock          func() int64
 }
 
-func NewRequestProcessor(
-	metadataRepo ports.MetadataRepository,
-	logManager ports.LogManager,
-) *RequestProcessor {
+type Dependencies struct {
+	MetadataRepo   ports.MetadataRepository
+	LogManager     ports.LogManager
+	BatchCodec     ports.RecordBatchCodec
+	MetadataWriter ports.MetadataWriter
+	Quorum         ports.MetadataQuorum
+	Replicas       ports.ReplicaManager
+	Elector        ports.LeaderElector
+	Reassigner     ports.PartitionReassigner
+	Creator        ports.TopicCreator
+	Configs        ports.ConfigAlterer
+	Local          domain.LocalBroker
+}
+
+func NewRequestProcessor(deps Dependencies) *RequestProcessor {
 	return &RequestProcessor{
-		metadataRepo: metadataRepo,
-		logManager:   logManager,
+		metadataRepo:   deps.MetadataRepo,
+		logManager:     deps.LogManager,
+		batchCodec:     deps.BatchCodec,
+		metadataWriter: deps.MetadataWriter,
+		quorum:         deps.Quorum,
+		replicas:       deps.Replicas,
+		elector:        deps.Elector,
+		reassigner:     deps.Reassigner,
+		creator:        deps.Creator,
+		configs:        deps.Configs,
+		local:          deps.Local,
+		clock:          func() int64 { return time.Now().UnixMilli() },
 	}
 }
 
@@ -41,6 +77,60 @@ func (p *RequestProcessor) Process(
 	case *request.ProduceRequest:
 		return p.processProduce(req.Header, body), nil
 
+	case *request.DescribeLogDirsRequest:
+		return p.processDescribeLogDirs(req.Header, body), nil
+
+	case *request.AlterReplicaLogDirsRequest:
+		return p.processAlterReplicaLogDirs(req.Header, body), nil
+
+	case *request.DescribeClusterRequest:
+		return p.processDescribeCluster(req.Header, body), nil
+
+	case *request.UpdateFeaturesRequest:
+		return p.processUpdateFeatures(req.Header, body), nil
+
+	case *request.VoteRequest:
+		return p.processVote(req.Header, body), nil
+
+	case *request.BeginQuorumEpochRequest:
+		return p.processBeginQuorumEpoch(req.Header, body), nil
+
+	case *request.EndQuorumEpochRequest:
+		return p.processEndQuorumEpoch(req.Header, body), nil
+
+	case *request.FetchSnapshotRequest:
+		return p.processFetchSnapshot(req.Header, body), nil
+
+	case *request.DescribeQuorumRequest:
+		return p.processDescribeQuorum(req.Header, body), nil
+
+	case *request.AlterPartitionRequest:
+		return p.processAlterPartition(req.Header, body), nil
+
+	case *request.ElectLeadersRequest:
+		return p.processElectLeaders(req.Header, body), nil
+
+	case *request.OffsetForLeaderEpochRequest:
+		return p.processOffsetForLeaderEpoch(req.Header, body), nil
+
+	case *request.AlterPartitionReassignmentsRequest:
+		return p.processAlterPartitionReassignments(req.Header, body), nil
+
+	case *request.ListPartitionReassignmentsRequest:
+		return p.processListPartitionReassignments(req.Header, body), nil
+
+	case *request.CreateTopicsRequest:
+		return p.processCreateTopics(req.Header, body), nil
+
+	case *request.DescribeConfigsRequest:
+		return p.processDescribeConfigs(req.Header, body), nil
+
+	case *request.AlterConfigsRequest:
+		return p.processAlterConfigs(req.Header, body), nil
+
+	case *request.IncrementalAlterConfigsRequest:
+		return p.processIncrementalAlterConfigs(req.Header, body), nil
+
 	default:
 		return p.processApiVersions(req.Header), nil
 	}
@@ -55,15 +145,65 @@ func (p *RequestProcessor) processApiVersions(
 		errorCode = domain.ErrorNotSupportedApiVersion
 	}
 
+	features := p.metadataRepo.FinalizedFeatures()
+
+	apiKeys := []response.ApiKeyResponse{
+		response.GetApiVersions(),
+		response.GetDescribeTopicPartitionsApikey(),
+		response.GetFetchApiKey(),
+		response.GetProduceApiKey(),
+		response.GetDescribeLogDirsApiKey(),
+	}
+	if features.MetadataVersion().IsDirectoryAssignmentSupported() {
+		apiKeys = append(apiKeys, response.GetAlterReplicaLogDirsApiKey())
+	}
+	apiKeys = append(apiKeys,
+		response.GetDescribeClusterApiKey(),
+		response.GetUpdateFeaturesApiKey(),
+		response.GetVoteApiKey(),
+		response.GetBeginQuorumEpochApiKey(),
+		response.GetEndQuorumEpochApiKey(),
+		response.GetFetchSnapshotApiKey(),
+		response.GetDescribeQuorumApiKey(),
+		response.GetAlterPartitionApiKey(),
+		response.GetElectLeadersApiKey(),
+		response.GetOffsetForLeaderEpochApiKey(),
+		response.GetAlterPartitionReassignmentsApiKey(),
+		response.GetListPartitionReassignmentsApiKey(),
+		response.GetCreateTopicsApiKey(),
+		response.GetDescribeConfigsApiKey(),
+		response.GetAlterConfigsApiKey(),
+		response.GetIncrementalAlterConfigsApiKey(),
+	)
+
 	body := &response.ApiVersionsResponseBody{
-		ErrorCode: errorCode,
-		ApiKeys: []response.ApiKeyResponse{
-			response.GetApiVersions(),
-			response.GetDescribeTopicPartitionsApikey(),
-			response.GetFetchApiKey(),
-			response.GetProduceApiKey(),
-		},
-		ThrottleTime: 0,
+		Version:                h.ApiVersion,
+		ErrorCode:              errorCode,
+		ApiKeys:                apiKeys,
+		ThrottleTime:           0,
+		FinalizedFeaturesEpoch: features.Epoch,
+	}
+
+	for _, f := range domain.SupportedFeatures() {
+		body.SupportedFeatures = append(body.SupportedFeatures, response.SupportedFeatureKey{
+			Name:       f.Name,
+			MinVersion: f.MinVersion,
+			MaxVersion: f.MaxVersion,
+		})
+	}
+
+	names := make([]string, 0, len(features.Levels))
+	for name := range features.Levels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		level := features.Levels[name]
+		body.FinalizedFeatures = append(body.FinalizedFeatures, response.FinalizedFeatureKey{
+			Name:            name,
+			MaxVersionLevel: level,
+			MinVersionLevel: level,
+		})
 	}
 
 	return &response.MessageResponse{
@@ -78,6 +218,7 @@ func (p *RequestProcessor) processDescribeTopicPartitions(
 ) *response.MessageResponse {
 
 	topics := make([]response.TopicDescription, 0, len(r.Topics))
+	fenced := p.fencedBrokers()
 
 	for _, t := range r.Topics {
 		meta, err := p.metadataRepo.GetTopic(t.Name)
@@ -111,9 +252,9 @@ func (p *RequestProcessor) processDescribeTopicPartitions(
 					LeaderEpoch:     pm.LeaderEpoch,
 					Replicas:        pm.Replicas,
 					ISR:             pm.ISR,
-					EligibleLeaders: []int32{},
-					LastKnownELR:    []int32{},
-					OfflineReplicas: []int32{},
+					EligibleLeaders: append([]int32{}, pm.EligibleLeaderReplicas...),
+					LastKnownELR:    append([]int32{}, pm.LastKnownELR...),
+					OfflineReplicas: offlineReplicas(pm, fenced),
 				},
 			)
 		}
@@ -138,6 +279,26 @@ func (p *RequestProcessor) processDescribeTopicPartitions(
 	}
 }
 
+func (p *RequestProcessor) fencedBrokers() map[int32]bool {
+	fenced := map[int32]bool{}
+	for _, b := range p.metadataRepo.Brokers() {
+		if b.Fenced {
+			fenced[b.ID] = true
+		}
+	}
+	return fenced
+}
+
+func offlineReplicas(pm domain.PartitionMetadata, fenced map[int32]bool) []int32 {
+	offline := make([]int32, 0)
+	for _, id := range pm.Replicas {
+		if fenced[id] {
+			offline = append(offline, id)
+		}
+	}
+	return offline
+}
+
 func (p *RequestProcessor) processFetch(
 	h request.RequestHeader,
 	r *request.FetchRequest,
@@ -145,35 +306,38 @@ func (p *RequestProcessor) processFetch(
 
 	responses := make([]response.FetchTopicResponse, 0, len(r.Topics))
 
-	for _, t := range r.Topics {
-		meta, _ := p.metadataRepo.GetTopicByID(t.TopicID)
+	if !p.matchesClusterID(r.ClusterID) {
+		return &response.MessageResponse{
+			CorrelationID: h.CorrelationID,
+			HeaderVersion: 1,
+			Body: &response.FetchResponseBody{
+				ErrorCode: domain.ErrorInconsistentClusterID,
+				Responses: responses,
+			},
+		}
+	}
 
-		partitionResp := response.FetchPartitionResponse{
-			PartitionIndex:   0,
-			ErrorCode:        domain.ErrorUnknownTopicId,
-			HighWatermark:    0,
-			LastStableOffset: 0,
-			LogStartOffset:   0,
-			Records:          nil,
+	for _, t := range r.Topics {
+		if t.TopicID == domain.MetadataTopicID {
+			responses = append(responses, p.fetchMetadataPartitions(r, t))
+			continue
 		}
 
-		if meta != nil {
-			raw, err := p.logManager.LoadLog(meta.Name, 0)
-			if err != nil {
-				raw = nil
-			}
+		partitions := t.Partitions
+		if len(partitions) == 0 {
+			partitions = []request.FetchPartition{{CurrentLeaderEpoch: -1}}
+		}
 
-			partitionResp.ErrorCode = 0
-			partitionResp.Records = raw
+		meta, _ := p.metadataRepo.GetTopicByID(t.TopicID)
+		topicResp := response.FetchTopicResponse{
+			TopicID:    t.TopicID,
+			Partitions: make([]response.FetchPartitionResponse, 0, len(partitions)),
+		}
+		for _, part := range partitions {
+			topicResp.Partitions = append(topicResp.Partitions, p.fetchPartition(r, meta, part))
 		}
 
-		responses = append(
-			responses,
-			response.FetchTopicResponse{
-				TopicID:    t.TopicID,
-				Partitions: []response.FetchPartitionResponse{partitionResp},
-			},
-		)
+		responses = append(responses, topicResp)
 	}
 
 	body := &response.FetchResponseBody{
@@ -190,12 +354,136 @@ func (p *RequestProcessor) processFetch(
 	}
 }
 
+func (p *RequestProcessor) fetchPartition(
+	r *request.FetchRequest,
+	meta *domain.TopicMetadata,
+	part request.FetchPartition,
+) response.FetchPartitionResponse {
+
+	resp := response.FetchPartitionResponse{
+		PartitionIndex:   part.Partition,
+		ErrorCode:        domain.ErrorUnknownTopicId,
+		HighWatermark:    -1,
+		LastStableOffset: -1,
+		LogStartOffset:   -1,
+	}
+	if meta == nil {
+		return resp
+	}
+
+	pm, ok := findPartition(meta, part.Partition)
+	if !ok {
+		resp.ErrorCode = domain.ErrorUnknownTopicOrPartition
+		return resp
+	}
+	leader := pm.LeaderID == p.local.NodeID
+	if !leader && (r.ReplicaID >= 0 || !slices.Contains(pm.Replicas, p.local.NodeID)) {
+		resp.ErrorCode = domain.ErrorNotLeaderOrFollower
+		resp.CurrentLeader = &domain.LeaderAndEpoch{LeaderID: pm.LeaderID, Epoch: pm.LeaderEpoch}
+		return resp
+	}
+	if code := checkLeaderEpoch(part.CurrentLeaderEpoch, pm.LeaderEpoch); code != 0 {
+		resp.ErrorCode = code
+		resp.CurrentLeader = &domain.LeaderAndEpoch{LeaderID: pm.LeaderID, Epoch: pm.LeaderEpoch}
+		return resp
+	}
+
+	if diverging := p.divergingEpoch(meta.Name, part); diverging != nil {
+		resp.ErrorCode = 0
+		resp.HighWatermark = p.replicas.HighWatermark(meta.Name, part.Partition)
+		resp.LastStableOffset = resp.HighWatermark
+		resp.LogStartOffset = 0
+		resp.DivergingEpoch = diverging
+		return resp
+	}
+
+	limit := int64(math.MaxInt64)
+	if r.ReplicaID >= 0 {
+		p.replicas.RecordFollowerFetch(meta.Name, part.Partition, r.ReplicaID, part.FetchOffset)
+	}
+	hw := p.replicas.HighWatermark(meta.Name, part.Partition)
+	if r.ReplicaID < 0 {
+		limit = hw
+	}
+
+	resp.ErrorCode = 0
+	resp.HighWatermark = hw
+	resp.LastStableOffset = hw
+	resp.LogStartOffset = 0
+
+	if r.ReplicaID >= 0 && p.replicas.ThrottleLeaderFetch(meta.Name, part.Partition, r.ReplicaID) {
+		return resp
+	}
+	if r.ReplicaID < 0 && leader {
+		client := domain.ClientMetadata{RackID: r.RackID}
+		if preferred := p.replicas.PreferredReadReplica(meta.Name, part.Partition, part.FetchOffset, client); preferred >= 0 {
+			resp.PreferredReadReplica = &preferred
+			return resp
+		}
+	}
+
+	maxBytes := part.PartitionMaxBytes
+	if r.MaxBytes > 0 && (maxBytes <= 0 || r.MaxBytes < maxBytes) {
+		maxBytes = r.MaxBytes
+	}
+
+	region, err := p.logManager.OpenLogRegion(meta.Name, part.Partition, part.FetchOffset, limit, maxBytes)
+	switch {
+	case err == nil:
+		resp.RecordsRegion = region
+		if r.ReplicaID >= 0 {
+			p.replicas.RecordLeaderFetchBytes(meta.Name, part.Partition, r.ReplicaID, region.Length)
+		}
+	case errors.Is(err, domain.ErrOffsetOutOfRange):
+		resp.ErrorCode = domain.ErrorOffsetOutOfRange
+	case errors.Is(err, domain.ErrLogDirOffline):
+		resp.ErrorCode = domain.ErrorKafkaStorage
+	default:
+		resp.ErrorCode = domain.ErrorUnknownServerError
+	}
+	return resp
+}
+
+func (p *RequestProcessor) divergingEpoch(topic string, part request.FetchPartition) *domain.EpochEndOffset {
+	if part.LastFetchedEpoch < 0 {
+		return nil
+	}
+
+	end, err := p.logManager.EndOffsetForEpoch(topic, part.Partition, part.LastFetchedEpoch)
+	if err != nil {
+		return nil
+	}
+	if end.Epoch < part.LastFetchedEpoch || end.EndOffset < part.FetchOffset {
+		return &end
+	}
+	return nil
+}
+
+func checkLeaderEpoch(requested int32, current int32) int16 {
+	switch {
+	case requested < 0 || requested == current:
+		return 0
+	case requested < current:
+		return domain.ErrorFencedLeaderEpoch
+	default:
+		return domain.ErrorUnknownLeaderEpoch
+	}
+}
+
+type pendingAck struct {
+	topic     string
+	index     int32
+	endOffset int64
+	minISR    int
+}
+
 func (p *RequestProcessor) processProduce(
 	h request.RequestHeader,
 	r *request.ProduceRequest,
 ) *response.MessageResponse {
 
 	topics := make([]response.ProduceTopicResponse, 0, len(r.Topics))
+	pending := make([]pendingAck, 0)
 
 	for _, t := range r.Topics {
 		topicResp := response.ProduceTopicResponse{
@@ -215,13 +503,18 @@ func (p *RequestProcessor) processProduce(
 				LogStartOffset:  -1,
 			}
 
-			if topicExists && partitionExists(meta, part.Index) {
-				p.logManager.AppendLog(t.Name, part.Index, part.Records)
-
-				partitionResp.ErrorCode = 0
-				partitionResp.BaseOffset = 0
-				partitionResp.LogAppendTimeMs = -1
-				partitionResp.LogStartOffset = 0
+			if topicExists {
+				if pm, ok := findPartition(meta, part.Index); ok {
+					info, ack := p.appendPartition(meta, pm, part, r.Acks, &partitionResp)
+					if ack && r.Acks == domain.AcksAll {
+						pending = append(pending, pendingAck{
+							topic:     t.Name,
+							index:     part.Index,
+							endOffset: info.LastOffset + 1,
+							minISR:    meta.MinInsyncReplicas(),
+						})
+					}
+				}
 			}
 
 			topicResp.Partitions = append(topicResp.Partitions, partitionResp)
@@ -230,6 +523,8 @@ func (p *RequestProcessor) processProduce(
 		topics = append(topics, topicResp)
 	}
 
+	p.awaitReplication(topics, pending, time.Duration(r.TimeoutMs)*time.Millisecond)
+
 	body := &response.ProduceResponseBody{
 		ThrottleTimeMs: 0,
 		Topics:         topics,
@@ -242,11 +537,190 @@ func (p *RequestProcessor) processProduce(
 	}
 }
 
+func (p *RequestProcessor) appendPartition(
+	meta *domain.TopicMetadata,
+	pm domain.PartitionMetadata,
+	part request.ProducePartition,
+	acks int16,
+	resp *response.ProducePartitionResponse,
+) (domain.LogAppendInfo, bool) {
+
+	if pm.LeaderID != p.local.NodeID {
+		resp.ErrorCode = domain.ErrorNotLeaderOrFollower
+		return domain.LogAppendInfo{}, false
+	}
+	if acks == domain.AcksAll && len(pm.ISR) < meta.MinInsyncReplicas() {
+		resp.ErrorCode = domain.ErrorNotEnoughReplicas
+		return domain.LogAppendInfo{}, false
+	}
+
+	records, logAppendTime, errorCode := p.prepareRecords(meta, part.Records)
+	if errorCode != 0 {
+		resp.ErrorCode = errorCode
+		return domain.LogAppendInfo{}, false
+	}
+
+	info, err := p.logManager.AppendLog(meta.Name, part.Index, records, pm.LeaderEpoch)
+	if err != nil {
+		resp.ErrorCode = domain.ErrorKafkaStorage
+		if errors.Is(err, domain.ErrCorruptRecords) {
+			resp.ErrorCode = domain.ErrorCorruptMessage
+		}
+		return info, false
+	}
+	p.replicas.RecordAppend(meta.Name, part.Index, info.LastOffset+1)
+
+	resp.ErrorCode = 0
+	resp.BaseOffset = info.BaseOffset
+	resp.LogAppendTimeMs = logAppendTime
+	resp.LogStartOffset = 0
+	return info, true
+}
+
+func (p *RequestProcessor) awaitReplication(
+	topics []response.ProduceTopicResponse,
+	pending []pendingAck,
+	timeout time.Duration,
+) {
+
+	deadline := time.Now().Add(timeout)
+	for _, ack := range pending {
+		err := p.replicas.WaitForHighWatermark(ack.topic, ack.index, ack.endOffset, ack.minISR, time.Until(deadline))
+		if err == nil {
+			continue
+		}
+
+		code := int16(domain.ErrorRequestTimedOut)
+		switch {
+		case errors.Is(err, domain.ErrNotLeaderOrFollower):
+			code = domain.ErrorNotLeaderOrFollower
+		case errors.Is(err, domain.ErrNotEnoughReplicas):
+			code = domain.ErrorNotEnoughReplicasAfterAppend
+		}
+		setProduceError(topics, ack.topic, ack.index, code)
+	}
+}
+
+func setProduceError(topics []response.ProduceTopicResponse, topic string, index int32, code int16) {
+	for i := range topics {
+		if topics[i].Name != topic {
+			continue
+		}
+		for j := range topics[i].Partitions {
+			if topics[i].Partitions[j].Index == index {
+				topics[i].Partitions[j] = response.ProducePartitionResponse{
+					Index:           index,
+					ErrorCode:       code,
+					BaseOffset:      -1,
+					LogAppendTimeMs: -1,
+					LogStartOffset:  -1,
+				}
+			}
+		}
+	}
+}
+
+func (p *RequestProcessor) prepareRecords(
+	meta *domain.TopicMetadata,
+	data []byte,
+) ([]byte, int64, int16) {
+
+	batches, err := p.batchCodec.Decode(data)
+	if err != nil {
+		return nil, -1, domain.ErrorCorruptMessage
+	}
+
+	now := p.clock()
+	logAppendTime := int64(-1)
+	changed := false
+
+	if target, ok := topicCompression(meta); ok {
+		for i := range batches {
+			if batches[i].Compression() != target {
+				batches[i].SetCompression(target)
+				changed = true
+			}
+		}
+	}
+
+	if tsType, _ := meta.Config(domain.TopicConfigMessageTimestampType); tsType == domain.TimestampTypeNameLogAppendTime {
+		for i := range batches {
+			batches[i].SetTimestampType(domain.TimestampTypeLogAppendTime)
+			batches[i].BaseTimestamp = now
+			batches[i].MaxTimestamp = now
+		}
+		logAppendTime = now
+		changed = true
+	} else if !timestampsWithinBounds(meta, batches, now) {
+		return nil, -1, domain.ErrorInvalidTimestamp
+	}
+
+	if !changed {
+		return data, logAppendTime, 0
+	}
+
+	out, err := p.batchCodec.Encode(batches)
+	if err != nil {
+		return nil, -1, domain.ErrorCorruptMessage
+	}
+	return out, logAppendTime, 0
+}
+
+func timestampsWithinBounds(
+	meta *domain.TopicMetadata,
+	batches []domain.RecordBatch,
+	now int64,
+) bool {
+
+	beforeMax := topicConfigInt64(meta, domain.TopicConfigTimestampBeforeMaxMs, math.MaxInt64)
+	afterMax := topicConfigInt64(meta, domain.TopicConfigTimestampAfterMaxMs, math.MaxInt64)
+
+	for _, b := range batches {
+		for _, rec := range b.Records {
+			ts := b.BaseTimestamp + rec.TimestampDelta
+			if ts < now && now-ts > beforeMax {
+				return false
+			}
+			if ts > now && ts-now > afterMax {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func topicConfigInt64(meta *domain.TopicMetadata, key string, def int64) int64 {
+	v, ok := meta.Config(key)
+	if !ok {
+		return def
+	}
+
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+func topicCompression(meta *domain.TopicMetadata) (domain.CompressionType, bool) {
+	name, ok := meta.Config(domain.TopicConfigCompressionType)
+	if !ok || name == domain.TopicCompressionProducer {
+		return domain.CompressionNone, false
+	}
+	return domain.ParseCompressionType(name)
+}
+
 func partitionExists(meta *domain.TopicMetadata, index int32) bool {
+	_, ok := findPartition(meta, index)
+	return ok
+}
+
+func findPartition(meta *domain.TopicMetadata, index int32) (domain.PartitionMetadata, bool) {
 	for _, p := range meta.Partitions {
 		if p.PartitionIndex == index {
-			return true
+			return p, true
 		}
 	}
-	return false
+	return domain.PartitionMetadata{}, false
 }
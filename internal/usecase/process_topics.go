@@ -0,0 +1,98 @@
+package usecase
+
+import (
+	"errors"
+
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/request"
+	"github.com/codecrafters-io/kafka-starter-go/internal/domain/response"
+)
+
+func (p *RequestProcessor) processCreateTopics(
+	h request.RequestHeader,
+	r *request.CreateTopicsRequest,
+) *response.MessageResponse {
+
+	body := &response.CreateTopicsResponseBody{Version: h.ApiVersion}
+	resp := &response.MessageResponse{
+		CorrelationID: h.CorrelationID,
+		Body:          body,
+	}
+	if h.ApiVersion >= 5 {
+		resp.HeaderVersion = 1
+	}
+
+	topics := make([]domain.TopicCreation, 0, len(r.Topics))
+	for _, t := range r.Topics {
+		creation := domain.TopicCreation{
+			Name:              t.Name,
+			NumPartitions:     t.NumPartitions,
+			ReplicationFactor: t.ReplicationFactor,
+		}
+		if len(t.Assignments) > 0 {
+			creation.Assignments = make(map[int32][]int32, len(t.Assignments))
+			for _, a := range t.Assignments {
+				creation.Assignments[a.PartitionIndex] = a.BrokerIDs
+			}
+		}
+		for _, c := range t.Configs {
+			creation.Configs = append(creation.Configs, domain.ConfigEntry{Name: c.Name, Value: c.Value})
+		}
+		topics = append(topics, creation)
+	}
+
+	results, err := p.creator.CreateTopics(topics, r.ValidateOnly)
+	if err != nil {
+		code := int16(domain.ErrorUnknownServerError)
+		if errors.Is(err, domain.ErrNotController) {
+			code = domain.ErrorNotController
+		}
+		msg := err.Error()
+		for _, t := range r.Topics {
+			body.Topics = append(body.Topics, response.CreatableTopicResult{
+				Name:              t.Name,
+				ErrorCode:         code,
+				ErrorMessage:      &msg,
+				NumPartitions:     -1,
+				ReplicationFactor: -1,
+			})
+		}
+		return resp
+	}
+
+	for _, result := range results {
+		topic := response.CreatableTopicResult{
+			Name:              result.Name,
+			TopicID:           result.TopicID,
+			ErrorCode:         result.ErrorCode,
+			ErrorMessage:      result.ErrorMessage,
+			NumPartitions:     result.NumPartitions,
+			ReplicationFactor: result.ReplicationFactor,
+		}
+		if result.ErrorCode == 0 {
+			topic.Configs = p.createdTopicConfigs(result.Configs)
+		}
+		body.Topics = append(body.Topics, topic)
+	}
+	return resp
+}
+
+func (p *RequestProcessor) createdTopicConfigs(configs map[string]string) []response.CreatableTopicConfigs {
+	layers := domain.ConfigLayers{
+		Topic:          configs,
+		ClusterDefault: p.metadataRepo.BrokerConfigs(""),
+		Static:         p.local.Configs,
+	}
+
+	out := make([]response.CreatableTopicConfigs, 0, len(domain.TopicConfigDefinitions()))
+	for _, def := range domain.TopicConfigDefinitions() {
+		d := domain.DescribeTopicConfig(def, layers)
+		out = append(out, response.CreatableTopicConfigs{
+			Name:         d.Name,
+			Value:        d.Value,
+			ReadOnly:     d.ReadOnly,
+			ConfigSource: d.Source,
+		})
+	}
+	return out
+}
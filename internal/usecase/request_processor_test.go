@@ -2,16 +2,27 @@ package usecase
 
 import (
 	"errors"
+	"math"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/codecrafters-io/kafka-starter-go/internal/domain"
 	"github.com/codecrafters-io/kafka-starter-go/internal/domain/request"
 	"github.com/codecrafters-io/kafka-starter-go/internal/domain/response"
+	"github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/metadata/parser"
+	"github.com/codecrafters-io/kafka-starter-go/internal/infrastructure/repository"
+	"github.com/codecrafters-io/kafka-starter-go/internal/ports"
 )
 
 type fakeMetadataRepo struct {
 	topicsByName map[string]*domain.TopicMetadata
 	topicsByID   map[[16]byte]*domain.TopicMetadata
+	brokers      []domain.BrokerRegistration
+	controllers  []domain.ControllerRegistration
+	controllerID int32
+	features     domain.FinalizedFeatures
+	configs      map[string]map[string]string
 }
 
 func (f *fakeMetadataRepo) GetTopic(name string) (*domain.TopicMetadata, error) {
@@ -30,8 +41,196 @@ func (f *fakeMetadataRepo) GetTopicByID(id [16]byte) (*domain.TopicMetadata, err
 	return t, nil
 }
 
+func (f *fakeMetadataRepo) Topics() []*domain.TopicMetadata {
+	out := make([]*domain.TopicMetadata, 0, len(f.topicsByID))
+	for _, t := range f.topicsByID {
+		out = append(out, t)
+	}
+	return out
+}
+
+func (f *fakeMetadataRepo) Brokers() []domain.BrokerRegistration {
+	return f.brokers
+}
+
+func (f *fakeMetadataRepo) Controllers() []domain.ControllerRegistration {
+	return f.controllers
+}
+
+func (f *fakeMetadataRepo) ControllerID() int32 {
+	return f.controllerID
+}
+
+func (f *fakeMetadataRepo) FinalizedFeatures() domain.FinalizedFeatures {
+	return f.features
+}
+
+func (f *fakeMetadataRepo) BrokerConfigs(name string) map[string]string {
+	return f.configs[name]
+}
+
+func (f *fakeMetadataRepo) Version() domain.MetadataVersion {
+	return domain.MetadataVersion{}
+}
+
+func (f *fakeMetadataRepo) Subscribe(listener func(domain.MetadataChange)) func() {
+	return func() {}
+}
+
+type fakeMetadataWriter struct {
+	features   []map[string]int16
+	partitions []domain.PartitionChange
+	repo       *fakeMetadataRepo
+	err        error
+}
+
+func (f *fakeMetadataWriter) UpdateFeatures(levels map[string]int16) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.features = append(f.features, levels)
+	return nil
+}
+
+func (f *fakeMetadataWriter) AlterPartitions(changes []domain.PartitionChange) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.partitions = append(f.partitions, changes...)
+	if f.repo == nil {
+		return nil
+	}
+	for _, c := range changes {
+		t := f.repo.topicsByID[c.TopicID]
+		for i := range t.Partitions {
+			if t.Partitions[i].PartitionIndex == c.Partition {
+				t.Partitions[i].ISR = c.ISR
+				t.Partitions[i].PartitionEpoch++
+			}
+		}
+	}
+	return nil
+}
+
+func (f *fakeMetadataWriter) CreateTopics(topics []*domain.TopicMetadata) error {
+	return f.err
+}
+
+func (f *fakeMetadataWriter) AlterConfigs(changes []domain.ConfigChange) error {
+	return f.err
+}
+
+func (f *fakeMetadataWriter) FenceBrokers(fencing []domain.BrokerFencing, changes []domain.PartitionChange) error {
+	return f.AlterPartitions(changes)
+}
+
+type fakeElector struct {
+	electionType domain.ElectionType
+	targets      []domain.ElectionTarget
+	results      []domain.ElectionResult
+	err          error
+}
+
+func (f *fakeElector) ElectLeaders(
+	electionType domain.ElectionType,
+	targets []domain.ElectionTarget,
+) ([]domain.ElectionResult, error) {
+
+	f.electionType = electionType
+	f.targets = targets
+	return f.results, f.err
+}
+
+type fakeReassigner struct {
+	targets []domain.ReassignmentTarget
+	results []domain.ReassignmentResult
+	err     error
+}
+
+func (f *fakeReassigner) AlterPartitionReassignments(
+	targets []domain.ReassignmentTarget,
+) ([]domain.ReassignmentResult, error) {
+
+	f.targets = targets
+	return f.results, f.err
+}
+
+type fakeCreator struct {
+	topics       []domain.TopicCreation
+	validateOnly bool
+	results      []domain.TopicCreationResult
+	err          error
+}
+
+func (f *fakeCreator) CreateTopics(
+	topics []domain.TopicCreation,
+	validateOnly bool,
+) ([]domain.TopicCreationResult, error) {
+
+	f.topics = topics
+	f.validateOnly = validateOnly
+	return f.results, f.err
+}
+
+type fakeConfigs struct {
+	alterations  []domain.ConfigAlteration
+	validateOnly bool
+	results      []domain.ConfigAlterationResult
+	err          error
+}
+
+func (f *fakeConfigs) AlterConfigs(
+	alterations []domain.ConfigAlteration,
+	validateOnly bool,
+) ([]domain.ConfigAlterationResult, error) {
+
+	f.alterations = alterations
+	f.validateOnly = validateOnly
+	return f.results, f.err
+}
+
+type fakeQuorum struct {
+	votes       []domain.QuorumVote
+	fetches     []domain.QuorumFetch
+	fetch       domain.QuorumFetchResult
+	description domain.QuorumDescription
+}
+
+func (f *fakeQuorum) HandleVote(vote domain.QuorumVote) domain.QuorumVoteResult {
+	f.votes = append(f.votes, vote)
+	return domain.QuorumVoteResult{Leader: domain.LeaderAndEpoch{LeaderID: -1, Epoch: vote.CandidateEpoch}, Granted: true}
+}
+
+func (f *fakeQuorum) HandleBeginQuorumEpoch(leader domain.LeaderAndEpoch) domain.QuorumEpochResult {
+	return domain.QuorumEpochResult{Leader: leader}
+}
+
+func (f *fakeQuorum) HandleEndQuorumEpoch(leader domain.LeaderAndEpoch, successors []int32) domain.QuorumEpochResult {
+	return domain.QuorumEpochResult{Leader: domain.LeaderAndEpoch{LeaderID: -1, Epoch: leader.Epoch}}
+}
+
+func (f *fakeQuorum) HandleFetch(fetch domain.QuorumFetch) domain.QuorumFetchResult {
+	f.fetches = append(f.fetches, fetch)
+	return f.fetch
+}
+
+func (f *fakeQuorum) HandleFetchSnapshot(fetch domain.QuorumSnapshotFetch) domain.QuorumSnapshotChunk {
+	return domain.QuorumSnapshotChunk{SnapshotID: fetch.SnapshotID, Size: 3, Position: fetch.Position, Bytes: []byte("abc")}
+}
+
+func (f *fakeQuorum) DescribeQuorum() domain.QuorumDescription {
+	return f.description
+}
+
 type fakeLogManager struct {
-	logs map[string][]byte
+	logs      map[string][]byte
+	endOffset int64
+	appendErr error
+	regionErr error
+	dirs      []domain.LogDirDescription
+	moved     []string
+	moveErr   error
+	epochs    map[int32]domain.EpochEndOffset
 }
 
 func (f *fakeLogManager) LoadLog(topic string, partition int32) ([]byte, error) {
@@ -41,13 +240,211 @@ func (f *fakeLogManager) LoadLog(topic string, partition int32) ([]byte, error)
 	return nil, errors.New("not found")
 }
 
-func (f *fakeLogManager) AppendLog(topic string, partition int32, data []byte) error {
+func (f *fakeLogManager) OpenLogRegion(
+	topic string,
+	partition int32,
+	fromOffset int64,
+	maxOffset int64,
+	maxBytes int32,
+) (*domain.FileRegion, error) {
+	if f.regionErr != nil {
+		return nil, f.regionErr
+	}
+	if v, ok := f.logs[topic]; ok {
+		return &domain.FileRegion{Length: int64(len(v))}, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func (f *fakeLogManager) DescribeLogDirs() []domain.LogDirDescription {
+	return f.dirs
+}
+
+func (f *fakeLogManager) MoveReplica(topic string, partition int32, path string) error {
+	f.moved = append(f.moved, path)
+	return f.moveErr
+}
+
+func (f *fakeLogManager) Assign(topic string, partition int32, dirID [16]byte) {}
+
+func (f *fakeLogManager) AppendLog(topic string, partition int32, data []byte, leaderEpoch int32) (domain.LogAppendInfo, error) {
+	if f.appendErr != nil {
+		return domain.LogAppendInfo{}, f.appendErr
+	}
+	base := f.endOffset
 	f.logs[topic] = data
+	f.endOffset++
+	return domain.LogAppendInfo{BaseOffset: base, LastOffset: base}, nil
+}
+
+func (f *fakeLogManager) AppendReplicaLog(topic string, partition int32, data []byte) (domain.LogAppendInfo, error) {
+	return f.AppendLog(topic, partition, data, 0)
+}
+
+func (f *fakeLogManager) LogEndOffset(topic string, partition int32) (int64, error) {
+	return f.endOffset, nil
+}
+
+func (f *fakeLogManager) EndOffsetForEpoch(topic string, partition int32, epoch int32) (domain.EpochEndOffset, error) {
+	if end, ok := f.epochs[epoch]; ok {
+		return end, nil
+	}
+	return domain.EpochEndOffset{Epoch: epoch, EndOffset: math.MaxInt64}, nil
+}
+
+func (f *fakeLogManager) LatestEpoch(topic string, partition int32) (int32, error) {
+	return -1, nil
+}
+
+func (f *fakeLogManager) DeleteLog(topic string, partition int32) error {
+	delete(f.logs, topic)
 	return nil
 }
 
+func (f *fakeLogManager) TruncateLog(topic string, partition int32, offset int64) error {
+	f.endOffset = min(f.endOffset, offset)
+	return nil
+}
+
+type fakeReplicas struct {
+	highWatermark int64
+	followers     map[int32]int64
+	waitErr       error
+	throttled     bool
+	throttleBytes int64
+	preferred     map[string]int32
+}
+
+func (f *fakeReplicas) HighWatermark(topic string, partition int32) int64 {
+	return f.highWatermark
+}
+
+func (f *fakeReplicas) RecordAppend(topic string, partition int32, endOffset int64) {}
+
+func (f *fakeReplicas) RecordFollowerFetch(topic string, partition int32, replicaID int32, fetchOffset int64) {
+	if f.followers == nil {
+		f.followers = map[int32]int64{}
+	}
+	f.followers[replicaID] = fetchOffset
+}
+
+func (f *fakeReplicas) ThrottleLeaderFetch(topic string, partition int32, replicaID int32) bool {
+	return f.throttled
+}
+
+func (f *fakeReplicas) RecordLeaderFetchBytes(topic string, partition int32, replicaID int32, bytes int64) {
+	f.throttleBytes += bytes
+}
+
+func (f *fakeReplicas) PreferredReadReplica(topic string, partition int32, fetchOffset int64, client domain.ClientMetadata) int32 {
+	if id, ok := f.preferred[client.RackID]; ok {
+		return id
+	}
+	return -1
+}
+
+func (f *fakeReplicas) WaitForHighWatermark(topic string, partition int32, offset int64, minISR int, timeout time.Duration) error {
+	return f.waitErr
+}
+
+type fakeBatchCodec struct {
+	batches []domain.RecordBatch
+	encoded []domain.RecordBatch
+	err     error
+}
+
+func (f *fakeBatchCodec) Decode(data []byte) ([]domain.RecordBatch, error) {
+	return f.batches, f.err
+}
+
+func (f *fakeBatchCodec) Encode(batches []domain.RecordBatch) ([]byte, error) {
+	f.encoded = batches
+	return []byte("encoded"), nil
+}
+
+type testDeps struct {
+	repo       ports.MetadataRepository
+	logs       ports.LogManager
+	codec      ports.RecordBatchCodec
+	writer     ports.MetadataWriter
+	quorum     ports.MetadataQuorum
+	replicas   ports.ReplicaManager
+	elector    ports.LeaderElector
+	reassigner ports.PartitionReassigner
+	creator    ports.TopicCreator
+	configs    ports.ConfigAlterer
+	local      domain.LocalBroker
+}
+
+func newTestProcessor(d testDeps) *RequestProcessor {
+	if d.repo == nil {
+		d.repo = &fakeMetadataRepo{}
+	}
+	if d.logs == nil {
+		d.logs = &fakeLogManager{}
+	}
+	if d.codec == nil {
+		d.codec = &fakeBatchCodec{}
+	}
+	if d.writer == nil {
+		d.writer = &fakeMetadataWriter{}
+	}
+	if d.quorum == nil {
+		d.quorum = &fakeQuorum{}
+	}
+	if d.replicas == nil {
+		d.replicas = &fakeReplicas{}
+	}
+	if d.elector == nil {
+		d.elector = &fakeElector{}
+	}
+	if d.reassigner == nil {
+		d.reassigner = &fakeReassigner{}
+	}
+	if d.creator == nil {
+		d.creator = &fakeCreator{}
+	}
+	if d.configs == nil {
+		d.configs = &fakeConfigs{}
+	}
+	return NewRequestProcessor(Dependencies{
+		MetadataRepo:   d.repo,
+		LogManager:     d.logs,
+		BatchCodec:     d.codec,
+		MetadataWriter: d.writer,
+		Quorum:         d.quorum,
+		Replicas:       d.replicas,
+		Elector:        d.elector,
+		Reassigner:     d.reassigner,
+		Creator:        d.creator,
+		Configs:        d.configs,
+		Local:          d.local,
+	})
+}
+
+func replayedTopicRepo(configs map[string]string, isr ...int32) ports.MetadataRepository {
+	id := [16]byte{1}
+	if len(isr) == 0 {
+		isr = []int32{0}
+	}
+
+	delta := repository.NewMetadataDelta(repository.EmptyMetadataImage())
+	delta.Replay(parser.Record{Value: parser.RecordTopic{TopicName: "test", TopicUUID: id}})
+	delta.Replay(parser.Record{Value: parser.RecordPartition{TopicUUID: id, ReplicaArray: isr, SyncReplicaArray: isr}})
+	for _, name := range domain.SortedConfigNames(configs) {
+		value := configs[name]
+		delta.Replay(parser.Record{Value: parser.RecordConfig{
+			ResourceType: parser.ConfigResourceTopic,
+			ResourceName: "test",
+			Name:         name,
+			Value:        &value,
+		}})
+	}
+	return repository.NewKraftMetadataRepository(delta.Apply())
+}
+
 func TestProcess_ApiVersions(t *testing.T) {
-	p := NewRequestProcessor(&fakeMetadataRepo{}, &fakeLogManager{})
+	p := newTestProcessor(testDeps{})
 
 	req := &request.MessageRequest{
 		Header: request.RequestHeader{
@@ -74,7 +471,7 @@ func TestProcess_ApiVersions(t *testing.T) {
 }
 
 func TestProcess_DescribeTopicPartitions_UnknownTopic(t *testing.T) {
-	p := NewRequestProcessor(&fakeMetadataRepo{}, &fakeLogManager{})
+	p := newTestProcessor(testDeps{})
 
 	req := &request.MessageRequest{
 		Header: request.RequestHeader{CorrelationID: 2},
@@ -116,7 +513,7 @@ func TestProcess_DescribeTopicPartitions_ExistingTopic(t *testing.T) {
 		topicsByName: map[string]*domain.TopicMetadata{"test": meta},
 	}
 
-	p := NewRequestProcessor(repo, &fakeLogManager{})
+	p := newTestProcessor(testDeps{repo: repo})
 
 	req := &request.MessageRequest{
 		Header: request.RequestHeader{CorrelationID: 3},
@@ -136,8 +533,37 @@ func TestProcess_DescribeTopicPartitions_ExistingTopic(t *testing.T) {
 	}
 }
 
+func TestProcess_DescribeTopicPartitions_EligibleAndOfflineReplicas(t *testing.T) {
+	meta := &domain.TopicMetadata{
+		Name: "test",
+		Partitions: []domain.PartitionMetadata{{
+			LeaderID:               domain.NoLeader,
+			Replicas:               []int32{1, 2, 3},
+			EligibleLeaderReplicas: []int32{1, 2},
+			LastKnownELR:           []int32{1, 2},
+		}},
+	}
+	repo := &fakeMetadataRepo{
+		topicsByName: map[string]*domain.TopicMetadata{"test": meta},
+		brokers:      []domain.BrokerRegistration{{ID: 1, Fenced: true}, {ID: 2, Fenced: true}, {ID: 3}},
+	}
+	p := newTestProcessor(testDeps{repo: repo})
+
+	resp, _ := p.Process(&request.MessageRequest{
+		Body: &request.DescribeTopicPartitionsRequest{Topics: []request.TopicRequest{{Name: "test"}}},
+	})
+	part := resp.Body.(*response.DescribeTopicPartitionsResponseBody).Topics[0].Partitions[0]
+
+	if !slices.Equal(part.EligibleLeaders, []int32{1, 2}) || !slices.Equal(part.LastKnownELR, []int32{1, 2}) {
+		t.Fatalf("unexpected ELR %+v", part)
+	}
+	if !slices.Equal(part.OfflineReplicas, []int32{1, 2}) {
+		t.Fatalf("expected fenced brokers offline, got %v", part.OfflineReplicas)
+	}
+}
+
 func TestProcess_Fetch_UnknownTopicID(t *testing.T) {
-	p := NewRequestProcessor(&fakeMetadataRepo{}, &fakeLogManager{})
+	p := newTestProcessor(testDeps{})
 
 	var id [16]byte
 	id[0] = 9
@@ -165,8 +591,9 @@ func TestProcess_Fetch_WithLog(t *testing.T) {
 	id[0] = 7
 
 	meta := &domain.TopicMetadata{
-		Name:    "test",
-		TopicID: id,
+		Name:       "test",
+		TopicID:    id,
+		Partitions: []domain.PartitionMetadata{{PartitionIndex: 0}},
 	}
 
 	repo := &fakeMetadataRepo{
@@ -177,7 +604,7 @@ func TestProcess_Fetch_WithLog(t *testing.T) {
 		logs: map[string][]byte{"test": {0x01, 0x02}},
 	}
 
-	p := NewRequestProcessor(repo, logs)
+	p := newTestProcessor(testDeps{repo: repo, logs: logs})
 
 	req := &request.MessageRequest{
 		Header: request.RequestHeader{CorrelationID: 5},
@@ -192,7 +619,8 @@ func TestProcess_Fetch_WithLog(t *testing.T) {
 	}
 
 	body := resp.Body.(*response.FetchResponseBody)
-	if len(body.Responses[0].Partitions[0].Records) == 0 {
+	region := body.Responses[0].Partitions[0].RecordsRegion
+	if region == nil || region.Length == 0 {
 		t.Fatal("expected records")
 	}
 }
@@ -211,7 +639,7 @@ func TestProcess_Produce(t *testing.T) {
 
 	logs := &fakeLogManager{logs: map[string][]byte{}}
 
-	p := NewRequestProcessor(repo, logs)
+	p := newTestProcessor(testDeps{repo: repo, logs: logs})
 
 	req := &request.MessageRequest{
 		Header: request.RequestHeader{CorrelationID: 6},
@@ -237,3 +665,1446 @@ func TestProcess_Produce(t *testing.T) {
 		t.Fatal("expected success")
 	}
 }
+
+func TestProcess_Produce_CorruptBatch(t *testing.T) {
+	meta := &domain.TopicMetadata{
+		Name:       "test",
+		Partitions: []domain.PartitionMetadata{{PartitionIndex: 0}},
+	}
+
+	repo := &fakeMetadataRepo{
+		topicsByName: map[string]*domain.TopicMetadata{"test": meta},
+	}
+
+	logs := &fakeLogManager{logs: map[string][]byte{}}
+	codec := &fakeBatchCodec{err: errors.New("crc mismatch")}
+
+	p := newTestProcessor(testDeps{repo: repo, logs: logs, codec: codec})
+
+	req := &request.MessageRequest{
+		Body: &request.ProduceRequest{
+			Topics: []request.ProduceTopic{
+				{
+					Name:       "test",
+					Partitions: []request.ProducePartition{{Index: 0, Records: []byte{0x01}}},
+				},
+			},
+		},
+	}
+
+	resp, _ := p.Process(req)
+
+	body := resp.Body.(*response.ProduceResponseBody)
+	if body.Topics[0].Partitions[0].ErrorCode != domain.ErrorCorruptMessage {
+		t.Fatal("expected corrupt message error")
+	}
+	if _, ok := logs.logs["test"]; ok {
+		t.Fatal("corrupt batch must not be appended")
+	}
+}
+
+func TestProcess_Produce_TopicRecompression(t *testing.T) {
+	meta := &domain.TopicMetadata{
+		Name:       "test",
+		Partitions: []domain.PartitionMetadata{{PartitionIndex: 0}},
+		Configs:    map[string]string{domain.TopicConfigCompressionType: "zstd"},
+	}
+
+	repo := &fakeMetadataRepo{
+		topicsByName: map[string]*domain.TopicMetadata{"test": meta},
+	}
+
+	logs := &fakeLogManager{logs: map[string][]byte{}}
+	batch := domain.RecordBatch{}
+	batch.SetCompression(domain.CompressionGzip)
+	codec := &fakeBatchCodec{batches: []domain.RecordBatch{batch}}
+
+	p := newTestProcessor(testDeps{repo: repo, logs: logs, codec: codec})
+
+	req := &request.MessageRequest{
+		Body: &request.ProduceRequest{
+			Topics: []request.ProduceTopic{
+				{
+					Name:       "test",
+					Partitions: []request.ProducePartition{{Index: 0, Records: []byte{0x01}}},
+				},
+			},
+		},
+	}
+
+	p.Process(req)
+
+	if len(codec.encoded) != 1 || codec.encoded[0].Compression() != domain.CompressionZstd {
+		t.Fatal("expected batch recompressed with zstd")
+	}
+	if string(logs.logs["test"]) != "encoded" {
+		t.Fatal("expected recompressed records appended")
+	}
+}
+
+func TestProcess_Produce_RecompressionFromConfigRecord(t *testing.T) {
+	logs := &fakeLogManager{logs: map[string][]byte{}}
+	batch := domain.RecordBatch{}
+	batch.SetCompression(domain.CompressionGzip)
+	codec := &fakeBatchCodec{batches: []domain.RecordBatch{batch}}
+
+	repo := replayedTopicRepo(map[string]string{domain.TopicConfigCompressionType: "lz4"})
+	p := newTestProcessor(testDeps{repo: repo, logs: logs, codec: codec})
+
+	resp, _ := p.Process(singleProduceRequest("test"))
+
+	if part := resp.Body.(*response.ProduceResponseBody).Topics[0].Partitions[0]; part.ErrorCode != 0 {
+		t.Fatalf("unexpected partition response %+v", part)
+	}
+	if len(codec.encoded) != 1 || codec.encoded[0].Compression() != domain.CompressionLz4 {
+		t.Fatalf("expected the replayed compression.type to recompress the batch, got %+v", codec.encoded)
+	}
+}
+
+func singleProduceRequest(topic string) *request.MessageRequest {
+	return &request.MessageRequest{
+		Body: &request.ProduceRequest{
+			Topics: []request.ProduceTopic{
+				{
+					Name:       topic,
+					Partitions: []request.ProducePartition{{Index: 0, Records: []byte{0x01}}},
+				},
+			},
+		},
+	}
+}
+
+func TestProcess_Produce_LogAppendTime(t *testing.T) {
+	meta := &domain.TopicMetadata{
+		Name:       "test",
+		Partitions: []domain.PartitionMetadata{{PartitionIndex: 0}},
+		Configs: map[string]string{
+			domain.TopicConfigMessageTimestampType: domain.TimestampTypeNameLogAppendTime,
+		},
+	}
+
+	repo := &fakeMetadataRepo{
+		topicsByName: map[string]*domain.TopicMetadata{"test": meta},
+	}
+
+	codec := &fakeBatchCodec{
+		batches: []domain.RecordBatch{{BaseTimestamp: 10, MaxTimestamp: 20}},
+	}
+
+	p := newTestProcessor(testDeps{repo: repo, logs: &fakeLogManager{logs: map[string][]byte{}}, codec: codec})
+	p.clock = func() int64 { return 5000 }
+
+	resp, _ := p.Process(singleProduceRequest("test"))
+
+	body := resp.Body.(*response.ProduceResponseBody)
+	if body.Topics[0].Partitions[0].LogAppendTimeMs != 5000 {
+		t.Fatal("expected log append time in response")
+	}
+
+	rb := codec.encoded[0]
+	if rb.BaseTimestamp != 5000 || rb.MaxTimestamp != 5000 {
+		t.Fatal("expected batch timestamps overwritten")
+	}
+	if rb.TimestampType() != domain.TimestampTypeLogAppendTime {
+		t.Fatal("expected log append time attribute")
+	}
+}
+
+func TestProcess_Produce_CreateTimeOutOfBounds(t *testing.T) {
+	meta := &domain.TopicMetadata{
+		Name:       "test",
+		Partitions: []domain.PartitionMetadata{{PartitionIndex: 0}},
+		Configs: map[string]string{
+			domain.TopicConfigTimestampBeforeMaxMs: "1000",
+			domain.TopicConfigTimestampAfterMaxMs:  "1000",
+		},
+	}
+
+	repo := &fakeMetadataRepo{
+		topicsByName: map[string]*domain.TopicMetadata{"test": meta},
+	}
+
+	cases := []int64{10000 - 1001, 10000 + 1001}
+
+	for _, ts := range cases {
+		codec := &fakeBatchCodec{
+			batches: []domain.RecordBatch{{BaseTimestamp: ts, Records: []domain.Record{{}}}},
+		}
+
+		p := newTestProcessor(testDeps{repo: repo, logs: &fakeLogManager{logs: map[string][]byte{}}, codec: codec})
+		p.clock = func() int64 { return 10000 }
+
+		resp, _ := p.Process(singleProduceRequest("test"))
+
+		body := resp.Body.(*response.ProduceResponseBody)
+		if body.Topics[0].Partitions[0].ErrorCode != domain.ErrorInvalidTimestamp {
+			t.Fatalf("timestamp %d: expected invalid timestamp error", ts)
+		}
+	}
+}
+
+func TestProcess_Produce_TimestampsFromConfigRecords(t *testing.T) {
+	codec := &fakeBatchCodec{batches: []domain.RecordBatch{{BaseTimestamp: 10, MaxTimestamp: 20}}}
+	repo := replayedTopicRepo(map[string]string{domain.TopicConfigMessageTimestampType: domain.TimestampTypeNameLogAppendTime})
+	p := newTestProcessor(testDeps{repo: repo, logs: &fakeLogManager{logs: map[string][]byte{}}, codec: codec})
+	p.clock = func() int64 { return 5000 }
+
+	resp, _ := p.Process(singleProduceRequest("test"))
+
+	if part := resp.Body.(*response.ProduceResponseBody).Topics[0].Partitions[0]; part.ErrorCode != 0 || part.LogAppendTimeMs != 5000 {
+		t.Fatalf("expected log append time from the config record, got %+v", part)
+	}
+	if rb := codec.encoded[0]; rb.TimestampType() != domain.TimestampTypeLogAppendTime || rb.MaxTimestamp != 5000 {
+		t.Fatalf("expected batch stamped with log append time, got %+v", rb)
+	}
+
+	repo = replayedTopicRepo(map[string]string{
+		domain.TopicConfigTimestampBeforeMaxMs: "1000",
+		domain.TopicConfigTimestampAfterMaxMs:  "1000",
+	})
+	for ts, code := range map[int64]int16{10000 - 1001: domain.ErrorInvalidTimestamp, 10000 + 1001: domain.ErrorInvalidTimestamp, 10000 - 999: 0} {
+		codec := &fakeBatchCodec{batches: []domain.RecordBatch{{BaseTimestamp: ts, Records: []domain.Record{{}}}}}
+		p := newTestProcessor(testDeps{repo: repo, logs: &fakeLogManager{logs: map[string][]byte{}}, codec: codec})
+		p.clock = func() int64 { return 10000 }
+
+		resp, _ := p.Process(singleProduceRequest("test"))
+
+		if got := resp.Body.(*response.ProduceResponseBody).Topics[0].Partitions[0].ErrorCode; got != code {
+			t.Fatalf("timestamp %d: expected error %d, got %d", ts, code, got)
+		}
+	}
+}
+
+func TestProcess_Produce_OfflineLogDir(t *testing.T) {
+	meta := &domain.TopicMetadata{
+		Name:       "test",
+		Partitions: []domain.PartitionMetadata{{PartitionIndex: 0}},
+	}
+
+	repo := &fakeMetadataRepo{
+		topicsByName: map[string]*domain.TopicMetadata{"test": meta},
+	}
+
+	logs := &fakeLogManager{logs: map[string][]byte{}, appendErr: domain.ErrLogDirOffline}
+
+	p := newTestProcessor(testDeps{repo: repo, logs: logs})
+
+	resp, _ := p.Process(singleProduceRequest("test"))
+
+	body := resp.Body.(*response.ProduceResponseBody)
+	if body.Topics[0].Partitions[0].ErrorCode != domain.ErrorKafkaStorage {
+		t.Fatal("expected kafka storage error")
+	}
+}
+
+func TestProcess_Produce_NotLeader(t *testing.T) {
+	meta := &domain.TopicMetadata{
+		Name:       "test",
+		Partitions: []domain.PartitionMetadata{{PartitionIndex: 0, LeaderID: 2}},
+	}
+
+	repo := &fakeMetadataRepo{
+		topicsByName: map[string]*domain.TopicMetadata{"test": meta},
+	}
+
+	logs := &fakeLogManager{logs: map[string][]byte{}}
+
+	p := newTestProcessor(testDeps{repo: repo, logs: logs})
+
+	resp, _ := p.Process(singleProduceRequest("test"))
+
+	body := resp.Body.(*response.ProduceResponseBody)
+	if body.Topics[0].Partitions[0].ErrorCode != domain.ErrorNotLeaderOrFollower {
+		t.Fatal("expected not leader or follower error")
+	}
+	if len(logs.logs) != 0 {
+		t.Fatal("expected nothing appended on a follower")
+	}
+}
+
+func TestProcess_Produce_AcksAllWaitsForReplication(t *testing.T) {
+	meta := &domain.TopicMetadata{
+		Name:       "test",
+		Partitions: []domain.PartitionMetadata{{PartitionIndex: 0, ISR: []int32{0}}},
+	}
+
+	repo := &fakeMetadataRepo{
+		topicsByName: map[string]*domain.TopicMetadata{"test": meta},
+	}
+
+	for _, c := range []struct {
+		waitErr error
+		code    int16
+	}{
+		{nil, 0},
+		{domain.ErrReplicationTimedOut, domain.ErrorRequestTimedOut},
+		{domain.ErrNotLeaderOrFollower, domain.ErrorNotLeaderOrFollower},
+		{domain.ErrNotEnoughReplicas, domain.ErrorNotEnoughReplicasAfterAppend},
+	} {
+		logs := &fakeLogManager{logs: map[string][]byte{}, endOffset: 7}
+		replicas := &fakeReplicas{waitErr: c.waitErr}
+		p := newTestProcessor(testDeps{repo: repo, logs: logs, replicas: replicas})
+
+		req := singleProduceRequest("test")
+		req.Body.(*request.ProduceRequest).Acks = domain.AcksAll
+		resp, _ := p.Process(req)
+
+		part := resp.Body.(*response.ProduceResponseBody).Topics[0].Partitions[0]
+		if part.ErrorCode != c.code {
+			t.Fatalf("wait error %v: expected code %d, got %d", c.waitErr, c.code, part.ErrorCode)
+		}
+		offset := int64(7)
+		if c.code != 0 {
+			offset = -1
+		}
+		if part.BaseOffset != offset || (c.code != 0 && (part.LogAppendTimeMs != -1 || part.LogStartOffset != -1)) {
+			t.Fatalf("wait error %v: unexpected partition response %+v", c.waitErr, part)
+		}
+	}
+}
+
+func TestProcess_Produce_NotEnoughReplicas(t *testing.T) {
+	meta := &domain.TopicMetadata{
+		Name:       "test",
+		Partitions: []domain.PartitionMetadata{{PartitionIndex: 0, Replicas: []int32{0, 1}, ISR: []int32{0}}},
+		Configs:    map[string]string{domain.TopicConfigMinInsyncReplicas: "2"},
+	}
+
+	repo := &fakeMetadataRepo{
+		topicsByName: map[string]*domain.TopicMetadata{"test": meta},
+	}
+	logs := &fakeLogManager{logs: map[string][]byte{}}
+	p := newTestProcessor(testDeps{repo: repo, logs: logs})
+
+	req := singleProduceRequest("test")
+	req.Body.(*request.ProduceRequest).Acks = domain.AcksAll
+	resp, _ := p.Process(req)
+
+	part := resp.Body.(*response.ProduceResponseBody).Topics[0].Partitions[0]
+	if part.ErrorCode != domain.ErrorNotEnoughReplicas {
+		t.Fatalf("expected NOT_ENOUGH_REPLICAS, got %d", part.ErrorCode)
+	}
+	if _, ok := logs.logs["test"]; ok {
+		t.Fatal("records appended despite too few in-sync replicas")
+	}
+
+	req = singleProduceRequest("test")
+	req.Body.(*request.ProduceRequest).Acks = 1
+	resp, _ = p.Process(req)
+	if code := resp.Body.(*response.ProduceResponseBody).Topics[0].Partitions[0].ErrorCode; code != 0 {
+		t.Fatalf("expected acks=1 produce to succeed, got %d", code)
+	}
+}
+
+func TestProcess_Produce_MinInsyncReplicasFromConfigRecord(t *testing.T) {
+	repo := replayedTopicRepo(map[string]string{domain.TopicConfigMinInsyncReplicas: "2"}, 0)
+	logs := &fakeLogManager{logs: map[string][]byte{}}
+	p := newTestProcessor(testDeps{repo: repo, logs: logs})
+
+	req := singleProduceRequest("test")
+	req.Body.(*request.ProduceRequest).Acks = domain.AcksAll
+	resp, _ := p.Process(req)
+
+	if code := resp.Body.(*response.ProduceResponseBody).Topics[0].Partitions[0].ErrorCode; code != domain.ErrorNotEnoughReplicas {
+		t.Fatalf("expected NOT_ENOUGH_REPLICAS from the replayed min.insync.replicas, got %d", code)
+	}
+	if _, ok := logs.logs["test"]; ok {
+		t.Fatal("records appended despite too few in-sync replicas")
+	}
+
+	repo = replayedTopicRepo(map[string]string{domain.TopicConfigMinInsyncReplicas: "2"}, 0, 1)
+	p = newTestProcessor(testDeps{repo: repo, logs: logs})
+	resp, _ = p.Process(req)
+	if code := resp.Body.(*response.ProduceResponseBody).Topics[0].Partitions[0].ErrorCode; code != 0 {
+		t.Fatalf("expected produce to succeed with two in-sync replicas, got %d", code)
+	}
+}
+
+func TestProcess_Fetch_UnexpectedLogError(t *testing.T) {
+	var id [16]byte
+	id[0] = 7
+
+	repo := &fakeMetadataRepo{
+		topicsByID: map[[16]byte]*domain.TopicMetadata{id: {
+			Name:       "test",
+			TopicID:    id,
+			Partitions: []domain.PartitionMetadata{{PartitionIndex: 0}},
+		}},
+	}
+	logs := &fakeLogManager{regionErr: errors.New("corrupt batch header")}
+
+	p := newTestProcessor(testDeps{repo: repo, logs: logs})
+
+	resp, _ := p.Process(&request.MessageRequest{
+		Body: &request.FetchRequest{Topics: []request.FetchTopic{{TopicID: id}}},
+	})
+
+	part := resp.Body.(*response.FetchResponseBody).Responses[0].Partitions[0]
+	if part.ErrorCode != domain.ErrorUnknownServerError || part.RecordsRegion != nil {
+		t.Fatalf("expected an unknown server error without records, got %+v", part)
+	}
+}
+
+func TestProcess_Fetch_FollowerReportsOffset(t *testing.T) {
+	id := [16]byte{7}
+	meta := &domain.TopicMetadata{
+		Name:       "test",
+		TopicID:    id,
+		Partitions: []domain.PartitionMetadata{{PartitionIndex: 0, LeaderEpoch: 3}},
+	}
+
+	repo := &fakeMetadataRepo{
+		topicsByID: map[[16]byte]*domain.TopicMetadata{id: meta},
+	}
+	logs := &fakeLogManager{logs: map[string][]byte{"test": {0x01}}}
+	replicas := &fakeReplicas{highWatermark: 4}
+
+	p := newTestProcessor(testDeps{repo: repo, logs: logs, replicas: replicas})
+
+	req := &request.MessageRequest{
+		Body: &request.FetchRequest{
+			ReplicaID: 2,
+			Topics: []request.FetchTopic{{
+				TopicID: id,
+				Partitions: []request.FetchPartition{
+					{Partition: 0, CurrentLeaderEpoch: 3, FetchOffset: 5},
+					{Partition: 0, CurrentLeaderEpoch: 2},
+					{Partition: 1, CurrentLeaderEpoch: 3},
+				},
+			}},
+		},
+	}
+
+	resp, _ := p.Process(req)
+
+	parts := resp.Body.(*response.FetchResponseBody).Responses[0].Partitions
+	if parts[0].ErrorCode != 0 || parts[0].HighWatermark != 4 || replicas.followers[2] != 5 {
+		t.Fatalf("unexpected follower fetch %+v", parts[0])
+	}
+	if parts[1].ErrorCode != domain.ErrorFencedLeaderEpoch {
+		t.Fatal("expected fenced leader epoch")
+	}
+	if parts[2].ErrorCode != domain.ErrorUnknownTopicOrPartition {
+		t.Fatal("expected unknown partition")
+	}
+}
+
+func TestProcess_Fetch_NotLeader(t *testing.T) {
+	id := [16]byte{7}
+	meta := &domain.TopicMetadata{
+		Name:       "test",
+		TopicID:    id,
+		Partitions: []domain.PartitionMetadata{{PartitionIndex: 0, LeaderID: 2, LeaderEpoch: 1}},
+	}
+
+	repo := &fakeMetadataRepo{
+		topicsByID: map[[16]byte]*domain.TopicMetadata{id: meta},
+	}
+
+	p := newTestProcessor(testDeps{repo: repo})
+
+	resp, _ := p.Process(&request.MessageRequest{
+		Body: &request.FetchRequest{
+			ReplicaID: -1,
+			Topics:    []request.FetchTopic{{TopicID: id}},
+		},
+	})
+
+	part := resp.Body.(*response.FetchResponseBody).Responses[0].Partitions[0]
+	if part.ErrorCode != domain.ErrorNotLeaderOrFollower || part.CurrentLeader == nil || part.CurrentLeader.LeaderID != 2 {
+		t.Fatalf("unexpected partition %+v", part)
+	}
+}
+
+func TestProcess_DescribeLogDirs_FiltersTopics(t *testing.T) {
+	logs := &fakeLogManager{
+		dirs: []domain.LogDirDescription{
+			{
+				Path: "/a",
+				Partitions: []domain.LogDirPartition{
+					{Topic: "keep", Partition: 0, Size: 10},
+					{Topic: "keep", Partition: 1, Size: 10},
+					{Topic: "drop", Partition: 0, Size: 10},
+				},
+			},
+			{Path: "/b", Offline: true},
+		},
+	}
+
+	p := newTestProcessor(testDeps{logs: logs})
+
+	resp, _ := p.Process(&request.MessageRequest{
+		Header: request.RequestHeader{ApiVersion: 4},
+		Body: &request.DescribeLogDirsRequest{
+			Topics: []request.TopicPartitions{{Name: "keep", Partitions: []int32{1}}},
+		},
+	})
+
+	body := resp.Body.(*response.DescribeLogDirsResponseBody)
+	if len(body.Results) != 2 {
+		t.Fatal("expected both log dirs")
+	}
+
+	topics := body.Results[0].Topics
+	if len(topics) != 1 || topics[0].Name != "keep" || len(topics[0].Partitions) != 1 {
+		t.Fatal("expected only keep-1")
+	}
+
+	if body.Results[1].ErrorCode != domain.ErrorKafkaStorage {
+		t.Fatal("expected storage error for offline dir")
+	}
+}
+
+func TestProcess_AlterReplicaLogDirs(t *testing.T) {
+	meta := &domain.TopicMetadata{
+		Name:       "test",
+		Partitions: []domain.PartitionMetadata{{PartitionIndex: 0}},
+	}
+
+	repo := &fakeMetadataRepo{
+		topicsByName: map[string]*domain.TopicMetadata{"test": meta},
+	}
+
+	logs := &fakeLogManager{moveErr: domain.ErrLogDirNotFound}
+
+	p := newTestProcessor(testDeps{repo: repo, logs: logs})
+
+	resp, _ := p.Process(&request.MessageRequest{
+		Body: &request.AlterReplicaLogDirsRequest{
+			Dirs: []request.AlterReplicaLogDir{
+				{
+					Path:   "/missing",
+					Topics: []request.TopicPartitions{{Name: "test", Partitions: []int32{0, 5}}},
+				},
+			},
+		},
+	})
+
+	parts := resp.Body.(*response.AlterReplicaLogDirsResponseBody).Results[0].Partitions
+	if parts[0].ErrorCode != domain.ErrorLogDirNotFound {
+		t.Fatal("expected log dir not found")
+	}
+	if parts[1].ErrorCode != domain.ErrorUnknownTopicOrPartition {
+		t.Fatal("expected unknown partition")
+	}
+	if len(logs.moved) != 1 {
+		t.Fatal("expected a single move attempt")
+	}
+}
+
+func TestProcess_DescribeCluster(t *testing.T) {
+	rack := "r1"
+	repo := &fakeMetadataRepo{
+		controllerID: 1,
+		brokers: []domain.BrokerRegistration{
+			{ID: 1, Rack: &rack, Endpoints: []domain.BrokerEndpoint{
+				{Listener: "CONTROLLER", Host: "c1", Port: 9093},
+				{Listener: "PLAINTEXT", Host: "b1", Port: 9092},
+			}},
+			{ID: 2, Fenced: true, Endpoints: []domain.BrokerEndpoint{{Listener: "PLAINTEXT", Host: "b2", Port: 9092}}},
+		},
+		controllers: []domain.ControllerRegistration{
+			{ID: 1, Endpoints: []domain.BrokerEndpoint{{Listener: "CONTROLLER", Host: "c1", Port: 9093}}},
+		},
+	}
+	local := domain.LocalBroker{
+		ClusterIdentity: domain.ClusterIdentity{ClusterID: "cluster", NodeID: 3},
+		Endpoint:        domain.BrokerEndpoint{Listener: "PLAINTEXT", Host: "localhost", Port: 9094},
+	}
+	p := newTestProcessor(testDeps{repo: repo, local: local})
+
+	resp, _ := p.Process(&request.MessageRequest{
+		Header: request.RequestHeader{ApiKey: domain.DescribeClusterApiKey, ApiVersion: 1, CorrelationID: 4},
+		Body:   &request.DescribeClusterRequest{IncludeClusterAuthorizedOperations: true, EndpointType: domain.EndpointTypeBroker},
+	})
+	body := resp.Body.(*response.DescribeClusterResponseBody)
+
+	if resp.HeaderVersion != 1 {
+		t.Fatalf("DescribeCluster is flexible from v0, got header version %d", resp.HeaderVersion)
+	}
+	if body.ClusterID != "cluster" || body.ControllerID != 1 {
+		t.Fatalf("unexpected cluster %+v", body)
+	}
+	if body.ClusterAuthorizedOperations != domain.ClusterAuthorizedOperationsAll {
+		t.Fatal("expected cluster authorized operations")
+	}
+	if len(body.Brokers) != 2 {
+		t.Fatalf("expected unfenced broker plus local broker, got %+v", body.Brokers)
+	}
+	if body.Brokers[0].Host != "b1" || body.Brokers[0].Rack == nil || *body.Brokers[0].Rack != "r1" {
+		t.Fatalf("unexpected broker %+v", body.Brokers[0])
+	}
+	if body.Brokers[1].BrokerID != 3 || body.Brokers[1].Port != 9094 {
+		t.Fatalf("unexpected local broker %+v", body.Brokers[1])
+	}
+
+	resp, _ = p.Process(&request.MessageRequest{
+		Header: request.RequestHeader{ApiKey: domain.DescribeClusterApiKey, ApiVersion: 1},
+		Body:   &request.DescribeClusterRequest{EndpointType: domain.EndpointTypeController},
+	})
+	body = resp.Body.(*response.DescribeClusterResponseBody)
+	if len(body.Brokers) != 1 || body.Brokers[0].Host != "c1" {
+		t.Fatalf("unexpected controllers %+v", body.Brokers)
+	}
+	if body.ClusterAuthorizedOperations != domain.AuthorizedOperationsOmitted {
+		t.Fatal("authorized operations must be omitted when not requested")
+	}
+
+	resp, _ = p.Process(&request.MessageRequest{
+		Header: request.RequestHeader{ApiKey: domain.DescribeClusterApiKey, ApiVersion: 1},
+		Body:   &request.DescribeClusterRequest{EndpointType: 7},
+	})
+	if resp.Body.(*response.DescribeClusterResponseBody).ErrorCode != domain.ErrorUnsupportedEndpointType {
+		t.Fatal("expected unsupported endpoint type")
+	}
+}
+
+func TestProcess_ApiVersions_FinalizedFeatures(t *testing.T) {
+	repo := &fakeMetadataRepo{
+		features: domain.FinalizedFeatures{
+			Epoch: 12,
+			Levels: map[string]int16{
+				domain.FeatureMetadataVersion: 20,
+				domain.FeatureKRaftVersion:    1,
+			},
+		},
+	}
+	p := newTestProcessor(testDeps{repo: repo})
+
+	resp, _ := p.Process(&request.MessageRequest{
+		Header: request.RequestHeader{ApiKey: domain.ApiVersionApikey, ApiVersion: 4},
+		Body:   &request.ApiVersionsRequest{},
+	})
+	body := resp.Body.(*response.ApiVersionsResponseBody)
+
+	if body.FinalizedFeaturesEpoch != 12 || len(body.FinalizedFeatures) != 2 {
+		t.Fatalf("unexpected finalized features %+v", body.FinalizedFeatures)
+	}
+	if body.FinalizedFeatures[0].Name != domain.FeatureKRaftVersion || body.FinalizedFeatures[1].MaxVersionLevel != 20 {
+		t.Fatalf("unexpected finalized features %+v", body.FinalizedFeatures)
+	}
+	if len(body.SupportedFeatures) != len(domain.SupportedFeatures()) {
+		t.Fatal("expected supported features")
+	}
+}
+
+func TestProcess_ApiVersions_GatedOnMetadataVersion(t *testing.T) {
+	repo := &fakeMetadataRepo{
+		features: domain.FinalizedFeatures{
+			Levels: map[string]int16{domain.FeatureMetadataVersion: 14},
+		},
+	}
+	p := newTestProcessor(testDeps{repo: repo})
+
+	resp, _ := p.Process(&request.MessageRequest{
+		Header: request.RequestHeader{ApiKey: domain.ApiVersionApikey, ApiVersion: 4},
+		Body:   &request.ApiVersionsRequest{},
+	})
+	for _, k := range resp.Body.(*response.ApiVersionsResponseBody).ApiKeys {
+		if k.ApiKey == domain.AlterReplicaLogDirsApiKey {
+			t.Fatal("AlterReplicaLogDirs requires directory assignment support")
+		}
+	}
+
+	resp, _ = p.Process(&request.MessageRequest{
+		Header: request.RequestHeader{ApiKey: domain.AlterReplicaLogDirsApiKey, ApiVersion: 2},
+		Body: &request.AlterReplicaLogDirsRequest{Dirs: []request.AlterReplicaLogDir{
+			{Path: "/d", Topics: []request.TopicPartitions{{Name: "t", Partitions: []int32{0}}}},
+		}},
+	})
+	got := resp.Body.(*response.AlterReplicaLogDirsResponseBody).Results[0].Partitions[0].ErrorCode
+	if got != domain.ErrorNotSupportedApiVersion {
+		t.Fatalf("unexpected error code %d", got)
+	}
+}
+
+func updateFeatures(p *RequestProcessor, validateOnly bool, updates ...request.FeatureUpdate) *response.UpdateFeaturesResponseBody {
+	resp, _ := p.Process(&request.MessageRequest{
+		Header: request.RequestHeader{ApiKey: domain.UpdateFeaturesApiKey, ApiVersion: 1},
+		Body:   &request.UpdateFeaturesRequest{Updates: updates, ValidateOnly: validateOnly},
+	})
+	return resp.Body.(*response.UpdateFeaturesResponseBody)
+}
+
+func TestProcess_UpdateFeatures(t *testing.T) {
+	repo := &fakeMetadataRepo{
+		features: domain.FinalizedFeatures{
+			Levels: map[string]int16{domain.FeatureMetadataVersion: 20},
+		},
+	}
+	writer := &fakeMetadataWriter{}
+	p := newTestProcessor(testDeps{repo: repo, writer: writer})
+
+	upgrade := request.FeatureUpdate{Feature: domain.FeatureMetadataVersion, MaxVersionLevel: 21, UpgradeType: domain.FeatureUpgrade}
+
+	body := updateFeatures(p, true, upgrade)
+	if body.Results[0].ErrorCode != 0 || len(writer.features) != 0 {
+		t.Fatal("validate-only must not persist")
+	}
+
+	body = updateFeatures(p, false, upgrade)
+	if body.Results[0].ErrorCode != 0 || len(writer.features) != 1 || writer.features[0][domain.FeatureMetadataVersion] != 21 {
+		t.Fatalf("expected upgrade to be persisted, got %+v", body.Results)
+	}
+
+	cases := []struct {
+		name   string
+		update request.FeatureUpdate
+		code   int16
+	}{
+		{"unknown feature", request.FeatureUpdate{Feature: "x.version", MaxVersionLevel: 1, UpgradeType: domain.FeatureUpgrade}, domain.ErrorInvalidUpdateVersion},
+		{"above supported", request.FeatureUpdate{Feature: domain.FeatureMetadataVersion, MaxVersionLevel: 99, UpgradeType: domain.FeatureUpgrade}, domain.ErrorInvalidUpdateVersion},
+		{"downgrade as upgrade", request.FeatureUpdate{Feature: domain.FeatureMetadataVersion, MaxVersionLevel: 19, UpgradeType: domain.FeatureUpgrade}, domain.ErrorInvalidUpdateVersion},
+		{"lossy safe downgrade", request.FeatureUpdate{Feature: domain.FeatureMetadataVersion, MaxVersionLevel: 14, UpgradeType: domain.FeatureSafeDowngrade}, domain.ErrorInvalidUpdateVersion},
+		{"downgrade to newer", request.FeatureUpdate{Feature: domain.FeatureMetadataVersion, MaxVersionLevel: 21, UpgradeType: domain.FeatureSafeDowngrade}, domain.ErrorInvalidUpdateVersion},
+		{"remove metadata.version", request.FeatureUpdate{Feature: domain.FeatureMetadataVersion, MaxVersionLevel: 0, UpgradeType: domain.FeatureUnsafeDowngrade}, domain.ErrorInvalidUpdateVersion},
+		{"bad upgrade type", request.FeatureUpdate{Feature: domain.FeatureMetadataVersion, MaxVersionLevel: 21, UpgradeType: 9}, domain.ErrorInvalidRequest},
+	}
+	for _, c := range cases {
+		body := updateFeatures(p, false, c.update)
+		if body.Results[0].ErrorCode != c.code {
+			t.Fatalf("%s: got %d want %d", c.name, body.Results[0].ErrorCode, c.code)
+		}
+	}
+
+	body = updateFeatures(p, false,
+		request.FeatureUpdate{Feature: domain.FeatureMetadataVersion, MaxVersionLevel: 14, UpgradeType: domain.FeatureUnsafeDowngrade},
+		request.FeatureUpdate{Feature: "x.version", MaxVersionLevel: 1, UpgradeType: domain.FeatureUpgrade},
+	)
+	if body.Results[0].ErrorCode != domain.ErrorFeatureUpdateFailed || len(writer.features) != 1 {
+		t.Fatal("a failed update must prevent the others from being applied")
+	}
+
+	writer.err = errors.New("disk full")
+	body = updateFeatures(p, false, upgrade)
+	if body.Results[0].ErrorCode != domain.ErrorFeatureUpdateFailed {
+		t.Fatal("expected write failure to be reported")
+	}
+}
+
+func TestProcess_Vote(t *testing.T) {
+	quorum := &fakeQuorum{}
+	local := domain.LocalBroker{ClusterIdentity: domain.ClusterIdentity{ClusterID: "cluster", NodeID: 1}}
+	p := newTestProcessor(testDeps{quorum: quorum, local: local})
+
+	vote := func(clusterID string, partition int32) *response.VoteResponseBody {
+		resp, _ := p.Process(&request.MessageRequest{
+			Header: request.RequestHeader{ApiKey: domain.VoteApiKey},
+			Body: &request.VoteRequest{
+				ClusterID:       &clusterID,
+				QuorumPartition: request.QuorumPartition{TopicName: domain.MetadataTopicName, PartitionIndex: partition},
+				Vote:            domain.QuorumVote{CandidateID: 2, CandidateEpoch: 5},
+			},
+		})
+		return resp.Body.(*response.VoteResponseBody)
+	}
+
+	body := vote("cluster", 0)
+	if body.ErrorCode != 0 || !body.VoteGranted || body.Partition.LeaderEpoch != 5 || len(quorum.votes) != 1 {
+		t.Fatalf("unexpected vote response %+v", body)
+	}
+
+	if body := vote("other", 0); body.ErrorCode != domain.ErrorInconsistentClusterID || body.VoteGranted {
+		t.Fatalf("expected inconsistent cluster id, got %+v", body)
+	}
+	if body := vote("cluster", 1); body.Partition.ErrorCode != domain.ErrorUnknownTopicOrPartition {
+		t.Fatalf("expected unknown partition, got %+v", body)
+	}
+	if len(quorum.votes) != 1 {
+		t.Fatal("rejected requests must not reach the quorum")
+	}
+}
+
+func TestProcess_FetchMetadataPartition(t *testing.T) {
+	quorum := &fakeQuorum{fetch: domain.QuorumFetchResult{
+		Leader:         domain.LeaderAndEpoch{LeaderID: 1, Epoch: 3},
+		HighWatermark:  10,
+		LogStartOffset: 4,
+		Records:        []byte("records"),
+		DivergingEpoch: &domain.EpochEndOffset{Epoch: 2, EndOffset: 8},
+	}}
+	p := newTestProcessor(testDeps{quorum: quorum})
+
+	resp, _ := p.Process(&request.MessageRequest{
+		Header: request.RequestHeader{ApiKey: domain.FetchApikey, ApiVersion: 16},
+		Body: &request.FetchRequest{
+			ReplicaID: 2,
+			MaxBytes:  1024,
+			Topics: []request.FetchTopic{{
+				TopicID: domain.MetadataTopicID,
+				Partitions: []request.FetchPartition{{
+					CurrentLeaderEpoch: 3,
+					FetchOffset:        9,
+					LastFetchedEpoch:   3,
+					PartitionMaxBytes:  512,
+				}},
+			}},
+		},
+	})
+	part := resp.Body.(*response.FetchResponseBody).Responses[0].Partitions[0]
+
+	if part.HighWatermark != 10 || part.LogStartOffset != 4 || string(part.Records) != "records" {
+		t.Fatalf("unexpected partition %+v", part)
+	}
+	if part.CurrentLeader == nil || part.CurrentLeader.LeaderID != 1 || part.DivergingEpoch.EndOffset != 8 {
+		t.Fatalf("expected leader and diverging epoch, got %+v", part)
+	}
+	f := quorum.fetches[0]
+	if f.ReplicaID != 2 || f.FetchOffset != 9 || f.MaxBytes != 512 {
+		t.Fatalf("unexpected quorum fetch %+v", f)
+	}
+}
+
+func TestProcess_DescribeQuorum(t *testing.T) {
+	quorum := &fakeQuorum{description: domain.QuorumDescription{
+		Leader:        domain.LeaderAndEpoch{LeaderID: 1, Epoch: 4},
+		HighWatermark: 42,
+		Voters: []domain.QuorumReplicaState{
+			{ReplicaID: 1, LogEndOffset: 43, LastFetchTimestamp: 100, LastCaughtUpTimestamp: 100},
+			{ReplicaID: 2, LogEndOffset: 40, LastFetchTimestamp: 90, LastCaughtUpTimestamp: 80},
+		},
+		Observers: []domain.QuorumReplicaState{{ReplicaID: 7, LogEndOffset: 12, LastFetchTimestamp: 70, LastCaughtUpTimestamp: -1}},
+	}}
+	p := newTestProcessor(testDeps{quorum: quorum})
+
+	describe := func(topic string) *response.DescribeQuorumResponseBody {
+		resp, _ := p.Process(&request.MessageRequest{
+			Header: request.RequestHeader{ApiKey: domain.DescribeQuorumApiKey, ApiVersion: 1},
+			Body:   &request.DescribeQuorumRequest{QuorumPartition: request.QuorumPartition{TopicName: topic}},
+		})
+		return resp.Body.(*response.DescribeQuorumResponseBody)
+	}
+
+	body := describe(domain.MetadataTopicName)
+	if body.Partition.ErrorCode != 0 || body.Partition.LeaderID != 1 || body.Partition.LeaderEpoch != 4 || body.HighWatermark != 42 {
+		t.Fatalf("unexpected quorum %+v", body)
+	}
+	if len(body.CurrentVoters) != 2 || len(body.Observers) != 1 || body.Observers[0].ReplicaID != 7 {
+		t.Fatalf("unexpected replicas %+v / %+v", body.CurrentVoters, body.Observers)
+	}
+
+	if body := describe("events"); body.Partition.ErrorCode != domain.ErrorUnknownTopicOrPartition {
+		t.Fatalf("expected unknown partition, got %+v", body.Partition)
+	}
+
+	quorum.description = domain.QuorumDescription{
+		ErrorCode: domain.ErrorNotLeaderOrFollower,
+		Leader:    domain.LeaderAndEpoch{LeaderID: 2, Epoch: 5},
+	}
+	body = describe(domain.MetadataTopicName)
+	if body.Partition.ErrorCode != domain.ErrorNotLeaderOrFollower || body.Partition.LeaderID != 2 || len(body.CurrentVoters) != 0 {
+		t.Fatalf("followers must point at the leader, got %+v", body)
+	}
+}
+
+func TestProcess_AlterPartition(t *testing.T) {
+	id := [16]byte{7}
+	meta := &domain.TopicMetadata{
+		Name:    "test",
+		TopicID: id,
+		Partitions: []domain.PartitionMetadata{{
+			PartitionIndex: 0,
+			LeaderID:       1,
+			LeaderEpoch:    2,
+			PartitionEpoch: 4,
+			Replicas:       []int32{1, 2, 3},
+			ISR:            []int32{1, 2, 3},
+		}},
+	}
+	repo := &fakeMetadataRepo{
+		topicsByID: map[[16]byte]*domain.TopicMetadata{id: meta},
+		brokers:    []domain.BrokerRegistration{{ID: 3, Fenced: true}},
+	}
+	writer := &fakeMetadataWriter{repo: repo}
+	quorum := &fakeQuorum{description: domain.QuorumDescription{Leader: domain.LeaderAndEpoch{LeaderID: 1}}}
+	local := domain.LocalBroker{ClusterIdentity: domain.ClusterIdentity{NodeID: 1}}
+	p := newTestProcessor(testDeps{repo: repo, writer: writer, quorum: quorum, local: local})
+
+	alter := func(partition request.AlterPartitionPartition) response.AlterPartitionPartitionResult {
+		resp, _ := p.Process(&request.MessageRequest{
+			Header: request.RequestHeader{ApiKey: domain.AlterPartitionApiKey, ApiVersion: 2},
+			Body: &request.AlterPartitionRequest{
+				BrokerID: 1,
+				Topics: []request.AlterPartitionTopic{{
+					TopicID:    id,
+					Partitions: []request.AlterPartitionPartition{partition},
+				}},
+			},
+		})
+		return resp.Body.(*response.AlterPartitionResponseBody).Topics[0].Partitions[0]
+	}
+
+	got := alter(request.AlterPartitionPartition{LeaderEpoch: 2, PartitionEpoch: 4, NewISR: []int32{1, 2}})
+	if got.ErrorCode != 0 || got.PartitionEpoch != 5 || len(got.ISR) != 2 || got.LeaderID != 1 {
+		t.Fatalf("unexpected shrink result %+v", got)
+	}
+	if len(writer.partitions) != 1 || writer.partitions[0].Leader != domain.NoLeaderChange {
+		t.Fatalf("unexpected partition changes %+v", writer.partitions)
+	}
+
+	for _, c := range []struct {
+		partition request.AlterPartitionPartition
+		code      int16
+	}{
+		{request.AlterPartitionPartition{LeaderEpoch: 1, PartitionEpoch: 5, NewISR: []int32{1}}, domain.ErrorFencedLeaderEpoch},
+		{request.AlterPartitionPartition{LeaderEpoch: 2, PartitionEpoch: 4, NewISR: []int32{1}}, domain.ErrorInvalidUpdateVersion},
+		{request.AlterPartitionPartition{LeaderEpoch: 2, PartitionEpoch: 5, NewISR: []int32{1, 4}}, domain.ErrorIneligibleReplica},
+		{request.AlterPartitionPartition{LeaderEpoch: 2, PartitionEpoch: 5, NewISR: []int32{1, 2, 3}}, domain.ErrorIneligibleReplica},
+		{request.AlterPartitionPartition{LeaderEpoch: 2, PartitionEpoch: 5, NewISR: []int32{2}}, domain.ErrorInvalidRequest},
+		{request.AlterPartitionPartition{PartitionIndex: 9, LeaderEpoch: 2, PartitionEpoch: 5}, domain.ErrorUnknownTopicOrPartition},
+	} {
+		if got := alter(c.partition); got.ErrorCode != c.code {
+			t.Fatalf("request %+v: expected code %d, got %d", c.partition, c.code, got.ErrorCode)
+		}
+	}
+	if len(writer.partitions) != 1 {
+		t.Fatalf("rejected requests wrote partition changes %+v", writer.partitions)
+	}
+
+	quorum.description.Leader.LeaderID = 2
+	resp, _ := p.Process(&request.MessageRequest{Body: &request.AlterPartitionRequest{BrokerID: 1}})
+	if code := resp.Body.(*response.AlterPartitionResponseBody).ErrorCode; code != domain.ErrorNotController {
+		t.Fatalf("expected NOT_CONTROLLER, got %d", code)
+	}
+}
+
+func TestProcess_ElectLeaders(t *testing.T) {
+	elector := &fakeElector{results: []domain.ElectionResult{
+		{Topic: "a", Partition: 0},
+		{Topic: "b", Partition: 1, ErrorCode: domain.ErrorElectionNotNeeded},
+		{Topic: "a", Partition: 2, ErrorCode: domain.ErrorPreferredLeaderNotAvailable},
+	}}
+	p := newTestProcessor(testDeps{elector: elector})
+
+	resp, _ := p.Process(&request.MessageRequest{
+		Header: request.RequestHeader{ApiKey: domain.ElectLeadersApiKey, ApiVersion: 2},
+		Body:   &request.ElectLeadersRequest{ElectionType: 1},
+	})
+	body := resp.Body.(*response.ElectLeadersResponseBody)
+
+	if elector.electionType != domain.ElectionUnclean || elector.targets != nil {
+		t.Fatalf("expected unclean election of all partitions, got %d %+v", elector.electionType, elector.targets)
+	}
+	if resp.HeaderVersion != 1 || len(body.ReplicaElectionResults) != 2 {
+		t.Fatalf("unexpected response %+v", body)
+	}
+	if a := body.ReplicaElectionResults[0]; a.Topic != "a" || len(a.PartitionResult) != 2 || a.PartitionResult[1].ErrorCode != domain.ErrorPreferredLeaderNotAvailable {
+		t.Fatalf("unexpected results for topic a %+v", a)
+	}
+
+	elector.err = domain.ErrNotController
+	req := &request.ElectLeadersRequest{TopicPartitions: []request.ElectLeadersTopic{{Topic: "a", Partitions: []int32{0, 1}}}}
+
+	resp, _ = p.Process(&request.MessageRequest{Header: request.RequestHeader{ApiVersion: 1}, Body: req})
+	if body := resp.Body.(*response.ElectLeadersResponseBody); body.ErrorCode != domain.ErrorNotController || resp.HeaderVersion != 0 {
+		t.Fatalf("expected NOT_CONTROLLER, got %+v", body)
+	}
+
+	resp, _ = p.Process(&request.MessageRequest{Header: request.RequestHeader{ApiVersion: 0}, Body: req})
+	body = resp.Body.(*response.ElectLeadersResponseBody)
+	if len(body.ReplicaElectionResults) != 1 || len(body.ReplicaElectionResults[0].PartitionResult) != 2 ||
+		body.ReplicaElectionResults[0].PartitionResult[1].ErrorCode != domain.ErrorNotController {
+		t.Fatalf("expected per-partition NOT_CONTROLLER for v0, got %+v", body)
+	}
+}
+
+func TestProcess_OffsetForLeaderEpoch(t *testing.T) {
+	meta := &domain.TopicMetadata{
+		Name: "test",
+		Partitions: []domain.PartitionMetadata{
+			{PartitionIndex: 0, LeaderEpoch: 4},
+			{PartitionIndex: 1, LeaderID: 2, LeaderEpoch: 4},
+		},
+	}
+	repo := &fakeMetadataRepo{topicsByName: map[string]*domain.TopicMetadata{"test": meta}}
+	logs := &fakeLogManager{epochs: map[int32]domain.EpochEndOffset{2: {Epoch: 1, EndOffset: 12}}}
+
+	p := newTestProcessor(testDeps{repo: repo, logs: logs})
+
+	resp, _ := p.Process(&request.MessageRequest{
+		Header: request.RequestHeader{ApiKey: domain.OffsetForLeaderEpochApiKey, ApiVersion: 4},
+		Body: &request.OffsetForLeaderEpochRequest{
+			ReplicaID: 2,
+			Topics: []request.OffsetForLeaderTopic{
+				{Topic: "test", Partitions: []request.OffsetForLeaderPartition{
+					{Partition: 0, CurrentLeaderEpoch: 4, LeaderEpoch: 2},
+					{Partition: 0, CurrentLeaderEpoch: 3, LeaderEpoch: 2},
+					{Partition: 1, CurrentLeaderEpoch: -1, LeaderEpoch: 2},
+				}},
+				{Topic: "missing", Partitions: []request.OffsetForLeaderPartition{{Partition: 0}}},
+			},
+		},
+	})
+	body := resp.Body.(*response.OffsetForLeaderEpochResponseBody)
+
+	if resp.HeaderVersion != 1 || len(body.Topics) != 2 {
+		t.Fatalf("unexpected response %+v", body)
+	}
+	parts := body.Topics[0].Partitions
+	if parts[0] != (response.EpochEndOffsetResult{Partition: 0, LeaderEpoch: 1, EndOffset: 12}) {
+		t.Fatalf("unexpected end offset %+v", parts[0])
+	}
+	if parts[1].ErrorCode != domain.ErrorFencedLeaderEpoch || parts[1].EndOffset != -1 {
+		t.Fatalf("expected fenced leader epoch, got %+v", parts[1])
+	}
+	if parts[2].ErrorCode != domain.ErrorNotLeaderOrFollower {
+		t.Fatalf("expected not leader, got %+v", parts[2])
+	}
+	if missing := body.Topics[1].Partitions[0]; missing.ErrorCode != domain.ErrorUnknownTopicOrPartition || missing.LeaderEpoch != -1 {
+		t.Fatalf("expected unknown partition, got %+v", missing)
+	}
+}
+
+func TestProcess_Fetch_ReportsDivergingEpoch(t *testing.T) {
+	id := [16]byte{7}
+	meta := &domain.TopicMetadata{
+		Name:       "test",
+		TopicID:    id,
+		Partitions: []domain.PartitionMetadata{{PartitionIndex: 0, LeaderEpoch: 3}},
+	}
+	repo := &fakeMetadataRepo{topicsByID: map[[16]byte]*domain.TopicMetadata{id: meta}}
+	logs := &fakeLogManager{
+		logs:   map[string][]byte{"test": {0x01}},
+		epochs: map[int32]domain.EpochEndOffset{2: {Epoch: 1, EndOffset: 6}},
+	}
+	replicas := &fakeReplicas{highWatermark: 4}
+
+	p := newTestProcessor(testDeps{repo: repo, logs: logs, replicas: replicas})
+
+	resp, _ := p.Process(&request.MessageRequest{
+		Body: &request.FetchRequest{
+			ReplicaID: 2,
+			Topics: []request.FetchTopic{{
+				TopicID:    id,
+				Partitions: []request.FetchPartition{{Partition: 0, CurrentLeaderEpoch: 3, FetchOffset: 9, LastFetchedEpoch: 2}},
+			}},
+		},
+	})
+
+	part := resp.Body.(*response.FetchResponseBody).Responses[0].Partitions[0]
+	if part.ErrorCode != 0 || part.DivergingEpoch == nil || *part.DivergingEpoch != (domain.EpochEndOffset{Epoch: 1, EndOffset: 6}) {
+		t.Fatalf("expected diverging epoch 1 ending at 6, got %+v", part)
+	}
+	if part.RecordsRegion != nil || len(replicas.followers) != 0 {
+		t.Fatal("diverging follower must not be served records or advance its offset")
+	}
+}
+
+func TestProcess_AlterPartition_CompletesReassignment(t *testing.T) {
+	id := [16]byte{7}
+	meta := &domain.TopicMetadata{
+		Name:    "test",
+		TopicID: id,
+		Partitions: []domain.PartitionMetadata{{
+			LeaderID:         1,
+			LeaderEpoch:      2,
+			PartitionEpoch:   4,
+			Replicas:         []int32{2, 3, 1},
+			ISR:              []int32{1, 2},
+			AddingReplicas:   []int32{3},
+			RemovingReplicas: []int32{1},
+		}},
+	}
+	repo := &fakeMetadataRepo{topicsByID: map[[16]byte]*domain.TopicMetadata{id: meta}}
+	writer := &fakeMetadataWriter{}
+	quorum := &fakeQuorum{description: domain.QuorumDescription{Leader: domain.LeaderAndEpoch{LeaderID: 1}}}
+	local := domain.LocalBroker{ClusterIdentity: domain.ClusterIdentity{NodeID: 1}}
+	p := newTestProcessor(testDeps{repo: repo, writer: writer, quorum: quorum, local: local})
+
+	p.Process(&request.MessageRequest{
+		Header: request.RequestHeader{ApiKey: domain.AlterPartitionApiKey, ApiVersion: 2},
+		Body: &request.AlterPartitionRequest{
+			BrokerID: 1,
+			Topics: []request.AlterPartitionTopic{{
+				TopicID:    id,
+				Partitions: []request.AlterPartitionPartition{{LeaderEpoch: 2, PartitionEpoch: 4, NewISR: []int32{1, 2, 3}}},
+			}},
+		},
+	})
+
+	if len(writer.partitions) != 1 {
+		t.Fatalf("expected one partition change, got %+v", writer.partitions)
+	}
+	ch := writer.partitions[0]
+	if !slices.Equal(ch.Replicas, []int32{2, 3}) || !slices.Equal(ch.ISR, []int32{2, 3}) || ch.Leader != 2 {
+		t.Fatalf("expected reassignment to complete with 2 leading, got %+v", ch)
+	}
+	if ch.AddingReplicas == nil || len(ch.AddingReplicas) != 0 || ch.RemovingReplicas == nil || len(ch.RemovingReplicas) != 0 {
+		t.Fatalf("expected adding and removing replicas cleared, got %+v", ch)
+	}
+}
+
+func TestProcess_AlterPartitionReassignments(t *testing.T) {
+	msg := "replica assignment is empty"
+	reassigner := &fakeReassigner{results: []domain.ReassignmentResult{
+		{Topic: "a", Partition: 0},
+		{Topic: "b", Partition: 0, ErrorCode: domain.ErrorNoReassignmentInProgress},
+		{Topic: "a", Partition: 1, ErrorCode: domain.ErrorInvalidReplicaAssignment, ErrorMessage: &msg},
+	}}
+	p := newTestProcessor(testDeps{reassigner: reassigner})
+
+	req := &request.MessageRequest{
+		Header: request.RequestHeader{ApiKey: domain.AlterPartitionReassignmentsApiKey},
+		Body: &request.AlterPartitionReassignmentsRequest{Topics: []request.ReassignableTopic{
+			{Name: "a", Partitions: []request.ReassignablePartition{{PartitionIndex: 0, Replicas: []int32{2, 3}}, {PartitionIndex: 1, Replicas: []int32{}}}},
+			{Name: "b", Partitions: []request.ReassignablePartition{{PartitionIndex: 0}}},
+		}},
+	}
+	resp, _ := p.Process(req)
+	body := resp.Body.(*response.AlterPartitionReassignmentsResponseBody)
+
+	if len(reassigner.targets) != 3 || reassigner.targets[2].Replicas != nil || !slices.Equal(reassigner.targets[0].Replicas, []int32{2, 3}) {
+		t.Fatalf("unexpected targets %+v", reassigner.targets)
+	}
+	if resp.HeaderVersion != 1 || len(body.Responses) != 2 || len(body.Responses[0].Partitions) != 2 {
+		t.Fatalf("unexpected response %+v", body)
+	}
+	if part := body.Responses[0].Partitions[1]; part.ErrorCode != domain.ErrorInvalidReplicaAssignment || part.ErrorMessage == nil {
+		t.Fatalf("unexpected partition result %+v", part)
+	}
+
+	reassigner.err = domain.ErrNotController
+	resp, _ = p.Process(req)
+	if body := resp.Body.(*response.AlterPartitionReassignmentsResponseBody); body.ErrorCode != domain.ErrorNotController || len(body.Responses) != 0 {
+		t.Fatalf("expected NOT_CONTROLLER, got %+v", body)
+	}
+}
+
+func TestProcess_ListPartitionReassignments(t *testing.T) {
+	moving := &domain.TopicMetadata{
+		Name:    "moving",
+		TopicID: [16]byte{1},
+		Partitions: []domain.PartitionMetadata{
+			{PartitionIndex: 0, Replicas: []int32{1, 2}},
+			{PartitionIndex: 1, Replicas: []int32{3, 1}, AddingReplicas: []int32{3}, RemovingReplicas: []int32{1}},
+		},
+	}
+	idle := &domain.TopicMetadata{Name: "idle", TopicID: [16]byte{2}, Partitions: []domain.PartitionMetadata{{Replicas: []int32{1}}}}
+	repo := &fakeMetadataRepo{
+		topicsByName: map[string]*domain.TopicMetadata{"moving": moving, "idle": idle},
+		topicsByID:   map[[16]byte]*domain.TopicMetadata{moving.TopicID: moving, idle.TopicID: idle},
+	}
+	p := newTestProcessor(testDeps{repo: repo})
+
+	list := func(topics []request.ListPartitionReassignmentsTopic) *response.ListPartitionReassignmentsResponseBody {
+		resp, _ := p.Process(&request.MessageRequest{
+			Header: request.RequestHeader{ApiKey: domain.ListPartitionReassignmentsApiKey},
+			Body:   &request.ListPartitionReassignmentsRequest{Topics: topics},
+		})
+		return resp.Body.(*response.ListPartitionReassignmentsResponseBody)
+	}
+
+	body := list(nil)
+	if len(body.Topics) != 1 || body.Topics[0].Name != "moving" || len(body.Topics[0].Partitions) != 1 {
+		t.Fatalf("expected only the moving partition, got %+v", body)
+	}
+	if part := body.Topics[0].Partitions[0]; part.PartitionIndex != 1 || !slices.Equal(part.AddingReplicas, []int32{3}) || !slices.Equal(part.RemovingReplicas, []int32{1}) {
+		t.Fatalf("unexpected reassignment %+v", part)
+	}
+
+	body = list([]request.ListPartitionReassignmentsTopic{{Name: "moving", PartitionIndexes: []int32{0}}, {Name: "missing", PartitionIndexes: []int32{0}}})
+	if len(body.Topics) != 0 {
+		t.Fatalf("expected no reassignments for the requested partitions, got %+v", body)
+	}
+}
+
+func TestProcess_Fetch_ThrottledFollower(t *testing.T) {
+	id := [16]byte{7}
+	meta := &domain.TopicMetadata{
+		Name:       "test",
+		TopicID:    id,
+		Partitions: []domain.PartitionMetadata{{PartitionIndex: 0, LeaderEpoch: 3}},
+	}
+	repo := &fakeMetadataRepo{topicsByID: map[[16]byte]*domain.TopicMetadata{id: meta}}
+	logs := &fakeLogManager{logs: map[string][]byte{"test": {0x01, 0x02}}}
+	replicas := &fakeReplicas{highWatermark: 4}
+
+	p := newTestProcessor(testDeps{repo: repo, logs: logs, replicas: replicas})
+
+	fetch := func() response.FetchPartitionResponse {
+		resp, _ := p.Process(&request.MessageRequest{
+			Body: &request.FetchRequest{
+				ReplicaID: 2,
+				Topics: []request.FetchTopic{{
+					TopicID:    id,
+					Partitions: []request.FetchPartition{{Partition: 0, CurrentLeaderEpoch: 3, FetchOffset: 1, LastFetchedEpoch: -1}},
+				}},
+			},
+		})
+		return resp.Body.(*response.FetchResponseBody).Responses[0].Partitions[0]
+	}
+
+	if part := fetch(); part.RecordsRegion == nil || replicas.throttleBytes != 2 {
+		t.Fatalf("expected records counted against the throttle, got %+v (%d bytes)", part, replicas.throttleBytes)
+	}
+
+	replicas.throttled = true
+	part := fetch()
+	if part.ErrorCode != 0 || part.RecordsRegion != nil || part.HighWatermark != 4 || replicas.followers[2] != 1 {
+		t.Fatalf("expected an empty response once the throttle is exceeded, got %+v", part)
+	}
+}
+
+func TestProcess_Fetch_PreferredReadReplica(t *testing.T) {
+	id := [16]byte{7}
+	meta := &domain.TopicMetadata{
+		Name:       "test",
+		TopicID:    id,
+		Partitions: []domain.PartitionMetadata{{PartitionIndex: 0, LeaderID: 0, Replicas: []int32{0, 1}, ISR: []int32{0, 1}}},
+	}
+	repo := &fakeMetadataRepo{topicsByID: map[[16]byte]*domain.TopicMetadata{id: meta}}
+	logs := &fakeLogManager{logs: map[string][]byte{"test": {0x01, 0x02}}}
+	replicas := &fakeReplicas{highWatermark: 4, preferred: map[string]int32{"b": 1}}
+
+	p := newTestProcessor(testDeps{repo: repo, logs: logs, replicas: replicas})
+
+	fetch := func(rack string, replicaID int32) response.FetchPartitionResponse {
+		resp, _ := p.Process(&request.MessageRequest{
+			Body: &request.FetchRequest{
+				ReplicaID: replicaID,
+				RackID:    rack,
+				Topics: []request.FetchTopic{{
+					TopicID:    id,
+					Partitions: []request.FetchPartition{{Partition: 0, CurrentLeaderEpoch: -1, LastFetchedEpoch: -1}},
+				}},
+			},
+		})
+		return resp.Body.(*response.FetchResponseBody).Responses[0].Partitions[0]
+	}
+
+	part := fetch("b", domain.ReplicaIDConsumer)
+	if part.PreferredReadReplica == nil || *part.PreferredReadReplica != 1 || part.RecordsRegion != nil || part.HighWatermark != 4 {
+		t.Fatalf("expected the consumer to be redirected to broker 1 without records, got %+v", part)
+	}
+	if part := fetch("a", domain.ReplicaIDConsumer); part.PreferredReadReplica != nil || part.RecordsRegion == nil {
+		t.Fatalf("expected the leader to serve the consumer, got %+v", part)
+	}
+	if part := fetch("b", 1); part.PreferredReadReplica != nil || part.RecordsRegion == nil {
+		t.Fatalf("followers must never be redirected, got %+v", part)
+	}
+}
+
+func TestProcess_Fetch_ConsumerReadsFromFollower(t *testing.T) {
+	id := [16]byte{7}
+	meta := &domain.TopicMetadata{
+		Name:       "test",
+		TopicID:    id,
+		Partitions: []domain.PartitionMetadata{{PartitionIndex: 0, LeaderID: 1, Replicas: []int32{1, 0}, ISR: []int32{1, 0}}},
+	}
+	repo := &fakeMetadataRepo{topicsByID: map[[16]byte]*domain.TopicMetadata{id: meta}}
+	logs := &fakeLogManager{logs: map[string][]byte{"test": {0x01, 0x02}}}
+	replicas := &fakeReplicas{highWatermark: 2}
+
+	p := newTestProcessor(testDeps{repo: repo, logs: logs, replicas: replicas})
+
+	fetch := func(replicaID int32) response.FetchPartitionResponse {
+		resp, _ := p.Process(&request.MessageRequest{
+			Body: &request.FetchRequest{
+				ReplicaID: replicaID,
+				Topics: []request.FetchTopic{{
+					TopicID:    id,
+					Partitions: []request.FetchPartition{{Partition: 0, CurrentLeaderEpoch: -1, LastFetchedEpoch: -1}},
+				}},
+			},
+		})
+		return resp.Body.(*response.FetchResponseBody).Responses[0].Partitions[0]
+	}
+
+	if part := fetch(domain.ReplicaIDConsumer); part.ErrorCode != 0 || part.RecordsRegion == nil || part.HighWatermark != 2 {
+		t.Fatalf("expected the follower to serve the consumer up to its high watermark, got %+v", part)
+	}
+	if part := fetch(2); part.ErrorCode != domain.ErrorNotLeaderOrFollower {
+		t.Fatalf("expected replica fetches to be rejected by a follower, got %+v", part)
+	}
+}
+
+func TestProcess_CreateTopics(t *testing.T) {
+	msg := "topic 'b' already exists"
+	creator := &fakeCreator{results: []domain.TopicCreationResult{
+		{Name: "a", TopicID: [16]byte{1}, NumPartitions: 3, ReplicationFactor: 2, Configs: map[string]string{domain.TopicConfigMinInsyncReplicas: "2"}},
+		{Name: "b", ErrorCode: domain.ErrorTopicAlreadyExists, ErrorMessage: &msg, NumPartitions: -1, ReplicationFactor: -1},
+	}}
+	p := newTestProcessor(testDeps{creator: creator})
+
+	value := "2"
+	req := &request.MessageRequest{
+		Header: request.RequestHeader{ApiKey: domain.CreateTopicsApiKey, ApiVersion: 7},
+		Body: &request.CreateTopicsRequest{
+			ValidateOnly: true,
+			Topics: []request.CreatableTopic{
+				{Name: "a", NumPartitions: 3, ReplicationFactor: 2, Configs: []request.CreatableTopicConfig{{Name: domain.TopicConfigMinInsyncReplicas, Value: &value}}},
+				{Name: "b", NumPartitions: -1, ReplicationFactor: -1, Assignments: []request.CreatableReplicaAssignment{{PartitionIndex: 0, BrokerIDs: []int32{1}}}},
+			},
+		},
+	}
+	resp, _ := p.Process(req)
+	body := resp.Body.(*response.CreateTopicsResponseBody)
+
+	if !creator.validateOnly || len(creator.topics) != 2 || !slices.Equal(creator.topics[1].Assignments[0], []int32{1}) {
+		t.Fatalf("unexpected creations %+v", creator.topics)
+	}
+	if resp.HeaderVersion != 1 || body.Version != 7 || len(body.Topics) != 2 {
+		t.Fatalf("unexpected response %+v", body)
+	}
+	a := body.Topics[0]
+	if a.TopicID != ([16]byte{1}) || len(a.Configs) != len(domain.TopicConfigDefinitions()) {
+		t.Fatalf("unexpected created topic %+v", a)
+	}
+	for _, c := range a.Configs {
+		switch c.Name {
+		case domain.TopicConfigMinInsyncReplicas:
+			if *c.Value != "2" || c.ConfigSource != domain.ConfigSourceDynamicTopic {
+				t.Fatalf("unexpected topic override %+v", c)
+			}
+		case domain.TopicConfigCompressionType:
+			if *c.Value != domain.TopicCompressionProducer || c.ConfigSource != domain.ConfigSourceDefault {
+				t.Fatalf("unexpected default config %+v", c)
+			}
+		}
+	}
+	if b := body.Topics[1]; b.ErrorCode != domain.ErrorTopicAlreadyExists || b.Configs != nil {
+		t.Fatalf("unexpected failed topic %+v", b)
+	}
+
+	creator.err = domain.ErrNotController
+	resp, _ = p.Process(req)
+	for _, topic := range resp.Body.(*response.CreateTopicsResponseBody).Topics {
+		if topic.ErrorCode != domain.ErrorNotController {
+			t.Fatalf("expected NOT_CONTROLLER, got %+v", topic)
+		}
+	}
+}
+
+func TestProcess_DescribeConfigs(t *testing.T) {
+	repo := &fakeMetadataRepo{
+		topicsByName: map[string]*domain.TopicMetadata{
+			"t": {Name: "t", Configs: map[string]string{domain.TopicConfigMinInsyncReplicas: "2"}},
+		},
+		configs: map[string]map[string]string{
+			"":  {domain.BrokerConfigMinInsyncReplicas: "3", domain.BrokerConfigCompressionType: "gzip"},
+			"1": {domain.BrokerConfigLeaderThrottledRate: "1024"},
+		},
+	}
+	local := domain.LocalBroker{
+		ClusterIdentity: domain.ClusterIdentity{NodeID: 1},
+		Configs:         map[string]string{domain.BrokerConfigNodeID: "1", domain.BrokerConfigMinInsyncReplicas: "4"},
+	}
+	p := newTestProcessor(testDeps{repo: repo, local: local})
+
+	resp, _ := p.Process(&request.MessageRequest{
+		Header: request.RequestHeader{ApiKey: domain.DescribeConfigsApiKey, ApiVersion: 4},
+		Body: &request.DescribeConfigsRequest{
+			IncludeSynonyms:      true,
+			IncludeDocumentation: true,
+			Resources: []request.DescribeConfigsResource{
+				{
+					ResourceType:      domain.ConfigResourceTopic,
+					ResourceName:      "t",
+					ConfigurationKeys: []string{domain.TopicConfigMinInsyncReplicas, domain.TopicConfigCompressionType},
+				},
+				{ResourceType: domain.ConfigResourceBroker, ResourceName: "1"},
+				{ResourceType: domain.ConfigResourceBroker, ResourceName: ""},
+				{ResourceType: domain.ConfigResourceBroker, ResourceName: "2"},
+				{ResourceType: domain.ConfigResourceTopic, ResourceName: "missing"},
+			},
+		},
+	})
+	body := resp.Body.(*response.DescribeConfigsResponseBody)
+	if resp.HeaderVersion != 1 || len(body.Results) != 5 {
+		t.Fatalf("unexpected response %+v", body)
+	}
+
+	topic := body.Results[0]
+	if topic.ErrorCode != 0 || len(topic.Configs) != 2 {
+		t.Fatalf("unexpected topic result %+v", topic)
+	}
+	compression, minISR := topic.Configs[0], topic.Configs[1]
+	if compression.Name != domain.TopicConfigCompressionType || *compression.Value != "gzip" ||
+		compression.ConfigSource != domain.ConfigSourceDynamicDefaultBroker || *compression.Documentation == "" {
+		t.Fatalf("unexpected compression.type %+v", compression)
+	}
+	sources := []int8{}
+	for _, s := range minISR.Synonyms {
+		sources = append(sources, s.Source)
+	}
+	if *minISR.Value != "2" || minISR.ConfigType != int8(domain.ConfigTypeInt) || !slices.Equal(sources, []int8{
+		domain.ConfigSourceDynamicTopic,
+		domain.ConfigSourceDynamicDefaultBroker,
+		domain.ConfigSourceStaticBroker,
+		domain.ConfigSourceDefault,
+	}) {
+		t.Fatalf("unexpected min.insync.replicas %+v", minISR)
+	}
+
+	broker := map[string]response.DescribeConfigsResourceResult{}
+	for _, c := range body.Results[1].Configs {
+		broker[c.Name] = c
+	}
+	if c := broker[domain.BrokerConfigLeaderThrottledRate]; *c.Value != "1024" || c.ConfigSource != domain.ConfigSourceDynamicBroker {
+		t.Fatalf("unexpected throttle rate %+v", c)
+	}
+	if c := broker[domain.BrokerConfigNodeID]; *c.Value != "1" || !c.ReadOnly || c.ConfigSource != domain.ConfigSourceStaticBroker {
+		t.Fatalf("unexpected node.id %+v", c)
+	}
+	if c := broker[domain.BrokerConfigRack]; c.Value != nil || c.ConfigSource != domain.ConfigSourceDefault {
+		t.Fatalf("unexpected broker.rack %+v", c)
+	}
+
+	if defaults := body.Results[2].Configs; len(defaults) != 2 || defaults[0].ConfigSource != domain.ConfigSourceDynamicDefaultBroker {
+		t.Fatalf("unexpected cluster defaults %+v", defaults)
+	}
+	if body.Results[3].ErrorCode != domain.ErrorInvalidRequest || body.Results[4].ErrorCode != domain.ErrorUnknownTopicOrPartition {
+		t.Fatalf("unexpected errors %+v %+v", body.Results[3], body.Results[4])
+	}
+}
+
+func TestProcess_IncrementalAlterConfigs(t *testing.T) {
+	msg := "unknown topic config name: x"
+	configs := &fakeConfigs{results: []domain.ConfigAlterationResult{
+		{Resource: domain.ConfigResource{Type: domain.ConfigResourceTopic, Name: "t"}},
+		{Resource: domain.ConfigResource{Type: domain.ConfigResourceBroker, Name: ""}, ErrorCode: domain.ErrorInvalidConfig, ErrorMessage: &msg},
+	}}
+	p := newTestProcessor(testDeps{configs: configs})
+
+	value := "0:1"
+	req := &request.MessageRequest{
+		Header: request.RequestHeader{ApiKey: domain.IncrementalAlterConfigsApiKey, ApiVersion: 1},
+		Body: &request.IncrementalAlterConfigsRequest{
+			ValidateOnly: true,
+			Resources: []request.AlterConfigsResource{
+				{
+					ResourceType: domain.ConfigResourceTopic,
+					ResourceName: "t",
+					Configs: []request.AlterableConfig{
+						{Name: domain.TopicConfigLeaderThrottledReplicas, ConfigOperation: domain.ConfigOpAppend, Value: &value},
+					},
+				},
+				{ResourceType: domain.ConfigResourceBroker, Configs: []request.AlterableConfig{{Name: "x", Value: &value}}},
+			},
+		},
+	}
+	resp, _ := p.Process(req)
+	body := resp.Body.(*response.IncrementalAlterConfigsResponseBody)
+
+	if !configs.validateOnly || len(configs.alterations) != 2 || !configs.alterations[0].Incremental {
+		t.Fatalf("unexpected alterations %+v", configs.alterations)
+	}
+	if op := configs.alterations[0].Ops[0]; op.Op != domain.ConfigOpAppend || *op.Value != "0:1" {
+		t.Fatalf("unexpected op %+v", op)
+	}
+	if resp.HeaderVersion != 1 || len(body.Responses) != 2 || body.Responses[0].ResourceName != "t" ||
+		body.Responses[1].ErrorCode != domain.ErrorInvalidConfig {
+		t.Fatalf("unexpected response %+v", body)
+	}
+
+	configs.err = domain.ErrNotController
+	resp, _ = p.Process(&request.MessageRequest{
+		Header: request.RequestHeader{ApiKey: domain.AlterConfigsApiKey, ApiVersion: 0},
+		Body:   &request.AlterConfigsRequest{Resources: []request.AlterConfigsResource{{ResourceType: domain.ConfigResourceTopic, ResourceName: "t"}}},
+	})
+	legacy := resp.Body.(*response.AlterConfigsResponseBody)
+	if configs.alterations[0].Incremental || resp.HeaderVersion != 0 ||
+		len(legacy.Responses) != 1 || legacy.Responses[0].ErrorCode != domain.ErrorNotController {
+		t.Fatalf("unexpected legacy response %+v", legacy)
+	}
+}